@@ -0,0 +1,229 @@
+package bacnet
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// AuditLog is an Audit Log object. It keeps the last BufferSize audit
+// notifications of the device Device, numbered from 1, and answers
+// AuditLogQuery requests.
+type AuditLog struct {
+	InstanceNumber uint32
+	Device         uint32
+	BufferSize     int
+
+	records []services.AuditLogRecord
+	nextSeq uint32
+
+	mu sync.Mutex
+}
+
+// NewAuditLog creates an empty AuditLog.
+func NewAuditLog(instN uint32, device uint32, bufferSize int) *AuditLog {
+	return &AuditLog{
+		InstanceNumber: instN,
+		Device:         device,
+		BufferSize:     bufferSize,
+		nextSeq:        1,
+	}
+}
+
+// Append records the notification n, dropping the oldest record when the
+// buffer is full, and returns its sequence number.
+func (l *AuditLog) Append(n services.AuditNotification) uint32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seq := l.nextSeq
+	l.nextSeq++
+
+	l.records = append(l.records, services.AuditLogRecord{
+		SequenceNumber: seq,
+		Timestamp:      time.Now(),
+		Datum:          services.AuditLogDatumNotification,
+		Notification:   n,
+	})
+	if l.BufferSize > 0 && len(l.records) > l.BufferSize {
+		l.records = l.records[len(l.records)-l.BufferSize:]
+	}
+	return seq
+}
+
+// RecordWrite records the WriteProperty request req, identified by invokeID
+// and received from src. before and after are the values of the property
+// around the write, and err the error the request was refused with, if any.
+func (l *AuditLog) RecordWrite(src net.Addr, invokeID uint8, req services.WritePropertyValue, before, after []objects.APDUPayload, err error) uint32 {
+	target := objects.ObjectIdentifier{ObjectType: req.ObjectType, InstanceNumber: req.InstanceId}
+	property := services.PropertyReference{PropertyId: req.PropertyId}
+	if req.ArrayIndex != objects.ArrayAll {
+		arrayIndex := req.ArrayIndex
+		property.ArrayIndex = &arrayIndex
+	}
+
+	n := services.AuditNotification{
+		SourceDevice: services.Recipient{ByAddress: true, MAC: bipMAC(src)},
+		Operation:    services.AuditOperationWrite,
+		InvokeId:     &invokeID,
+		TargetDevice: services.Recipient{
+			Device: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: l.Device},
+		},
+		TargetObject:   &target,
+		TargetProperty: &property,
+		TargetPriority: req.Priority,
+		TargetValue:    after,
+		CurrentValue:   before,
+	}
+	if err != nil {
+		n.Result = objects.NewBACnetError(objects.ErrorClassDevice, objects.ErrorCodeOther)
+		if bErr, ok := errors.Cause(err).(*objects.BACnetError); ok {
+			n.Result = bErr
+		}
+	}
+
+	return l.Append(n)
+}
+
+// Query returns the records selected by the AuditLogQuery request q.
+func (l *AuditLog) Query(q services.ConfirmedAuditLogQueryDec) services.AuditLogQueryACKDec {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ack := services.AuditLogQueryACKDec{
+		AuditLog:    objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAuditLog, InstanceNumber: l.InstanceNumber},
+		Records:     []services.AuditLogRecord{},
+		NoMoreItems: true,
+	}
+	for _, rec := range l.records {
+		if q.StartAtSequenceNumber != nil && rec.SequenceNumber < *q.StartAtSequenceNumber {
+			continue
+		}
+		if rec.Datum != services.AuditLogDatumNotification || !auditQueryMatch(q, rec.Notification) {
+			continue
+		}
+		if len(ack.Records) == int(q.RequestedCount) {
+			ack.NoMoreItems = false
+			break
+		}
+		ack.Records = append(ack.Records, rec)
+	}
+	return ack
+}
+
+// NewAuditLogQueryACK answers the AuditLogQuery request req, identified by
+// invokeID, with the records of l.
+func NewAuditLogQueryACK(invokeID uint8, l *AuditLog, req services.ConfirmedAuditLogQueryDec) ([]byte, error) {
+	if req.AuditLog.ObjectType != objects.ObjectTypeAuditLog || req.AuditLog.InstanceNumber != l.InstanceNumber {
+		return nil, objects.NewBACnetError(objects.ErrorClassObject, objects.ErrorCodeUnknownObject)
+	}
+
+	objs, err := services.AuditLogQueryACKObjects(l.Query(req))
+	if err != nil {
+		return nil, err
+	}
+
+	ack := services.NewAuditLogQueryACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = invokeID
+	ack.APDU.Objects = objs
+	ack.SetLength()
+	return ack.MarshalBinary()
+}
+
+func auditQueryMatch(q services.ConfirmedAuditLogQueryDec, n services.AuditNotification) bool {
+	switch {
+	case q.ByTarget != nil:
+		t := q.ByTarget
+		if !auditDeviceMatch(n.TargetDevice, t.Device, t.Address) {
+			return false
+		}
+		if t.Object != nil && (n.TargetObject == nil || *n.TargetObject != *t.Object) {
+			return false
+		}
+		if t.PropertyId != nil && (n.TargetProperty == nil || n.TargetProperty.PropertyId != *t.PropertyId) {
+			return false
+		}
+		if t.ArrayIndex != nil && (n.TargetProperty == nil || n.TargetProperty.ArrayIndex == nil ||
+			*n.TargetProperty.ArrayIndex != *t.ArrayIndex) {
+			return false
+		}
+		if t.Priority != 0 && n.TargetPriority != t.Priority {
+			return false
+		}
+		return auditOperationMatch(t.Operations, t.ResultFilter, n)
+	case q.BySource != nil:
+		s := q.BySource
+		if !auditDeviceMatch(n.SourceDevice, s.Device, s.Address) {
+			return false
+		}
+		if s.Object != nil && (n.SourceObject == nil || *n.SourceObject != *s.Object) {
+			return false
+		}
+		return auditOperationMatch(s.Operations, s.ResultFilter, n)
+	}
+	return false
+}
+
+// auditDeviceMatch tells whether rcp is the device identified by device or,
+// when rcp only has an address, by address.
+func auditDeviceMatch(rcp services.Recipient, device objects.ObjectIdentifier, address *services.Address) bool {
+	if !rcp.ByAddress {
+		return rcp.Device == device
+	}
+	return address != nil && rcp.Network == address.Network && string(rcp.MAC) == string(address.MAC)
+}
+
+func auditOperationMatch(operations []bool, resultFilter uint8, n services.AuditNotification) bool {
+	if operations != nil && (int(n.Operation) >= len(operations) || !operations[n.Operation]) {
+		return false
+	}
+	switch resultFilter {
+	case services.SuccessFilterSuccessesOnly:
+		return n.Result == nil
+	case services.SuccessFilterFailuresOnly:
+		return n.Result != nil
+	}
+	return true
+}
+
+// bipMAC returns the B/IP MAC address of addr: its IPv4 address followed by
+// its port.
+func bipMAC(addr net.Addr) []byte {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+	ip := udpAddr.IP.To4()
+	if ip == nil {
+		return nil
+	}
+	return append(append([]byte{}, ip...), byte(udpAddr.Port>>8), byte(udpAddr.Port))
+}
+
+// NewAuditNotification builds an AuditNotification request reporting ns, as
+// an unconfirmed request unless confirmed is set.
+func NewAuditNotification(ns []services.AuditNotification, confirmed bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+
+	if confirmed {
+		c := services.NewConfirmedAuditNotification(bvlc, plumbing.NewNPDU(false, false, false, true))
+		c.APDU.MaxSize = 5
+		c.APDU.InvokeID = 1
+		c.APDU.Objects = services.AuditNotificationObjects(ns)
+		c.SetLength()
+		return c.MarshalBinary()
+	}
+
+	u := services.NewUnconfirmedAuditNotification(bvlc, plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.AuditNotificationObjects(ns)
+	u.SetLength()
+	return u.MarshalBinary()
+}
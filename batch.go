@@ -0,0 +1,254 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// maxUnsegmentedAPDU is the largest APDU a Client accepts, as it doesn't
+// support segmentation.
+const maxUnsegmentedAPDU = 1476
+
+// Sizes, in octets, used to estimate how many properties fit in a
+// ReadPropertyMultiple acknowledgement. Values are guessed, the batches the
+// device finds too large being split.
+const (
+	rpmHeaderLen     = 4
+	rpmObjectLen     = 7
+	rpmPropertyLen   = 2
+	rpmArrayIndexLen = 5
+	rpmValueLen      = 2 + 16
+)
+
+// PropertyResult is the result of reading a property: its value, as
+// application tagged objects, or Err, the Error, Reject or Abort returned by
+// the device for it.
+type PropertyResult struct {
+	Value []objects.APDUPayload
+	Err   error
+}
+
+// BatchReader reads properties of a device, packing them in
+// ReadPropertyMultiple requests whose acknowledgements should fit in the max
+// APDU the device announced in its I-Am. Batches the device still finds too
+// large are split, and once the device rejects ReadPropertyMultiple, the
+// properties are read one by one with ReadProperty instead.
+type BatchReader struct {
+	client *Client
+	device DeviceRecord
+
+	noRPM bool
+	mu    sync.Mutex
+}
+
+// NewBatchReader creates a BatchReader reading from the device d through c.
+func NewBatchReader(c *Client, d DeviceRecord) *BatchReader {
+	return &BatchReader{client: c, device: d}
+}
+
+// Read reads the properties referred to by refs, whose Device is ignored,
+// and returns their results in the same order. The properties the device
+// can't read have the error of their result set, the error returned being
+// kept for failures to reach the device, such as timeouts.
+func (b *BatchReader) Read(ctx context.Context, refs []objects.DeviceObjectPropertyReference) ([]PropertyResult, error) {
+	results := make([]PropertyResult, len(refs))
+
+	for _, batch := range b.batches(refs) {
+		if err := b.readBatch(ctx, refs, results, batch[0], batch[1]); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// batches splits refs in ranges whose acknowledgement is estimated to fit in
+// the max APDU of the device.
+func (b *BatchReader) batches(refs []objects.DeviceObjectPropertyReference) [][2]int {
+	limit := int(b.device.MaxAPDU)
+	if limit == 0 || limit > maxUnsegmentedAPDU {
+		limit = maxUnsegmentedAPDU
+	}
+
+	var batches [][2]int
+	start, size := 0, rpmHeaderLen
+	for i, ref := range refs {
+		refLen := rpmPropertyLen + rpmValueLen
+		if ref.ArrayIndex != objects.ArrayAll {
+			refLen += rpmArrayIndexLen
+		}
+		newObject := i == start || !sameObject(refs[i-1], ref)
+		if newObject {
+			refLen += rpmObjectLen
+		}
+
+		if i > start && size+refLen > limit {
+			batches = append(batches, [2]int{start, i})
+			start, size = i, rpmHeaderLen
+			if !newObject {
+				refLen += rpmObjectLen
+			}
+		}
+		size += refLen
+	}
+	if start < len(refs) {
+		batches = append(batches, [2]int{start, len(refs)})
+	}
+	return batches
+}
+
+// readBatch reads refs[start:end] into results[start:end].
+func (b *BatchReader) readBatch(ctx context.Context, refs []objects.DeviceObjectPropertyReference, results []PropertyResult, start, end int) error {
+	if b.useRPM() {
+		err := b.readRPM(ctx, refs[start:end], results[start:end])
+		if err == nil {
+			return nil
+		}
+
+		switch e := errors.Cause(err).(type) {
+		case *objects.AbortError:
+			if tooLong(e) && end-start > 1 {
+				mid := (start + end) / 2
+				if err := b.readBatch(ctx, refs, results, start, mid); err != nil {
+					return err
+				}
+				return b.readBatch(ctx, refs, results, mid, end)
+			}
+		case *objects.RejectError:
+			b.mu.Lock()
+			b.noRPM = true
+			b.mu.Unlock()
+		case *objects.BACnetError:
+			// Some devices answer with an Error when none of the properties
+			// can be read: read them one by one to tell which fail.
+		default:
+			return err
+		}
+	}
+
+	for i := start; i < end; i++ {
+		value, err := b.readRP(ctx, refs[i])
+		if err != nil && !refusedByDevice(err) {
+			return err
+		}
+		results[i] = PropertyResult{Value: value, Err: err}
+	}
+	return nil
+}
+
+func (b *BatchReader) useRPM() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !b.noRPM
+}
+
+// readRPM reads refs with a single ReadPropertyMultiple request.
+func (b *BatchReader) readRPM(ctx context.Context, refs []objects.DeviceObjectPropertyReference, results []PropertyResult) error {
+	var specs []services.ReadAccessSpec
+	for i, ref := range refs {
+		if i == 0 || !sameObject(refs[i-1], ref) {
+			specs = append(specs, services.ReadAccessSpec{ObjectType: ref.ObjectType, InstanceId: ref.InstanceNumber})
+		}
+		propRef := services.PropertyReference{PropertyId: ref.PropertyId}
+		if ref.ArrayIndex != objects.ArrayAll {
+			index := ref.ArrayIndex
+			propRef.ArrayIndex = &index
+		}
+		spec := &specs[len(specs)-1]
+		spec.Properties = append(spec.Properties, propRef)
+	}
+
+	req, err := NewReadAccess(specs)
+	if err != nil {
+		return err
+	}
+	reply, err := b.client.RequestDevice(ctx, b.device, req)
+	if err != nil {
+		return err
+	}
+	ack, ok := reply.(*services.ReadPropertyMultipleACK)
+	if !ok {
+		return errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected ReadPropertyMultiple reply %T", reply),
+		)
+	}
+	accessResults, err := ack.Decode()
+	if err != nil {
+		return err
+	}
+
+	i := 0
+	for _, res := range accessResults {
+		for _, rr := range res.Results {
+			if i >= len(refs) || res.ObjectType != refs[i].ObjectType ||
+				res.InstanceId != refs[i].InstanceNumber || rr.PropertyId != refs[i].PropertyId {
+				return errors.Wrap(common.ErrWrongStructure, "ReadPropertyMultiple results don't match the request")
+			}
+			results[i] = PropertyResult{Value: rr.Value}
+			if rr.Err != nil {
+				results[i] = PropertyResult{Err: rr.Err}
+			}
+			i++
+		}
+	}
+	if i != len(refs) {
+		return errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("%d results for %d properties", i, len(refs)),
+		)
+	}
+	return nil
+}
+
+// readRP reads ref with a ReadProperty request.
+func (b *BatchReader) readRP(ctx context.Context, ref objects.DeviceObjectPropertyReference) ([]objects.APDUPayload, error) {
+	var req []byte
+	var err error
+	if ref.ArrayIndex == objects.ArrayAll {
+		req, err = NewReadProperty(ref.ObjectType, ref.InstanceNumber, ref.PropertyId)
+	} else {
+		req, err = NewReadPropertyIndex(ref.ObjectType, ref.InstanceNumber, ref.PropertyId, ref.ArrayIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := b.client.RequestDevice(ctx, b.device, req)
+	if err != nil {
+		return nil, err
+	}
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected ReadProperty reply %T", reply),
+		)
+	}
+	res, err := cack.DecodeResult()
+	if err != nil {
+		return nil, err
+	}
+	return res.Results[0].Value, nil
+}
+
+func sameObject(a, b objects.DeviceObjectPropertyReference) bool {
+	return a.ObjectType == b.ObjectType && a.InstanceNumber == b.InstanceNumber
+}
+
+// tooLong tells whether the device aborted a request as its reply doesn't
+// fit in an unsegmented APDU.
+func tooLong(e *objects.AbortError) bool {
+	switch e.Reason {
+	case objects.AbortReasonSegmentationNotSupported, objects.AbortReasonBufferOverflow, objects.AbortReasonAPDUTooLong:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,196 @@
+package bacnet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// newBatchDatabase creates the Database of device 1 with n analog values,
+// whose Present_Value is their instance number.
+func newBatchDatabase(t *testing.T, n int) *objects.Database {
+	t.Helper()
+	db := objects.NewDatabase(1, "test", 0)
+	for i := 0; i < n; i++ {
+		if err := db.Add(objects.ObjectTypeAnalogValue, uint32(i), fmt.Sprintf("AV-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		setProperty(t, db, objects.ObjectTypeAnalogValue, uint32(i), objects.PropertyIdPresentValue, objects.EncReal(float32(i)))
+	}
+	return db
+}
+
+// presentValues returns the references to the Present_Value of the analog
+// values whose instance numbers are instances.
+func presentValues(instances ...uint32) []objects.DeviceObjectPropertyReference {
+	refs := make([]objects.DeviceObjectPropertyReference, len(instances))
+	for i, instN := range instances {
+		refs[i] = objects.DeviceObjectPropertyReference{
+			ObjectType:     objects.ObjectTypeAnalogValue,
+			InstanceNumber: instN,
+			PropertyId:     objects.PropertyIdPresentValue,
+			ArrayIndex:     objects.ArrayAll,
+		}
+	}
+	return refs
+}
+
+// serveRPM has s serve the ReadPropertyMultiple requests for the objects of
+// db, sending the number of properties of each request to sizes. The ones
+// asking for more than maxProperties are aborted as their acknowledgement
+// wouldn't fit in an unsegmented APDU.
+func serveRPM(s *bacnet.Server, db *objects.Database, maxProperties int, sizes chan<- int) {
+	s.Handle(services.ServiceConfirmedReadPropMultiple, func(req bacnet.Request) ([]byte, error) {
+		specs, err := req.Msg.(*services.ConfirmedReadProperty).DecodeMultiple()
+		if err != nil {
+			return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+		}
+		size := 0
+		for _, spec := range specs {
+			size += len(spec.Properties)
+		}
+		sizes <- size
+		if size > maxProperties {
+			return nil, objects.NewAbortError(objects.AbortReasonSegmentationNotSupported, true)
+		}
+
+		results := make([]services.ReadAccessResult, len(specs))
+		for i, spec := range specs {
+			results[i] = services.ReadAccessResult{ObjectType: spec.ObjectType, InstanceId: spec.InstanceId}
+			for _, ref := range spec.Properties {
+				value, err := db.ReadProperty(spec.ObjectType, spec.InstanceId, ref.PropertyId, objects.ArrayAll)
+				rr := services.ReadResult{PropertyId: ref.PropertyId, Value: value}
+				if err != nil {
+					rr = services.ReadResult{PropertyId: ref.PropertyId, Err: errors.Cause(err).(*objects.BACnetError)}
+				}
+				results[i].Results = append(results[i].Results, rr)
+			}
+		}
+		return bacnet.NewReadPropertyMultipleACK(req.InvokeID, results)
+	})
+}
+
+// received returns the sizes sent to sizes so far.
+func received(sizes chan int) []int {
+	var got []int
+	for {
+		select {
+		case size := <-sizes:
+			got = append(got, size)
+		default:
+			return got
+		}
+	}
+}
+
+// checkResults checks that results hold the Present_Value of the analog
+// values whose instance numbers are instances, in order, the ones missing
+// from the Database being an UNKNOWN_OBJECT error.
+func checkResults(t *testing.T, db *objects.Database, results []bacnet.PropertyResult, instances ...uint32) {
+	t.Helper()
+	if len(results) != len(instances) {
+		t.Fatalf("got %d results, want %d", len(results), len(instances))
+	}
+	unknown := objects.NewBACnetError(objects.ErrorClassObject, objects.ErrorCodeUnknownObject)
+	for i, instN := range instances {
+		if _, err := db.ReadProperty(objects.ObjectTypeAnalogValue, instN, objects.PropertyIdPresentValue, objects.ArrayAll); err != nil {
+			if diff := cmp.Diff(unknown, errors.Cause(results[i].Err)); diff != "" {
+				t.Errorf("result %d differs: (-want +got)\n%s", i, diff)
+			}
+			continue
+		}
+		if results[i].Err != nil {
+			t.Errorf("result %d: %v", i, results[i].Err)
+			continue
+		}
+		if value, err := objects.DecReal(results[i].Value[0]); err != nil || value != float32(instN) {
+			t.Errorf("result %d is %v, want %d", i, value, instN)
+		}
+	}
+}
+
+func TestBatchReaderSplit(t *testing.T) {
+	db := newBatchDatabase(t, 40)
+	s, addr := newTestServer(t)
+	bacnet.ServeDatabase(s, db, nil)
+	sizes := make(chan int, 64)
+	serveRPM(s, db, 40, sizes)
+
+	// An acknowledgement of 206 octets is estimated to hold 7 properties of
+	// different objects. AV-40, read first, doesn't exist.
+	instances := make([]uint32, 41)
+	for i := range instances {
+		instances[i] = uint32(len(instances) - 1 - i)
+	}
+	b := bacnet.NewBatchReader(newTestClient(t), bacnet.DeviceRecord{DeviceId: 1, Addr: addr, MaxAPDU: 206})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results, err := b.Read(ctx, presentValues(instances...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, db, results, instances...)
+	if diff := cmp.Diff([]int{7, 7, 7, 7, 7, 6}, received(sizes)); diff != "" {
+		t.Errorf("request sizes differ: (-want +got)\n%s", diff)
+	}
+}
+
+func TestBatchReaderTooLong(t *testing.T) {
+	db := newBatchDatabase(t, 10)
+	s, addr := newTestServer(t)
+	bacnet.ServeDatabase(s, db, nil)
+	sizes := make(chan int, 64)
+	serveRPM(s, db, 4, sizes)
+
+	// The batches the device aborts are split in halves.
+	instances := []uint32{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}
+	b := bacnet.NewBatchReader(newTestClient(t), bacnet.DeviceRecord{DeviceId: 1, Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results, err := b.Read(ctx, presentValues(instances...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, db, results, instances...)
+	if diff := cmp.Diff([]int{10, 5, 2, 3, 5, 2, 3}, received(sizes)); diff != "" {
+		t.Errorf("request sizes differ: (-want +got)\n%s", diff)
+	}
+}
+
+func TestBatchReaderRejected(t *testing.T) {
+	db := newBatchDatabase(t, 3)
+	s, addr := newTestServer(t)
+	bacnet.ServeDatabase(s, db, nil)
+	rejected := make(chan struct{}, 16)
+	s.Handle(services.ServiceConfirmedReadPropMultiple, func(req bacnet.Request) ([]byte, error) {
+		rejected <- struct{}{}
+		return nil, objects.NewRejectError(objects.RejectReasonUnrecognizedService)
+	})
+
+	// Once the device rejects ReadPropertyMultiple, the properties are read
+	// one by one, in order.
+	instances := []uint32{2, 7, 0, 1}
+	b := bacnet.NewBatchReader(newTestClient(t), bacnet.DeviceRecord{DeviceId: 1, Addr: addr})
+	for _, want := range []int{1, 0} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		results, err := b.Read(ctx, presentValues(instances...))
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkResults(t, db, results, instances...)
+		if got := len(rejected); got != want {
+			t.Errorf("%d ReadPropertyMultiple sent, want %d", got, want)
+		}
+		for len(rejected) > 0 {
+			<-rejected
+		}
+	}
+}
@@ -0,0 +1,632 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// Default values of the APDU_Timeout and Number_Of_APDU_Retries used by a
+// Client, and of the number of requests it lets be outstanding at once, with a
+// single device and overall.
+const (
+	DefaultAPDUTimeout           = 3 * time.Second
+	DefaultAPDURetries           = 3
+	DefaultMaxOutstandingPerPeer = 8
+	DefaultMaxOutstanding        = 512
+)
+
+// Listener is called with every message a Client receives which isn't the
+// reply to one of its requests, such as I-Am or unconfirmed notifications.
+type Listener func(src net.Addr, msg plumbing.BACnet)
+
+// Client is a BACnet/IP client. It owns a socket shared by all its requests,
+// gives every confirmed request an invoke ID which is unique amongst the
+// requests outstanding with the same peer, and matches the replies it
+// receives with them. A request is sent again when no reply comes within
+// APDUTimeout, up to APDURetries times. When the context of a request has a
+// deadline, the time left until it is shared between the attempts instead.
+//
+// Requests follow the client transaction state machine of Clause 5.4, short
+// of segmentation. A Client can be used by many goroutines at once. At most
+// MaxOutstandingPerPeer requests are outstanding with a device, and
+// MaxOutstanding overall, a zero limit meaning no other limit than the 256
+// invoke IDs of a device. Requests over the limits wait for earlier ones to
+// complete, which keeps a slow device from holding every slot.
+type Client struct {
+	APDUTimeout           time.Duration
+	APDURetries           int
+	MaxOutstandingPerPeer int
+	MaxOutstanding        int
+
+	conn        net.PacketConn
+	peers       map[string]*peer
+	outstanding int
+	freed       chan struct{}
+	listeners   map[int]Listener
+	nextID      int
+	closed      bool
+
+	mu sync.Mutex
+}
+
+// peer holds the transactions outstanding with a device, by invoke ID.
+type peer struct {
+	nextInvokeID uint8
+	transactions map[uint8]chan plumbing.BACnet
+}
+
+// NewClient creates a Client sending and receiving on conn, and starts
+// reading from it. conn is closed with the Client.
+func NewClient(conn net.PacketConn) *Client {
+	c := &Client{
+		APDUTimeout:           DefaultAPDUTimeout,
+		APDURetries:           DefaultAPDURetries,
+		MaxOutstandingPerPeer: DefaultMaxOutstandingPerPeer,
+		MaxOutstanding:        DefaultMaxOutstanding,
+		conn:                  conn,
+		peers:                 map[string]*peer{},
+		freed:                 make(chan struct{}),
+		listeners:             map[int]Listener{},
+	}
+	go c.receive()
+
+	return c
+}
+
+// Close closes the socket of the Client. Outstanding requests fail. Closing
+// it again returns common.ErrClosed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return common.ErrClosed
+	}
+	c.closed = true
+	for _, p := range c.peers {
+		for _, reply := range p.transactions {
+			close(reply)
+		}
+	}
+	c.peers = map[string]*peer{}
+	c.outstanding = 0
+	close(c.freed)
+	c.mu.Unlock()
+
+	return c.conn.Close()
+}
+
+// Listen registers l to be called with the unsolicited messages the Client
+// receives, until the returned function is called.
+func (c *Client) Listen(l Listener) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextID
+	c.nextID++
+	c.listeners[id] = l
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.listeners, id)
+	}
+}
+
+// Send sends the unconfirmed request req to addr.
+func (c *Client) Send(ctx context.Context, addr net.Addr, req []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := c.conn.WriteTo(req, addr); err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	return nil
+}
+
+// Request sends the confirmed request req to addr, under an invoke ID of its
+// own, and returns the reply. Error, Reject and Abort replies are returned as
+// *objects.BACnetError, *objects.RejectError and *objects.AbortError. The
+// transaction is abandoned, and ctx.Err() returned, when ctx is done.
+func (c *Client) Request(ctx context.Context, addr net.Addr, req []byte) (plumbing.BACnet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Requests routed to a remote device are told apart from the ones to the
+	// router itself, or to the other devices behind it.
+	var npdu plumbing.NPDU
+	if err := frameNPDU(req, &npdu); err != nil {
+		return nil, err
+	}
+	key := peerKey(addr, npdu.DNET, npdu.DADR)
+	invokeID, reply, err := c.begin(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer c.end(key, invokeID)
+
+	req = append([]byte{}, req...)
+	if err := setInvokeID(req, invokeID); err != nil {
+		return nil, err
+	}
+
+	msg, err := transact(ctx, c.conn, addr, req, reply, c.APDUTimeout, c.APDURetries)
+	if errors.Cause(err) == common.ErrTimeout {
+		return nil, errors.Wrap(err, fmt.Sprintf("request %d to %s", invokeID, key))
+	}
+	return msg, err
+}
+
+// transact sends req to addr on conn and returns the reply received on
+// reply, negative replies being turned into errors. req is sent again when no
+// reply comes within apduTimeout, up to apduRetries times, or within its share
+// of the time left until the deadline of ctx if it has one.
+func transact(ctx context.Context, conn net.PacketConn, addr net.Addr, req []byte, reply <-chan plumbing.BACnet, apduTimeout time.Duration, apduRetries int) (plumbing.BACnet, error) {
+	for attempt := 0; attempt <= apduRetries; attempt++ {
+		if _, err := conn.WriteTo(req, addr); err != nil {
+			return nil, errors.Wrap(err, "failed to send request")
+		}
+
+		timer := attemptTimer(ctx, apduTimeout, apduRetries, attempt)
+		select {
+		case msg, ok := <-reply:
+			stopTimer(timer)
+			if !ok {
+				return nil, common.ErrClosed
+			}
+			return replyResult(msg)
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return nil, common.ErrTimeout
+}
+
+// RequestDevice sends the confirmed request req to the device d, through the
+// router it sits behind if any, and returns the reply like Request.
+func (c *Client) RequestDevice(ctx context.Context, d DeviceRecord, req []byte) (plumbing.BACnet, error) {
+	if d.SNET != 0 {
+		routed, err := setDestination(req, d.SNET, d.SADR)
+		if err != nil {
+			return nil, err
+		}
+		req = routed
+	}
+	return c.Request(ctx, d.Addr, req)
+}
+
+// ReadProperty reads the property propertyId of an object of the device at
+// addr.
+func (c *Client) ReadProperty(ctx context.Context, addr net.Addr, objectType uint16, instN uint32, propertyId uint8) (services.ComplexACKDec, error) {
+	req, err := NewReadProperty(objectType, instN, propertyId)
+	if err != nil {
+		return services.ComplexACKDec{}, err
+	}
+
+	reply, err := c.Request(ctx, addr, req)
+	if err != nil {
+		return services.ComplexACKDec{}, err
+	}
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		return services.ComplexACKDec{}, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected ReadProperty reply %T", reply),
+		)
+	}
+	return cack.Decode()
+}
+
+// ReadPropertyIndex reads the element arrayIndex of the array property
+// propertyId of an object of the device at addr, or the whole property when
+// arrayIndex is objects.ArrayAll. The value is returned as application tagged
+// objects, one for each element of an array.
+func (c *Client) ReadPropertyIndex(ctx context.Context, addr net.Addr, objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32) ([]objects.APDUPayload, error) {
+	var req []byte
+	var err error
+	if arrayIndex == objects.ArrayAll {
+		req, err = NewReadProperty(objectType, instN, propertyId)
+	} else {
+		req, err = NewReadPropertyIndex(objectType, instN, propertyId, arrayIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.Request(ctx, addr, req)
+	if err != nil {
+		return nil, err
+	}
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected ReadProperty reply %T", reply),
+		)
+	}
+	res, err := cack.DecodeResult()
+	if err != nil {
+		return nil, err
+	}
+	return res.Results[0].Value, nil
+}
+
+// ReadPropertyMultiple reads the properties described by specs from the
+// device at addr. The properties which can't be read have their error set in
+// the results instead of failing the request.
+func (c *Client) ReadPropertyMultiple(ctx context.Context, addr net.Addr, specs []services.ReadAccessSpec) ([]services.ReadAccessResult, error) {
+	req, err := NewReadAccess(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.Request(ctx, addr, req)
+	if err != nil {
+		return nil, err
+	}
+	ack, ok := reply.(*services.ReadPropertyMultipleACK)
+	if !ok {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected ReadPropertyMultiple reply %T", reply),
+		)
+	}
+	return ack.Decode()
+}
+
+// WriteProperty writes value, application tagged objects, at priority to the
+// property propertyId of an object of the device at addr. Writing a NULL value
+// relinquishes the priority of a commandable property.
+func (c *Client) WriteProperty(ctx context.Context, addr net.Addr, objectType uint16, instN uint32, propertyId uint8, value []objects.APDUPayload, priority uint8) error {
+	req, err := NewWritePropertyValue(services.WritePropertyValue{
+		ObjectType: objectType,
+		InstanceId: instN,
+		PropertyId: propertyId,
+		ArrayIndex: objects.ArrayAll,
+		Value:      value,
+		Priority:   priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	reply, err := c.Request(ctx, addr, req)
+	if err != nil {
+		return err
+	}
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		return errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected WriteProperty reply %T", reply),
+		)
+	}
+	return nil
+}
+
+// begin allocates an invoke ID for a request to the peer key, waiting for
+// the outstanding requests to go under the limits of the Client first.
+func (c *Client) begin(ctx context.Context, key string) (uint8, chan plumbing.BACnet, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return 0, nil, common.ErrClosed
+		}
+
+		p, ok := c.peers[key]
+		if !ok {
+			p = &peer{transactions: map[uint8]chan plumbing.BACnet{}}
+			c.peers[key] = p
+		}
+
+		if c.available(p) {
+			invokeID, reply := p.allocate()
+			c.outstanding++
+			c.mu.Unlock()
+			return invokeID, reply, nil
+		}
+		freed := c.freed
+		c.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+}
+
+// available tells whether a request to p can be sent right away.
+func (c *Client) available(p *peer) bool {
+	if c.MaxOutstanding > 0 && c.outstanding >= c.MaxOutstanding {
+		return false
+	}
+	if c.MaxOutstandingPerPeer > 0 && len(p.transactions) >= c.MaxOutstandingPerPeer {
+		return false
+	}
+	return len(p.transactions) < 256
+}
+
+// allocate gives the next free invoke ID of p to a new transaction. There
+// must be one.
+func (p *peer) allocate() (uint8, chan plumbing.BACnet) {
+	for {
+		invokeID := p.nextInvokeID
+		p.nextInvokeID++
+		if _, used := p.transactions[invokeID]; !used {
+			reply := make(chan plumbing.BACnet, 1)
+			p.transactions[invokeID] = reply
+			return invokeID, reply
+		}
+	}
+}
+
+// end frees the invoke ID of a request to the peer key and wakes up the
+// requests waiting for a slot. The peer is kept so that its next request
+// doesn't reuse the invoke ID straight away, which would let a late reply be
+// taken for the answer to the new request.
+func (c *Client) end(key string, invokeID uint8) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.peers[key]
+	if !ok {
+		return
+	}
+	if _, ok := p.transactions[invokeID]; !ok {
+		return
+	}
+	delete(p.transactions, invokeID)
+	c.outstanding--
+
+	if !c.closed {
+		close(c.freed)
+		c.freed = make(chan struct{})
+	}
+}
+
+// receive reads from the socket until it's closed, passing the replies to
+// the requests waiting for them and the other messages to the listeners.
+func (c *Client) receive() {
+	buf := make([]byte, maxBIPFrame)
+	for {
+		nBytes, src, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			c.mu.Lock()
+			closed := c.closed
+			c.mu.Unlock()
+			if closed {
+				return
+			}
+			log.Printf("client failed to read: %v\n", err)
+			continue
+		}
+
+		// The decoded message refers to the bytes it was parsed from, which
+		// must outlive the next read.
+		frame := append([]byte{}, buf[:nBytes]...)
+		msg, err := Parse(frame)
+		if err != nil {
+			log.Printf("client failed to parse message from %s: %v\n", src, err)
+			continue
+		}
+
+		if c.deliver(src, frame, msg) {
+			continue
+		}
+
+		c.mu.Lock()
+		listeners := make([]Listener, 0, len(c.listeners))
+		for _, l := range c.listeners {
+			listeners = append(listeners, l)
+		}
+		c.mu.Unlock()
+
+		for _, l := range listeners {
+			l(src, msg)
+		}
+	}
+}
+
+// deliver passes msg, read from src as b, to the request it answers. It
+// reports whether msg is a reply at all.
+func (c *Client) deliver(src net.Addr, b []byte, msg plumbing.BACnet) bool {
+	pduType, invokeID, err := apduHeader(b)
+	if err != nil {
+		return false
+	}
+	switch pduType {
+	case plumbing.SimpleAck, plumbing.ComplexAck, plumbing.Error, plumbing.Reject, plumbing.Abort:
+	default:
+		return false
+	}
+
+	var npdu plumbing.NPDU
+	if err := frameNPDU(b, &npdu); err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.peers[peerKey(src, npdu.SNET, npdu.SADR)]; ok {
+		if reply, ok := p.transactions[invokeID]; ok {
+			select {
+			case reply <- msg:
+			default:
+				// A duplicate reply to a retried request.
+			}
+		}
+	}
+	return true
+}
+
+// attemptTimer returns the timer ending the attempt attempt of a request:
+// apduTimeout, or when ctx has a deadline, an equal share of the time left
+// until it between the attempts left out of apduRetries. The last attempt
+// lasts until the deadline, its timer being the zero Timer which never fires.
+func attemptTimer(ctx context.Context, apduTimeout time.Duration, apduRetries, attempt int) *time.Timer {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.NewTimer(apduTimeout)
+	}
+	if attempt < apduRetries {
+		return time.NewTimer(time.Until(deadline) / time.Duration(apduRetries-attempt+1))
+	}
+	return &time.Timer{}
+}
+
+// stopTimer stops t unless it's the zero Timer, which never fires.
+func stopTimer(t *time.Timer) {
+	if t.C != nil {
+		t.Stop()
+	}
+}
+
+// replyResult turns the negative replies into errors.
+func replyResult(msg plumbing.BACnet) (plumbing.BACnet, error) {
+	switch r := msg.(type) {
+	case *services.Error:
+		decErr, err := r.Decode()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode error")
+		}
+		return nil, objects.NewBACnetError(decErr.ErrorClass, decErr.ErrorCode)
+	case *services.Reject:
+		rejErr, err := r.Decode()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode reject")
+		}
+		return nil, rejErr
+	case *services.Abort:
+		abortErr, err := r.Decode()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode abort")
+		}
+		return nil, abortErr
+	}
+	return msg, nil
+}
+
+// setDestination returns a copy of the B/IP frame b addressed to the device
+// dadr of the remote network dnet, through the router it's sent to.
+func setDestination(b []byte, dnet uint16, dadr []byte) ([]byte, error) {
+	var bvlc plumbing.BVLC
+	var npdu plumbing.NPDU
+
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	offset := bvlc.MarshalLen()
+	if err := npdu.UnmarshalBinary(b[offset:]); err != nil {
+		return nil, err
+	}
+	apdu := b[offset+npdu.MarshalLen():]
+
+	npdu.Control |= 0x20
+	npdu.DNET = dnet
+	npdu.DLEN = uint8(len(dadr))
+	npdu.DADR = dadr
+	npdu.Hop = 0xFF
+	bvlc.Length = uint16(bvlc.MarshalLen() + npdu.MarshalLen() + len(apdu))
+
+	routed := make([]byte, int(bvlc.Length))
+	if err := bvlc.MarshalTo(routed); err != nil {
+		return nil, errors.Wrap(err, "failed to route request")
+	}
+	if err := npdu.MarshalTo(routed[bvlc.MarshalLen():]); err != nil {
+		return nil, errors.Wrap(err, "failed to route request")
+	}
+	copy(routed[bvlc.MarshalLen()+npdu.MarshalLen():], apdu)
+
+	return routed, nil
+}
+
+// frameNPDU decodes into npdu the NPDU of the B/IP frame b.
+func frameNPDU(b []byte, npdu *plumbing.NPDU) error {
+	var bvlc plumbing.BVLC
+
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return err
+	}
+	return npdu.UnmarshalBinary(b[bvlc.MarshalLen():])
+}
+
+// peerKey identifies the device at addr, or the device mac of the remote
+// network network behind the router at addr when network isn't 0.
+func peerKey(addr net.Addr, network uint16, mac []byte) string {
+	if network == 0 {
+		return addr.String()
+	}
+	return fmt.Sprintf("%s/%d/%x", addr, network, mac)
+}
+
+// apduOffset returns the offset of the APDU carried by the frame b.
+func apduOffset(b []byte) (int, error) {
+	var bvlc plumbing.BVLC
+	var npdu plumbing.NPDU
+
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return 0, err
+	}
+	offset := bvlc.MarshalLen()
+	if err := npdu.UnmarshalBinary(b[offset:]); err != nil {
+		return 0, err
+	}
+	offset += npdu.MarshalLen()
+
+	if offset >= len(b) {
+		return 0, errors.Wrap(common.ErrTooShortToParse, "missing APDU")
+	}
+	return offset, nil
+}
+
+// apduHeader returns the PDU type of the frame b and, for the PDUs carrying
+// one, its invoke ID.
+func apduHeader(b []byte) (uint8, uint8, error) {
+	offset, err := apduOffset(b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pduType := b[offset] >> 4
+	switch pduType {
+	case plumbing.ConfirmedReq:
+		offset += 2
+	case plumbing.UnConfirmedReq:
+		return pduType, 0, nil
+	default:
+		offset++
+	}
+	if offset >= len(b) {
+		return 0, 0, errors.Wrap(common.ErrTooShortToParse, "missing invoke ID")
+	}
+	return pduType, b[offset], nil
+}
+
+// setInvokeID sets the invoke ID of the confirmed request b.
+func setInvokeID(b []byte, invokeID uint8) error {
+	offset, err := apduOffset(b)
+	if err != nil {
+		return err
+	}
+	if b[offset]>>4 != plumbing.ConfirmedReq || offset+2 >= len(b) {
+		return errors.Wrap(common.ErrWrongStructure, "not a confirmed request")
+	}
+	b[offset+2] = invokeID
+	return nil
+}
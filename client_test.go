@@ -0,0 +1,294 @@
+package bacnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+// testPeer is a device on the loopback interface passing the WriteProperty
+// and Who-Is requests it receives to the test, which answers them when it
+// likes.
+type testPeer struct {
+	conn     net.PacketConn
+	requests chan testPeerRequest
+	whoIs    chan testPeerWhoIs
+}
+
+type testPeerRequest struct {
+	src      net.Addr
+	dnet     uint16
+	dadr     []byte
+	invokeID uint8
+}
+
+type testPeerWhoIs struct {
+	src net.Addr
+	services.UnconfirmedWhoIsDec
+}
+
+func newTestPeer(t *testing.T) *testPeer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	p := &testPeer{
+		conn:     conn,
+		requests: make(chan testPeerRequest, 64),
+		whoIs:    make(chan testPeerWhoIs, 64),
+	}
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, src, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			msg, err := bacnet.Parse(append([]byte{}, buf[:n]...))
+			if err != nil {
+				continue
+			}
+			switch msg := msg.(type) {
+			case *services.ConfirmedWriteProperty:
+				p.requests <- testPeerRequest{src: src, dnet: msg.NPDU.DNET, dadr: msg.NPDU.DADR, invokeID: msg.APDU.InvokeID}
+			case *services.UnconfirmedWhoIs:
+				if dec, err := msg.Decode(); err == nil {
+					p.whoIs <- testPeerWhoIs{src: src, UnconfirmedWhoIsDec: dec}
+				}
+			}
+		}
+	}()
+	return p
+}
+
+// next returns the next request received, failing the test when none comes
+// within a second.
+func (p *testPeer) next(t *testing.T) testPeerRequest {
+	t.Helper()
+	select {
+	case r := <-p.requests:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("no request received")
+		return testPeerRequest{}
+	}
+}
+
+// none fails the test when a request is received within d.
+func (p *testPeer) none(t *testing.T, d time.Duration) {
+	t.Helper()
+	select {
+	case <-p.requests:
+		t.Fatal("unexpected request received")
+	case <-time.After(d):
+	}
+}
+
+// ack answers r with a SimpleACK, from the remote device r was routed to if
+// any.
+func (p *testPeer) ack(t *testing.T, r testPeerRequest) {
+	t.Helper()
+	npdu := plumbing.NewNPDU(false, false, r.dnet != 0, false)
+	npdu.SNET, npdu.SLEN, npdu.SADR = r.dnet, uint8(len(r.dadr)), r.dadr
+	s := services.NewSimpleACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+	s.APDU.Service = services.ServiceConfirmedWriteProperty
+	s.APDU.InvokeID = r.invokeID
+	s.SetLength()
+
+	b, err := s.MarshalBinary()
+	if err == nil {
+		_, err = p.conn.WriteTo(b, r.src)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// nextWhoIs returns the next Who-Is received, failing the test when none
+// comes within a second.
+func (p *testPeer) nextWhoIs(t *testing.T) testPeerWhoIs {
+	t.Helper()
+	select {
+	case w := <-p.whoIs:
+		return w
+	case <-time.After(time.Second):
+		t.Fatal("no Who-Is received")
+		return testPeerWhoIs{}
+	}
+}
+
+// iAm sends dst an I-Am for the device deviceId.
+func (p *testPeer) iAm(t *testing.T, deviceId uint32, dst net.Addr) {
+	t.Helper()
+	b, err := bacnet.NewIAm(deviceId, 0)
+	if err == nil {
+		_, err = p.conn.WriteTo(b, dst)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func newTestClient(t *testing.T) *bacnet.Client {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := bacnet.NewClient(conn)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClientDeadlineShare(t *testing.T) {
+	peer := newTestPeer(t)
+	c := newTestClient(t)
+	c.APDUTimeout = 50 * time.Millisecond
+	c.APDURetries = 1
+
+	// The first attempt lasts half of the deadline, well over APDUTimeout,
+	// so that the slow reply to it is still awaited.
+	go func() {
+		r := <-peer.requests
+		time.Sleep(300 * time.Millisecond)
+		peer.ack(t, r)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := c.WriteProperty(ctx, peer.conn.LocalAddr(), objects.ObjectTypeAnalogOutput, 0, objects.PropertyIdPresentValue,
+		[]objects.APDUPayload{objects.EncReal(1)}, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientMaxOutstanding(t *testing.T) {
+	req, err := bacnet.NewWritePropertyValue(services.WritePropertyValue{
+		ObjectType: objects.ObjectTypeAnalogOutput,
+		PropertyId: objects.PropertyIdPresentValue,
+		ArrayIndex: objects.ArrayAll,
+		Value:      []objects.APDUPayload{objects.EncReal(1)},
+		Priority:   16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name           string
+		perPeer, total int
+		// devices are the MAC addresses, on network 5 behind the peer, of the
+		// devices the first requests are sent to, nil being the peer itself.
+		// The last request goes to the first device and waits.
+		devices [][]byte
+	}{
+		{"per device", 1, 0, [][]byte{{1}, {2}, nil}},
+		{"overall", 0, 2, [][]byte{{1}, {2}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			peer := newTestPeer(t)
+			client := newTestClient(t)
+			client.MaxOutstandingPerPeer = c.perPeer
+			client.MaxOutstanding = c.total
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			errs := make(chan error, len(c.devices)+1)
+			request := func(mac []byte) {
+				d := bacnet.DeviceRecord{Addr: peer.conn.LocalAddr()}
+				if mac != nil {
+					d.SNET, d.SADR = 5, mac
+				}
+				_, err := client.RequestDevice(ctx, d, req)
+				errs <- err
+			}
+
+			// Routed or not, the requests to distinct devices don't hold
+			// each other up under the per device limit.
+			sent := make([]testPeerRequest, 0, len(c.devices))
+			for _, mac := range c.devices {
+				go request(mac)
+				sent = append(sent, peer.next(t))
+			}
+
+			go request(c.devices[0])
+			peer.none(t, 100*time.Millisecond)
+
+			// Ending the first request frees its slot for the waiting one.
+			peer.ack(t, sent[0])
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+			last := peer.next(t)
+			if last.dnet != sent[0].dnet || !cmp.Equal(last.dadr, sent[0].dadr) {
+				t.Errorf("request sent to %d/%x, want %d/%x", last.dnet, last.dadr, sent[0].dnet, sent[0].dadr)
+			}
+
+			for _, r := range append(sent[1:], last) {
+				peer.ack(t, r)
+			}
+			for range sent {
+				if err := <-errs; err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestClientMaxOutstandingCanceled(t *testing.T) {
+	peer := newTestPeer(t)
+	client := newTestClient(t)
+	client.MaxOutstandingPerPeer = 1
+
+	req, err := bacnet.NewWritePropertyValue(services.WritePropertyValue{
+		ObjectType: objects.ObjectTypeAnalogOutput,
+		PropertyId: objects.PropertyIdPresentValue,
+		ArrayIndex: objects.ArrayAll,
+		Value:      []objects.APDUPayload{objects.EncNull()},
+		Priority:   16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go client.Request(context.Background(), peer.conn.LocalAddr(), req)
+	first := peer.next(t)
+	defer peer.ack(t, first)
+
+	// A request waiting for a slot gives up with its context.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.Request(ctx, peer.conn.LocalAddr(), req); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientCloseTwice(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := bacnet.NewClient(conn)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != common.ErrClosed {
+		t.Errorf("got %v, want %v", err, common.ErrClosed)
+	}
+}
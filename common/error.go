@@ -17,4 +17,8 @@ var (
 	ErrWrongStructure          = errors.New("unexpected object structure")
 	ErrWrongPayload            = errors.New("wrong payload type")
 	ErrInvalidObjectType       = errors.New("invalid object type")
+	ErrTimeout                 = errors.New("no reply before timeout")
+	ErrClosed                  = errors.New("use of closed client")
+	ErrNoInvokeID              = errors.New("no invoke ID available")
+	ErrUnknownAddress          = errors.New("unknown address")
 )
@@ -0,0 +1,372 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// DefaultCOVLifetime is the lifetime of the subscriptions of a COVManager.
+const DefaultCOVLifetime = 10 * time.Minute
+
+// COVManager keeps points subscribed to with SubscribeCOV. Subscriptions are
+// renewed once three quarters of their Lifetime went by, a zero Lifetime
+// asking for subscriptions which never expire, and Confirmed tells whether
+// notifications are requested as ConfirmedCOVNotification. Every point is
+// given a subscriber process ID of its own, which routes the notifications to
+// its channel. An I-Am from a device, which devices broadcast when they
+// start, has its points subscribed again. The points of a device which
+// rejects SubscribeCOV, and the points it refuses to subscribe to, are polled
+// instead every Interval, their changes being sent like notifications.
+//
+// Process IDs are only unique within a COVManager, so a Client should be used
+// by a single one.
+type COVManager struct {
+	Lifetime  time.Duration
+	Confirmed bool
+
+	client    *Client
+	subs      map[uint32]*covSubscription
+	noCOV     map[string]bool
+	processId uint32
+
+	mu sync.Mutex
+}
+
+type covSubscription struct {
+	Point
+	processId uint32
+	values    chan PointValue
+	restart   chan struct{}
+	value     PointValue
+}
+
+// NewCOVManager creates a COVManager subscribing through c.
+func NewCOVManager(c *Client) *COVManager {
+	return &COVManager{
+		Lifetime: DefaultCOVLifetime,
+		client:   c,
+		subs:     map[uint32]*covSubscription{},
+		noCOV:    map[string]bool{},
+	}
+}
+
+// Subscribe adds the point pt, which must be done before Run, and returns
+// the channel its values are sent to. The property of pt must be one the
+// device reports in its notifications, usually Present_Value. A value not
+// received yet is replaced by the next one, so the channel always holds the
+// latest value.
+func (m *COVManager) Subscribe(pt Point) <-chan PointValue {
+	if pt.Interval <= 0 {
+		pt.Interval = DefaultPollInterval
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.processId++
+	s := &covSubscription{
+		Point:     pt,
+		processId: m.processId,
+		values:    make(chan PointValue, 1),
+		restart:   make(chan struct{}, 1),
+		value:     PointValue{Quality: QualityStale},
+	}
+	m.subs[s.processId] = s
+	return s.values
+}
+
+// Run keeps the points subscribed until ctx is done, then cancels their
+// subscriptions and returns ctx.Err().
+func (m *COVManager) Run(ctx context.Context) error {
+	stop := m.client.Listen(m.hear)
+	defer stop()
+
+	m.mu.Lock()
+	subs := make([]*covSubscription, 0, len(m.subs))
+	for _, s := range m.subs {
+		subs = append(subs, s)
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, s := range subs {
+		wg.Add(1)
+		go func(s *covSubscription) {
+			defer wg.Done()
+			m.maintain(ctx, s)
+		}(s)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// maintain keeps s subscribed, or polls it once the device refused the
+// subscription, until ctx is done.
+func (m *COVManager) maintain(ctx context.Context, s *covSubscription) {
+	for {
+		d := m.device(s)
+		if m.refusesCOV(d) {
+			m.poll(ctx, s)
+			return
+		}
+
+		err := m.subscribe(ctx, d, s, false)
+		if ctx.Err() != nil {
+			// The device may have taken a subscription whose reply was
+			// abandoned.
+			if err == nil || errors.Cause(err) == ctx.Err() {
+				m.cancel(d, s)
+			}
+			return
+		}
+
+		var timer *time.Timer
+		switch {
+		case err == nil:
+			if m.Lifetime > 0 {
+				timer = time.NewTimer(m.Lifetime * 3 / 4)
+			}
+		case refusedByDevice(err):
+			if _, ok := errors.Cause(err).(*objects.RejectError); ok {
+				m.mu.Lock()
+				m.noCOV[d.bindingKey()] = true
+				m.mu.Unlock()
+			}
+			m.poll(ctx, s)
+			return
+		default:
+			m.update(s, PointValue{Quality: QualityStale, Err: err}, false)
+			timer = time.NewTimer(s.Interval)
+		}
+
+		// A nil channel never fires: subscriptions without lifetime are only
+		// renewed when the device restarts.
+		var renew <-chan time.Time
+		if timer != nil {
+			renew = timer.C
+		}
+		select {
+		case <-renew:
+		case <-s.restart:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			if err == nil {
+				m.cancel(d, s)
+			}
+			return
+		}
+	}
+}
+
+// subscribe sends the SubscribeCOV request of s to the device d, or the one
+// cancelling it.
+func (m *COVManager) subscribe(ctx context.Context, d DeviceRecord, s *covSubscription, cancel bool) error {
+	var req []byte
+	var err error
+	if cancel {
+		req, err = NewSubscribeCOVCancel(s.processId, s.Ref.ObjectType, s.Ref.InstanceNumber)
+	} else {
+		req, err = NewSubscribeCOV(s.processId, s.Ref.ObjectType, s.Ref.InstanceNumber, m.Confirmed, uint32(m.Lifetime/time.Second))
+	}
+	if err != nil {
+		return err
+	}
+
+	reply, err := m.client.RequestDevice(ctx, d, req)
+	if err != nil {
+		return err
+	}
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		return errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected SubscribeCOV reply %T", reply),
+		)
+	}
+	return nil
+}
+
+// cancel cancels the subscription of s, once the context of Run is done.
+func (m *COVManager) cancel(d DeviceRecord, s *covSubscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.client.APDUTimeout)
+	defer cancel()
+
+	if err := m.subscribe(ctx, d, s, true); err != nil {
+		log.Printf("failed to cancel subscription %d: %v\n", s.processId, err)
+	}
+}
+
+// poll reads s every Interval until ctx is done.
+func (m *COVManager) poll(ctx context.Context, s *covSubscription) {
+	for {
+		b := NewBatchReader(m.client, m.device(s))
+		results, err := b.Read(ctx, []objects.DeviceObjectPropertyReference{s.Ref})
+		if ctx.Err() != nil {
+			return
+		}
+
+		v := PointValue{Quality: QualityStale, Time: time.Now()}
+		switch {
+		case err != nil:
+			v.Err = err
+		case results[0].Err != nil:
+			v.Err = results[0].Err
+		default:
+			if v.Value, v.Err = decPointValue(results[0].Value); v.Err == nil {
+				v.Quality = QualityGood
+			}
+		}
+		m.update(s, v, true)
+
+		if err := sleepContext(ctx, s.Interval); err != nil {
+			return
+		}
+	}
+}
+
+// hear routes the COV notifications received by the Client to the points
+// they report, and subscribes again the points of the devices announcing
+// themselves. Confirmed notifications are always answered: with an Error
+// when they report no point of the COVManager.
+func (m *COVManager) hear(src net.Addr, msg plumbing.BACnet) {
+	switch n := msg.(type) {
+	case *services.UnconfirmedCOVNotification:
+		dec, err := n.Decode()
+		if err != nil {
+			log.Printf("COV manager ignoring notification from %s: %v\n", src, err)
+			return
+		}
+		m.notified(dec)
+	case *services.ConfirmedCOVNotification:
+		var reply []byte
+		dec, err := n.Decode()
+		switch {
+		case err != nil:
+			log.Printf("COV manager rejecting notification from %s: %v\n", src, err)
+			reply, err = NewRejectReply(n.APDU.InvokeID, objects.RejectReasonInvalidTag)
+		case m.notified(dec):
+			reply, err = NewSimpleACKReply(n.APDU.InvokeID, services.ServiceConfirmedCOVNotification)
+		default:
+			reply, err = NewErrorReply(n.APDU.InvokeID, services.ServiceConfirmedCOVNotification,
+				objects.NewBACnetError(objects.ErrorClassService, objects.ErrorCodeUnknownSubscription))
+		}
+		if err == nil {
+			err = m.answer(src, n.NPDU, reply)
+		}
+		if err != nil {
+			log.Printf("failed to answer notification %d from %s: %v\n", n.APDU.InvokeID, src, err)
+		}
+	case *services.UnconfirmedIAm:
+		d, err := NewDeviceRecord(src, n)
+		if err != nil {
+			return
+		}
+		m.restarted(d)
+	}
+}
+
+// notified sends the value reported by n to the point it's routed to, and
+// tells whether there is one.
+func (m *COVManager) notified(n services.COVNotificationDec) bool {
+	m.mu.Lock()
+	s, ok := m.subs[n.ProcessId]
+	m.mu.Unlock()
+	if !ok || n.InitiatingDevice.InstanceNumber != m.device(s).DeviceId ||
+		n.MonitoredObject.ObjectType != s.Ref.ObjectType || n.MonitoredObject.InstanceNumber != s.Ref.InstanceNumber {
+		return false
+	}
+
+	for _, pv := range n.Values {
+		if pv.PropertyId != s.Ref.PropertyId || pv.ArrayIndex != s.Ref.ArrayIndex {
+			continue
+		}
+		v := PointValue{Quality: QualityGood, Time: time.Now()}
+		if v.Value, v.Err = decPointValue(pv.Value); v.Err != nil {
+			v.Quality = QualityStale
+		}
+		m.update(s, v, false)
+	}
+	return true
+}
+
+// answer sends reply to the ConfirmedCOVNotification received from src with
+// npdu, through the router it came from if any.
+func (m *COVManager) answer(src net.Addr, npdu *plumbing.NPDU, reply []byte) error {
+	if npdu.SNET != 0 {
+		routed, err := setDestination(reply, npdu.SNET, npdu.SADR)
+		if err != nil {
+			return err
+		}
+		reply = routed
+	}
+	return m.client.Send(context.Background(), src, reply)
+}
+
+// restarted updates the binding of the points of the device d and has them
+// subscribed again.
+func (m *COVManager) restarted(d DeviceRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.subs {
+		if s.Device.DeviceId != d.DeviceId {
+			continue
+		}
+		s.Device = d
+		select {
+		case s.restart <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (m *COVManager) device(s *covSubscription) DeviceRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return s.Device
+}
+
+func (m *COVManager) refusesCOV(d DeviceRecord) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.noCOV[d.bindingKey()]
+}
+
+// update sends v to the channel of s. Failures keep the last value read and
+// are only sent when the quality of the point changes, and polled values when
+// they changed by more than the deadband of the point.
+func (m *COVManager) update(s *covSubscription, v PointValue, polled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if v.Quality != QualityGood {
+		v.Value, v.Time = s.value.Value, s.value.Time
+	}
+	if v.Quality == s.value.Quality &&
+		(v.Quality != QualityGood || (polled && !valueChanged(s.value.Value, v.Value, s.Deadband))) {
+		return
+	}
+	s.value = v
+
+	select {
+	case <-s.values:
+	default:
+	}
+	s.values <- v
+}
@@ -0,0 +1,181 @@
+package bacnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// covSubscribe is a SubscribeCOV request received by a test device from src.
+type covSubscribe struct {
+	src net.Addr
+	services.SubscribeCOVDec
+}
+
+// nextSubscribe returns the next SubscribeCOV request received in subs,
+// failing the test when none comes within d.
+func nextSubscribe(t *testing.T, subs <-chan covSubscribe, d time.Duration) covSubscribe {
+	t.Helper()
+	select {
+	case s := <-subs:
+		return s
+	case <-time.After(d):
+		t.Fatal("no SubscribeCOV received")
+		return covSubscribe{}
+	}
+}
+
+// nextPointValue returns the next value sent to values, failing the test
+// when none comes within a second.
+func nextPointValue(t *testing.T, values <-chan bacnet.PointValue) bacnet.PointValue {
+	t.Helper()
+	select {
+	case v := <-values:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no value received")
+		return bacnet.PointValue{}
+	}
+}
+
+func TestCOVManagerSubscribe(t *testing.T) {
+	s, addr := newTestServer(t)
+	subs := make(chan covSubscribe, 16)
+	s.Handle(services.ServiceConfirmedSubscribeCOV, func(req bacnet.Request) ([]byte, error) {
+		dec, err := req.Msg.(*services.ConfirmedSubscribeCOV).Decode()
+		if err != nil {
+			return nil, err
+		}
+		subs <- covSubscribe{src: req.Src, SubscribeCOVDec: dec}
+		return bacnet.NewSimpleACKReply(req.InvokeID, req.Service)
+	})
+
+	m := bacnet.NewCOVManager(newTestClient(t))
+	m.Lifetime = time.Second
+	m.Confirmed = true
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	values := m.Subscribe(bacnet.Point{
+		Device: bacnet.DeviceRecord{DeviceId: 1, Addr: addr},
+		Ref: objects.DeviceObjectPropertyReference{
+			ObjectType:     av.ObjectType,
+			InstanceNumber: av.InstanceNumber,
+			PropertyId:     objects.PropertyIdPresentValue,
+			ArrayIndex:     objects.ArrayAll,
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	first := nextSubscribe(t, subs, time.Second)
+	if diff := cmp.Diff(services.SubscribeCOVDec{
+		ProcessId:       first.ProcessId,
+		MonitoredObject: av,
+		IssueConfirmed:  true,
+		Lifetime:        1,
+	}, first.SubscribeCOVDec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	// Confirmed notifications are acknowledged when they report the point,
+	// and answered with an Error otherwise.
+	notify := func(processId uint32, object objects.ObjectIdentifier) error {
+		t.Helper()
+		req, err := bacnet.NewCOVNotification(services.COVNotificationDec{
+			ProcessId:        processId,
+			InitiatingDevice: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 1},
+			MonitoredObject:  object,
+			Values: []services.PropertyValue{{
+				PropertyId: objects.PropertyIdPresentValue,
+				ArrayIndex: objects.ArrayAll,
+				Value:      []objects.APDUPayload{objects.EncReal(42)},
+			}},
+		}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqCtx, reqCancel := context.WithTimeout(context.Background(), time.Second)
+		defer reqCancel()
+		_, err = s.Request(reqCtx, first.src, req)
+		return err
+	}
+	if err := notify(first.ProcessId, av); err != nil {
+		t.Fatal(err)
+	}
+	if v := nextPointValue(t, values); v.Quality != bacnet.QualityGood || v.Value != float32(42) {
+		t.Errorf("got %+v, want a good 42", v)
+	}
+	unknown := objects.NewBACnetError(objects.ErrorClassService, objects.ErrorCodeUnknownSubscription)
+	other := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 4}
+	for _, err := range []error{notify(first.ProcessId+1, av), notify(first.ProcessId, other)} {
+		if diff := cmp.Diff(unknown, errors.Cause(err)); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+	}
+
+	// The subscription is renewed once three quarters of its lifetime went
+	// by, and right away when the device announces itself.
+	if renewed := nextSubscribe(t, subs, time.Second); renewed.ProcessId != first.ProcessId || renewed.Cancel {
+		t.Errorf("got %+v, want a renewal of subscription %d", renewed.SubscribeCOVDec, first.ProcessId)
+	}
+	iAm, err := bacnet.NewIAm(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(first.src, iAm); err != nil {
+		t.Fatal(err)
+	}
+	if again := nextSubscribe(t, subs, 300*time.Millisecond); again.ProcessId != first.ProcessId || again.Cancel {
+		t.Errorf("got %+v, want subscription %d again", again.SubscribeCOVDec, first.ProcessId)
+	}
+
+	// Subscriptions are cancelled once Run is over.
+	cancel()
+	if cancelled := nextSubscribe(t, subs, time.Second); !cancelled.Cancel || cancelled.ProcessId != first.ProcessId {
+		t.Errorf("got %+v, want subscription %d cancelled", cancelled.SubscribeCOVDec, first.ProcessId)
+	}
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCOVManagerPolling(t *testing.T) {
+	db := objects.NewDatabase(1, "test", 0)
+	if err := db.Add(objects.ObjectTypeAnalogValue, 0, "AV-0"); err != nil {
+		t.Fatal(err)
+	}
+	// The device serves its objects but not SubscribeCOV, which it rejects.
+	s, addr := newTestServer(t)
+	bacnet.ServeDatabase(s, db, nil)
+
+	m := bacnet.NewCOVManager(newTestClient(t))
+	values := m.Subscribe(bacnet.Point{
+		Device: bacnet.DeviceRecord{DeviceId: 1, Addr: addr},
+		Ref: objects.DeviceObjectPropertyReference{
+			ObjectType:     objects.ObjectTypeAnalogValue,
+			InstanceNumber: 0,
+			PropertyId:     objects.PropertyIdPresentValue,
+			ArrayIndex:     objects.ArrayAll,
+		},
+		Interval: 50 * time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	if v := nextPointValue(t, values); v.Quality != bacnet.QualityGood || v.Value != float32(0) {
+		t.Errorf("got %+v, want a good 0", v)
+	}
+	setProperty(t, db, objects.ObjectTypeAnalogValue, 0, objects.PropertyIdPresentValue, objects.EncReal(5))
+	if v := nextPointValue(t, values); v.Quality != bacnet.QualityGood || v.Value != float32(5) {
+		t.Errorf("got %+v, want a good 5", v)
+	}
+}
@@ -0,0 +1,361 @@
+package bacnet
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+// COVServer serves the SubscribeCOV and SubscribeCOVProperty requests for the
+// objects of a Database, and notifies the subscribers of their changes
+// through a Server. SubscribeCOV reports the Present_Value and Status_Flags of
+// objects, analog values being reported when they moved by COV_Increment or
+// more since the last notification and the others on any change.
+// SubscribeCOVProperty reports the monitored property along with the
+// Status_Flags, its increment defaulting to the COV_Increment of the object
+// for Present_Value and to any change otherwise. A change of the Status_Flags
+// is always reported, as is the current value upon subscription.
+//
+// Subscriptions are identified by their subscriber address, along with the
+// network and address it sits at behind a router if any, process ID,
+// monitored object and property, and expire after their lifetime.
+// Notifications to a subscriber are sent in order, a change made while the
+// previous one is being confirmed replacing the changes not sent yet.
+type COVServer struct {
+	server *Server
+	db     *objects.Database
+	stop   func()
+	subs   []*covSubscriber
+
+	mu sync.Mutex
+}
+
+type covSubscriber struct {
+	addr      net.Addr
+	snet      uint16
+	sadr      []byte
+	processId uint32
+	object    objects.ObjectIdentifier
+	property  *services.PropertyReference
+	increment *float32
+	confirmed bool
+	expires   time.Time
+	expiry    *time.Timer
+
+	// value and flags were reported last.
+	value []objects.APDUPayload
+	flags []objects.APDUPayload
+
+	pending chan []services.PropertyValue
+	done    chan struct{}
+}
+
+// NewCOVServer creates a COVServer serving the objects of db through s,
+// registering its handlers on s.
+func NewCOVServer(s *Server, db *objects.Database) *COVServer {
+	c := &COVServer{
+		server: s,
+		db:     db,
+	}
+	c.stop = db.Listen(c.changed)
+
+	s.Handle(services.ServiceConfirmedSubscribeCOV, func(req Request) ([]byte, error) {
+		scov, ok := req.Msg.(*services.ConfirmedSubscribeCOV)
+		if !ok {
+			return nil, objects.NewRejectError(objects.RejectReasonOther)
+		}
+		dec, err := scov.Decode()
+		if err != nil {
+			return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+		}
+		if err := c.subscribe(req.Src, scov.NPDU, dec, nil, nil); err != nil {
+			return nil, err
+		}
+		return NewSimpleACKReply(req.InvokeID, services.ServiceConfirmedSubscribeCOV)
+	})
+	s.Handle(services.ServiceConfirmedSubscribeCOVProperty, func(req Request) ([]byte, error) {
+		scovp, ok := req.Msg.(*services.ConfirmedSubscribeCOVProperty)
+		if !ok {
+			return nil, objects.NewRejectError(objects.RejectReasonOther)
+		}
+		dec, err := scovp.Decode()
+		if err != nil {
+			return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+		}
+		if err := c.subscribe(req.Src, scovp.NPDU, dec.SubscribeCOVDec, &dec.MonitoredProperty, dec.COVIncrement); err != nil {
+			return nil, err
+		}
+		return NewSimpleACKReply(req.InvokeID, services.ServiceConfirmedSubscribeCOVProperty)
+	})
+
+	return c
+}
+
+// Close stops following the changes of the Database and drops the
+// subscriptions.
+func (c *COVServer) Close() {
+	c.stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, sub := range c.subs {
+		sub.end()
+	}
+	c.subs = nil
+}
+
+// subscribe creates, renews or cancels the subscription of the subscriber
+// src, whose request came with npdu, to the property ref of an object, its
+// Present_Value when ref is nil.
+func (c *COVServer) subscribe(src net.Addr, npdu *plumbing.NPDU, s services.SubscribeCOVDec, ref *services.PropertyReference, increment *float32) error {
+	obj := s.MonitoredObject
+	if ref == nil {
+		// Only the objects with Status_Flags report their Present_Value.
+		_, err := c.db.ReadProperty(obj.ObjectType, obj.InstanceNumber, objects.PropertyIdStatusFlags, objects.ArrayAll)
+		if err != nil && bacnetError(err).Code == objects.ErrorCodeUnknownProperty {
+			return objects.NewBACnetError(objects.ErrorClassObject, objects.ErrorCodeOptionalFunctionalityNotSupported)
+		}
+		if err != nil {
+			return err
+		}
+	} else if _, err := c.db.ReadProperty(obj.ObjectType, obj.InstanceNumber, ref.PropertyId, arrayIndex(ref)); err != nil {
+		return err
+	}
+
+	// The subscribers behind a router are told apart by their source.
+	var snet uint16
+	var sadr []byte
+	if npdu != nil {
+		snet, sadr = npdu.SNET, npdu.SADR
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.find(src, snet, sadr, s.ProcessId, obj, ref)
+	if s.Cancel {
+		if i >= 0 {
+			c.subs[i].end()
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+		}
+		return nil
+	}
+
+	var sub *covSubscriber
+	if i >= 0 {
+		sub = c.subs[i]
+		if sub.expiry != nil {
+			sub.expiry.Stop()
+		}
+	} else {
+		sub = &covSubscriber{
+			addr:      src,
+			snet:      snet,
+			sadr:      sadr,
+			processId: s.ProcessId,
+			object:    obj,
+			property:  ref,
+			pending:   make(chan []services.PropertyValue, 1),
+			done:      make(chan struct{}),
+		}
+		c.subs = append(c.subs, sub)
+		go c.send(sub)
+	}
+	sub.increment = increment
+	sub.confirmed = s.IssueConfirmed
+	sub.expires, sub.expiry = time.Time{}, nil
+	if s.Lifetime > 0 {
+		lifetime := time.Duration(s.Lifetime) * time.Second
+		sub.expires = time.Now().Add(lifetime)
+		sub.expiry = time.AfterFunc(lifetime, func() { c.expire(sub) })
+	}
+
+	// The current value is reported right away.
+	sub.value, sub.flags = nil, nil
+	c.check(sub)
+	return nil
+}
+
+// find returns the index of the subscription of the subscriber src, at sadr
+// on the network snet if it isn't 0, or -1.
+func (c *COVServer) find(src net.Addr, snet uint16, sadr []byte, processId uint32, object objects.ObjectIdentifier, ref *services.PropertyReference) int {
+	key := peerKey(src, snet, sadr)
+	for i, sub := range c.subs {
+		if peerKey(sub.addr, sub.snet, sub.sadr) == key && sub.processId == processId && sub.object == object &&
+			reflect.DeepEqual(sub.property, ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+// expire drops sub once its lifetime is over.
+func (c *COVServer) expire(sub *covSubscriber) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, s := range c.subs {
+		if s == sub && !s.expires.IsZero() && !time.Now().Before(s.expires) {
+			s.end()
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// changed checks the subscriptions to the object id, whose property
+// propertyId changed.
+func (c *COVServer) changed(id objects.ObjectIdentifier, propertyId uint8, value []objects.APDUPayload) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, sub := range c.subs {
+		if sub.object == id {
+			c.check(sub)
+		}
+	}
+}
+
+// check queues a notification to sub when its property changed enough since
+// the last one.
+func (c *COVServer) check(sub *covSubscriber) {
+	propertyId, index := objects.PropertyIdPresentValue, objects.ArrayAll
+	if sub.property != nil {
+		propertyId, index = sub.property.PropertyId, arrayIndex(sub.property)
+	}
+	value, err := c.db.ReadProperty(sub.object.ObjectType, sub.object.InstanceNumber, propertyId, index)
+	if err != nil {
+		return
+	}
+	flags, _ := c.db.ReadProperty(sub.object.ObjectType, sub.object.InstanceNumber, objects.PropertyIdStatusFlags, objects.ArrayAll)
+
+	if sub.value != nil && reflect.DeepEqual(flags, sub.flags) && !c.moved(sub, propertyId, value) {
+		return
+	}
+	sub.value, sub.flags = value, flags
+
+	values := []services.PropertyValue{{PropertyId: propertyId, ArrayIndex: index, Value: value}}
+	if flags != nil {
+		values = append(values, services.PropertyValue{
+			PropertyId: objects.PropertyIdStatusFlags,
+			ArrayIndex: objects.ArrayAll,
+			Value:      flags,
+		})
+	}
+	select {
+	case <-sub.pending:
+	default:
+	}
+	sub.pending <- values
+}
+
+// moved tells whether value moved away from the value last reported to sub
+// by its increment, REAL values only, or changed at all otherwise.
+func (c *COVServer) moved(sub *covSubscriber, propertyId uint8, value []objects.APDUPayload) bool {
+	if len(value) != 1 || len(sub.value) != 1 {
+		return !reflect.DeepEqual(value, sub.value)
+	}
+	now, errNow := objects.DecReal(value[0])
+	last, errLast := objects.DecReal(sub.value[0])
+	if errNow != nil || errLast != nil {
+		return !reflect.DeepEqual(value, sub.value)
+	}
+
+	var increment float32
+	switch {
+	case sub.increment != nil:
+		increment = *sub.increment
+	case propertyId == objects.PropertyIdPresentValue:
+		if v, err := c.db.ReadProperty(sub.object.ObjectType, sub.object.InstanceNumber, objects.PropertyIdCOVIncrement, objects.ArrayAll); err == nil {
+			increment, _ = objects.DecReal(v[0])
+		}
+	}
+	if increment == 0 {
+		return now != last
+	}
+	return math.Abs(float64(now-last)) >= float64(increment)
+}
+
+// send sends the notifications queued for sub until it ends.
+func (c *COVServer) send(sub *covSubscriber) {
+	for {
+		select {
+		case values := <-sub.pending:
+			if err := c.notify(sub, values); err != nil {
+				log.Printf("failed to notify subscriber %d at %s: %v\n", sub.processId, sub.addr, err)
+			}
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// notify sends a COVNotification reporting values to sub, waiting for its
+// acknowledgement when it's confirmed.
+func (c *COVServer) notify(sub *covSubscriber, values []services.PropertyValue) error {
+	c.mu.Lock()
+	n := services.COVNotificationDec{
+		ProcessId:        sub.processId,
+		InitiatingDevice: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: c.db.DeviceId()},
+		MonitoredObject:  sub.object,
+		Values:           values,
+	}
+	if !sub.expires.IsZero() {
+		n.TimeRemaining = uint32(math.Ceil(time.Until(sub.expires).Seconds()))
+	}
+	confirmed, snet, sadr := sub.confirmed, sub.snet, sub.sadr
+	c.mu.Unlock()
+
+	req, err := NewCOVNotification(n, confirmed)
+	if err != nil {
+		return err
+	}
+	if snet != 0 {
+		if req, err = setDestination(req, snet, sadr); err != nil {
+			return err
+		}
+	}
+
+	if !confirmed {
+		return c.server.Send(sub.addr, req)
+	}
+
+	// Give up once the subscription ends.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-sub.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	_, err = c.server.Request(ctx, sub.addr, req)
+	return err
+}
+
+// end stops the notifications of sub.
+func (sub *covSubscriber) end() {
+	if sub.expiry != nil {
+		sub.expiry.Stop()
+	}
+	close(sub.done)
+}
+
+// arrayIndex returns the array index ref refers to, objects.ArrayAll when
+// it refers to a whole property.
+func arrayIndex(ref *services.PropertyReference) uint32 {
+	if ref.ArrayIndex == nil {
+		return objects.ArrayAll
+	}
+	return *ref.ArrayIndex
+}
@@ -0,0 +1,343 @@
+package bacnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+// testSubscriber subscribes to the changes of the objects of a COVServer
+// from the loopback interface, either directly or as devices behind a
+// router, and passes the notifications it receives to the test.
+type testSubscriber struct {
+	conn          net.PacketConn
+	invokeID      uint8
+	replies       chan plumbing.BACnet
+	notifications chan covNotification
+}
+
+// covNotification is a COVNotification received by a testSubscriber, routed
+// to the device sadr on the network snet if it isn't 0.
+type covNotification struct {
+	src       net.Addr
+	snet      uint16
+	sadr      []byte
+	confirmed bool
+	invokeID  uint8
+	services.COVNotificationDec
+}
+
+func newTestSubscriber(t *testing.T) *testSubscriber {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s := &testSubscriber{
+		conn:          conn,
+		replies:       make(chan plumbing.BACnet, 16),
+		notifications: make(chan covNotification, 64),
+	}
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, src, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			msg, err := bacnet.Parse(append([]byte{}, buf[:n]...))
+			if err != nil {
+				continue
+			}
+			switch msg := msg.(type) {
+			case *services.ConfirmedCOVNotification:
+				if dec, err := msg.Decode(); err == nil {
+					s.notifications <- covNotification{src, msg.NPDU.DNET, msg.NPDU.DADR, true, msg.APDU.InvokeID, dec}
+				}
+			case *services.UnconfirmedCOVNotification:
+				if dec, err := msg.Decode(); err == nil {
+					s.notifications <- covNotification{src, msg.NPDU.DNET, msg.NPDU.DADR, false, 0, dec}
+				}
+			default:
+				s.replies <- msg
+			}
+		}
+	}()
+	return s
+}
+
+// subscribe sends the SubscribeCOV request sub to the device at addr, from
+// the device sadr on the network snet if it isn't 0, and waits for its
+// SimpleACK.
+func (s *testSubscriber) subscribe(t *testing.T, addr net.Addr, snet uint16, sadr []byte, sub services.SubscribeCOVDec) {
+	t.Helper()
+	npdu := plumbing.NewNPDU(false, false, snet != 0, true)
+	npdu.SNET, npdu.SLEN, npdu.SADR = snet, uint8(len(sadr)), sadr
+	c := services.NewConfirmedSubscribeCOV(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+	c.APDU.MaxSize = 5
+	s.invokeID++
+	c.APDU.InvokeID = s.invokeID
+	c.APDU.Objects = services.SubscribeCOVObjects(sub)
+	c.SetLength()
+
+	b, err := c.MarshalBinary()
+	if err == nil {
+		_, err = s.conn.WriteTo(b, addr)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case reply := <-s.replies:
+		if _, ok := reply.(*services.SimpleACK); !ok {
+			t.Fatalf("got %T, want a SimpleACK", reply)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no reply received")
+	}
+}
+
+// next returns the next notification received, failing the test when none
+// comes within a second.
+func (s *testSubscriber) next(t *testing.T) covNotification {
+	t.Helper()
+	select {
+	case n := <-s.notifications:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("no notification received")
+		return covNotification{}
+	}
+}
+
+// none fails the test when a notification is received within 100ms.
+func (s *testSubscriber) none(t *testing.T) {
+	t.Helper()
+	select {
+	case n := <-s.notifications:
+		t.Fatalf("unexpected notification %+v", n.COVNotificationDec)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// ack acknowledges the confirmed notification n, from the device it was
+// routed to if any.
+func (s *testSubscriber) ack(t *testing.T, n covNotification) {
+	t.Helper()
+	npdu := plumbing.NewNPDU(false, false, n.snet != 0, false)
+	npdu.SNET, npdu.SLEN, npdu.SADR = n.snet, uint8(len(n.sadr)), n.sadr
+	a := services.NewSimpleACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+	a.APDU.Service = services.ServiceConfirmedCOVNotification
+	a.APDU.InvokeID = n.invokeID
+	a.SetLength()
+
+	b, err := a.MarshalBinary()
+	if err == nil {
+		_, err = s.conn.WriteTo(b, n.src)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// reported returns the Present_Value reported by n, decoded with dec, and the
+// Status_Flags.
+func reported(t *testing.T, n covNotification, dec func(objects.APDUPayload) (interface{}, error)) (interface{}, []bool) {
+	t.Helper()
+	var value interface{}
+	var flags []bool
+	for _, pv := range n.Values {
+		var err error
+		switch pv.PropertyId {
+		case objects.PropertyIdPresentValue:
+			value, err = dec(pv.Value[0])
+		case objects.PropertyIdStatusFlags:
+			flags, err = objects.DecBitString(pv.Value[0])
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return value, flags
+}
+
+func decReal(p objects.APDUPayload) (interface{}, error)       { return objects.DecReal(p) }
+func decEnumerated(p objects.APDUPayload) (interface{}, error) { return objects.DecEnumerated(p) }
+
+// newCOVDatabase creates the Database of device 1 with the objects AV-0,
+// whose COV_Increment is 1, and BV-0.
+func newCOVDatabase(t *testing.T) *objects.Database {
+	t.Helper()
+	db := objects.NewDatabase(1, "test", 0)
+	if err := db.Add(objects.ObjectTypeAnalogValue, 0, "AV-0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(objects.ObjectTypeBinaryValue, 0, "BV-0"); err != nil {
+		t.Fatal(err)
+	}
+	setProperty(t, db, objects.ObjectTypeAnalogValue, 0, objects.PropertyIdCOVIncrement, objects.EncReal(1))
+	return db
+}
+
+func TestCOVServerChanges(t *testing.T) {
+	db := newCOVDatabase(t)
+	s, addr := newTestServer(t)
+	cov := bacnet.NewCOVServer(s, db)
+	defer cov.Close()
+
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 0}
+	bv := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeBinaryValue, InstanceNumber: 0}
+	sub := newTestSubscriber(t)
+	sub.subscribe(t, addr, 0, nil, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, Lifetime: 60})
+	sub.subscribe(t, addr, 0, nil, services.SubscribeCOVDec{ProcessId: 2, MonitoredObject: bv, Lifetime: 60})
+
+	// The current values are reported upon subscription.
+	for range []int{1, 2} {
+		n := sub.next(t)
+		if n.confirmed || n.InitiatingDevice.InstanceNumber != 1 || n.TimeRemaining == 0 || n.TimeRemaining > 60 {
+			t.Errorf("got %+v, want an unconfirmed notification from device 1 with some time remaining", n.COVNotificationDec)
+		}
+		switch n.MonitoredObject {
+		case av:
+			if value, flags := reported(t, n, decReal); value != float32(0) || !cmp.Equal(flags, []bool{false, false, false, false}) {
+				t.Errorf("AV-0 reported %v %v, want 0 with no flag set", value, flags)
+			}
+		case bv:
+			if value, _ := reported(t, n, decEnumerated); value != uint32(0) {
+				t.Errorf("BV-0 reported %v, want 0", value)
+			}
+		default:
+			t.Errorf("unexpected notification for %+v", n.MonitoredObject)
+		}
+	}
+
+	// Analog values are reported once they moved by COV_Increment.
+	setProperty(t, db, av.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(0.5))
+	sub.none(t)
+	setProperty(t, db, av.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(1.2))
+	if value, _ := reported(t, sub.next(t), decReal); value != float32(1.2) {
+		t.Errorf("AV-0 reported %v, want 1.2", value)
+	}
+
+	// A change of the Status_Flags is reported whatever the value.
+	setProperty(t, db, av.ObjectType, 0, objects.PropertyIdOutOfService, objects.EncBoolean(true))
+	if value, flags := reported(t, sub.next(t), decReal); value != float32(1.2) || !cmp.Equal(flags, []bool{false, false, false, true}) {
+		t.Errorf("AV-0 reported %v %v, want 1.2 out of service", value, flags)
+	}
+
+	// Binary values are reported on any change.
+	setProperty(t, db, bv.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncEnumerated(1))
+	n := sub.next(t)
+	if value, _ := reported(t, n, decEnumerated); n.MonitoredObject != bv || value != uint32(1) {
+		t.Errorf("%+v reported %v, want BV-0 reporting 1", n.MonitoredObject, value)
+	}
+}
+
+func TestCOVServerEnd(t *testing.T) {
+	db := newCOVDatabase(t)
+	s, addr := newTestServer(t)
+	cov := bacnet.NewCOVServer(s, db)
+	defer cov.Close()
+
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 0}
+	sub := newTestSubscriber(t)
+	value := float32(0)
+	change := func() {
+		t.Helper()
+		value += 5
+		setProperty(t, db, av.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(value))
+	}
+
+	// Cancelled subscriptions aren't notified anymore.
+	sub.subscribe(t, addr, 0, nil, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, Lifetime: 60})
+	sub.next(t)
+	sub.subscribe(t, addr, 0, nil, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, Cancel: true})
+	change()
+	sub.none(t)
+
+	// Neither are the ones whose lifetime is over.
+	sub.subscribe(t, addr, 0, nil, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, Lifetime: 1})
+	sub.next(t)
+	change()
+	sub.next(t)
+	time.Sleep(1100 * time.Millisecond)
+	change()
+	sub.none(t)
+}
+
+func TestCOVServerConfirmed(t *testing.T) {
+	db := newCOVDatabase(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := bacnet.NewServer(conn, 1, 0)
+	defer s.Close()
+	s.APDUTimeout = 100 * time.Millisecond
+	s.APDURetries = 1
+	cov := bacnet.NewCOVServer(s, db)
+	defer cov.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Serve(ctx)
+
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 0}
+	sub := newTestSubscriber(t)
+	sub.subscribe(t, conn.LocalAddr(), 0, nil, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, IssueConfirmed: true})
+
+	// Confirmed notifications are sent again until acknowledged.
+	first := sub.next(t)
+	if !first.confirmed || first.TimeRemaining != 0 {
+		t.Errorf("got %+v, want a confirmed notification of a subscription without lifetime", first.COVNotificationDec)
+	}
+	retry := sub.next(t)
+	if !retry.confirmed || retry.invokeID != first.invokeID {
+		t.Errorf("got notification %d, want notification %d again", retry.invokeID, first.invokeID)
+	}
+
+	// The changes made while a notification waits for its acknowledgement
+	// are reported once it's acknowledged, the last one only.
+	setProperty(t, db, av.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(5))
+	setProperty(t, db, av.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(10))
+	sub.ack(t, retry)
+	n := sub.next(t)
+	if value, _ := reported(t, n, decReal); !n.confirmed || value != float32(10) {
+		t.Errorf("got %+v reporting %v, want a confirmed notification reporting 10", n.COVNotificationDec, value)
+	}
+	sub.ack(t, n)
+	sub.none(t)
+}
+
+func TestCOVServerRouted(t *testing.T) {
+	db := newCOVDatabase(t)
+	s, addr := newTestServer(t)
+	cov := bacnet.NewCOVServer(s, db)
+	defer cov.Close()
+
+	// Two devices behind the same router subscribe under the same process ID.
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 0}
+	sub := newTestSubscriber(t)
+	for _, sadr := range [][]byte{{1}, {2}} {
+		sub.subscribe(t, addr, 5, sadr, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, Lifetime: 60})
+		if n := sub.next(t); n.snet != 5 || !cmp.Equal(n.sadr, sadr) {
+			t.Errorf("notification routed to %d/%x, want 5/%x", n.snet, n.sadr, sadr)
+		}
+	}
+
+	// Cancelling one subscription leaves the other one.
+	sub.subscribe(t, addr, 5, []byte{1}, services.SubscribeCOVDec{ProcessId: 1, MonitoredObject: av, Cancel: true})
+	setProperty(t, db, av.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(5))
+	if n := sub.next(t); n.snet != 5 || !cmp.Equal(n.sadr, []byte{2}) {
+		t.Errorf("notification routed to %d/%x, want 5/02", n.snet, n.sadr)
+	}
+	sub.none(t)
+}
@@ -0,0 +1,138 @@
+package bacnet
+
+import (
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// ServeDatabase has s serve the objects of db, which holds the Device object
+// of s, registering handlers for ReadProperty, ReadPropertyMultiple and
+// WriteProperty. The Protocol_Services_Supported of the device follows the
+// handlers registered on s. The writes are recorded in auditLog, unless it's
+// nil.
+func ServeDatabase(s *Server, db *objects.Database, auditLog *AuditLog) {
+	db.SetPropertyFunc(objects.ObjectTypeDevice, db.DeviceId(), objects.PropertyIdProtocolServicesSupported, func() []objects.APDUPayload {
+		return []objects.APDUPayload{objects.EncBitString(s.ServicesSupported())}
+	})
+
+	s.Handle(services.ServiceConfirmedReadProperty, func(req Request) ([]byte, error) {
+		return serveReadProperty(db, req)
+	})
+	s.Handle(services.ServiceConfirmedReadPropMultiple, func(req Request) ([]byte, error) {
+		return serveReadPropertyMultiple(db, req)
+	})
+	s.Handle(services.ServiceConfirmedWriteProperty, func(req Request) ([]byte, error) {
+		return serveWriteProperty(db, auditLog, req)
+	})
+}
+
+func serveReadProperty(db *objects.Database, req Request) ([]byte, error) {
+	rp, ok := req.Msg.(*services.ConfirmedReadProperty)
+	if !ok {
+		return nil, objects.NewRejectError(objects.RejectReasonOther)
+	}
+	ref, err := rp.DecodeReference()
+	if err != nil {
+		return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+	}
+
+	value, err := db.ReadProperty(ref.ObjectType, ref.InstanceNumber, ref.PropertyId, ref.ArrayIndex)
+	if err != nil {
+		return nil, err
+	}
+	return NewReadPropertyACK(req.InvokeID, ref.ObjectType, ref.InstanceNumber, ref.PropertyId, ref.ArrayIndex, value)
+}
+
+// serveReadPropertyMultiple reads every property requested, the ones which
+// can't be read being answered with their error.
+func serveReadPropertyMultiple(db *objects.Database, req Request) ([]byte, error) {
+	rpm, ok := req.Msg.(*services.ConfirmedReadProperty)
+	if !ok {
+		return nil, objects.NewRejectError(objects.RejectReasonOther)
+	}
+	specs, err := rpm.DecodeMultiple()
+	if err != nil {
+		return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+	}
+
+	results := make([]services.ReadAccessResult, 0, len(specs))
+	for _, spec := range specs {
+		res := services.ReadAccessResult{ObjectType: spec.ObjectType, InstanceId: spec.InstanceId}
+		for _, ref := range spec.Properties {
+			switch ref.PropertyId {
+			case objects.PropertyIdAll, objects.PropertyIdRequired, objects.PropertyIdOptional:
+				res.Results = append(res.Results, readAllProperties(db, spec, ref.PropertyId)...)
+			default:
+				res.Results = append(res.Results, readResult(db, spec, ref))
+			}
+		}
+		results = append(results, res)
+	}
+	return NewReadPropertyMultipleACK(req.InvokeID, results)
+}
+
+// readAllProperties reads the properties of the object of spec selected by
+// propertyId, one of ALL, REQUIRED and OPTIONAL.
+func readAllProperties(db *objects.Database, spec services.ReadAccessSpec, propertyId uint8) []services.ReadResult {
+	ids, err := db.Properties(spec.ObjectType, spec.InstanceId, propertyId)
+	if err != nil {
+		return []services.ReadResult{{PropertyId: propertyId, Err: bacnetError(err)}}
+	}
+
+	results := make([]services.ReadResult, 0, len(ids))
+	for _, id := range ids {
+		results = append(results, readResult(db, spec, services.PropertyReference{PropertyId: id}))
+	}
+	return results
+}
+
+func readResult(db *objects.Database, spec services.ReadAccessSpec, ref services.PropertyReference) services.ReadResult {
+	res := services.ReadResult{PropertyId: ref.PropertyId, ArrayIndex: ref.ArrayIndex}
+	value, err := db.ReadProperty(spec.ObjectType, spec.InstanceId, ref.PropertyId, arrayIndex(&ref))
+	if err != nil {
+		res.Err = bacnetError(err)
+	} else {
+		res.Value = value
+	}
+	return res
+}
+
+// serveWriteProperty writes the property requested, recording the values it
+// held before and after in auditLog if it isn't nil.
+func serveWriteProperty(db *objects.Database, auditLog *AuditLog, req Request) ([]byte, error) {
+	wp, ok := req.Msg.(*services.ConfirmedWriteProperty)
+	if !ok {
+		return nil, objects.NewRejectError(objects.RejectReasonOther)
+	}
+	w, err := wp.DecodeValue()
+	if err != nil {
+		return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+	}
+
+	if auditLog == nil {
+		if err := db.WriteProperty(w.ObjectType, w.InstanceId, w.PropertyId, w.ArrayIndex, w.Value, w.Priority); err != nil {
+			return nil, err
+		}
+		return NewSimpleACKReply(req.InvokeID, services.ServiceConfirmedWriteProperty)
+	}
+
+	before, after, err := db.SwapProperty(w.ObjectType, w.InstanceId, w.PropertyId, w.ArrayIndex, w.Value, w.Priority)
+	if after == nil {
+		after = w.Value
+	}
+	auditLog.RecordWrite(req.Src, req.InvokeID, w, before, after, err)
+	if err != nil {
+		return nil, err
+	}
+	return NewSimpleACKReply(req.InvokeID, services.ServiceConfirmedWriteProperty)
+}
+
+// bacnetError returns the BACnetError err carries, or an other one.
+func bacnetError(err error) *objects.BACnetError {
+	var bErr *objects.BACnetError
+	if errors.As(err, &bErr) {
+		return bErr
+	}
+	return objects.NewBACnetError(objects.ErrorClassDevice, objects.ErrorCodeOther)
+}
@@ -0,0 +1,69 @@
+package bacnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+func TestServeDatabaseAudit(t *testing.T) {
+	db := objects.NewDatabase(1, "test", 0)
+	if err := db.Add(objects.ObjectTypeNotificationClass, 1, "NC-1"); err != nil {
+		t.Fatal(err)
+	}
+	auditLog := bacnet.NewAuditLog(0, 1, 10)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := bacnet.NewServer(conn, 1, 0)
+	defer server.Close()
+	bacnet.ServeDatabase(server, db, auditLog)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go server.Serve(ctx)
+
+	req, err := bacnet.NewWritePropertyValue(services.WritePropertyValue{
+		ObjectType: objects.ObjectTypeNotificationClass,
+		InstanceId: 1,
+		PropertyId: objects.PropertyIdPriority,
+		ArrayIndex: 2,
+		Value:      []objects.APDUPayload{objects.EncUnsignedInteger32(5)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestClient(t).Request(ctx, conn.LocalAddr(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// The write of an array element is recorded with its index and the
+	// values of that element.
+	nc := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeNotificationClass, InstanceNumber: 1}
+	two := uint32(2)
+	ack := auditLog.Query(services.ConfirmedAuditLogQueryDec{
+		ByTarget: &services.AuditQueryByTarget{
+			Device:     objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 1},
+			Object:     &nc,
+			ArrayIndex: &two,
+		},
+		RequestedCount: 10,
+	})
+	if len(ack.Records) != 1 {
+		t.Fatalf("got %d records, want 1", len(ack.Records))
+	}
+	n := ack.Records[0].Notification
+	if diff := cmp.Diff([]objects.APDUPayload{objects.EncUnsignedInteger32(255)}, n.CurrentValue); diff != "" {
+		t.Errorf("value before differs: (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]objects.APDUPayload{objects.EncUnsignedInteger32(5)}, n.TargetValue); diff != "" {
+		t.Errorf("value after differs: (-want +got)\n%s", diff)
+	}
+}
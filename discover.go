@@ -0,0 +1,232 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// MaxDeviceInstance is the highest device instance number. 4194303 itself is
+// reserved for addressing the local device and never bound to one.
+const MaxDeviceInstance = 0x3FFFFE
+
+// DefaultDiscoverWindow is how long Discover waits for I-Am replies after its
+// last Who-Is when DiscoverOptions.Window isn't set.
+const DefaultDiscoverWindow = 3 * time.Second
+
+// DiscoverOptions configures a discovery. Addr is where Who-Is requests are
+// sent, usually the broadcast address of the network. Only the devices whose
+// instance lies within [Low, High] are asked for, High being MaxDeviceInstance
+// when left zero. When RangeSize is set, that range is split into Who-Is
+// requests each covering RangeSize instances, sent Interval apart, so that a
+// large network doesn't answer all at once.
+type DiscoverOptions struct {
+	Addr      net.Addr
+	Low       uint32
+	High      uint32
+	RangeSize uint32
+	Interval  time.Duration
+	Window    time.Duration
+}
+
+// DeviceRecord binds a device instance to the address it can be reached at:
+// the B/IP address an I-Am came from and, when a router forwarded it, the
+// network (SNET) and MAC address (SADR) the device sits on.
+type DeviceRecord struct {
+	DeviceId     uint32
+	Addr         net.Addr
+	SNET         uint16
+	SADR         []byte
+	MaxAPDU      uint16
+	Segmentation uint8
+	VendorId     uint16
+}
+
+func (d DeviceRecord) bindingKey() string {
+	return fmt.Sprintf("%s/%d/%x", d.Addr, d.SNET, d.SADR)
+}
+
+// DiscoverResult is the address binding table built by Discover. Devices
+// holds one record by device and address, sorted by instance. Duplicates
+// holds the records of the instances claimed by more than one device, which
+// a site should fix as requests to them reach either device.
+type DiscoverResult struct {
+	Devices    []DeviceRecord
+	Duplicates map[uint32][]DeviceRecord
+}
+
+// NewDeviceRecord builds the record of the device which sent the I-Am msg
+// from src.
+func NewDeviceRecord(src net.Addr, msg *services.UnconfirmedIAm) (DeviceRecord, error) {
+	dec, err := msg.Decode()
+	if err != nil {
+		return DeviceRecord{}, errors.Wrap(err, "failed to decode I-Am")
+	}
+
+	d := DeviceRecord{
+		DeviceId:     dec.DeviceId,
+		Addr:         src,
+		MaxAPDU:      dec.MaxAPDULength,
+		Segmentation: dec.SegmentationSupported,
+		VendorId:     dec.VendorId,
+	}
+	if msg.NPDU.SNET != 0 {
+		d.SNET = msg.NPDU.SNET
+		d.SADR = append([]byte{}, msg.NPDU.SADR...)
+	}
+	return d, nil
+}
+
+// Discover broadcasts Who-Is requests as configured by opts and collects the
+// I-Am replies until opts.Window has passed since the last one was sent. The
+// devices found so far are returned along with ctx.Err() when ctx is done
+// before.
+func (c *Client) Discover(ctx context.Context, opts DiscoverOptions) (DiscoverResult, error) {
+	var mu sync.Mutex
+	var records []DeviceRecord
+	seen := map[string]bool{}
+
+	stop := c.Listen(func(src net.Addr, msg plumbing.BACnet) {
+		iAm, ok := msg.(*services.UnconfirmedIAm)
+		if !ok {
+			return
+		}
+		d, err := NewDeviceRecord(src, iAm)
+		if err != nil {
+			log.Printf("ignoring I-Am from %s: %v\n", src, err)
+			return
+		}
+		if !discoverMatch(opts, d.DeviceId) {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if key := fmt.Sprintf("%d@%s", d.DeviceId, d.bindingKey()); !seen[key] {
+			seen[key] = true
+			records = append(records, d)
+		}
+	})
+	defer stop()
+
+	collected := func() DiscoverResult {
+		mu.Lock()
+		defer mu.Unlock()
+		return newDiscoverResult(records)
+	}
+
+	window := opts.Window
+	if window == 0 {
+		window = DefaultDiscoverWindow
+	}
+
+	ranges := discoverRanges(opts)
+	for i, r := range ranges {
+		var req []byte
+		var err error
+		if r[0] == 0 && r[1] == MaxDeviceInstance && len(ranges) == 1 {
+			req, err = NewWhois()
+		} else {
+			req, err = NewWhoIsRange(r[0], r[1])
+		}
+		if err != nil {
+			return collected(), errors.Wrap(err, "failed to build Who-Is")
+		}
+		if err := c.Send(ctx, opts.Addr, req); err != nil {
+			return collected(), err
+		}
+
+		if i < len(ranges)-1 && opts.Interval > 0 {
+			if err := sleepContext(ctx, opts.Interval); err != nil {
+				return collected(), err
+			}
+		}
+	}
+
+	if err := sleepContext(ctx, window); err != nil {
+		return collected(), err
+	}
+	return collected(), nil
+}
+
+func newDiscoverResult(records []DeviceRecord) DiscoverResult {
+	res := DiscoverResult{
+		Devices:    append([]DeviceRecord{}, records...),
+		Duplicates: map[uint32][]DeviceRecord{},
+	}
+	sort.SliceStable(res.Devices, func(i, j int) bool {
+		return res.Devices[i].DeviceId < res.Devices[j].DeviceId
+	})
+
+	for i := 0; i < len(res.Devices); {
+		j := i + 1
+		for j < len(res.Devices) && res.Devices[j].DeviceId == res.Devices[i].DeviceId {
+			j++
+		}
+		if j-i > 1 {
+			res.Duplicates[res.Devices[i].DeviceId] = append([]DeviceRecord{}, res.Devices[i:j]...)
+		}
+		i = j
+	}
+	return res
+}
+
+// discoverBounds returns the instance range a discovery is limited to.
+func discoverBounds(opts DiscoverOptions) (uint32, uint32) {
+	low, high := opts.Low, opts.High
+	if high == 0 || high > MaxDeviceInstance {
+		high = MaxDeviceInstance
+	}
+	return low, high
+}
+
+func discoverMatch(opts DiscoverOptions, deviceId uint32) bool {
+	low, high := discoverBounds(opts)
+	return low <= deviceId && deviceId <= high
+}
+
+// discoverRanges splits the instance range of a discovery in the ranges of
+// its Who-Is requests.
+func discoverRanges(opts DiscoverOptions) [][2]uint32 {
+	low, high := discoverBounds(opts)
+	if low > high {
+		return nil
+	}
+	if opts.RangeSize == 0 || high-low < opts.RangeSize {
+		return [][2]uint32{{low, high}}
+	}
+
+	var ranges [][2]uint32
+	for start := low; start <= high; start += opts.RangeSize {
+		end := start + opts.RangeSize - 1
+		if end > high || end < start {
+			end = high
+		}
+		ranges = append(ranges, [2]uint32{start, end})
+		if end == high {
+			break
+		}
+	}
+	return ranges
+}
+
+// sleepContext waits for d, or returns ctx.Err() if ctx is done before.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package bacnet_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+// routedIAm sends dst an I-Am for the device deviceId, sitting at sadr on
+// the network snet behind the router p.
+func (p *testPeer) routedIAm(t *testing.T, deviceId uint32, snet uint16, sadr []byte, dst net.Addr) {
+	t.Helper()
+	npdu := plumbing.NewNPDU(false, false, true, false)
+	npdu.SNET, npdu.SLEN, npdu.SADR = snet, uint8(len(sadr)), sadr
+	u := services.NewUnconfirmedIAm(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+	u.APDU.Objects = services.IAmObjects(deviceId, bacnet.DEFAULT_ACCEPTED_SIZE, bacnet.DEFAULT_SEGMENTATION_SUPPORT, 0)
+	u.SetLength()
+
+	b, err := u.MarshalBinary()
+	if err == nil {
+		_, err = p.conn.WriteTo(b, dst)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// discoverResult is the outcome of a discovery.
+type discoverResult struct {
+	bacnet.DiscoverResult
+	err error
+}
+
+// discover runs the discovery of c with opts, returning its outcome once it
+// ends.
+func discover(ctx context.Context, c *bacnet.Client, opts bacnet.DiscoverOptions) <-chan discoverResult {
+	res := make(chan discoverResult, 1)
+	go func() {
+		r, err := c.Discover(ctx, opts)
+		res <- discoverResult{r, err}
+	}()
+	return res
+}
+
+// bindings returns the device instances and addresses of records, as
+// "instance@address/snet/sadr".
+func bindings(records []bacnet.DeviceRecord) []string {
+	b := make([]string, len(records))
+	for i, d := range records {
+		b[i] = fmt.Sprintf("%d@%s/%d/%x", d.DeviceId, d.Addr, d.SNET, d.SADR)
+	}
+	return b
+}
+
+func TestDiscoverRanges(t *testing.T) {
+	peer, other := newTestPeer(t), newTestPeer(t)
+	res := discover(context.Background(), newTestClient(t), bacnet.DiscoverOptions{
+		Addr:      peer.conn.LocalAddr(),
+		Low:       10,
+		High:      34,
+		RangeSize: 10,
+		Interval:  10 * time.Millisecond,
+		Window:    200 * time.Millisecond,
+	})
+
+	// The range is split in Who-Is requests of RangeSize instances.
+	var ranges [][2]uint32
+	for i := 0; i < 3; i++ {
+		w := peer.nextWhoIs(t)
+		if !w.Limited {
+			t.Fatalf("got Who-Is %+v, want a limited one", w.UnconfirmedWhoIsDec)
+		}
+		ranges = append(ranges, [2]uint32{w.LowLimit, w.HighLimit})
+
+		// Devices answering several times are recorded once by address,
+		// and the ones out of the range aren't. The records of an instance
+		// are kept in the order they were received.
+		switch i {
+		case 0:
+			peer.iAm(t, 12, w.src)
+			peer.iAm(t, 12, w.src)
+			peer.iAm(t, 5, w.src)
+		case 1:
+			other.iAm(t, 30, w.src)
+		case 2:
+			peer.iAm(t, 30, w.src)
+			peer.routedIAm(t, 12, 5, []byte{1}, w.src)
+		}
+	}
+	if diff := cmp.Diff([][2]uint32{{10, 19}, {20, 29}, {30, 34}}, ranges); diff != "" {
+		t.Errorf("Who-Is ranges differ: (-want +got)\n%s", diff)
+	}
+
+	r := <-res
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	at := func(p *testPeer, deviceId uint32, snet uint16, sadr string) string {
+		return fmt.Sprintf("%d@%s/%d/%s", deviceId, p.conn.LocalAddr(), snet, sadr)
+	}
+	want := []string{at(peer, 12, 0, ""), at(peer, 12, 5, "01"), at(other, 30, 0, ""), at(peer, 30, 0, "")}
+	if diff := cmp.Diff(want, bindings(r.Devices)); diff != "" {
+		t.Errorf("devices differ: (-want +got)\n%s", diff)
+	}
+
+	// Instances claimed by several devices are reported.
+	duplicates := map[uint32][]string{}
+	for id, records := range r.Duplicates {
+		duplicates[id] = bindings(records)
+	}
+	if diff := cmp.Diff(map[uint32][]string{12: want[:2], 30: want[2:]}, duplicates); diff != "" {
+		t.Errorf("duplicates differ: (-want +got)\n%s", diff)
+	}
+}
+
+func TestDiscoverAll(t *testing.T) {
+	peer := newTestPeer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	res := discover(ctx, newTestClient(t), bacnet.DiscoverOptions{Addr: peer.conn.LocalAddr()})
+
+	// The whole range is asked for by a single Who-Is without limits.
+	w := peer.nextWhoIs(t)
+	if w.Limited {
+		t.Errorf("got Who-Is %+v, want one without limits", w.UnconfirmedWhoIsDec)
+	}
+	peer.iAm(t, bacnet.MaxDeviceInstance, w.src)
+
+	// The devices found are returned when ctx is done before the window is
+	// over.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	r := <-res
+	if r.err != context.Canceled {
+		t.Errorf("got %v, want %v", r.err, context.Canceled)
+	}
+	if len(r.Devices) != 1 || r.Devices[0].DeviceId != bacnet.MaxDeviceInstance {
+		t.Errorf("got devices %v, want %d", bindings(r.Devices), bacnet.MaxDeviceInstance)
+	}
+}
@@ -1,8 +1,10 @@
 package bacnet
 
 import (
+	"github.com/pierreyves258/bacnet/objects"
 	"github.com/pierreyves258/bacnet/plumbing"
 	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -17,6 +19,17 @@ func NewWhois() ([]byte, error) {
 	return u.MarshalBinary()
 }
 
+// NewWhoIsRange builds a broadcast Who-Is only answered by the devices whose
+// instance lies within [low, high].
+func NewWhoIsRange(low, high uint32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+	u := services.NewUnconfirmedWhoIs(bvlc, npdu)
+	u.APDU.Objects = services.WhoIsObjects(low, high)
+	u.SetLength()
+	return u.MarshalBinary()
+}
+
 func NewIAm(deviceId uint32, vendorId uint16) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
 
@@ -49,6 +62,40 @@ func NewCACK(service uint8, objectType uint16, instN uint32, propertyId uint8, v
 	return c.MarshalBinary()
 }
 
+// NewReadPropertyACK answers the ReadProperty request identified by invokeID
+// with value, the application tagged objects of the property read, or of its
+// element arrayIndex unless it's objects.ArrayAll.
+func NewReadPropertyACK(invokeID uint8, objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, value []objects.APDUPayload) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	c := services.NewComplexACK(bvlc, npdu)
+
+	c.APDU.Service = services.ServiceConfirmedReadProperty
+	c.APDU.InvokeID = invokeID
+	c.APDU.Objects = services.ReadPropertyACKObjects(objectType, instN, propertyId, arrayIndex, value)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewReadPropertyMultipleACK answers the ReadPropertyMultiple request
+// identified by invokeID with results.
+func NewReadPropertyMultipleACK(invokeID uint8, results []services.ReadAccessResult) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	c := services.NewReadPropertyMultipleACK(bvlc, npdu)
+
+	c.APDU.InvokeID = invokeID
+	c.APDU.Objects = services.ReadPropertyMultipleACKObjects(results)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
 func NewSACK(service uint8) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, false)
@@ -78,6 +125,91 @@ func NewError(service, errorClass, errorCode uint8) ([]byte, error) {
 	return e.MarshalBinary()
 }
 
+// NewSimpleACKReply acknowledges the confirmed request service identified by
+// invokeID.
+func NewSimpleACKReply(invokeID, service uint8) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	s := services.NewSimpleACK(bvlc, npdu)
+
+	s.APDU.Service = service
+	s.APDU.InvokeID = invokeID
+
+	s.SetLength()
+
+	return s.MarshalBinary()
+}
+
+// NewErrorReply answers the confirmed request service, identified by invokeID,
+// with the Error PDU matching err. A *objects.ElementError is reported with the
+// element that failed, as are the errors of the services acting on lists of
+// elements. Any other error is reported as a generic device error.
+func NewErrorReply(invokeID, service uint8, err error) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	e := services.NewError(bvlc, npdu)
+
+	e.APDU.Service = service
+	e.APDU.InvokeID = invokeID
+
+	var elemErr *objects.ElementError
+	var bacErr *objects.BACnetError
+	switch {
+	case errors.As(err, &elemErr):
+		e.APDU.Objects = services.ElementErrorObjects(elemErr.Class, elemErr.Code, elemErr.FirstFailedElement)
+	case errors.As(err, &bacErr):
+		switch service {
+		case services.ServiceConfirmedCreateObject, services.ServiceConfirmedAddListElement,
+			services.ServiceConfirmedRemoveListElement:
+			e.APDU.Objects = services.ElementErrorObjects(bacErr.Class, bacErr.Code, 0)
+		default:
+			e.APDU.Objects = services.ErrorObjects(bacErr.Class, bacErr.Code)
+		}
+	default:
+		e.APDU.Objects = services.ErrorObjects(objects.ErrorClassDevice, objects.ErrorCodeOther)
+	}
+
+	e.SetLength()
+
+	return e.MarshalBinary()
+}
+
+// NewRejectReply rejects the confirmed request identified by invokeID.
+func NewRejectReply(invokeID, reason uint8) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	r := services.NewReject(bvlc, npdu)
+
+	r.APDU.Service = reason
+	r.APDU.InvokeID = invokeID
+
+	r.SetLength()
+
+	return r.MarshalBinary()
+}
+
+// NewAbortReply aborts the transaction identified by invokeID. server tells
+// whether the Abort is sent by the server side of the transaction.
+func NewAbortReply(invokeID, reason uint8, server bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	a := services.NewAbort(bvlc, npdu)
+
+	a.APDU.Service = reason
+	a.APDU.InvokeID = invokeID
+	if server {
+		a.APDU.Flags = 0x1
+	}
+
+	a.SetLength()
+
+	return a.MarshalBinary()
+}
+
 func NewReadProperty(objectType uint16, instanceNumber uint32, propertyId uint8) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, true)
@@ -94,13 +226,45 @@ func NewReadProperty(objectType uint16, instanceNumber uint32, propertyId uint8)
 	return c.MarshalBinary()
 }
 
+// NewReadAccess builds a ReadPropertyMultiple request reading specs.
+func NewReadAccess(specs []services.ReadAccessSpec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedReadPropertyMultiple(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ReadPropertyMultipleObjects(specs)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewReadPropertyIndex builds a ReadProperty request reading the element
+// arrayIndex of an array property.
+func NewReadPropertyIndex(objectType uint16, instanceNumber uint32, propertyId uint8, arrayIndex uint32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedReadProperty(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedReadPropertyIndexObjects(objectType, instanceNumber, propertyId, arrayIndex)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
 func NewReadPropertyMultiple(objectType uint16, instanceNumber uint32, propertyId []uint8) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, true)
 
 	c := services.NewConfirmedReadPropertyMultiple(bvlc, npdu)
 
-	c.APDU.Service = services.ServiceConfirmedReadProperty
 	c.APDU.MaxSize = 5
 	c.APDU.InvokeID = 1
 	c.APDU.Objects = services.ConfirmedReadMultiplePropertyObjects(objectType, instanceNumber, propertyId)
@@ -125,3 +289,235 @@ func NewWriteProperty(objectType uint16, instanceNumber uint32, propertyId uint8
 
 	return c.MarshalBinary()
 }
+
+// NewWritePropertyValue builds the WriteProperty request w, whatever the
+// datatype of its value.
+func NewWritePropertyValue(w services.WritePropertyValue) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedWriteProperty(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.WritePropertyValueObjects(w)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewDeviceCommunicationControl(duration uint16, enableDisable uint8, password string) ([]byte, error) {
+	if err := services.CheckPassword(password); err != nil {
+		return nil, err
+	}
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedDeviceCommunicationControl(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedDeviceCommunicationControlObjects(duration, enableDisable, password)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewReinitializeDevice(state uint8, password string) ([]byte, error) {
+	if err := services.CheckPassword(password); err != nil {
+		return nil, err
+	}
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedReinitializeDevice(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedReinitializeDeviceObjects(state, password)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewAtomicReadFile(instanceNumber uint32, start int32, octetCount uint32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedAtomicReadFile(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedAtomicReadFileStreamObjects(instanceNumber, start, octetCount)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewAtomicWriteFile(instanceNumber uint32, start int32, data []byte) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedAtomicWriteFile(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedAtomicWriteFileStreamObjects(instanceNumber, start, data)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewCreateObject(objectType uint16, initialValues []services.PropertyValue) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedCreateObject(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedCreateObjectByTypeObjects(objectType, initialValues)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewDeleteObject(objectType uint16, instanceNumber uint32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedDeleteObject(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedDeleteObjectObjects(objectType, instanceNumber)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewAddListElement(objectType uint16, instanceNumber uint32, propertyId uint8, elements []objects.APDUPayload) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedAddListElement(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedAddListElementObjects(objectType, instanceNumber, propertyId, objects.ArrayAll, elements)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewRemoveListElement(objectType uint16, instanceNumber uint32, propertyId uint8, elements []objects.APDUPayload) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedRemoveListElement(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedRemoveListElementObjects(objectType, instanceNumber, propertyId, objects.ArrayAll, elements)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+func NewReadRange(objectType uint16, instanceNumber uint32, propertyId uint8, index uint32, count int32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedReadRange(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedReadRangeByPositionObjects(objectType, instanceNumber, propertyId, index, count)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewSubscribeCOV builds a SubscribeCOV request subscribing processId to the
+// changes of an object for lifetime seconds, a zero lifetime meaning forever.
+// confirmed tells whether the notifications are ConfirmedCOVNotification
+// requests.
+func NewSubscribeCOV(processId uint32, objectType uint16, instanceNumber uint32, confirmed bool, lifetime uint32) ([]byte, error) {
+	return newSubscribeCOV(services.SubscribeCOVDec{
+		ProcessId:       processId,
+		MonitoredObject: objects.ObjectIdentifier{ObjectType: objectType, InstanceNumber: instanceNumber},
+		IssueConfirmed:  confirmed,
+		Lifetime:        lifetime,
+	})
+}
+
+// NewSubscribeCOVCancel builds a SubscribeCOV request cancelling the
+// subscription of processId to an object.
+func NewSubscribeCOVCancel(processId uint32, objectType uint16, instanceNumber uint32) ([]byte, error) {
+	return newSubscribeCOV(services.SubscribeCOVDec{
+		ProcessId:       processId,
+		MonitoredObject: objects.ObjectIdentifier{ObjectType: objectType, InstanceNumber: instanceNumber},
+		Cancel:          true,
+	})
+}
+
+func newSubscribeCOV(s services.SubscribeCOVDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedSubscribeCOV(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.SubscribeCOVObjects(s)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewSubscribeCOVProperty builds the SubscribeCOVProperty request s.
+func NewSubscribeCOVProperty(s services.SubscribeCOVPropertyDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedSubscribeCOVProperty(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.SubscribeCOVPropertyObjects(s)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewCOVNotification builds the COVNotification n, a ConfirmedCOVNotification
+// request when confirmed is set and an UnconfirmedCOVNotification otherwise.
+func NewCOVNotification(n services.COVNotificationDec, confirmed bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+
+	if !confirmed {
+		u := services.NewUnconfirmedCOVNotification(bvlc, plumbing.NewNPDU(false, false, false, false))
+		u.APDU.Objects = services.COVNotificationObjects(n)
+		u.SetLength()
+		return u.MarshalBinary()
+	}
+
+	c := services.NewConfirmedCOVNotification(bvlc, plumbing.NewNPDU(false, false, false, true))
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.COVNotificationObjects(n)
+	c.SetLength()
+	return c.MarshalBinary()
+}
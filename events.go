@@ -0,0 +1,96 @@
+package bacnet
+
+import (
+	"context"
+	"net"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// GetEventInformation collects the event summaries of the device at addr,
+// sending GetEventInformation requests until the device reports no more
+// events.
+func (c *Client) GetEventInformation(ctx context.Context, addr net.Addr) ([]services.EventSummary, error) {
+	events := []services.EventSummary{}
+
+	for {
+		msg := services.NewConfirmedGetEventInformation(
+			plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+			plumbing.NewNPDU(false, false, false, true),
+		)
+		msg.APDU.MaxSize = 5
+		if len(events) == 0 {
+			msg.APDU.Objects = services.ConfirmedGetEventInformationObjects()
+		} else {
+			msg.APDU.Objects = services.ConfirmedGetEventInformationAfterObjects(events[len(events)-1].Object)
+		}
+		msg.SetLength()
+
+		req, err := msg.MarshalBinary()
+		if err != nil {
+			return events, errors.Wrap(err, "failed to build GetEventInformation")
+		}
+
+		reply, err := c.Request(ctx, addr, req)
+		if err != nil {
+			return events, errors.Wrap(err, "GetEventInformation failed")
+		}
+
+		r, ok := reply.(*services.GetEventInformationACK)
+		if !ok {
+			return events, errors.Wrap(common.ErrWrongPayload, "unexpected GetEventInformation reply")
+		}
+		ack, err := r.Decode()
+		if err != nil {
+			return events, errors.Wrap(err, "failed to decode GetEventInformation reply")
+		}
+
+		events = append(events, ack.Events...)
+
+		if !ack.MoreEvents {
+			return events, nil
+		}
+		if len(ack.Events) == 0 {
+			return events, errors.Wrap(common.ErrWrongStructure, "GetEventInformation reply announces more events but carries none")
+		}
+	}
+}
+
+// NewAcknowledgeAlarm builds the AcknowledgeAlarm request described by a.
+func NewAcknowledgeAlarm(a services.ConfirmedAcknowledgeAlarmDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedAcknowledgeAlarm(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedAcknowledgeAlarmObjects(a)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewEventNotification builds the EventNotification described by n, as an
+// unconfirmed request unless confirmed is set.
+func NewEventNotification(n services.EventNotificationDec, confirmed bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+
+	if confirmed {
+		c := services.NewConfirmedEventNotification(bvlc, plumbing.NewNPDU(false, false, false, true))
+		c.APDU.MaxSize = 5
+		c.APDU.InvokeID = 1
+		c.APDU.Objects = services.EventNotificationObjects(n)
+		c.SetLength()
+		return c.MarshalBinary()
+	}
+
+	u := services.NewUnconfirmedEventNotification(bvlc, plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.EventNotificationObjects(n)
+	u.SetLength()
+	return u.MarshalBinary()
+}
@@ -0,0 +1,348 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// EventServer notifies the events the objects of a Database report through a
+// Server. Every transition of an Event_State allowed by the Event_Enable of
+// its object is sent in an EventNotification to the recipients of the
+// Notification Class of the object which take the transition on the current
+// day and time, with the priority and acknowledgment requirement of the
+// class, its type and values being those of the event algorithm of the
+// object. AcknowledgeAlarm requests are served as well, the acknowledgments
+// being notified the same way. The time stamp they carry must be the date and
+// time one of the notification of the transition.
+//
+// Recipients given by device are reached at their address in the
+// Device_Address_Binding of the Device object. Recipients on remote networks
+// are reached through routers, the notifications being sent to the
+// BroadcastAddr of the Server.
+type EventServer struct {
+	server *Server
+	db     *objects.Database
+	stop   func()
+	// states are the event states last reported, normal for the objects
+	// missing.
+	states map[objects.ObjectIdentifier]uint8
+
+	mu sync.Mutex
+}
+
+// NewEventServer creates an EventServer notifying the events of db through
+// s, registering its handlers on s.
+func NewEventServer(s *Server, db *objects.Database) *EventServer {
+	e := &EventServer{
+		server: s,
+		db:     db,
+		states: map[objects.ObjectIdentifier]uint8{},
+	}
+	e.stop = db.Listen(e.changed)
+
+	s.Handle(services.ServiceConfirmedAcknowledgeAlarm, func(req Request) ([]byte, error) {
+		aa, ok := req.Msg.(*services.ConfirmedAcknowledgeAlarm)
+		if !ok {
+			return nil, objects.NewRejectError(objects.RejectReasonOther)
+		}
+		ack, err := aa.Decode()
+		if err != nil {
+			return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
+		}
+		if ack.TimeStamp.Kind != services.TimeStampDateTime {
+			return nil, objects.NewBACnetError(objects.ErrorClassService, objects.ErrorCodeInvalidTimeStamp)
+		}
+		if err := db.Acknowledge(ack.EventObject.ObjectType, ack.EventObject.InstanceNumber, ack.EventState, ack.TimeStamp.DateTime); err != nil {
+			return nil, err
+		}
+		e.acknowledged(ack.EventObject, ack.EventState, time.Now())
+		return NewSimpleACKReply(req.InvokeID, services.ServiceConfirmedAcknowledgeAlarm)
+	})
+
+	return e
+}
+
+// Close stops following the changes of the Database.
+func (e *EventServer) Close() {
+	e.stop()
+}
+
+// changed notifies the transitions of the Event_State of the object id.
+func (e *EventServer) changed(id objects.ObjectIdentifier, propertyId uint8, value []objects.APDUPayload) {
+	if propertyId != objects.PropertyIdEventState {
+		return
+	}
+	state, err := objects.DecEnumerated(value[0])
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	from, to := e.states[id], uint8(state)
+	e.states[id] = to
+	e.mu.Unlock()
+
+	t, err := e.db.EventTimeStamp(id.ObjectType, id.InstanceNumber, objects.TransitionOf(to))
+	if err == nil {
+		err = e.transitioned(id, from, to, t)
+	}
+	if err != nil {
+		log.Printf("failed to notify event of object %d:%d: %v\n", id.ObjectType, id.InstanceNumber, err)
+	}
+}
+
+// transitioned notifies the transition of the object id from the event state
+// from to to, made at t.
+func (e *EventServer) transitioned(id objects.ObjectIdentifier, from, to uint8, t time.Time) error {
+	transition := objects.TransitionOf(to)
+	enable, err := e.read(id, objects.PropertyIdEventEnable)
+	if err != nil {
+		return err
+	}
+	if bits, _ := objects.DecBitString(enable[0]); transition >= len(bits) || !bits[transition] {
+		return nil
+	}
+
+	notifyType, err := e.read(id, objects.PropertyIdNotifyType)
+	if err != nil {
+		return err
+	}
+	kind, _ := objects.DecEnumerated(notifyType[0])
+	values, err := e.eventValues(id, from, to)
+	if err != nil {
+		return err
+	}
+
+	return e.notify(id, transition, services.EventNotificationDec{
+		EventObject: id,
+		TimeStamp:   services.TimeStamp{Kind: services.TimeStampDateTime, DateTime: t},
+		EventType:   values.EventType(),
+		NotifyType:  uint8(kind),
+		FromState:   from,
+		ToState:     to,
+		EventValues: values,
+	}, t)
+}
+
+// acknowledged notifies the acknowledgment, made at t, of the transition of
+// the object id into state.
+func (e *EventServer) acknowledged(id objects.ObjectIdentifier, state uint8, t time.Time) {
+	eventType, err := e.db.EventType(id.ObjectType, id.InstanceNumber)
+	if err == nil {
+		err = e.notify(id, objects.TransitionOf(state), services.EventNotificationDec{
+			EventObject: id,
+			TimeStamp:   services.TimeStamp{Kind: services.TimeStampDateTime, DateTime: t},
+			EventType:   eventType,
+			NotifyType:  services.NotifyTypeAckNotification,
+			ToState:     state,
+		}, t)
+	}
+	if err != nil {
+		log.Printf("failed to notify acknowledgment of object %d:%d: %v\n", id.ObjectType, id.InstanceNumber, err)
+	}
+}
+
+// eventValues returns the parameters of the transition of the object id from
+// the event state from to to, which depend on the event algorithm of the
+// object.
+func (e *EventServer) eventValues(id objects.ObjectIdentifier, from, to uint8) (services.EventValues, error) {
+	eventType, err := e.db.EventType(id.ObjectType, id.InstanceNumber)
+	if err != nil {
+		return nil, err
+	}
+	if eventType == services.EventTypeChangeOfState {
+		return e.changeOfStateValues(id)
+	}
+	return e.outOfRangeValues(id, from, to)
+}
+
+// changeOfStateValues returns the CHANGE_OF_STATE parameters of a transition
+// of the object id: its new state is its Present_Value, a binary or an
+// unsigned one.
+func (e *EventServer) changeOfStateValues(id objects.ObjectIdentifier) (*services.ChangeOfStateValues, error) {
+	pv, err := e.read(id, objects.PropertyIdPresentValue)
+	if err != nil {
+		return nil, err
+	}
+	values := &services.ChangeOfStateValues{}
+	if state, err := objects.DecEnumerated(pv[0]); err == nil {
+		values.NewState = services.PropertyState{Kind: services.PropertyStateBinaryValue, Value: state}
+	} else if state, err := objects.DecUnisgnedInteger(pv[0]); err == nil {
+		values.NewState = services.PropertyState{Kind: services.PropertyStateUnsigned, Value: state}
+	} else {
+		return nil, err
+	}
+
+	flags, err := e.read(id, objects.PropertyIdStatusFlags)
+	if err != nil {
+		return nil, err
+	}
+	values.StatusFlags, err = objects.DecBitString(flags[0])
+	return values, err
+}
+
+// outOfRangeValues returns the OUT_OF_RANGE parameters of the transition of
+// the object id from the event state from to to: the limit exceeded is the
+// one of the offnormal state involved.
+func (e *EventServer) outOfRangeValues(id objects.ObjectIdentifier, from, to uint8) (*services.OutOfRangeValues, error) {
+	limit := objects.PropertyIdLowLimit
+	if to == services.EventStateHighLimit || (to == services.EventStateNormal && from == services.EventStateHighLimit) {
+		limit = objects.PropertyIdHighLimit
+	}
+
+	values := &services.OutOfRangeValues{}
+	var err error
+	if values.ExceedingValue, err = e.readReal(id, objects.PropertyIdPresentValue); err != nil {
+		return nil, err
+	}
+	if values.Deadband, err = e.readReal(id, objects.PropertyIdDeadband); err != nil {
+		return nil, err
+	}
+	if values.ExceededLimit, err = e.readReal(id, limit); err != nil {
+		return nil, err
+	}
+	flags, err := e.read(id, objects.PropertyIdStatusFlags)
+	if err != nil {
+		return nil, err
+	}
+	values.StatusFlags, err = objects.DecBitString(flags[0])
+	return values, err
+}
+
+// notify completes n with the Notification Class of the object id and sends
+// it to the recipients of the class which take transition at t.
+func (e *EventServer) notify(id objects.ObjectIdentifier, transition int, n services.EventNotificationDec, t time.Time) error {
+	class, err := e.read(id, objects.PropertyIdNotificationClass)
+	if err != nil {
+		return err
+	}
+	instN, _ := objects.DecUnisgnedInteger(class[0])
+	nc := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeNotificationClass, InstanceNumber: instN}
+
+	priority, err := e.db.ReadProperty(nc.ObjectType, nc.InstanceNumber, objects.PropertyIdPriority, uint32(transition+1))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("notification class %d", instN))
+	}
+	prio, _ := objects.DecUnisgnedInteger(priority[0])
+	ackRequired, err := e.read(nc, objects.PropertyIdAckRequired)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("notification class %d", instN))
+	}
+	acks, _ := objects.DecBitString(ackRequired[0])
+	recipients, err := e.read(nc, objects.PropertyIdRecipientList)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("notification class %d", instN))
+	}
+	dests, err := services.DecodeDestinations(recipients)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("notification class %d", instN))
+	}
+
+	n.InitiatingDevice = objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: e.db.DeviceId()}
+	n.NotificationClass = instN
+	n.Priority = uint8(prio)
+	n.AckRequired = transition < len(acks) && acks[transition]
+
+	for _, d := range dests {
+		if transition >= len(d.Transitions) || !d.Transitions[transition] || !d.Active(t) {
+			continue
+		}
+		n.ProcessId = d.ProcessId
+		req, err := NewEventNotification(n, d.IssueConfirmed)
+		if err != nil {
+			return err
+		}
+		go e.send(d, req)
+	}
+	return nil
+}
+
+// send sends the EventNotification req to d, waiting for its acknowledgment
+// when it's confirmed.
+func (e *EventServer) send(d services.Destination, req []byte) {
+	addr, req, err := e.route(d.Recipient, req)
+	if err == nil {
+		if d.IssueConfirmed {
+			_, err = e.server.Request(context.Background(), addr, req)
+		} else {
+			err = e.server.Send(addr, req)
+		}
+	}
+	if err != nil {
+		log.Printf("failed to notify event to process %d: %v\n", d.ProcessId, err)
+	}
+}
+
+// route returns the address to send req to so that it reaches rcp, and req
+// routed there if needed.
+func (e *EventServer) route(rcp services.Recipient, req []byte) (net.Addr, []byte, error) {
+	address := services.Address{Network: rcp.Network, MAC: rcp.MAC}
+	if !rcp.ByAddress {
+		var err error
+		if address, err = e.binding(rcp.Device); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if address.Network == 0 {
+		addr, err := bipAddr(address.MAC)
+		return addr, req, err
+	}
+	if e.server.BroadcastAddr == nil {
+		return nil, nil, errors.Wrap(common.ErrUnknownAddress, fmt.Sprintf("no router to network %d", address.Network))
+	}
+	routed, err := setDestination(req, address.Network, address.MAC)
+	return e.server.BroadcastAddr, routed, err
+}
+
+// binding returns the address of device found in the Device_Address_Binding
+// of the Device object.
+func (e *EventServer) binding(device objects.ObjectIdentifier) (services.Address, error) {
+	value, err := e.read(objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: e.db.DeviceId()}, objects.PropertyIdDeviceAddressBinding)
+	if err != nil {
+		return services.Address{}, err
+	}
+	bindings, err := services.DecodeAddressBindings(value)
+	if err != nil {
+		return services.Address{}, err
+	}
+	for _, b := range bindings {
+		if b.Device == device {
+			return b.Address, nil
+		}
+	}
+	return services.Address{}, errors.Wrap(common.ErrUnknownAddress, fmt.Sprintf("no binding for device %d", device.InstanceNumber))
+}
+
+func (e *EventServer) read(id objects.ObjectIdentifier, propertyId uint8) ([]objects.APDUPayload, error) {
+	return e.db.ReadProperty(id.ObjectType, id.InstanceNumber, propertyId, objects.ArrayAll)
+}
+
+func (e *EventServer) readReal(id objects.ObjectIdentifier, propertyId uint8) (float32, error) {
+	value, err := e.read(id, propertyId)
+	if err != nil {
+		return 0, err
+	}
+	return objects.DecReal(value[0])
+}
+
+// bipAddr returns the address of the B/IP MAC address mac, the reverse of
+// bipMAC.
+func bipAddr(mac []byte) (net.Addr, error) {
+	if len(mac) != 6 {
+		return nil, errors.Wrap(common.ErrUnknownAddress, fmt.Sprintf("MAC address %x isn't a B/IP one", mac))
+	}
+	return &net.UDPAddr{
+		IP:   net.IPv4(mac[0], mac[1], mac[2], mac[3]),
+		Port: int(mac[4])<<8 | int(mac[5]),
+	}, nil
+}
@@ -0,0 +1,261 @@
+package bacnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// setProperty sets a property of an object of db on behalf of the
+// application.
+func setProperty(t *testing.T, db *objects.Database, objectType uint16, instN uint32, propertyId uint8, value ...objects.APDUPayload) {
+	t.Helper()
+	if err := db.SetProperty(objectType, instN, propertyId, value); err != nil {
+		t.Fatalf("failed to set property %d: %v", propertyId, err)
+	}
+}
+
+func readAckedTransitions(t *testing.T, db *objects.Database, objectType uint16, instN uint32) []bool {
+	t.Helper()
+	value, err := db.ReadProperty(objectType, instN, objects.PropertyIdAckedTransitions, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bits, err := objects.DecBitString(value[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bits
+}
+
+// newEventDatabase creates a Database holding Analog Input 0, limited to 10
+// to 50 with a Deadband of 5, and Notification Class 1 it reports to.
+func newEventDatabase(t *testing.T) *objects.Database {
+	t.Helper()
+	db := objects.NewDatabase(1, "test", 0)
+	if err := db.Add(objects.ObjectTypeNotificationClass, 1, "NC-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(objects.ObjectTypeAnalogInput, 0, "AI-0"); err != nil {
+		t.Fatal(err)
+	}
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdNotificationClass, objects.EncUnsignedInteger32(1))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(30))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdHighLimit, objects.EncReal(50))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdLowLimit, objects.EncReal(10))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdDeadband, objects.EncReal(5))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdLimitEnable, objects.EncBitString([]bool{true, true}))
+	return db
+}
+
+// testRecipient receives the event notifications sent to it on the loopback
+// interface.
+type testRecipient struct {
+	conn          net.PacketConn
+	notifications chan services.EventNotificationDec
+}
+
+func newTestRecipient(t *testing.T) *testRecipient {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	r := &testRecipient{conn: conn, notifications: make(chan services.EventNotificationDec, 16)}
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			msg, err := bacnet.Parse(append([]byte{}, buf[:n]...))
+			if err != nil {
+				continue
+			}
+			if en, ok := msg.(*services.UnconfirmedEventNotification); ok {
+				if dec, err := en.Decode(); err == nil {
+					r.notifications <- dec
+				}
+			}
+		}
+	}()
+	return r
+}
+
+// destination returns the recipient as a destination taking every
+// transition at any time.
+func (r *testRecipient) destination() services.Destination {
+	addr := r.conn.LocalAddr().(*net.UDPAddr)
+	return services.Destination{
+		ValidDays:   []bool{true, true, true, true, true, true, true},
+		ToTime:      23*time.Hour + 59*time.Minute + 59*time.Second,
+		Recipient:   services.Recipient{ByAddress: true, MAC: append(addr.IP.To4(), byte(addr.Port>>8), byte(addr.Port))},
+		ProcessId:   7,
+		Transitions: []bool{true, true, true},
+	}
+}
+
+func (r *testRecipient) next(t *testing.T) services.EventNotificationDec {
+	t.Helper()
+	select {
+	case n := <-r.notifications:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("no notification received")
+		return services.EventNotificationDec{}
+	}
+}
+
+func (r *testRecipient) none(t *testing.T) {
+	t.Helper()
+	select {
+	case n := <-r.notifications:
+		t.Fatalf("unexpected notification %+v", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEventServer(t *testing.T) {
+	db := newEventDatabase(t)
+	if err := db.Add(objects.ObjectTypeBinaryInput, 0, "BI-0"); err != nil {
+		t.Fatal(err)
+	}
+	setProperty(t, db, objects.ObjectTypeBinaryInput, 0, objects.PropertyIdNotificationClass, objects.EncUnsignedInteger32(1))
+
+	rcp := newTestRecipient(t)
+	setProperty(t, db, objects.ObjectTypeNotificationClass, 1, objects.PropertyIdPriority,
+		objects.EncUnsignedInteger32(10), objects.EncUnsignedInteger32(20), objects.EncUnsignedInteger32(30))
+	setProperty(t, db, objects.ObjectTypeNotificationClass, 1, objects.PropertyIdAckRequired,
+		objects.EncBitString([]bool{true, false, false}))
+	setProperty(t, db, objects.ObjectTypeNotificationClass, 1, objects.PropertyIdRecipientList,
+		services.DestinationsObjects([]services.Destination{rcp.destination()})...)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := bacnet.NewServer(conn, 1, 0)
+	defer server.Close()
+	events := bacnet.NewEventServer(server, db)
+	defer events.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Serve(ctx)
+
+	ai := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 0}
+	flags := []bool{true, false, false, false}
+
+	// Event_Enable filters the transitions to offnormal out.
+	setProperty(t, db, ai.ObjectType, 0, objects.PropertyIdEventEnable, objects.EncBitString([]bool{false, true, true}))
+	setProperty(t, db, ai.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(60))
+	rcp.none(t)
+
+	// Each transition takes its priority from the Notification Class.
+	setProperty(t, db, ai.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(30))
+	n := rcp.next(t)
+	want := services.EventNotificationDec{
+		ProcessId:         7,
+		InitiatingDevice:  objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 1},
+		EventObject:       ai,
+		TimeStamp:         n.TimeStamp,
+		NotificationClass: 1,
+		Priority:          30,
+		EventType:         services.EventTypeOutOfRange,
+		NotifyType:        services.NotifyTypeAlarm,
+		FromState:         services.EventStateHighLimit,
+		ToState:           services.EventStateNormal,
+		EventValues: &services.OutOfRangeValues{
+			ExceedingValue: 30,
+			StatusFlags:    []bool{false, false, false, false},
+			Deadband:       5,
+			ExceededLimit:  50,
+		},
+	}
+	if diff := cmp.Diff(want, n); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	setProperty(t, db, ai.ObjectType, 0, objects.PropertyIdEventEnable, objects.EncBitString([]bool{true, true, true}))
+	setProperty(t, db, ai.ObjectType, 0, objects.PropertyIdPresentValue, objects.EncReal(5))
+	n = rcp.next(t)
+	want.TimeStamp = n.TimeStamp
+	want.Priority = 10
+	want.AckRequired = true
+	want.FromState, want.ToState = services.EventStateNormal, services.EventStateLowLimit
+	want.EventValues = &services.OutOfRangeValues{ExceedingValue: 5, StatusFlags: flags, Deadband: 5, ExceededLimit: 10}
+	if diff := cmp.Diff(want, n); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	// Acknowledging the transition sets its bit of Acked_Transitions and is
+	// notified.
+	if diff := cmp.Diff([]bool{false, true, true}, readAckedTransitions(t, db, ai.ObjectType, 0)); diff != "" {
+		t.Errorf("Acked_Transitions differs: (-want +got)\n%s", diff)
+	}
+	ack := services.ConfirmedAcknowledgeAlarmDec{
+		ProcessId:   7,
+		EventObject: ai,
+		EventState:  services.EventStateLowLimit,
+		TimeStamp:   n.TimeStamp,
+		Source:      "operator",
+		AckTime:     services.TimeStamp{Kind: services.TimeStampDateTime, DateTime: time.Now()},
+	}
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Second)
+	defer reqCancel()
+	client := newTestClient(t)
+
+	// The time stamp must be the one of the transition.
+	wrong := ack
+	wrong.TimeStamp.DateTime = wrong.TimeStamp.DateTime.Add(time.Second)
+	req, err := bacnet.NewAcknowledgeAlarm(wrong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Request(reqCtx, conn.LocalAddr(), req)
+	if bErr, ok := errors.Cause(err).(*objects.BACnetError); !ok || bErr.Code != objects.ErrorCodeInvalidTimeStamp {
+		t.Fatalf("got %v, want invalid-time-stamp", err)
+	}
+
+	req, err = bacnet.NewAcknowledgeAlarm(ack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := client.Request(reqCtx, conn.LocalAddr(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		t.Fatalf("unexpected reply %T", reply)
+	}
+	if diff := cmp.Diff([]bool{true, true, true}, readAckedTransitions(t, db, ai.ObjectType, 0)); diff != "" {
+		t.Errorf("Acked_Transitions differs: (-want +got)\n%s", diff)
+	}
+	n = rcp.next(t)
+	if n.NotifyType != services.NotifyTypeAckNotification || n.EventType != services.EventTypeOutOfRange ||
+		n.ToState != services.EventStateLowLimit || n.Priority != 10 {
+		t.Errorf("unexpected acknowledgment notification %+v", n)
+	}
+
+	// Binary objects report CHANGE_OF_STATE events.
+	setProperty(t, db, objects.ObjectTypeBinaryInput, 0, objects.PropertyIdPresentValue, objects.EncEnumerated(1))
+	n = rcp.next(t)
+	if n.EventType != services.EventTypeChangeOfState || n.ToState != services.EventStateOffnormal {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	if diff := cmp.Diff(&services.ChangeOfStateValues{
+		NewState:    services.PropertyState{Kind: services.PropertyStateBinaryValue, Value: 1},
+		StatusFlags: flags,
+	}, n.EventValues); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
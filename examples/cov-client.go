@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	COVCmd.Flags().Uint32Var(&covDeviceId, "device-id", 0, "Instance of the device to subscribe to, looked up with a WhoIs sent to the remote address.")
+	COVCmd.Flags().Uint16Var(&covObjectType, "object-type", objects.ObjectTypeAnalogInput, "Type of the object to subscribe to.")
+	COVCmd.Flags().Uint32Var(&covInstanceId, "instance-id", 0, "Instance of the object to subscribe to.")
+	COVCmd.Flags().DurationVar(&covLifetime, "lifetime", bacnet.DefaultCOVLifetime, "Lifetime of the subscription, renewed before it expires.")
+	COVCmd.Flags().BoolVar(&covConfirmed, "confirmed", false, "Ask for confirmed notifications.")
+}
+
+var (
+	covDeviceId   uint32
+	covObjectType uint16
+	covInstanceId uint32
+	covLifetime   time.Duration
+	covConfirmed  bool
+
+	COVCmd = &cobra.Command{
+		Use:   "cov",
+		Short: "Subscribe to the changes of an object.",
+		Long:  "This command subscribes to the changes of the present value of an object with SubscribeCOV, polling it if the device doesn't support COV.",
+		Args:  argValidation,
+		Run:   COVExample,
+	}
+)
+
+func COVExample(cmd *cobra.Command, args []string) {
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
+	if err != nil {
+		log.Fatalf("Failed to resolve UDP address: %s", err)
+	}
+
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
+	}
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
+
+	resolver := bacnet.NewResolver(client, remoteUDPAddr)
+	defer resolver.Close()
+
+	device, err := resolver.Resolve(cmd.Context(), covDeviceId)
+	if err != nil {
+		log.Fatalf("failed to find device %d: %v\n", covDeviceId, err)
+	}
+
+	manager := bacnet.NewCOVManager(client)
+	manager.Lifetime = covLifetime
+	manager.Confirmed = covConfirmed
+	values := manager.Subscribe(bacnet.Point{
+		Device: device,
+		Ref: objects.DeviceObjectPropertyReference{
+			ObjectType:     covObjectType,
+			InstanceNumber: covInstanceId,
+			PropertyId:     objects.PropertyIdPresentValue,
+			ArrayIndex:     objects.ArrayAll,
+		},
+	})
+
+	go func() {
+		for v := range values {
+			log.Printf("value: %v, quality: %d, error: %v\n", v.Value, v.Quality, v.Err)
+		}
+	}()
+
+	manager.Run(cmd.Context())
+}
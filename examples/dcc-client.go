@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	DeviceCommunicationControlCmd.Flags().Uint16Var(&dccDuration, "duration", 0, "Minutes to remain in the requested state, being 0 indefinitely.")
+	DeviceCommunicationControlCmd.Flags().Uint8Var(&dccState, "state", services.CommunicationDisable, "0 to enable, 1 to disable and 2 to disable initiation.")
+	DeviceCommunicationControlCmd.Flags().StringVar(&devicePassword, "password", "", "Password expected by the remote device.")
+
+	ReinitializeDeviceCmd.Flags().Uint8Var(&reinitState, "state", services.ReinitializeWarmstart, "Reinitialized state of device (0 coldstart, 1 warmstart...).")
+	ReinitializeDeviceCmd.Flags().StringVar(&devicePassword, "password", "", "Password expected by the remote device.")
+}
+
+var (
+	dccDuration    uint16
+	dccState       uint8
+	reinitState    uint8
+	devicePassword string
+
+	DeviceCommunicationControlCmd = &cobra.Command{
+		Use:   "dcc",
+		Short: "Send a DeviceCommunicationControl request.",
+		Long:  "This command enables or disables the communication of a remote device for a given time.",
+		Args:  argValidation,
+		Run:   DeviceCommunicationControlExample,
+	}
+
+	ReinitializeDeviceCmd = &cobra.Command{
+		Use:   "reinit",
+		Short: "Send a ReinitializeDevice request.",
+		Long:  "This command asks a remote device to restart or to go through a backup/restore step.",
+		Args:  argValidation,
+		Run:   ReinitializeDeviceExample,
+	}
+)
+
+func DeviceCommunicationControlExample(cmd *cobra.Command, args []string) {
+	mDCC, err := bacnet.NewDeviceCommunicationControl(dccDuration, dccState, devicePassword)
+	if err != nil {
+		log.Fatalf("error generating DeviceCommunicationControl: %v\n", err)
+	}
+
+	sendDeviceManagementRequest(mDCC)
+}
+
+func ReinitializeDeviceExample(cmd *cobra.Command, args []string) {
+	mReinit, err := bacnet.NewReinitializeDevice(reinitState, devicePassword)
+	if err != nil {
+		log.Fatalf("error generating ReinitializeDevice: %v\n", err)
+	}
+
+	sendDeviceManagementRequest(mReinit)
+}
+
+func sendDeviceManagementRequest(req []byte) {
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
+	if err != nil {
+		log.Fatalf("Failed to resolve UDP address: %s", err)
+	}
+
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
+	}
+	defer listenConn.Close()
+
+	listenConn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := listenConn.WriteTo(req, remoteUDPAddr); err != nil {
+		log.Fatalf("Failed to write the request: %s\n", err)
+	}
+
+	log.Printf("sent: %x", req)
+
+	replyRaw := make([]byte, 1024)
+	nBytes, remoteAddr, err := listenConn.ReadFrom(replyRaw)
+	if err != nil {
+		log.Fatalf("error reading incoming packet: %v\n", err)
+	}
+
+	log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, replyRaw[:nBytes])
+
+	serviceMsg, err := bacnet.Parse(replyRaw[:nBytes])
+	if err != nil {
+		log.Fatalf("error parsing the received message: %v\n", err)
+	}
+
+	switch reply := serviceMsg.(type) {
+	case *services.SimpleACK:
+		log.Printf("the request was accepted!\n")
+	case *services.Error:
+		decodedErr, err := reply.Decode()
+		if err != nil {
+			log.Fatalf("couldn't decode the Error reply: %v\n", err)
+		}
+		log.Printf("the request was refused: class %d code %d\n", decodedErr.ErrorClass, decodedErr.ErrorCode)
+	default:
+		log.Fatalf("we didn't receive a SACK or Error reply...\n")
+	}
+}
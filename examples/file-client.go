@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"net"
+	"os"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	ReadFileCmd.Flags().Uint32Var(&fileInstanceId, "instance-id", 0, "Instance ID of the File object to read.")
+	ReadFileCmd.Flags().Uint16Var(&fileMaxAPDU, "max-apdu", 1476, "Maximum APDU length accepted by the remote device.")
+	ReadFileCmd.Flags().StringVar(&fileOutput, "output", "bacnet.file", "Path to store the file content at.")
+}
+
+var (
+	fileInstanceId uint32
+	fileMaxAPDU    uint16
+	fileOutput     string
+
+	ReadFileCmd = &cobra.Command{
+		Use:   "arf",
+		Short: "Download a File object with AtomicReadFile requests.",
+		Long: "This command reads a whole File object from a remote device, as many octets at a\n" +
+			"time as the remote device accepts, and stores it on the local filesystem.",
+		Args: argValidation,
+		Run:  ReadFileExample,
+	}
+)
+
+func ReadFileExample(cmd *cobra.Command, args []string) {
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
+	if err != nil {
+		log.Fatalf("Failed to resolve UDP address: %s", err)
+	}
+
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
+	}
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
+
+	out, err := os.Create(fileOutput)
+	if err != nil {
+		log.Fatalf("couldn't create the output file: %v\n", err)
+	}
+	defer out.Close()
+
+	n, err := client.ReadFile(cmd.Context(), remoteUDPAddr, fileInstanceId, fileMaxAPDU, out)
+	if err != nil {
+		log.Fatalf("error reading the remote file after %d octets: %v\n", n, err)
+	}
+
+	log.Printf("stored %d octets on %s\n", n, fileOutput)
+}
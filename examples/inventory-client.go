@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"net"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	InventoryCmd.Flags().Uint32Var(&invDeviceId, "device-id", 0, "Instance of the device to walk, looked up with a WhoIs sent to the remote address.")
+}
+
+var (
+	invDeviceId uint32
+
+	InventoryCmd = &cobra.Command{
+		Use:   "inventory",
+		Short: "List the objects of a device.",
+		Long:  "This command reads the Object_List of a device and the name, description, units and present value of its objects.",
+		Args:  argValidation,
+		Run:   InventoryExample,
+	}
+)
+
+func InventoryExample(cmd *cobra.Command, args []string) {
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
+	if err != nil {
+		log.Fatalf("Failed to resolve UDP address: %s", err)
+	}
+
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
+	}
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
+
+	resolver := bacnet.NewResolver(client, remoteUDPAddr)
+	defer resolver.Close()
+
+	device, err := resolver.Resolve(cmd.Context(), invDeviceId)
+	if err != nil {
+		log.Fatalf("failed to find device %d: %v\n", invDeviceId, err)
+	}
+
+	inv, err := client.Inventory(cmd.Context(), device)
+	if err != nil {
+		log.Fatalf("Inventory failed: %v\n", err)
+	}
+
+	for _, obj := range inv.Objects {
+		log.Printf(
+			"object %d:%d\n\tName: %s\n\tDescription: %s\n\tPresent value: %v\n",
+			obj.ObjectType, obj.InstanceId, obj.Name, obj.Description, obj.PresentValue,
+		)
+		if obj.Units != nil {
+			log.Printf("\tUnits: %d\n", *obj.Units)
+		}
+	}
+}
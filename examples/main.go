@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +21,13 @@ func init() {
 	rootCmd.AddCommand(ReadPropertyClientCmd)
 	rootCmd.AddCommand(WritePropertyServerCmd)
 	rootCmd.AddCommand(WritePropertyClientCmd)
+	rootCmd.AddCommand(DeviceCommunicationControlCmd)
+	rootCmd.AddCommand(ReinitializeDeviceCmd)
+	rootCmd.AddCommand(ReadFileCmd)
+	rootCmd.AddCommand(ReadLogBufferCmd)
+	rootCmd.AddCommand(InventoryCmd)
+	rootCmd.AddCommand(PollCmd)
+	rootCmd.AddCommand(COVCmd)
 
 	rootCmd.PersistentFlags().StringVar(&rAddr, "remote-address", "127.0.0.1:47808", "Remote IP:Port tuple to connect to.")
 	rootCmd.PersistentFlags().StringVar(&bAddr, "broadcast-address", ":47808", "Default broadcast address to bind to.")
@@ -58,7 +67,12 @@ func argValidation(cmd *cobra.Command, args []string) error {
 
 func execute() {
 	log.SetFlags(log.Lshortfile)
-	if err := rootCmd.Execute(); err != nil {
+
+	// Interrupting the examples cancels their outstanding requests.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
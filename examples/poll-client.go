@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	PollCmd.Flags().Uint32Var(&pollDeviceId, "device-id", 0, "Instance of the device to poll, looked up with a WhoIs sent to the remote address.")
+	PollCmd.Flags().Uint16Var(&pollObjectType, "object-type", objects.ObjectTypeAnalogInput, "Type of the object to poll.")
+	PollCmd.Flags().Uint32Var(&pollInstanceId, "instance-id", 0, "Instance of the object to poll.")
+	PollCmd.Flags().Uint8Var(&pollPropertyId, "property-id", objects.PropertyIdPresentValue, "Property to poll.")
+	PollCmd.Flags().DurationVar(&pollInterval, "interval", 10*time.Second, "Time between two polls.")
+	PollCmd.Flags().Float64Var(&pollDeadband, "deadband", 0, "Changes of numeric values ignored.")
+}
+
+var (
+	pollDeviceId   uint32
+	pollObjectType uint16
+	pollInstanceId uint32
+	pollPropertyId uint8
+	pollInterval   time.Duration
+	pollDeadband   float64
+
+	PollCmd = &cobra.Command{
+		Use:   "poll",
+		Short: "Poll a property of a device.",
+		Long:  "This command reads a property of a device periodically, printing its value when it changes.",
+		Args:  argValidation,
+		Run:   PollExample,
+	}
+)
+
+func PollExample(cmd *cobra.Command, args []string) {
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
+	if err != nil {
+		log.Fatalf("Failed to resolve UDP address: %s", err)
+	}
+
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
+	}
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
+
+	resolver := bacnet.NewResolver(client, remoteUDPAddr)
+	defer resolver.Close()
+
+	device, err := resolver.Resolve(cmd.Context(), pollDeviceId)
+	if err != nil {
+		log.Fatalf("failed to find device %d: %v\n", pollDeviceId, err)
+	}
+
+	poller := bacnet.NewPoller(client, func(id int, v bacnet.PointValue) {
+		log.Printf("value: %v, quality: %d, error: %v\n", v.Value, v.Quality, v.Err)
+	})
+	poller.Add(bacnet.Point{
+		Device: device,
+		Ref: objects.DeviceObjectPropertyReference{
+			ObjectType:     pollObjectType,
+			InstanceNumber: pollInstanceId,
+			PropertyId:     pollPropertyId,
+			ArrayIndex:     objects.ArrayAll,
+		},
+		Interval: pollInterval,
+		Deadband: pollDeadband,
+	})
+
+	poller.Run(cmd.Context())
+}
@@ -18,6 +18,7 @@ func init() {
 	ReadPropertyClientCmd.Flags().Uint16Var(&rpObjectType, "object-type", 1, "Object type to read.")
 	ReadPropertyClientCmd.Flags().Uint32Var(&rpInstanceId, "instance-id", 0, "Instance ID to read.") // Analog-input
 	ReadPropertyClientCmd.Flags().Uint8Var(&rpPropertyId, "property-id", 85, "Property ID to read.") // Current-value
+	ReadPropertyClientCmd.Flags().Int64Var(&rpDeviceId, "device-id", -1, "Device to read from, looked up with a WhoIs sent to the remote address, instead of the remote address itself.")
 	ReadPropertyClientCmd.Flags().IntVar(&rpPeriod, "period", 1, "Period, in seconds, between requests.")
 	ReadPropertyClientCmd.Flags().IntVar(&rpN, "messages", 1, "Number of messages to send, being 0 unlimited.")
 }
@@ -26,6 +27,7 @@ var (
 	rpObjectType uint16
 	rpInstanceId uint32
 	rpPropertyId uint8
+	rpDeviceId   int64
 	rpPeriod     int
 	rpN          int
 
@@ -48,46 +50,26 @@ func ReadPropertyClientExample(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to begin listening for packets: %v\n", err)
 	}
-	defer listenConn.Close()
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
 
-	mReadProperty, err := bacnet.NewReadProperty(rpObjectType, rpInstanceId, rpPropertyId)
-	if err != nil {
-		log.Fatalf("error generating initial ReadProperty: %v\n", err)
+	readProperty := func() (services.ComplexACKDec, error) {
+		return client.ReadProperty(cmd.Context(), remoteUDPAddr, rpObjectType, rpInstanceId, rpPropertyId)
 	}
+	if rpDeviceId >= 0 {
+		resolver := bacnet.NewResolver(client, remoteUDPAddr)
+		defer resolver.Close()
 
-	replyRaw := make([]byte, 1024)
-	sentRequests := 0
-	for {
-		listenConn.SetDeadline(time.Now().Add(5 * time.Second))
-		if _, err := listenConn.WriteTo(mReadProperty, remoteUDPAddr); err != nil {
-			log.Fatalf("Failed to write the request: %s\n", err)
+		readProperty = func() (services.ComplexACKDec, error) {
+			return resolver.ReadProperty(cmd.Context(), uint32(rpDeviceId), rpObjectType, rpInstanceId, rpPropertyId)
 		}
+	}
 
-		log.Printf("sent: %x", mReadProperty)
-
-		nBytes, remoteAddr, err := listenConn.ReadFrom(replyRaw)
-		if err != nil {
-			log.Fatalf("error reading incoming packet: %v\n", err)
-		}
-
-		log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, replyRaw[:nBytes])
-
-		serviceMsg, err := bacnet.Parse(replyRaw[:nBytes])
-		if err != nil {
-			log.Fatalf("error parsing the received message: %v\n", err)
-		}
-
-		cACKEnc, ok := serviceMsg.(*services.ComplexACK)
-		if !ok {
-			log.Fatalf("we didn't receive a CACK reply...\n")
-		}
-
-		log.Printf("unmarshalled BVLC: %#v\n", cACKEnc.BVLC)
-		log.Printf("unmarshalled NPDU: %#v\n", cACKEnc.NPDU)
-
-		decodedCACK, err := cACKEnc.Decode()
+	sentRequests := 0
+	for {
+		decodedCACK, err := readProperty()
 		if err != nil {
-			log.Fatalf("couldn't decode the CACK reply: %v\n", err)
+			log.Fatalf("ReadProperty failed: %v\n", err)
 		}
 
 		log.Printf(
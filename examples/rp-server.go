@@ -1,97 +1,68 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net"
 
 	"github.com/pierreyves258/bacnet"
 	"github.com/pierreyves258/bacnet/objects"
-	"github.com/pierreyves258/bacnet/services"
 	"github.com/spf13/cobra"
 )
 
 var (
 	ReadPropertyServerCmd = &cobra.Command{
 		Use:   "rps",
-		Short: "Reply ReadProperty requests with Complex ACKs.",
-		Long: "This example will wait until it receives a ReadProperty request. Upon reception\n" +
-			"it'll just reply with the configured Complex ACK fields",
+		Short: "Serve a database of objects.",
+		Long: "This example serves a device holding two Analog Output objects, answering\n" +
+			"ReadProperty, ReadPropertyMultiple and WriteProperty requests. Changes of their\n" +
+			"values are notified to the subscribers of SubscribeCOV and SubscribeCOVProperty\n" +
+			"requests. Their limits, once enabled, raise alarms notified to the recipients written\n" +
+			"to the Recipient_List of Notification Class 0. WhoIs requests are answered with an\n" +
+			"IAm and other requests are rejected.",
 		Args: argValidation,
 		Run:  ReadPropertyServerExample,
 	}
 )
 
 func ReadPropertyServerExample(cmd *cobra.Command, args []string) {
-	listenConn, err := net.ListenPacket("udp", bAddr)
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
 	if err != nil {
-		log.Fatalf("failed to begin listening for packets: %v\n", err)
+		log.Fatalf("Failed to resolve UDP address: %s", err)
 	}
-	defer listenConn.Close()
 
-	mCACKs := [][]byte{}
-	for i := 0; i < 2; i++ {
-		mCACK, err := bacnet.NewCACK(
-			services.ServiceConfirmedReadProperty,
-			objects.ObjectTypeAnalogOutput,
-			uint32(i),
-			objects.PropertyIdPresentValue,
-			1.1*float32((i+1)),
-		)
-		if err != nil {
-			log.Fatalf("error generating CACK %d: %v\n", i, err)
-		}
-		mCACKs = append(mCACKs, mCACK)
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
 	}
 
-	reqRaw := make([]byte, 1024)
-
-	var nBytes int
-	var remoteAddr net.Addr
-	for {
-		nBytes, remoteAddr, err = listenConn.ReadFrom(reqRaw)
-		if err != nil {
-			log.Fatalf("error reading incoming packet: %v\n", err)
-		}
-
-		log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, reqRaw[:nBytes])
+	server := bacnet.NewServer(listenConn, 321, 31)
+	server.BroadcastAddr = remoteUDPAddr
+	defer server.Close()
 
-		serviceMsg, err := bacnet.Parse(reqRaw[:nBytes])
-		if err != nil {
-			log.Fatalf("error parsing the received message: %v\n", err)
+	db := objects.NewDatabase(server.DeviceId, "rp-server", server.VendorId)
+	for i, presentValue := range []float32{1.1, 2.2} {
+		instN := uint32(i)
+		if err := db.Add(objects.ObjectTypeAnalogOutput, instN, fmt.Sprintf("AO-%d", instN)); err != nil {
+			log.Fatalf("failed to add object: %v", err)
 		}
-
-		readPropertyMessage, ok := serviceMsg.(*services.ConfirmedReadProperty)
-		if !ok {
-			log.Fatalf("we didn't receive a ReadProperty request: %x\n", reqRaw[:nBytes])
-		}
-		log.Printf("received a ReadProperty request!\n")
-
-		decodedReadPropertyMessage, err := readPropertyMessage.Decode()
-		if err != nil {
-			log.Fatalf("error decoding the ReadProperty message: %v\n", err)
-		}
-
-		log.Printf("decoded ReadProperty message:\n\tObjectType: %d\n\tInstance ID: %d\n\tProperty ID: %d\n",
-			decodedReadPropertyMessage.ObjectType, decodedReadPropertyMessage.InstanceId,
-			decodedReadPropertyMessage.PropertyId)
-
-		if decodedReadPropertyMessage.InstanceId >= uint32(len(mCACKs)) {
-			bErr, err := bacnet.NewError(
-				services.ServiceConfirmedReadProperty, objects.ErrorClassObject, objects.ErrorCodeUnknownObject)
-			if err != nil {
-				log.Fatalf("error generating Error reply: %v\n", err)
-			}
-			if _, err := listenConn.WriteTo(bErr, remoteAddr); err != nil {
-				log.Fatalf("error sending our Error reply: %v\n", err)
-			}
-			log.Printf("we were asked for a wrong instance ID!\n")
-			continue
+		if err := db.SetProperty(objects.ObjectTypeAnalogOutput, instN, objects.PropertyIdRelinquishDefault,
+			[]objects.APDUPayload{objects.EncReal(presentValue)}); err != nil {
+			log.Fatalf("failed to set relinquish default: %v", err)
 		}
+	}
+	if err := db.Add(objects.ObjectTypeNotificationClass, 0, "NC-0"); err != nil {
+		log.Fatalf("failed to add object: %v", err)
+	}
+	bacnet.ServeDatabase(server, db, nil)
+	covServer := bacnet.NewCOVServer(server, db)
+	defer covServer.Close()
+	eventServer := bacnet.NewEventServer(server, db)
+	defer eventServer.Close()
 
-		if _, err := listenConn.WriteTo(mCACKs[decodedReadPropertyMessage.InstanceId], remoteAddr); err != nil {
-			log.Fatalf("error sending our CACK reply: %v\n", err)
-		}
+	log.Printf("services supported: %v\n", server.ServicesSupported())
 
-		log.Printf("replied with our CACK!\n")
+	if err := server.Serve(cmd.Context()); err != nil {
+		log.Printf("server stopped: %v\n", err)
 	}
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"net"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	ReadLogBufferCmd.Flags().Uint32Var(&trendInstanceId, "instance-id", 0, "Instance ID of the Trend Log object to read.")
+	ReadLogBufferCmd.Flags().Int32Var(&trendPageSize, "page-size", 20, "Number of records asked for by every ReadRange request.")
+}
+
+var (
+	trendInstanceId uint32
+	trendPageSize   int32
+
+	ReadLogBufferCmd = &cobra.Command{
+		Use:   "trend",
+		Short: "Download the Log_Buffer of a Trend Log object with ReadRange requests.",
+		Long: "This command pages through the whole Log_Buffer of a Trend Log object of a remote\n" +
+			"device and prints every record.",
+		Args: argValidation,
+		Run:  ReadLogBufferExample,
+	}
+)
+
+func ReadLogBufferExample(cmd *cobra.Command, args []string) {
+	remoteUDPAddr, err := net.ResolveUDPAddr("udp", rAddr)
+	if err != nil {
+		log.Fatalf("Failed to resolve UDP address: %s", err)
+	}
+
+	listenConn, err := net.ListenPacket("udp", bAddr)
+	if err != nil {
+		log.Fatalf("failed to begin listening for packets: %v\n", err)
+	}
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
+
+	records, err := client.ReadLogBuffer(cmd.Context(), remoteUDPAddr, objects.ObjectTypeTrendLog, trendInstanceId, trendPageSize)
+	for _, r := range records {
+		log.Printf("%s datum %d value %v status %v\n", r.Timestamp, r.Datum, r.Value, r.StatusFlags)
+	}
+	if err != nil {
+		log.Fatalf("error reading the Log_Buffer after %d records: %v\n", len(records), err)
+	}
+}
@@ -10,24 +10,29 @@ import (
 	"time"
 
 	"github.com/pierreyves258/bacnet"
-	"github.com/pierreyves258/bacnet/services"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	whoIsCmd.Flags().Uint32Var(&wiLow, "low", 0, "Lowest device instance to discover.")
+	whoIsCmd.Flags().Uint32Var(&wiHigh, "high", 0, "Highest device instance to discover, being 0 the highest possible.")
+	whoIsCmd.Flags().Uint32Var(&wiRangeSize, "range-size", 0, "Number of instances each WhoIs asks for, being 0 all of them at once.")
 	whoIsCmd.Flags().IntVar(&wiPeriod, "period", 1, "Period, in seconds, between WhoIs requests.")
-	whoIsCmd.Flags().IntVar(&nWhoIs, "messages", 1, "Number of messages to send, being 0 unlimited.")
+	whoIsCmd.Flags().IntVar(&wiWindow, "window", 3, "Time, in seconds, to wait for IAm replies after the last WhoIs.")
 }
 
 var (
-	wiPeriod int
-	nWhoIs   int
+	wiLow       uint32
+	wiHigh      uint32
+	wiRangeSize uint32
+	wiPeriod    int
+	wiWindow    int
 
 	whoIsCmd = &cobra.Command{
 		Use:   "whois",
-		Short: "Send WhoIs requests.",
-		Long: "There's not much more really. This command sends a configurable number of\n" +
-			"WhoIs requests with a configurable period. That's pretty much it.",
+		Short: "Discover devices with WhoIs requests.",
+		Long: "This command broadcasts WhoIs requests, optionally split in instance ranges sent\n" +
+			"with a configurable period, and lists the devices answering with an IAm.",
 		Args: argValidation,
 		Run:  whoIsExample,
 	}
@@ -39,77 +44,32 @@ func whoIsExample(cmd *cobra.Command, args []string) {
 		log.Fatalf("Failed to resolve UDP address: %s", err)
 	}
 
-	_, err = net.InterfaceAddrs()
-	if err != nil {
-		log.Fatalf("couldn't get interface information: %v\n", err)
-	}
-
 	listenConn, err := net.ListenPacket("udp", bAddr)
 	if err != nil {
 		log.Fatalf("failed to begin listening for packets: %v\n", err)
 	}
-	defer listenConn.Close()
-
-	mWhoIs, err := bacnet.NewWhois()
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
+
+	res, err := client.Discover(cmd.Context(), bacnet.DiscoverOptions{
+		Addr:      remoteUDPAddr,
+		Low:       wiLow,
+		High:      wiHigh,
+		RangeSize: wiRangeSize,
+		Interval:  time.Duration(wiPeriod) * time.Second,
+		Window:    time.Duration(wiWindow) * time.Second,
+	})
 	if err != nil {
-		log.Fatalf("error generating initial WhoIs: %v\n", err)
+		log.Printf("discovery interrupted: %v\n", err)
 	}
 
-	replyRaw := make([]byte, 1024)
-	sentRequests := 0
-	for {
-		listenConn.SetDeadline(time.Now().Add(5 * time.Second))
-		if _, err := listenConn.WriteTo(mWhoIs, remoteUDPAddr); err != nil {
-			log.Fatalf("Failed to write Unconfimed request WhoIs packet: %s\n", err)
-		}
-
-		log.Printf("sent: %x", mWhoIs)
-
-		var nBytes int
-		var remoteAddr net.Addr
-		for {
-			nBytes, remoteAddr, err = listenConn.ReadFrom(replyRaw)
-			if err != nil {
-				log.Fatalf("error reading incoming packet: %v\n", err)
-			}
-			// if !common.IsLocalAddr(ifaceAddrs, remoteAddr) {
-			// 	break
-			// }
-			// log.Printf("got our own broadcast, back to listening...\n")
-			break
-		}
-
-		log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, replyRaw[:nBytes])
-
-		serviceMsg, err := bacnet.Parse(replyRaw[:nBytes])
-		if err != nil {
-			log.Fatalf("error parsing the received message: %v\n", err)
-		}
-
-		iAmMessage, ok := serviceMsg.(*services.UnconfirmedIAm)
-		if !ok {
-			log.Fatalf("we didn't receive an IAm reply...\n")
-		}
-
-		log.Printf("unmarshalled BVLC: %#v\n", iAmMessage.BVLC)
-		log.Printf("unmarshalled NPDU: %#v\n", iAmMessage.NPDU)
-
-		decodedIAm, err := iAmMessage.Decode()
-		if err != nil {
-			log.Fatalf("couldn't decode the IAm reply: %v\n", err)
-		}
-
+	for _, d := range res.Devices {
 		log.Printf(
-			"decoded IAm reply:\n\tDevice ID: %d\n\tMax. APDU Length: %d\n\tSegmentation support: %d\n\tVendor ID: %d\n",
-			decodedIAm.DeviceId, decodedIAm.MaxAPDULength, decodedIAm.SegmentationSupported, decodedIAm.VendorId,
+			"device %d at %s (SNET %d SADR %x):\n\tMax. APDU Length: %d\n\tSegmentation support: %d\n\tVendor ID: %d\n",
+			d.DeviceId, d.Addr, d.SNET, d.SADR, d.MaxAPDU, d.Segmentation, d.VendorId,
 		)
-
-		sentRequests++
-
-		if sentRequests == nWhoIs {
-			break
-		}
-
-		time.Sleep(time.Duration(wiPeriod) * time.Second)
+	}
+	for id, records := range res.Duplicates {
+		log.Printf("device instance %d is claimed by %d devices\n", id, len(records))
 	}
 }
@@ -10,7 +10,7 @@ import (
 	"time"
 
 	"github.com/pierreyves258/bacnet"
-	"github.com/pierreyves258/bacnet/services"
+	"github.com/pierreyves258/bacnet/objects"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +19,8 @@ func init() {
 	WritePropertyClientCmd.Flags().Uint32Var(&wpInstanceId, "instance-id", 0, "Instance ID to read.") // Analog-input
 	WritePropertyClientCmd.Flags().Uint8Var(&wpPropertyId, "property-id", 85, "Property ID to read.") // Current-value
 	WritePropertyClientCmd.Flags().Float32Var(&wpValue, "value", 1.1, "Value to write.")
+	WritePropertyClientCmd.Flags().BoolVar(&wpRelinquish, "relinquish", false, "Write NULL to relinquish the priority instead.")
+	WritePropertyClientCmd.Flags().Uint8Var(&wpPriority, "priority", 16, "Priority to write at.")
 	WritePropertyClientCmd.Flags().IntVar(&wpPeriod, "period", 1, "Period, in seconds, between requests.")
 	WritePropertyClientCmd.Flags().IntVar(&wpN, "messages", 1, "Number of requests to send, being 0 unlimited.")
 }
@@ -28,6 +30,8 @@ var (
 	wpInstanceId uint32
 	wpPropertyId uint8
 	wpValue      float32
+	wpRelinquish bool
+	wpPriority   uint8
 	wpPeriod     int
 	wpN          int
 
@@ -50,43 +54,21 @@ func WritePropertyClientExample(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to begin listening for packets: %v\n", err)
 	}
-	defer listenConn.Close()
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
 
-	mWriteProperty, err := bacnet.NewWriteProperty(wpObjectType, wpInstanceId, wpPropertyId, wpValue)
-	if err != nil {
-		log.Fatalf("error generating initial WriteProperty: %v\n", err)
+	value := []objects.APDUPayload{objects.EncReal(wpValue)}
+	if wpRelinquish {
+		value = []objects.APDUPayload{objects.EncNull()}
 	}
 
-	replyRaw := make([]byte, 1024)
 	sentRequests := 0
 	for {
-		if _, err := listenConn.WriteTo(mWriteProperty, remoteUDPAddr); err != nil {
-			log.Fatalf("failed to write the request: %v\n", err)
-		}
-
-		log.Printf("sent: %x", mWriteProperty)
-
-		nBytes, remoteAddr, err := listenConn.ReadFrom(replyRaw)
-		if err != nil {
-			log.Fatalf("error reading back the reply: %v\n", err)
-		}
-
-		log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, replyRaw[:nBytes])
-
-		serviceMsg, err := bacnet.Parse(replyRaw[:nBytes])
-		if err != nil {
-			log.Fatalf("error parsing the received message: %v\n", err)
+		if err := client.WriteProperty(cmd.Context(), remoteUDPAddr, wpObjectType, wpInstanceId, wpPropertyId, value, wpPriority); err != nil {
+			log.Fatalf("WriteProperty failed: %v\n", err)
 		}
 
-		sACKEnc, ok := serviceMsg.(*services.SimpleACK)
-		if !ok {
-			log.Fatalf("we didn't receive a SACK reply...\n")
-		}
-
-		log.Printf("unmarshalled BVLC: %#v\n", sACKEnc.BVLC)
-		log.Printf("unmarshalled NPDU: %#v\n", sACKEnc.NPDU)
-
-		log.Printf("decoded SACK reply:\n\tService: %d\n", sACKEnc.Service)
+		log.Printf("received a SACK reply\n")
 
 		sentRequests++
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net"
 
@@ -13,9 +14,11 @@ import (
 var (
 	WritePropertyServerCmd = &cobra.Command{
 		Use:   "wps",
-		Short: "Reply WriteProperty requests with Simple ACKs.",
-		Long: "This example will wait until it receives a WriteProperty request. Upon reception\n" +
-			"it'll store the value and reply with a Simple ACK.",
+		Short: "Command outputs with WriteProperty requests.",
+		Long: "This example serves a device holding two Analog Outputs and a Binary Output with\n" +
+			"5 seconds of Minimum_On_Time. WriteProperty requests command their Present_Value at\n" +
+			"the priority they carry, a NULL relinquishing the command. Every write is recorded in\n" +
+			"an Audit Log object which can be read back with AuditLogQuery requests.",
 		Args: argValidation,
 		Run:  WritePropertyServerExample,
 	}
@@ -31,80 +34,48 @@ func WritePropertyServerExample(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to begin listening for packets: %v\n", err)
 	}
-	defer listenConn.Close()
 
-	storedValues := []float32{0, 0}
+	server := bacnet.NewServer(listenConn, 321, 31)
+	server.BroadcastAddr = remoteUDPAddr
+	defer server.Close()
 
-	sACK, err := bacnet.NewSACK(services.ServiceConfirmedWriteProperty)
-	if err != nil {
-		log.Fatalf("error generating the SACK: %v\n", err)
+	db := objects.NewDatabase(server.DeviceId, "wp-server", server.VendorId)
+	for instN := uint32(0); instN < 2; instN++ {
+		if err := db.Add(objects.ObjectTypeAnalogOutput, instN, fmt.Sprintf("AO-%d", instN)); err != nil {
+			log.Fatalf("failed to add object: %v", err)
+		}
 	}
-
-	iAm, err := bacnet.NewIAm(321, 31)
-	if err != nil {
-		log.Fatalf("error generating initial IAm: %v\n", err)
+	if err := db.Add(objects.ObjectTypeBinaryOutput, 0, "BO-0"); err != nil {
+		log.Fatalf("failed to add object: %v", err)
 	}
+	if err := db.SetProperty(objects.ObjectTypeBinaryOutput, 0, objects.PropertyIdMinimumOnTime,
+		[]objects.APDUPayload{objects.EncUnsignedInteger32(5)}); err != nil {
+		log.Fatalf("failed to set minimum on time: %v", err)
+	}
+	auditLog := bacnet.NewAuditLog(1, server.DeviceId, 100)
+	bacnet.ServeDatabase(server, db, auditLog)
 
-	reqRaw := make([]byte, 1024)
-
-	var nBytes int
-	var remoteAddr net.Addr
-	for {
-		nBytes, remoteAddr, err = listenConn.ReadFrom(reqRaw)
-		if err != nil {
-			log.Fatalf("error reading incoming packet: %v\n", err)
+	stop := db.Listen(func(id objects.ObjectIdentifier, propertyId uint8, value []objects.APDUPayload) {
+		if propertyId != objects.PropertyIdPresentValue {
+			return
 		}
-
-		log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, reqRaw[:nBytes])
-
-		serviceMsg, err := bacnet.Parse(reqRaw[:nBytes])
+		v, err := objects.DecAppValue(value[0])
 		if err != nil {
-			log.Fatalf("error parsing the received message: %v\n", err)
-		}
-
-		writePropertyMessage, ok := serviceMsg.(*services.ConfirmedWriteProperty)
-		if !ok {
-			_, ok := serviceMsg.(*services.UnconfirmedWhoIs)
-			if !ok {
-				log.Printf("we didn't receive a WriteProperty request! Back to listening...\n")
-				continue
-			}
-			log.Printf("received a WhoIs request!\n")
-			if _, err := listenConn.WriteTo(iAm, remoteUDPAddr); err != nil {
-				log.Fatalf("error sending our IAm reply: %v\n", err)
-			}
-			continue
+			return
 		}
-		log.Printf("received a WriteProperty request!\n")
+		log.Printf("object %d:%d now at %v\n", id.ObjectType, id.InstanceNumber, v)
+	})
+	defer stop()
 
-		decodedWritePropertyMessage, err := writePropertyMessage.Decode()
+	server.Handle(services.ServiceConfirmedAuditLogQuery, func(req bacnet.Request) ([]byte, error) {
+		query, err := req.Msg.(*services.ConfirmedAuditLogQuery).Decode()
 		if err != nil {
-			log.Fatalf("error decoding the WriteProperty message: %v\n", err)
-		}
-
-		log.Printf(
-			"decoded WriteProperty message:\n\tObjectType: %d\n\tInstance ID: %d\n\tProperty ID: %d\n\tValue: %f\n",
-			decodedWritePropertyMessage.ObjectType, decodedWritePropertyMessage.InstanceId,
-			decodedWritePropertyMessage.PropertyId, decodedWritePropertyMessage.Value)
-
-		if decodedWritePropertyMessage.InstanceId >= uint32(len(storedValues)) {
-			bErr, err := bacnet.NewError(
-				services.ServiceConfirmedWriteProperty, objects.ErrorClassObject, objects.ErrorCodeUnknownObject)
-			if err != nil {
-				log.Fatalf("error generating Error reply: %v\n", err)
-			}
-			if _, err := listenConn.WriteTo(bErr, remoteAddr); err != nil {
-				log.Fatalf("error sending our Error reply: %v\n", err)
-			}
-			log.Printf("we were asked for a wrong instance ID!\n")
-			continue
+			return nil, objects.NewRejectError(objects.RejectReasonInvalidTag)
 		}
+		return bacnet.NewAuditLogQueryACK(req.InvokeID, auditLog, query)
+	})
 
-		storedValues[decodedWritePropertyMessage.InstanceId] = decodedWritePropertyMessage.Value
-
-		if _, err := listenConn.WriteTo(sACK, remoteAddr); err != nil {
-			log.Fatalf("error sending our CACK reply: %v\n", err)
-		}
-		log.Printf("replied with our SACK!\n")
+	if err := server.Serve(cmd.Context()); err != nil {
+		log.Printf("server stopped: %v\n", err)
 	}
 }
@@ -0,0 +1,162 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+const (
+	// atomicReadFileACKOverhead is the largest number of octets an
+	// AtomicReadFile stream acknowledgement adds around the file data.
+	atomicReadFileACKOverhead = 15
+	// recordOverhead is the largest number of octets the Octet String tag
+	// of a record adds in a record acknowledgement.
+	recordOverhead = 5
+
+	// maxBIPFrame is the largest BACnet/IP frame we expect to receive.
+	maxBIPFrame = 1497
+)
+
+// ReadFile streams the content of File object instanceNumber held by the
+// device at addr into w. Every AtomicReadFile request asks for as many octets
+// as fit in an acknowledgement of maxAPDU octets, the maximum APDU length the
+// device accepts as reported in its IAm. It returns the number of octets copied.
+func (c *Client) ReadFile(ctx context.Context, addr net.Addr, instanceNumber uint32, maxAPDU uint16, w io.Writer) (int64, error) {
+	if int(maxAPDU) <= atomicReadFileACKOverhead {
+		return 0, errors.Wrap(common.ErrTooBigValue, fmt.Sprintf("max APDU %d too small to read files", maxAPDU))
+	}
+	chunk := uint32(maxAPDU) - atomicReadFileACKOverhead
+
+	var read int64
+	for {
+		if read > math.MaxInt32 {
+			return read, errors.Wrap(common.ErrTooBigValue, "file too large for AtomicReadFile")
+		}
+		msg := services.NewConfirmedAtomicReadFile(
+			plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+			plumbing.NewNPDU(false, false, false, true),
+		)
+		msg.APDU.MaxSize = 5
+		msg.APDU.Objects = services.ConfirmedAtomicReadFileStreamObjects(instanceNumber, int32(read), chunk)
+		msg.SetLength()
+
+		req, err := msg.MarshalBinary()
+		if err != nil {
+			return read, errors.Wrap(err, "failed to build AtomicReadFile")
+		}
+
+		reply, err := c.Request(ctx, addr, req)
+		if err != nil {
+			return read, errors.Wrap(err, "AtomicReadFile failed")
+		}
+
+		r, ok := reply.(*services.AtomicReadFileACK)
+		if !ok {
+			return read, errors.Wrap(common.ErrWrongPayload, "unexpected AtomicReadFile reply")
+		}
+		ack, err := r.Decode()
+		if err != nil {
+			return read, errors.Wrap(err, "failed to decode AtomicReadFile reply")
+		}
+
+		if ack.RecordAccess || int64(ack.Start) != read {
+			return read, errors.Wrap(common.ErrWrongStructure, "AtomicReadFile reply doesn't match the request")
+		}
+		if len(ack.Data) == 0 && !ack.EndOfFile {
+			return read, errors.Wrap(common.ErrWrongStructure, "AtomicReadFile reply carries no data")
+		}
+
+		n, err := w.Write(ack.Data)
+		read += int64(n)
+		if err != nil {
+			return read, err
+		}
+
+		if ack.EndOfFile {
+			return read, nil
+		}
+	}
+}
+
+// NewAtomicReadFileACK answers the AtomicReadFile request req, identified by
+// invokeID, with the content of f. Fewer octets or records than requested are
+// read when needed for the acknowledgement to fit in maxAPDU octets, the
+// maximum APDU length the requester accepts. The error returned for requests
+// f can't serve is a *objects.BACnetError, or a *objects.AbortError when not
+// even a record fits.
+func NewAtomicReadFileACK(invokeID uint8, maxAPDU int, f *objects.File, req services.ConfirmedAtomicReadFileDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	c := services.NewAtomicReadFileACK(bvlc, npdu)
+	c.APDU.InvokeID = invokeID
+
+	room := maxAPDU - atomicReadFileACKOverhead
+	if room < 0 {
+		room = 0
+	}
+	if req.RecordAccess {
+		count := req.Count
+		if fit := uint32(room / (f.RecordSize + recordOverhead)); count > fit {
+			count = fit
+		}
+		if count == 0 && req.Count > 0 {
+			return nil, objects.NewAbortError(objects.AbortReasonSegmentationNotSupported, true)
+		}
+		records, eof, err := f.ReadRecords(req.Start, count)
+		if err != nil {
+			return nil, err
+		}
+		c.APDU.Objects = services.AtomicReadFileRecordACKObjects(eof, req.Start, records)
+	} else {
+		count := req.Count
+		if count > uint32(room) {
+			count = uint32(room)
+		}
+		data, eof, err := f.ReadStream(req.Start, count)
+		if err != nil {
+			return nil, err
+		}
+		c.APDU.Objects = services.AtomicReadFileStreamACKObjects(eof, req.Start, data)
+	}
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewAtomicWriteFileACK applies the AtomicWriteFile request req, identified by
+// invokeID, to f and builds the acknowledgement. The error returned for
+// requests f can't serve is a *objects.BACnetError.
+func NewAtomicWriteFileACK(invokeID uint8, f *objects.File, req services.ConfirmedAtomicWriteFileDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	c := services.NewAtomicWriteFileACK(bvlc, npdu)
+	c.APDU.InvokeID = invokeID
+
+	var start int32
+	var err error
+	if req.RecordAccess {
+		start, err = f.WriteRecords(req.Start, req.Records)
+	} else {
+		start, err = f.WriteStream(req.Start, req.Data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.APDU.Objects = services.AtomicWriteFileACKObjects(req.RecordAccess, start)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
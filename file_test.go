@@ -0,0 +1,82 @@
+package bacnet_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// nopWriter is an io.WriterAt discarding what it's given.
+type nopWriter struct{}
+
+func (nopWriter) WriteAt(p []byte, off int64) (int, error) {
+	return len(p), nil
+}
+
+func TestAtomicReadFileACKMaxAPDU(t *testing.T) {
+	content := bytes.Repeat([]byte{0x5A}, 4000)
+
+	cases := []struct {
+		name string
+		file *objects.File
+		req  services.ConfirmedAtomicReadFileDec
+		// want is the number of octets or records read.
+		want int
+	}{
+		{"stream", objects.NewFile(0, "text", 4000, bytes.NewReader(content), nil),
+			services.ConfirmedAtomicReadFileDec{Start: 0, Count: 4000}, 465},
+		{"records", &objects.File{FileType: "log", Size: 4000, RecordSize: 100, Reader: bytes.NewReader(content)},
+			services.ConfirmedAtomicReadFileDec{RecordAccess: true, Start: 0, Count: 40}, 4},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := bacnet.NewAtomicReadFileACK(1, 480, c.file, c.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := bacnet.Parse(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ack, err := msg.(*services.AtomicReadFileACK).Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ack.EndOfFile {
+				t.Error("end of file reported for a partial read")
+			}
+			got := len(ack.Data)
+			if c.req.RecordAccess {
+				got = len(ack.Records)
+			}
+			if got != c.want {
+				t.Errorf("read %d, want %d", got, c.want)
+			}
+		})
+	}
+
+	// Not even a record fits.
+	f := &objects.File{FileType: "log", Size: 4000, RecordSize: 1000, Reader: bytes.NewReader(content)}
+	_, err := bacnet.NewAtomicReadFileACK(1, 480, f, services.ConfirmedAtomicReadFileDec{RecordAccess: true, Count: 1})
+	if _, ok := errors.Cause(err).(*objects.AbortError); !ok {
+		t.Errorf("got %v, want an abort", err)
+	}
+}
+
+func TestFileWriteBeyondInt32(t *testing.T) {
+	f := objects.NewFile(0, "text", math.MaxInt32+1, bytes.NewReader(nil), nopWriter{})
+	if _, err := f.WriteStream(-1, []byte{1}); err == nil {
+		t.Error("appended beyond the positions of an int32")
+	}
+
+	f = objects.NewFile(0, "text", math.MaxInt32-1, bytes.NewReader(nil), nopWriter{})
+	if _, err := f.WriteStream(math.MaxInt32-1, []byte{1, 2}); err == nil {
+		t.Error("wrote beyond the positions of an int32")
+	}
+}
@@ -0,0 +1,192 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pkg/errors"
+)
+
+// objectListChunk is the number of Object_List elements read at once when
+// the list is read element by element, which keeps the memory used bounded
+// whatever size the device reports.
+const objectListChunk = 64
+
+// inventoryProperties are the properties read from every object of an
+// inventory.
+var inventoryProperties = []uint8{
+	objects.PropertyIdObjectName,
+	objects.PropertyIdDescription,
+	objects.PropertyIdUnits,
+	objects.PropertyIdPresentValue,
+}
+
+// InventoryObject describes an object of a device. Description and Units
+// are left empty and nil when the object doesn't have them, and PresentValue
+// holds the value decoded by objects.DecAppValue, nil when the object has
+// none.
+type InventoryObject struct {
+	ObjectType   uint16
+	InstanceId   uint32
+	Name         string
+	Description  string
+	Units        *uint32
+	PresentValue interface{}
+}
+
+// Inventory lists the objects of a device, in the order of its Object_List.
+type Inventory struct {
+	DeviceId uint32
+	Objects  []InventoryObject
+}
+
+// Inventory walks the objects of the device d. Its Object_List is read
+// whole, or element by element when the device refuses to, for instance
+// because the list doesn't fit in an unsegmented reply. The properties of the
+// objects are then read through a BatchReader.
+func (c *Client) Inventory(ctx context.Context, d DeviceRecord) (Inventory, error) {
+	inv := Inventory{DeviceId: d.DeviceId}
+	b := NewBatchReader(c, d)
+
+	ids, err := readObjectList(ctx, b, d.DeviceId)
+	if err != nil {
+		return inv, errors.Wrap(err, "failed to read Object_List")
+	}
+
+	refs := make([]objects.DeviceObjectPropertyReference, 0, len(ids)*len(inventoryProperties))
+	for _, id := range ids {
+		for _, propertyId := range inventoryProperties {
+			refs = append(refs, objects.DeviceObjectPropertyReference{
+				ObjectType:     id.ObjectType,
+				InstanceNumber: id.InstanceNumber,
+				PropertyId:     propertyId,
+				ArrayIndex:     objects.ArrayAll,
+			})
+		}
+	}
+	results, err := b.Read(ctx, refs)
+	if err != nil {
+		return inv, err
+	}
+
+	inv.Objects = make([]InventoryObject, len(ids))
+	for i, id := range ids {
+		obj := InventoryObject{ObjectType: id.ObjectType, InstanceId: id.InstanceNumber}
+		for j, propertyId := range inventoryProperties {
+			if res := results[i*len(inventoryProperties)+j]; res.Err == nil {
+				obj.set(propertyId, res.Value)
+			}
+		}
+		inv.Objects[i] = obj
+	}
+	return inv, nil
+}
+
+// readObjectList reads the Object_List of the device deviceId through b.
+func readObjectList(ctx context.Context, b *BatchReader, deviceId uint32) ([]objects.ObjectIdentifier, error) {
+	ref := objects.DeviceObjectPropertyReference{
+		ObjectType:     objects.ObjectTypeDevice,
+		InstanceNumber: deviceId,
+		PropertyId:     objects.PropertyIdObjectList,
+		ArrayIndex:     objects.ArrayAll,
+	}
+
+	results, err := b.Read(ctx, []objects.DeviceObjectPropertyReference{ref})
+	if err != nil {
+		return nil, err
+	}
+	if results[0].Err == nil {
+		return decObjectIds(results[0].Value)
+	}
+
+	ref.ArrayIndex = 0
+	results, err = b.Read(ctx, []objects.DeviceObjectPropertyReference{ref})
+	if err != nil {
+		return nil, err
+	}
+	if results[0].Err != nil {
+		return nil, results[0].Err
+	}
+	if len(results[0].Value) != 1 {
+		return nil, errors.Wrap(common.ErrWrongObjectCount, "Object_List size")
+	}
+	size, err := objects.DecUnisgnedInteger(results[0].Value[0])
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []objects.ObjectIdentifier{}
+	for first := uint64(1); first <= uint64(size); first += objectListChunk {
+		n := uint64(size) - first + 1
+		if n > objectListChunk {
+			n = objectListChunk
+		}
+		refs := make([]objects.DeviceObjectPropertyReference, n)
+		for i := range refs {
+			refs[i] = ref
+			refs[i].ArrayIndex = uint32(first) + uint32(i)
+		}
+		results, err = b.Read(ctx, refs)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, res := range results {
+			if res.Err != nil {
+				return nil, errors.Wrap(res.Err, fmt.Sprintf("element %d", refs[i].ArrayIndex))
+			}
+			id, err := decObjectIds(res.Value)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id...)
+		}
+	}
+	return ids, nil
+}
+
+// set sets the property propertyId of o to value, leaving it unset when
+// value can't be decoded.
+func (o *InventoryObject) set(propertyId uint8, value []objects.APDUPayload) {
+	if len(value) != 1 {
+		return
+	}
+
+	switch propertyId {
+	case objects.PropertyIdObjectName:
+		o.Name, _ = objects.DecString(value[0])
+	case objects.PropertyIdDescription:
+		o.Description, _ = objects.DecString(value[0])
+	case objects.PropertyIdUnits:
+		if units, err := objects.DecEnumerated(value[0]); err == nil {
+			o.Units = &units
+		}
+	case objects.PropertyIdPresentValue:
+		o.PresentValue, _ = objects.DecAppValue(value[0])
+	}
+}
+
+func decObjectIds(value []objects.APDUPayload) ([]objects.ObjectIdentifier, error) {
+	ids := make([]objects.ObjectIdentifier, len(value))
+	for i, obj := range value {
+		id, err := objects.DecObjectIdentifier(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("decoding object identifier %d", i))
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// refusedByDevice tells whether err is the Error, Reject or Abort reply of a
+// device, rather than a failure to reach it.
+func refusedByDevice(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *objects.BACnetError, *objects.RejectError, *objects.AbortError:
+		return true
+	default:
+		return false
+	}
+}
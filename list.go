@@ -0,0 +1,31 @@
+package bacnet
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// NewListElementACK applies the AddListElement or RemoveListElement request
+// req, identified by invokeID and service, to the object h and builds the
+// acknowledgement. The error returned when h refuses the change can be turned
+// into a ChangeList-Error with NewErrorReply.
+func NewListElementACK(invokeID, service uint8, h objects.ListElementHandler, req services.ListElementDec) ([]byte, error) {
+	var err error
+	switch service {
+	case services.ServiceConfirmedAddListElement:
+		err = h.AddListElement(req.PropertyId, req.ArrayIndex, req.Elements)
+	case services.ServiceConfirmedRemoveListElement:
+		err = h.RemoveListElement(req.PropertyId, req.ArrayIndex, req.Elements)
+	default:
+		return nil, errors.Wrap(common.ErrNotImplemented, fmt.Sprintf("list element service %d", service))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSimpleACKReply(invokeID, service)
+}
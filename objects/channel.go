@@ -0,0 +1,124 @@
+package objects
+
+import (
+	"sync"
+)
+
+// Write status of a Channel.
+const (
+	WriteStatusIdle uint8 = iota
+	WriteStatusInProgress
+	WriteStatusSuccessful
+	WriteStatusFailed
+)
+
+// DeviceObjectPropertyReference refers to a property of an object. Device is
+// nil when the object belongs to the local device.
+type DeviceObjectPropertyReference struct {
+	ObjectType     uint16
+	InstanceNumber uint32
+	PropertyId     uint8
+	ArrayIndex     uint32
+	Device         *ObjectIdentifier
+}
+
+// ReferenceWriter writes value at priority to the property referred to by ref.
+// It's used by Channel objects to reach their members.
+type ReferenceWriter interface {
+	WriteReference(ref DeviceObjectPropertyReference, value []APDUPayload, priority uint8) error
+}
+
+// Channel is a Channel object. Every value written to it is written in turn to
+// each of its List_Of_Object_Property_References through Writer.
+type Channel struct {
+	InstanceNumber uint32
+	ChannelNumber  uint16
+	ControlGroups  []uint32
+	References     []DeviceObjectPropertyReference
+	Writer         ReferenceWriter
+
+	presentValue []APDUPayload
+	lastPriority uint8
+	writeStatus  uint8
+
+	mu sync.Mutex
+}
+
+// NewChannel creates a Channel writing to refs through w.
+func NewChannel(instN uint32, channelNumber uint16, controlGroups []uint32, refs []DeviceObjectPropertyReference, w ReferenceWriter) *Channel {
+	return &Channel{
+		InstanceNumber: instN,
+		ChannelNumber:  channelNumber,
+		ControlGroups:  controlGroups,
+		References:     refs,
+		Writer:         w,
+	}
+}
+
+// InGroup tells whether group is one of the control groups of the channel.
+func (c *Channel) InGroup(group uint32) bool {
+	for _, g := range c.ControlGroups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Write sets the present value of the channel and writes it to every member.
+// All the members are written even if some of them fail, the first error
+// being returned.
+func (c *Channel) Write(value []APDUPayload, priority uint8) error {
+	if priority < 1 || priority > 16 {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.presentValue = value
+	c.lastPriority = priority
+	c.writeStatus = WriteStatusInProgress
+
+	var firstErr error
+	for _, ref := range c.References {
+		if c.Writer == nil {
+			firstErr = NewBACnetError(ErrorClassDevice, ErrorCodeOther)
+			break
+		}
+		if err := c.Writer.WriteReference(ref, value, priority); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		c.writeStatus = WriteStatusFailed
+		return firstErr
+	}
+	c.writeStatus = WriteStatusSuccessful
+	return nil
+}
+
+// PresentValue returns the last value written to the channel.
+func (c *Channel) PresentValue() []APDUPayload {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.presentValue
+}
+
+// LastPriority returns the priority of the last write.
+func (c *Channel) LastPriority() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastPriority
+}
+
+// WriteStatus returns the outcome of the last write.
+func (c *Channel) WriteStatus() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.writeStatus
+}
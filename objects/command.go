@@ -0,0 +1,136 @@
+package objects
+
+import (
+	"reflect"
+	"time"
+)
+
+const (
+	// lowestPriority is the priority of the commands written without one.
+	lowestPriority uint8 = 16
+	// minimumOnOffPriority is the priority Binary Outputs hold their value at
+	// for their Minimum_On_Time or Minimum_Off_Time.
+	minimumOnOffPriority uint8 = 6
+)
+
+// commandable tells whether the Present_Value of o is derived from its
+// Priority_Array.
+func (o *dbObject) commandable() bool {
+	_, ok := o.properties[PropertyIdPriorityArray]
+	return ok
+}
+
+// minimumTime returns how long o, a Binary Output, holds state once it
+// changed to it, zero when it doesn't.
+func (o *dbObject) minimumTime(state uint32) time.Duration {
+	propertyId := PropertyIdMinimumOffTime
+	if state != 0 {
+		propertyId = PropertyIdMinimumOnTime
+	}
+	p, ok := o.properties[propertyId]
+	if !ok {
+		return 0
+	}
+	seconds, _ := DecUnisgnedInteger(p.value[0])
+	return time.Duration(seconds) * time.Second
+}
+
+// holdsMinimumTimes tells whether o reserves minimumOnOffPriority for its
+// minimum on and off times.
+func (o *dbObject) holdsMinimumTimes() bool {
+	return o.minimumTime(0) > 0 || o.minimumTime(1) > 0
+}
+
+// command writes value to the slot priority of the Priority_Array of o, a
+// zero priority being the lowest one, and updates its Present_Value.
+func (db *Database) command(o *dbObject, arrayIndex uint32, value []APDUPayload, priority uint8) error {
+	if arrayIndex != ArrayAll {
+		return NewBACnetError(ErrorClassProperty, ErrorCodePropertyIsNotAnArray)
+	}
+	if priority == 0 {
+		priority = lowestPriority
+	}
+	if priority == minimumOnOffPriority && o.holdsMinimumTimes() {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeWriteAccessDenied)
+	}
+
+	pa := o.properties[PropertyIdPriorityArray]
+	if err := pa.check(value, 1); err != nil {
+		return err
+	}
+	if err := o.checkRange(PropertyIdPresentValue, value[0]); err != nil {
+		return err
+	}
+
+	db.setPriority(o, priority, value[0])
+	db.resolve(o)
+	return nil
+}
+
+// setPriority writes v to the slot priority of the Priority_Array of o.
+func (db *Database) setPriority(o *dbObject, priority uint8, v APDUPayload) {
+	pa := o.properties[PropertyIdPriorityArray]
+	old := pa.read()
+	pa.value[priority-1] = v
+	db.changed(o, PropertyIdPriorityArray, pa, old)
+}
+
+// resolve sets the Present_Value of o to its highest priority command, or to
+// its Relinquish_Default when every priority is relinquished.
+func (db *Database) resolve(o *dbObject) {
+	value := o.properties[PropertyIdRelinquishDefault].value[0]
+	for _, v := range o.properties[PropertyIdPriorityArray].value {
+		if isNull, _ := DecNull(v); !isNull {
+			value = v
+			break
+		}
+	}
+
+	pv := o.properties[PropertyIdPresentValue]
+	old := pv.read()
+	if reflect.DeepEqual(old[0], value) {
+		return
+	}
+	pv.value = one(value)
+	db.changed(o, PropertyIdPresentValue, pv, old)
+
+	if o.id.ObjectType == ObjectTypeBinaryOutput {
+		db.holdMinimumTime(o, value)
+	}
+}
+
+// holdMinimumTime holds the new Present_Value of o, a Binary Output, at
+// minimumOnOffPriority for its Minimum_On_Time or Minimum_Off_Time, so that
+// only higher priorities change it meanwhile. The priority is relinquished
+// once the time is over.
+func (db *Database) holdMinimumTime(o *dbObject, value APDUPayload) {
+	held := o.minimumTimer != nil
+	if held {
+		o.minimumTimer.Stop()
+		o.minimumTimer = nil
+	}
+
+	state, _ := DecEnumerated(value)
+	d := o.minimumTime(state)
+	if d == 0 {
+		if held {
+			db.setPriority(o, minimumOnOffPriority, EncNull())
+		}
+		return
+	}
+
+	db.setPriority(o, minimumOnOffPriority, value)
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		db.update(func() error {
+			if o.minimumTimer != timer {
+				return nil
+			}
+			o.minimumTimer = nil
+			db.setPriority(o, minimumOnOffPriority, EncNull())
+			db.resolve(o)
+			return nil
+		})
+	})
+	o.minimumTimer = timer
+}
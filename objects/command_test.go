@@ -0,0 +1,141 @@
+package objects_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet/objects"
+)
+
+func TestCommandPrioritization(t *testing.T) {
+	const (
+		inactive uint32 = 0
+		active   uint32 = 1
+		// wait stands for the release of the minimum on or off time.
+		wait uint8 = 0
+	)
+	type step struct {
+		priority uint8
+		value    objects.APDUPayload
+		want     uint32
+		// held is the state held at priority 6, nil when relinquished.
+		held *uint32
+	}
+	state := func(v uint32) *uint32 { return &v }
+
+	cases := []struct {
+		name              string
+		relinquishDefault uint32
+		minimumOnTime     uint32
+		steps             []step
+	}{
+		{
+			name: "highest priority wins",
+			steps: []step{
+				{16, objects.EncEnumerated32(active), active, nil},
+				{10, objects.EncEnumerated32(inactive), inactive, nil},
+				{12, objects.EncEnumerated32(active), inactive, nil},
+				{10, objects.EncNull(), active, nil},
+			},
+		},
+		{
+			name:              "relinquish default",
+			relinquishDefault: active,
+			steps: []step{
+				{8, objects.EncEnumerated32(inactive), inactive, nil},
+				{8, objects.EncNull(), active, nil},
+			},
+		},
+		{
+			name:          "minimum on time",
+			minimumOnTime: 1,
+			steps: []step{
+				{16, objects.EncEnumerated32(active), active, state(active)},
+				{16, objects.EncEnumerated32(inactive), active, state(active)},
+				{wait, nil, inactive, nil},
+			},
+		},
+		{
+			name:          "higher priority overrides minimum on time",
+			minimumOnTime: 1,
+			steps: []step{
+				{16, objects.EncEnumerated32(active), active, state(active)},
+				{3, objects.EncEnumerated32(inactive), inactive, nil},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := objects.NewDatabase(1, "test", 0)
+			if err := db.Add(objects.ObjectTypeBinaryOutput, 0, "BO-0"); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.SetProperty(objects.ObjectTypeBinaryOutput, 0, objects.PropertyIdRelinquishDefault,
+				[]objects.APDUPayload{objects.EncEnumerated32(c.relinquishDefault)}); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.SetProperty(objects.ObjectTypeBinaryOutput, 0, objects.PropertyIdMinimumOnTime,
+				[]objects.APDUPayload{objects.EncUnsignedInteger32(c.minimumOnTime)}); err != nil {
+				t.Fatal(err)
+			}
+			defer db.Delete(objects.ObjectTypeBinaryOutput, 0)
+
+			for i, s := range c.steps {
+				if s.priority == wait {
+					waitFor(t, 3*time.Second, func() bool {
+						return readPresentValue(t, db) == s.want
+					})
+				} else if err := db.WriteProperty(objects.ObjectTypeBinaryOutput, 0, objects.PropertyIdPresentValue,
+					objects.ArrayAll, []objects.APDUPayload{s.value}, s.priority); err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+
+				if got := readPresentValue(t, db); got != s.want {
+					t.Errorf("step %d: Present_Value %d, want %d", i, got, s.want)
+				}
+				slot, err := db.ReadProperty(objects.ObjectTypeBinaryOutput, 0, objects.PropertyIdPriorityArray, 6)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var held *uint32
+				if isNull, _ := objects.DecNull(slot[0]); !isNull {
+					v, err := objects.DecEnumerated(slot[0])
+					if err != nil {
+						t.Fatal(err)
+					}
+					held = &v
+				}
+				if diff := cmp.Diff(s.held, held); diff != "" {
+					t.Errorf("step %d: priority 6 differs: (-want +got)\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func readPresentValue(t *testing.T, db *objects.Database) uint32 {
+	t.Helper()
+	pv, err := db.ReadProperty(objects.ObjectTypeBinaryOutput, 0, objects.PropertyIdPresentValue, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := objects.DecEnumerated(pv[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// waitFor fails the test unless cond becomes true within d.
+func waitFor(t *testing.T, d time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -21,19 +21,161 @@ const (
 
 // Be sure to check ../bacnet-stack/src/bacnet/bacenum.h for more!
 const (
-	ObjectTypeAnalogInput  uint16 = 0
-	ObjectTypeAnalogOutput uint16 = 1
-	ObjectTypeDevice       uint16 = 8
+	ObjectTypeAnalogInput       uint16 = 0
+	ObjectTypeAnalogOutput      uint16 = 1
+	ObjectTypeAnalogValue       uint16 = 2
+	ObjectTypeBinaryInput       uint16 = 3
+	ObjectTypeBinaryOutput      uint16 = 4
+	ObjectTypeBinaryValue       uint16 = 5
+	ObjectTypeCalendar          uint16 = 6
+	ObjectTypeDevice            uint16 = 8
+	ObjectTypeFile              uint16 = 10
+	ObjectTypeMultiStateInput   uint16 = 13
+	ObjectTypeMultiStateOutput  uint16 = 14
+	ObjectTypeNotificationClass uint16 = 15
+	ObjectTypeSchedule          uint16 = 17
+	ObjectTypeMultiStateValue   uint16 = 19
+	ObjectTypeTrendLog          uint16 = 20
+	ObjectTypeEventLog          uint16 = 25
+	ObjectTypeTrendLogMultiple  uint16 = 27
+	ObjectTypeChannel           uint16 = 53
+	ObjectTypeAuditLog          uint16 = 61
 )
 
+// ArrayAll is the array index referring to a whole property.
+const ArrayAll uint32 = 0xFFFFFFFF
+
 const (
-	PropertyIdPresentValue uint8 = 85
+	PropertyIdAckedTransitions               uint8 = 0
+	PropertyIdAckRequired                    uint8 = 1
+	PropertyIdAlarmValue                     uint8 = 6
+	PropertyIdAlarmValues                    uint8 = 7
+	PropertyIdAll                            uint8 = 8
+	PropertyIdAPDUTimeout                    uint8 = 11
+	PropertyIdApplicationSoftwareVersion     uint8 = 12
+	PropertyIdNotificationClass              uint8 = 17
+	PropertyIdCOVIncrement                   uint8 = 22
+	PropertyIdDateList                       uint8 = 23
+	PropertyIdDeadband                       uint8 = 25
+	PropertyIdDescription                    uint8 = 28
+	PropertyIdDeviceAddressBinding           uint8 = 30
+	PropertyIdEventEnable                    uint8 = 35
+	PropertyIdEventState                     uint8 = 36
+	PropertyIdFirmwareRevision               uint8 = 44
+	PropertyIdHighLimit                      uint8 = 45
+	PropertyIdLimitEnable                    uint8 = 52
+	PropertyIdListOfObjectPropertyReferences uint8 = 54
+	PropertyIdLowLimit                       uint8 = 59
+	PropertyIdMaxAPDULengthAccepted          uint8 = 62
+	PropertyIdMinimumOffTime                 uint8 = 66
+	PropertyIdMinimumOnTime                  uint8 = 67
+	PropertyIdModelName                      uint8 = 70
+	PropertyIdNotifyType                     uint8 = 72
+	PropertyIdNumberOfAPDURetries            uint8 = 73
+	PropertyIdNumberOfStates                 uint8 = 74
+	PropertyIdObjectIdentifier               uint8 = 75
+	PropertyIdObjectList                     uint8 = 76
+	PropertyIdObjectName                     uint8 = 77
+	PropertyIdObjectType                     uint8 = 79
+	PropertyIdOptional                       uint8 = 80
+	PropertyIdOutOfService                   uint8 = 81
+	PropertyIdPolarity                       uint8 = 84
+	PropertyIdPresentValue                   uint8 = 85
+	PropertyIdPriority                       uint8 = 86
+	PropertyIdPriorityArray                  uint8 = 87
+	PropertyIdProtocolObjectTypesSupported   uint8 = 96
+	PropertyIdProtocolServicesSupported      uint8 = 97
+	PropertyIdProtocolVersion                uint8 = 98
+	PropertyIdRecipientList                  uint8 = 102
+	PropertyIdRelinquishDefault              uint8 = 104
+	PropertyIdRequired                       uint8 = 105
+	PropertyIdSegmentationSupported          uint8 = 107
+	PropertyIdStatusFlags                    uint8 = 111
+	PropertyIdSystemStatus                   uint8 = 112
+	PropertyIdTimeDelay                      uint8 = 113
+	PropertyIdUnits                          uint8 = 117
+	PropertyIdVendorIdentifier               uint8 = 120
+	PropertyIdVendorName                     uint8 = 121
+	PropertyIdEventTimeStamps                uint8 = 130
+	PropertyIdLogBuffer                      uint8 = 131
+	PropertyIdProtocolRevision               uint8 = 139
+	PropertyIdDatabaseRevision               uint8 = 155
 )
 
 const (
-	ErrorClassObject  uint8 = 1
-	ErrorClassService uint8 = 5
+	ErrorClassDevice    uint8 = 0
+	ErrorClassObject    uint8 = 1
+	ErrorClassProperty  uint8 = 2
+	ErrorClassResources uint8 = 3
+	ErrorClassService   uint8 = 5
+
+	ErrorCodeOther                             uint8 = 0
+	ErrorCodeDynamicCreationNotSupported       uint8 = 4
+	ErrorCodeFileAccessDenied                  uint8 = 5
+	ErrorCodeInvalidFileAccessMethod           uint8 = 10
+	ErrorCodeInvalidDataType                   uint8 = 9
+	ErrorCodeInvalidFileStartPosition          uint8 = 11
+	ErrorCodeNoSpaceForObject                  uint8 = 18
+	ErrorCodeNoSpaceToWriteProperty            uint8 = 20
+	ErrorCodePropertyIsNotAList                uint8 = 22
+	ErrorCodeObjectDeletionNotPermitted        uint8 = 23
+	ErrorCodeObjectIdentifierAlreadyExists     uint8 = 24
+	ErrorCodeServiceRequestDenied              uint8 = 29
+	ErrorCodeUnknownObject                     uint8 = 31
+	ErrorCodeUnknownProperty                   uint8 = 32
+	ErrorCodeUnsupportedObjectType             uint8 = 36
+	ErrorCodeValueOutOfRange                   uint8 = 37
+	ErrorCodeOptionalFunctionalityNotSupported uint8 = 45
+	ErrorCodeWriteAccessDenied                 uint8 = 40
+	ErrorCodeInvalidArrayIndex                 uint8 = 42
+	ErrorCodeDuplicateName                     uint8 = 48
+	ErrorCodePropertyIsNotAnArray              uint8 = 50
+	ErrorCodeInvalidEventState                 uint8 = 73
+	ErrorCodeInvalidTimeStamp                  uint8 = 74
+	ErrorCodeUnknownSubscription               uint8 = 79
+	ErrorCodeListElementNotFound               uint8 = 81
+)
 
-	ErrorCodeUnknownObject        uint8 = 31
-	ErrorCodeServiceRequestDenied uint8 = 29
+// Character sets for CharacterString values.
+const (
+	CharacterSetUTF8 uint8 = 0
+)
+
+// Reasons of Reject PDUs.
+const (
+	RejectReasonOther uint8 = iota
+	RejectReasonBufferOverflow
+	RejectReasonInconsistentParameters
+	RejectReasonInvalidParameterDataType
+	RejectReasonInvalidTag
+	RejectReasonMissingRequiredParameter
+	RejectReasonParameterOutOfRange
+	RejectReasonTooManyArguments
+	RejectReasonUndefinedEnumeration
+	RejectReasonUnrecognizedService
+)
+
+// Reasons of Abort PDUs.
+const (
+	AbortReasonOther uint8 = iota
+	AbortReasonBufferOverflow
+	AbortReasonInvalidAPDUInThisState
+	AbortReasonPreemptedByHigherPriorityTask
+	AbortReasonSegmentationNotSupported
+	AbortReasonSecurityError
+	AbortReasonInsufficientSecurity
+	AbortReasonWindowSizeOutOfRange
+	AbortReasonApplicationExceededReplyTime
+	AbortReasonOutOfResources
+	AbortReasonTSMTimeout
+	AbortReasonAPDUTooLong
+)
+
+// Event transitions, the positions of their bits in Event_Enable,
+// Acked_Transitions and Ack_Required, and of their element, minus one, in the
+// Priority of Notification Class objects.
+const (
+	TransitionToOffnormal = iota
+	TransitionToFault
+	TransitionToNormal
 )
@@ -0,0 +1,807 @@
+package objects
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Default values of the Device object properties a Database is created with.
+const (
+	DefaultMaxAPDULengthAccepted uint32 = 1024
+	DefaultAPDUTimeout           uint32 = 3000
+	DefaultNumberOfAPDURetries   uint32 = 3
+	DefaultProtocolRevision      uint32 = 12
+
+	// servicesSupportedLen is the number of bits of BACnetServicesSupported.
+	servicesSupportedLen = 49
+	// segmentationNone is the Segmentation_Supported of the device.
+	segmentationNone = 3
+	// unitsNoUnits is the Units analog objects are created with.
+	unitsNoUnits = 95
+	// lowestEventPriority is the Priority Notification Class objects are
+	// created with.
+	lowestEventPriority = 255
+)
+
+// databaseObjectTypes are the object types a Database holds.
+var databaseObjectTypes = []uint16{
+	ObjectTypeAnalogInput,
+	ObjectTypeAnalogOutput,
+	ObjectTypeAnalogValue,
+	ObjectTypeBinaryInput,
+	ObjectTypeBinaryOutput,
+	ObjectTypeBinaryValue,
+	ObjectTypeDevice,
+	ObjectTypeMultiStateInput,
+	ObjectTypeMultiStateOutput,
+	ObjectTypeNotificationClass,
+	ObjectTypeMultiStateValue,
+}
+
+// Database holds the objects of a device: the Device object itself, Analog,
+// Binary and Multi-state Input, Output and Value objects and Notification
+// Class objects, each with the properties the standard requires for its
+// type. Property_List, whose identifier doesn't fit the uint8 property
+// identifiers of this package, is left out, which is why the device claims
+// Protocol_Revision 12.
+//
+// Values are kept as application tagged objects, checked against the
+// datatype of their property when written. Output objects are commandable:
+// their Present_Value is derived from their Priority_Array. Objects report
+// events intrinsically, tracking their Event_State with the OUT_OF_RANGE
+// algorithm for Analog objects once their Limit_Enable is set, and with the
+// CHANGE_OF_STATE algorithm against their Alarm_Value or Alarm_Values for
+// Binary and Multi-state Inputs and Values, the time of their last
+// transitions being kept in their Event_Time_Stamps. Errors are reported as
+// *BACnetError, ready to be sent back to a peer. A Database is safe for
+// concurrent use.
+type Database struct {
+	device  ObjectIdentifier
+	objects map[ObjectIdentifier]*dbObject
+	order   []ObjectIdentifier
+	names   map[string]ObjectIdentifier
+
+	revision  uint32
+	listeners map[int]ChangeListener
+	nextID    int
+	changes   []propertyChange
+
+	mu sync.RWMutex
+}
+
+// ChangeListener is called with the new value of a property of the object id
+// whenever it changes, the Present_Value of commandable objects included.
+// Computed properties, such as Status_Flags, aren't reported.
+type ChangeListener func(id ObjectIdentifier, propertyId uint8, value []APDUPayload)
+
+type propertyChange struct {
+	id         ObjectIdentifier
+	propertyId uint8
+	value      []APDUPayload
+}
+
+type dbObject struct {
+	id         ObjectIdentifier
+	properties map[uint8]*dbProperty
+	order      []uint8
+
+	// minimumTimer relinquishes the Minimum_On_Time or Minimum_Off_Time
+	// priority of a Binary Output.
+	minimumTimer *time.Timer
+	// eventTimer moves an object reporting events into eventTarget once its
+	// Time_Delay is over.
+	eventTimer  *time.Timer
+	eventTarget uint32
+	// eventTimes are the times of the last transitions to offnormal, fault
+	// and normal, zero until they happen.
+	eventTimes [3]time.Time
+}
+
+// dbProperty is a property of a dbObject. Array properties hold element
+// objects per element, one when it's zero, whereas list properties hold any
+// number of objects, which aren't checked. The value of a property with a get
+// function is computed when read.
+type dbProperty struct {
+	tag      uint8
+	array    bool
+	element  int
+	list     bool
+	nullable bool
+	writable bool
+	optional bool
+
+	value []APDUPayload
+	get   func() []APDUPayload
+}
+
+// NewDatabase creates a Database holding the Device object deviceId.
+func NewDatabase(deviceId uint32, name string, vendorId uint16) *Database {
+	db := &Database{
+		device:    ObjectIdentifier{ObjectType: ObjectTypeDevice, InstanceNumber: deviceId},
+		objects:   map[ObjectIdentifier]*dbObject{},
+		names:     map[string]ObjectIdentifier{},
+		listeners: map[int]ChangeListener{},
+	}
+
+	o := newDBObject(db.device, name)
+	o.add(PropertyIdSystemStatus, &dbProperty{tag: TagEnumerated, value: one(EncEnumerated(0))})
+	o.add(PropertyIdVendorName, &dbProperty{tag: TagCharacterString, value: one(EncString(""))})
+	o.add(PropertyIdVendorIdentifier, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger16(vendorId))})
+	o.add(PropertyIdModelName, &dbProperty{tag: TagCharacterString, value: one(EncString(""))})
+	o.add(PropertyIdFirmwareRevision, &dbProperty{tag: TagCharacterString, value: one(EncString(""))})
+	o.add(PropertyIdApplicationSoftwareVersion, &dbProperty{tag: TagCharacterString, value: one(EncString(""))})
+	o.add(PropertyIdProtocolVersion, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger8(1))})
+	o.add(PropertyIdProtocolRevision, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(DefaultProtocolRevision))})
+	o.add(PropertyIdProtocolServicesSupported, &dbProperty{tag: TagBitString, value: one(EncBitString(make([]bool, servicesSupportedLen)))})
+	o.add(PropertyIdProtocolObjectTypesSupported, &dbProperty{tag: TagBitString, value: one(EncBitString(objectTypesSupported()))})
+	o.add(PropertyIdObjectList, &dbProperty{tag: TagBACnetObjectIdentifier, array: true, get: db.objectList})
+	o.add(PropertyIdMaxAPDULengthAccepted, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(DefaultMaxAPDULengthAccepted))})
+	o.add(PropertyIdSegmentationSupported, &dbProperty{tag: TagEnumerated, value: one(EncEnumerated(segmentationNone))})
+	o.add(PropertyIdAPDUTimeout, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(DefaultAPDUTimeout))})
+	o.add(PropertyIdNumberOfAPDURetries, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(DefaultNumberOfAPDURetries))})
+	o.add(PropertyIdDeviceAddressBinding, &dbProperty{list: true, value: []APDUPayload{}})
+	o.add(PropertyIdDatabaseRevision, &dbProperty{tag: TagUnsignedInteger, get: db.databaseRevision})
+
+	db.insert(o, name)
+	return db
+}
+
+// DeviceId returns the instance number of the Device object.
+func (db *Database) DeviceId() uint32 {
+	return db.device.InstanceNumber
+}
+
+// Add creates the object instN of type objectType named name, its properties
+// holding their default values: zero or inactive present values, the first
+// state of multi-state objects which have 2 of them, no units, a zero
+// COV_Increment, relinquished priority arrays and no minimum on and off times.
+// Analog objects have their limits disabled, Binary Inputs and Values alarm
+// when active and Multi-state Inputs and Values have no alarm values, all of
+// them notifying alarms of every transition to Notification Class 0.
+// Notification Class objects have the lowest priorities, require no
+// acknowledgment and have no recipients.
+func (db *Database) Add(objectType uint16, instN uint32, name string) error {
+	id := ObjectIdentifier{ObjectType: objectType, InstanceNumber: instN}
+	if objectType == ObjectTypeDevice || !supportedObjectType(objectType) {
+		return NewBACnetError(ErrorClassObject, ErrorCodeUnsupportedObjectType)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.objects[id]; ok {
+		return NewBACnetError(ErrorClassObject, ErrorCodeObjectIdentifierAlreadyExists)
+	}
+	if _, ok := db.names[name]; ok || name == "" {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeDuplicateName)
+	}
+
+	var o *dbObject
+	if objectType == ObjectTypeNotificationClass {
+		o = newNotificationClass(id, name)
+	} else {
+		o = newStandardObject(id, name)
+	}
+	db.insert(o, name)
+	db.revision++
+	return nil
+}
+
+// Delete deletes an object, which can't be the Device object.
+func (db *Database) Delete(objectType uint16, instN uint32) error {
+	id := ObjectIdentifier{ObjectType: objectType, InstanceNumber: instN}
+	if id == db.device {
+		return NewBACnetError(ErrorClassObject, ErrorCodeObjectDeletionNotPermitted)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	o, ok := db.objects[id]
+	if !ok {
+		return NewBACnetError(ErrorClassObject, ErrorCodeUnknownObject)
+	}
+	if o.minimumTimer != nil {
+		o.minimumTimer.Stop()
+	}
+	o.stopEventTimer()
+	name, _ := DecString(o.properties[PropertyIdObjectName].value[0])
+	delete(db.names, name)
+	delete(db.objects, id)
+	for i := range db.order {
+		if db.order[i] == id {
+			db.order = append(db.order[:i], db.order[i+1:]...)
+			break
+		}
+	}
+	db.revision++
+	return nil
+}
+
+// Objects returns the identifiers of the objects, the Device object first and
+// the others in the order they were added.
+func (db *Database) Objects() []ObjectIdentifier {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return append([]ObjectIdentifier{}, db.order...)
+}
+
+// Properties returns the identifiers of the properties of an object selected
+// by which: PropertyIdAll, PropertyIdRequired or PropertyIdOptional.
+func (db *Database) Properties(objectType uint16, instN uint32, which uint8) ([]uint8, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	o, err := db.object(objectType, instN)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint8{}
+	for _, id := range o.order {
+		optional := o.properties[id].optional
+		if which == PropertyIdAll || optional == (which == PropertyIdOptional) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Listen registers l to be called with the changes of the properties, until
+// the returned function is called. Listeners are called once the change is
+// made, outside of the Database lock.
+func (db *Database) Listen(l ChangeListener) func() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	id := db.nextID
+	db.nextID++
+	db.listeners[id] = l
+
+	return func() {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+
+		delete(db.listeners, id)
+	}
+}
+
+// ReadProperty returns the value of a property, or of the element arrayIndex
+// of an array, element 0 being its length.
+func (db *Database) ReadProperty(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32) ([]APDUPayload, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.readProperty(objectType, instN, propertyId, arrayIndex)
+}
+
+func (db *Database) readProperty(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32) ([]APDUPayload, error) {
+	_, p, err := db.property(objectType, instN, propertyId)
+	if err != nil {
+		return nil, err
+	}
+
+	value := p.read()
+	if arrayIndex == ArrayAll {
+		return value, nil
+	}
+	if !p.array {
+		return nil, NewBACnetError(ErrorClassProperty, ErrorCodePropertyIsNotAnArray)
+	}
+	size := p.elementSize()
+	if arrayIndex == 0 {
+		return one(EncUnsignedInteger32(uint32(len(value) / size))), nil
+	}
+	if arrayIndex > uint32(len(value)/size) {
+		return nil, NewBACnetError(ErrorClassProperty, ErrorCodeInvalidArrayIndex)
+	}
+	return value[int(arrayIndex-1)*size : int(arrayIndex)*size], nil
+}
+
+// WriteProperty writes value, at priority 1 to 16 or 0 when there is none, to
+// a property or the element arrayIndex of an array, as requested by a peer:
+// only the properties a peer may change are written, the Present_Value of
+// input objects being among them while the object is out of service. Writing
+// the Present_Value of a commandable object commands it at priority, the
+// lowest one when there is none, a NULL relinquishing the command.
+func (db *Database) WriteProperty(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, value []APDUPayload, priority uint8) error {
+	return db.update(func() error {
+		return db.writeProperty(objectType, instN, propertyId, arrayIndex, value, priority)
+	})
+}
+
+// SwapProperty writes value like WriteProperty and returns the values of the
+// property, or of its element arrayIndex, before and after the write, which
+// differ from value when a commandable property is commanded at a lower
+// priority than the one in effect. Both are read along with the write, so
+// that no other change comes in between; after is nil when the write fails.
+func (db *Database) SwapProperty(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, value []APDUPayload, priority uint8) (before, after []APDUPayload, err error) {
+	err = db.update(func() error {
+		before, _ = db.readProperty(objectType, instN, propertyId, arrayIndex)
+		if err := db.writeProperty(objectType, instN, propertyId, arrayIndex, value, priority); err != nil {
+			return err
+		}
+		after, _ = db.readProperty(objectType, instN, propertyId, arrayIndex)
+		return nil
+	})
+	return before, after, err
+}
+
+func (db *Database) writeProperty(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, value []APDUPayload, priority uint8) error {
+	if priority > lowestPriority {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+	}
+	o, p, err := db.property(objectType, instN, propertyId)
+	if err != nil {
+		return err
+	}
+	if !p.writable && !(propertyId == PropertyIdPresentValue && isInput(objectType) && o.outOfService()) {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeWriteAccessDenied)
+	}
+	if propertyId == PropertyIdPresentValue && o.commandable() {
+		return db.command(o, arrayIndex, value, priority)
+	}
+	return db.write(o, propertyId, p, arrayIndex, value)
+}
+
+// WriteReference writes value to the property referred to by ref, which must
+// belong to the device, as WriteProperty does. It lets a Channel write to the
+// objects of the Database.
+func (db *Database) WriteReference(ref DeviceObjectPropertyReference, value []APDUPayload, priority uint8) error {
+	if ref.Device != nil && *ref.Device != db.device {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeOptionalFunctionalityNotSupported)
+	}
+	return db.WriteProperty(ref.ObjectType, ref.InstanceNumber, ref.PropertyId, ref.ArrayIndex, value, priority)
+}
+
+// SetProperty sets the value of a property on behalf of the application,
+// whether peers may write it or not. Computed properties, such as Object_List,
+// can't be set, nor can the Present_Value of commandable objects, which is
+// commanded with WriteProperty.
+func (db *Database) SetProperty(objectType uint16, instN uint32, propertyId uint8, value []APDUPayload) error {
+	return db.update(func() error {
+		o, p, err := db.property(objectType, instN, propertyId)
+		if err != nil {
+			return err
+		}
+		return db.write(o, propertyId, p, ArrayAll, value)
+	})
+}
+
+// SetPropertyFunc has the value of a property computed by f whenever it's
+// read. f is called with the Database locked and mustn't use it.
+func (db *Database) SetPropertyFunc(objectType uint16, instN uint32, propertyId uint8, f func() []APDUPayload) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, p, err := db.property(objectType, instN, propertyId)
+	if err != nil {
+		return err
+	}
+	p.get = f
+	return nil
+}
+
+// update calls f with the Database locked, then reports the changes f made
+// to the listeners.
+func (db *Database) update(f func() error) error {
+	db.mu.Lock()
+	err := f()
+	changes := db.changes
+	db.changes = nil
+	listeners := make([]ChangeListener, 0, len(db.listeners))
+	for _, l := range db.listeners {
+		listeners = append(listeners, l)
+	}
+	db.mu.Unlock()
+
+	for _, c := range changes {
+		for _, l := range listeners {
+			l(c.id, c.propertyId, c.value)
+		}
+	}
+	return err
+}
+
+// changed records the change of the property propertyId of o, p, from old,
+// if its value differs, and runs the event algorithm of o when it depends on
+// the property.
+func (db *Database) changed(o *dbObject, propertyId uint8, p *dbProperty, old []APDUPayload) {
+	value := p.read()
+	if reflect.DeepEqual(old, value) {
+		return
+	}
+	db.changes = append(db.changes, propertyChange{id: o.id, propertyId: propertyId, value: value})
+
+	if eventTrigger(propertyId) {
+		db.evaluate(o)
+	}
+}
+
+func (db *Database) insert(o *dbObject, name string) {
+	db.objects[o.id] = o
+	db.order = append(db.order, o.id)
+	db.names[name] = o.id
+}
+
+func (db *Database) object(objectType uint16, instN uint32) (*dbObject, error) {
+	o, ok := db.objects[ObjectIdentifier{ObjectType: objectType, InstanceNumber: instN}]
+	if !ok {
+		return nil, NewBACnetError(ErrorClassObject, ErrorCodeUnknownObject)
+	}
+	return o, nil
+}
+
+func (db *Database) property(objectType uint16, instN uint32, propertyId uint8) (*dbObject, *dbProperty, error) {
+	o, err := db.object(objectType, instN)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, ok := o.properties[propertyId]
+	if !ok {
+		return nil, nil, NewBACnetError(ErrorClassProperty, ErrorCodeUnknownProperty)
+	}
+	return o, p, nil
+}
+
+// write checks value and stores it in the property propertyId of o. The
+// elements of an array are written one at a time, its length being fixed.
+func (db *Database) write(o *dbObject, propertyId uint8, p *dbProperty, arrayIndex uint32, value []APDUPayload) error {
+	if p.get != nil || (propertyId == PropertyIdPresentValue && o.commandable()) {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeWriteAccessDenied)
+	}
+	if err := db.store(o, propertyId, p, arrayIndex, value); err != nil {
+		return err
+	}
+
+	if o.commandable() && (propertyId == PropertyIdPriorityArray || propertyId == PropertyIdRelinquishDefault) {
+		db.resolve(o)
+	}
+	return nil
+}
+
+// store checks value and stores it in the property propertyId of o, p, or in
+// its element arrayIndex.
+func (db *Database) store(o *dbObject, propertyId uint8, p *dbProperty, arrayIndex uint32, value []APDUPayload) error {
+	old := p.read()
+
+	if arrayIndex != ArrayAll {
+		if !p.array {
+			return NewBACnetError(ErrorClassProperty, ErrorCodePropertyIsNotAnArray)
+		}
+		if arrayIndex == 0 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeWriteAccessDenied)
+		}
+		if arrayIndex > uint32(len(p.value)) {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeInvalidArrayIndex)
+		}
+		if err := p.check(value, 1); err != nil {
+			return err
+		}
+		if err := o.checkRange(propertyId, value[0]); err != nil {
+			return err
+		}
+		p.value[arrayIndex-1] = value[0]
+		db.changed(o, propertyId, p, old)
+		return nil
+	}
+
+	count := 1
+	switch {
+	case p.list:
+		count = -1
+	case p.array:
+		count = len(p.value)
+	}
+	if err := p.check(value, count); err != nil {
+		return err
+	}
+	for _, v := range value {
+		if err := o.checkRange(propertyId, v); err != nil {
+			return err
+		}
+	}
+
+	if propertyId == PropertyIdObjectName {
+		if err := db.rename(o, p, value[0]); err != nil {
+			return err
+		}
+	}
+	p.value = append([]APDUPayload{}, value...)
+	db.changed(o, propertyId, p, old)
+	return nil
+}
+
+// rename moves o to name in the names of the Database.
+func (db *Database) rename(o *dbObject, p *dbProperty, name APDUPayload) error {
+	newName, err := DecString(name)
+	if err != nil {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeInvalidDataType)
+	}
+	oldName, _ := DecString(p.value[0])
+	if newName == oldName {
+		return nil
+	}
+	if _, ok := db.names[newName]; ok || newName == "" {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeDuplicateName)
+	}
+
+	delete(db.names, oldName)
+	db.names[newName] = o.id
+	db.revision++
+	return nil
+}
+
+// objectList is the Object_List of the Device object.
+func (db *Database) objectList() []APDUPayload {
+	objs := make([]APDUPayload, 0, len(db.order))
+	for _, id := range db.order {
+		objs = append(objs, EncObjectIdentifier(false, TagBACnetObjectIdentifier, id.ObjectType, id.InstanceNumber))
+	}
+	return objs
+}
+
+func (db *Database) databaseRevision() []APDUPayload {
+	return one(EncUnsignedInteger32(db.revision))
+}
+
+func newDBObject(id ObjectIdentifier, name string) *dbObject {
+	o := &dbObject{
+		id:         id,
+		properties: map[uint8]*dbProperty{},
+	}
+	o.add(PropertyIdObjectIdentifier, &dbProperty{
+		tag:   TagBACnetObjectIdentifier,
+		value: one(EncObjectIdentifier(false, TagBACnetObjectIdentifier, id.ObjectType, id.InstanceNumber)),
+	})
+	o.add(PropertyIdObjectName, &dbProperty{tag: TagCharacterString, value: one(EncString(name))})
+	o.add(PropertyIdObjectType, &dbProperty{tag: TagEnumerated, value: one(EncEnumerated32(uint32(id.ObjectType)))})
+	return o
+}
+
+// newStandardObject creates an Analog, Binary or Multi-state object.
+func newStandardObject(id ObjectIdentifier, name string) *dbObject {
+	o := newDBObject(id, name)
+
+	var pv *dbProperty
+	switch {
+	case isAnalog(id.ObjectType):
+		pv = &dbProperty{tag: TagReal, value: one(EncReal(0))}
+	case isBinary(id.ObjectType):
+		pv = &dbProperty{tag: TagEnumerated, value: one(EncEnumerated(0))}
+	default:
+		pv = &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(1))}
+	}
+	pv.writable = !isInput(id.ObjectType)
+	o.add(PropertyIdPresentValue, pv)
+
+	o.add(PropertyIdStatusFlags, &dbProperty{tag: TagBitString, get: o.statusFlags})
+	o.add(PropertyIdEventState, &dbProperty{tag: TagEnumerated, value: one(EncEnumerated(0))})
+	o.add(PropertyIdOutOfService, &dbProperty{tag: TagBoolean, writable: true, value: one(EncBoolean(false))})
+
+	switch {
+	case isAnalog(id.ObjectType):
+		o.add(PropertyIdUnits, &dbProperty{tag: TagEnumerated, value: one(EncEnumerated32(unitsNoUnits))})
+		o.add(PropertyIdCOVIncrement, &dbProperty{tag: TagReal, writable: true, optional: true, value: one(EncReal(0))})
+		o.add(PropertyIdHighLimit, &dbProperty{tag: TagReal, writable: true, optional: true, value: one(EncReal(0))})
+		o.add(PropertyIdLowLimit, &dbProperty{tag: TagReal, writable: true, optional: true, value: one(EncReal(0))})
+		o.add(PropertyIdDeadband, &dbProperty{tag: TagReal, writable: true, optional: true, value: one(EncReal(0))})
+		o.add(PropertyIdLimitEnable, &dbProperty{tag: TagBitString, writable: true, optional: true, value: one(EncBitString([]bool{false, false}))})
+	case isBinary(id.ObjectType):
+		if id.ObjectType != ObjectTypeBinaryValue {
+			o.add(PropertyIdPolarity, &dbProperty{tag: TagEnumerated, value: one(EncEnumerated(0))})
+		}
+		if !isOutput(id.ObjectType) {
+			o.add(PropertyIdAlarmValue, &dbProperty{tag: TagEnumerated, writable: true, optional: true, value: one(EncEnumerated(1))})
+		}
+	default:
+		o.add(PropertyIdNumberOfStates, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(2))})
+		if !isOutput(id.ObjectType) {
+			o.add(PropertyIdAlarmValues, &dbProperty{tag: TagUnsignedInteger, list: true, writable: true, optional: true, value: []APDUPayload{}})
+		}
+	}
+	if o.reportsEvents() {
+		o.add(PropertyIdTimeDelay, &dbProperty{tag: TagUnsignedInteger, writable: true, optional: true, value: one(EncUnsignedInteger32(0))})
+		o.add(PropertyIdNotificationClass, &dbProperty{tag: TagUnsignedInteger, writable: true, optional: true, value: one(EncUnsignedInteger32(0))})
+		o.add(PropertyIdEventEnable, &dbProperty{tag: TagBitString, writable: true, optional: true, value: one(EncBitString([]bool{true, true, true}))})
+		o.add(PropertyIdAckedTransitions, &dbProperty{tag: TagBitString, optional: true, value: one(EncBitString([]bool{true, true, true}))})
+		o.add(PropertyIdNotifyType, &dbProperty{tag: TagEnumerated, writable: true, optional: true, value: one(EncEnumerated(0))})
+		o.add(PropertyIdEventTimeStamps, &dbProperty{array: true, element: 4, optional: true, get: o.eventTimeStamps})
+	}
+
+	if isOutput(id.ObjectType) {
+		relinquished := make([]APDUPayload, 16)
+		for i := range relinquished {
+			relinquished[i] = EncNull()
+		}
+		o.add(PropertyIdPriorityArray, &dbProperty{tag: pv.tag, array: true, nullable: true, value: relinquished})
+		o.add(PropertyIdRelinquishDefault, &dbProperty{tag: pv.tag, writable: true, value: one(pv.value[0])})
+	}
+	if id.ObjectType == ObjectTypeBinaryOutput {
+		o.add(PropertyIdMinimumOffTime, &dbProperty{tag: TagUnsignedInteger, writable: true, optional: true, value: one(EncUnsignedInteger32(0))})
+		o.add(PropertyIdMinimumOnTime, &dbProperty{tag: TagUnsignedInteger, writable: true, optional: true, value: one(EncUnsignedInteger32(0))})
+	}
+	return o
+}
+
+// newNotificationClass creates a Notification Class object.
+func newNotificationClass(id ObjectIdentifier, name string) *dbObject {
+	o := newDBObject(id, name)
+	o.add(PropertyIdNotificationClass, &dbProperty{tag: TagUnsignedInteger, value: one(EncUnsignedInteger32(id.InstanceNumber))})
+	o.add(PropertyIdPriority, &dbProperty{tag: TagUnsignedInteger, array: true, writable: true, value: []APDUPayload{
+		EncUnsignedInteger32(lowestEventPriority),
+		EncUnsignedInteger32(lowestEventPriority),
+		EncUnsignedInteger32(lowestEventPriority),
+	}})
+	o.add(PropertyIdAckRequired, &dbProperty{tag: TagBitString, writable: true, value: one(EncBitString([]bool{false, false, false}))})
+	o.add(PropertyIdRecipientList, &dbProperty{list: true, writable: true, value: []APDUPayload{}})
+	return o
+}
+
+func (o *dbObject) add(propertyId uint8, p *dbProperty) {
+	o.properties[propertyId] = p
+	o.order = append(o.order, propertyId)
+}
+
+func (o *dbObject) outOfService() bool {
+	p, ok := o.properties[PropertyIdOutOfService]
+	if !ok {
+		return false
+	}
+	oos, _ := DecBoolean(p.value[0])
+	return oos
+}
+
+// statusFlags is the Status_Flags of o: in alarm when its Event_State isn't
+// normal, and out of service.
+func (o *dbObject) statusFlags() []APDUPayload {
+	inAlarm := false
+	if p, ok := o.properties[PropertyIdEventState]; ok {
+		state, _ := DecEnumerated(p.read()[0])
+		inAlarm = state != 0
+	}
+	return one(EncBitString([]bool{inAlarm, false, false, o.outOfService()}))
+}
+
+// checkRange checks that v, of the right datatype, is valid for the property
+// propertyId of o.
+func (o *dbObject) checkRange(propertyId uint8, v APDUPayload) error {
+	if isNull, _ := DecNull(v); isNull {
+		return nil
+	}
+
+	switch propertyId {
+	case PropertyIdPresentValue, PropertyIdRelinquishDefault, PropertyIdPriorityArray:
+		switch {
+		case isBinary(o.id.ObjectType):
+			if state, _ := DecEnumerated(v); state > 1 {
+				return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+			}
+		case isMultiState(o.id.ObjectType):
+			state, _ := DecUnisgnedInteger(v)
+			states, _ := DecUnisgnedInteger(o.properties[PropertyIdNumberOfStates].value[0])
+			if state < 1 || state > states {
+				return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+			}
+		}
+	case PropertyIdAlarmValues:
+		state, err := DecUnisgnedInteger(v)
+		if err != nil {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeInvalidDataType)
+		}
+		states, _ := DecUnisgnedInteger(o.properties[PropertyIdNumberOfStates].value[0])
+		if state < 1 || state > states {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdPolarity, PropertyIdAlarmValue:
+		if polarity, _ := DecEnumerated(v); polarity > 1 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdCOVIncrement:
+		if increment, _ := DecReal(v); increment < 0 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdNumberOfStates:
+		if states, _ := DecUnisgnedInteger(v); states < 1 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdDeadband:
+		if deadband, _ := DecReal(v); deadband < 0 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdLimitEnable:
+		if bits, _ := DecBitString(v); len(bits) != 2 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdEventEnable, PropertyIdAckRequired:
+		if bits, _ := DecBitString(v); len(bits) != 3 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdNotifyType:
+		if notifyType, _ := DecEnumerated(v); notifyType > 1 {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	case PropertyIdPriority:
+		if priority, _ := DecUnisgnedInteger(v); priority > lowestEventPriority {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+		}
+	}
+	return nil
+}
+
+func (p *dbProperty) read() []APDUPayload {
+	if p.get != nil {
+		return p.get()
+	}
+	return append([]APDUPayload{}, p.value...)
+}
+
+// elementSize returns the number of objects an element of the array p is
+// made of.
+func (p *dbProperty) elementSize() int {
+	if p.element == 0 {
+		return 1
+	}
+	return p.element
+}
+
+// check checks that value holds count application tagged objects of the
+// datatype of p, any number of them if count is negative.
+func (p *dbProperty) check(value []APDUPayload, count int) error {
+	if count >= 0 && len(value) != count {
+		return NewBACnetError(ErrorClassProperty, ErrorCodeInvalidDataType)
+	}
+	if p.list {
+		return nil
+	}
+	for _, v := range value {
+		obj, ok := v.(*Object)
+		if !ok || obj.TagClass || (obj.TagNumber != p.tag && !(p.nullable && obj.TagNumber == TagNull)) {
+			return NewBACnetError(ErrorClassProperty, ErrorCodeInvalidDataType)
+		}
+	}
+	return nil
+}
+
+func one(obj APDUPayload) []APDUPayload {
+	return []APDUPayload{obj}
+}
+
+// objectTypesSupported is the Protocol_Object_Types_Supported of the device.
+func objectTypesSupported() []bool {
+	bits := make([]bool, ObjectTypeMultiStateValue+1)
+	for _, objectType := range databaseObjectTypes {
+		bits[objectType] = true
+	}
+	return bits
+}
+
+func supportedObjectType(objectType uint16) bool {
+	for _, t := range databaseObjectTypes {
+		if t == objectType {
+			return true
+		}
+	}
+	return false
+}
+
+func isAnalog(objectType uint16) bool {
+	return objectType == ObjectTypeAnalogInput || objectType == ObjectTypeAnalogOutput || objectType == ObjectTypeAnalogValue
+}
+
+func isBinary(objectType uint16) bool {
+	return objectType == ObjectTypeBinaryInput || objectType == ObjectTypeBinaryOutput || objectType == ObjectTypeBinaryValue
+}
+
+func isMultiState(objectType uint16) bool {
+	return objectType == ObjectTypeMultiStateInput || objectType == ObjectTypeMultiStateOutput || objectType == ObjectTypeMultiStateValue
+}
+
+func isInput(objectType uint16) bool {
+	return objectType == ObjectTypeAnalogInput || objectType == ObjectTypeBinaryInput || objectType == ObjectTypeMultiStateInput
+}
+
+func isOutput(objectType uint16) bool {
+	return objectType == ObjectTypeAnalogOutput || objectType == ObjectTypeBinaryOutput || objectType == ObjectTypeMultiStateOutput
+}
@@ -0,0 +1,135 @@
+package objects
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pkg/errors"
+)
+
+// unspecified marks a Date or Time field matching any value.
+const unspecified uint8 = 0xFF
+
+// DecDate decodes a Date into midnight of that day in the local time zone.
+// Dates with unspecified year, month or day can't be represented and are
+// reported as an error.
+func DecDate(rawPayload APDUPayload) (time.Time, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return time.Time{}, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode Date - %v", rawPayload),
+		)
+	}
+
+	if (!rawObject.TagClass && rawObject.TagNumber != TagDate) || rawObject.Length != 4 {
+		return time.Time{}, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode Date - wrong tag number - %v", rawObject.TagNumber),
+		)
+	}
+
+	year, month, day := rawObject.Data[0], rawObject.Data[1], rawObject.Data[2]
+	if year == unspecified || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("failed to decode Date - unspecified date %v", rawObject.Data),
+		)
+	}
+
+	return time.Date(1900+int(year), time.Month(month), int(day), 0, 0, 0, 0, time.Local), nil
+}
+
+// EncDate encodes the day of t as a Date.
+func EncDate(t time.Time) *Object {
+	newObj := Object{}
+
+	weekday := uint8(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	data := []byte{uint8(t.Year() - 1900), uint8(t.Month()), uint8(t.Day()), weekday}
+
+	newObj.TagNumber = TagDate
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+// DecTime decodes a Time into the duration elapsed since midnight. Unspecified
+// fields are taken as zero.
+func DecTime(rawPayload APDUPayload) (time.Duration, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return 0, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode Time - %v", rawPayload),
+		)
+	}
+
+	if (!rawObject.TagClass && rawObject.TagNumber != TagTime) || rawObject.Length != 4 {
+		return 0, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode Time - wrong tag number - %v", rawObject.TagNumber),
+		)
+	}
+
+	units := []time.Duration{time.Hour, time.Minute, time.Second, 10 * time.Millisecond}
+	var d time.Duration
+	for i, unit := range units {
+		if rawObject.Data[i] != unspecified {
+			d += time.Duration(rawObject.Data[i]) * unit
+		}
+	}
+
+	return d, nil
+}
+
+// EncTime encodes the duration d elapsed since midnight as a Time, truncated
+// to the hundredth of a second.
+func EncTime(d time.Duration) *Object {
+	newObj := Object{}
+
+	data := []byte{
+		uint8(d / time.Hour),
+		uint8(d % time.Hour / time.Minute),
+		uint8(d % time.Minute / time.Second),
+		uint8(d % time.Second / (10 * time.Millisecond)),
+	}
+
+	newObj.TagNumber = TagTime
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+// DecDateTime decodes the Date and Time making up a BACnetDateTime.
+func DecDateTime(date, tod APDUPayload) (time.Time, error) {
+	day, err := DecDate(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	d, err := DecTime(tod)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(d), nil
+}
+
+// encUnspecified encodes a Date or Time, tag being TagDate or TagTime, whose
+// fields are all unspecified.
+func encUnspecified(tag uint8) *Object {
+	data := []byte{unspecified, unspecified, unspecified, unspecified}
+	return &Object{TagNumber: tag, Data: data, Length: uint32(len(data))}
+}
+
+// EncDateTime encodes t as the Date and Time making up a BACnetDateTime.
+func EncDateTime(t time.Time) []APDUPayload {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return []APDUPayload{EncDate(t), EncTime(t.Sub(midnight))}
+}
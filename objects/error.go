@@ -0,0 +1,77 @@
+package objects
+
+import "fmt"
+
+// BACnetError is an error carrying the class and code to report back
+// to a peer in an Error PDU.
+type BACnetError struct {
+	Class uint8
+	Code  uint8
+}
+
+// NewBACnetError creates a BACnetError.
+func NewBACnetError(class, code uint8) *BACnetError {
+	return &BACnetError{
+		Class: class,
+		Code:  code,
+	}
+}
+
+func (e *BACnetError) Error() string {
+	return fmt.Sprintf("BACnet error class %d code %d", e.Class, e.Code)
+}
+
+// ElementError is a BACnetError raised by one element of a list, reported
+// in CreateObject and ChangeList errors. Elements are numbered from 1.
+type ElementError struct {
+	*BACnetError
+	FirstFailedElement uint32
+}
+
+// NewElementError creates an ElementError.
+func NewElementError(class, code uint8, firstFailedElement uint32) *ElementError {
+	return &ElementError{
+		BACnetError:        NewBACnetError(class, code),
+		FirstFailedElement: firstFailedElement,
+	}
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("%v on element %d", e.BACnetError, e.FirstFailedElement)
+}
+
+// RejectError is an error carrying the reason a request was, or is to be,
+// rejected with in a Reject PDU.
+type RejectError struct {
+	Reason uint8
+}
+
+// NewRejectError creates a RejectError.
+func NewRejectError(reason uint8) *RejectError {
+	return &RejectError{
+		Reason: reason,
+	}
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("BACnet reject reason %d", e.Reason)
+}
+
+// AbortError is an error carrying the reason a transaction was aborted with
+// in an Abort PDU. Server tells whether the server side sent the Abort.
+type AbortError struct {
+	Reason uint8
+	Server bool
+}
+
+// NewAbortError creates an AbortError.
+func NewAbortError(reason uint8, server bool) *AbortError {
+	return &AbortError{
+		Reason: reason,
+		Server: server,
+	}
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("BACnet abort reason %d", e.Reason)
+}
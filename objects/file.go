@@ -0,0 +1,191 @@
+package objects
+
+import (
+	"io"
+	"math"
+	"sync"
+)
+
+// File is a File object whose content is held by an io.ReaderAt. The file is
+// read-only unless Writer is set. When RecordSize is not zero the file is
+// made of records of that many octets and must be accessed by records,
+// otherwise it's accessed as a stream of octets.
+type File struct {
+	InstanceNumber uint32
+	FileType       string
+	Size           int64
+	RecordSize     int
+	Reader         io.ReaderAt
+	Writer         io.WriterAt
+
+	mu sync.Mutex
+}
+
+// NewFile creates a File of size octets accessed as a stream.
+func NewFile(instN uint32, fileType string, size int64, r io.ReaderAt, w io.WriterAt) *File {
+	return &File{
+		InstanceNumber: instN,
+		FileType:       fileType,
+		Size:           size,
+		Reader:         r,
+		Writer:         w,
+	}
+}
+
+// ReadOnly tells whether the file rejects writes.
+func (f *File) ReadOnly() bool {
+	return f.Writer == nil
+}
+
+// RecordCount returns the number of records held by a record based file.
+func (f *File) RecordCount() uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.RecordSize == 0 {
+		return 0
+	}
+	return uint32(f.Size / int64(f.RecordSize))
+}
+
+// ReadStream reads up to count octets from position start. It also tells
+// whether the returned data reaches the end of the file.
+func (f *File) ReadStream(start int32, count uint32) ([]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.RecordSize != 0 {
+		return nil, false, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileAccessMethod)
+	}
+	if start < 0 || int64(start) > f.Size {
+		return nil, false, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileStartPosition)
+	}
+
+	n := int64(count)
+	if left := f.Size - int64(start); n > left {
+		n = left
+	}
+
+	data := make([]byte, n)
+	if _, err := f.Reader.ReadAt(data, int64(start)); err != nil && err != io.EOF {
+		return nil, false, NewBACnetError(ErrorClassService, ErrorCodeFileAccessDenied)
+	}
+
+	return data, int64(start)+n >= f.Size, nil
+}
+
+// WriteStream writes data from position start, or at the end of the file if
+// start is -1. It returns the position the data was written at. The file can't
+// grow beyond the positions an int32 holds.
+func (f *File) WriteStream(start int32, data []byte) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Writer == nil {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeFileAccessDenied)
+	}
+	if f.RecordSize != 0 {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileAccessMethod)
+	}
+	if start == -1 {
+		if f.Size > math.MaxInt32 {
+			return 0, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileStartPosition)
+		}
+		start = int32(f.Size)
+	}
+	if start < 0 || int64(start) > f.Size {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileStartPosition)
+	}
+	if int64(start)+int64(len(data)) > math.MaxInt32 {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeNoSpaceToWriteProperty)
+	}
+
+	if _, err := f.Writer.WriteAt(data, int64(start)); err != nil {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeFileAccessDenied)
+	}
+	if end := int64(start) + int64(len(data)); end > f.Size {
+		f.Size = end
+	}
+
+	return start, nil
+}
+
+// ReadRecords reads up to count records from record start. It also tells
+// whether the returned records reach the end of the file.
+func (f *File) ReadRecords(start int32, count uint32) ([][]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.RecordSize == 0 {
+		return nil, false, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileAccessMethod)
+	}
+	total := f.Size / int64(f.RecordSize)
+	if start < 0 || int64(start) > total {
+		return nil, false, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileStartPosition)
+	}
+
+	n := int64(count)
+	if left := total - int64(start); n > left {
+		n = left
+	}
+
+	records := make([][]byte, 0, n)
+	for i := int64(0); i < n; i++ {
+		record := make([]byte, f.RecordSize)
+		if _, err := f.Reader.ReadAt(record, (int64(start)+i)*int64(f.RecordSize)); err != nil && err != io.EOF {
+			return nil, false, NewBACnetError(ErrorClassService, ErrorCodeFileAccessDenied)
+		}
+		records = append(records, record)
+	}
+
+	return records, int64(start)+n >= total, nil
+}
+
+// WriteRecords writes records from record start, or after the last record if
+// start is -1. Every record must be RecordSize octets long, or none is
+// written. It returns the record the data was written at. The file can't hold
+// more records than an int32 numbers.
+func (f *File) WriteRecords(start int32, records [][]byte) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Writer == nil {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeFileAccessDenied)
+	}
+	if f.RecordSize == 0 {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileAccessMethod)
+	}
+	total := f.Size / int64(f.RecordSize)
+	if start == -1 {
+		if total > math.MaxInt32 {
+			return 0, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileStartPosition)
+		}
+		start = int32(total)
+	}
+	if start < 0 || int64(start) > total {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeInvalidFileStartPosition)
+	}
+	if int64(start)+int64(len(records)) > math.MaxInt32 {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeNoSpaceToWriteProperty)
+	}
+
+	// The records are checked before any is written, and written at once, so
+	// that a bad record leaves the file untouched.
+	data := make([]byte, 0, len(records)*f.RecordSize)
+	for _, record := range records {
+		if len(record) != f.RecordSize {
+			return 0, NewBACnetError(ErrorClassService, ErrorCodeValueOutOfRange)
+		}
+		data = append(data, record...)
+	}
+
+	off := int64(start) * int64(f.RecordSize)
+	if _, err := f.Writer.WriteAt(data, off); err != nil {
+		return 0, NewBACnetError(ErrorClassService, ErrorCodeFileAccessDenied)
+	}
+	if end := off + int64(len(data)); end > f.Size {
+		f.Size = end
+	}
+
+	return start, nil
+}
@@ -0,0 +1,317 @@
+package objects
+
+import (
+	"time"
+)
+
+// Event states of the Event_State of objects. The services package holds
+// their exported counterparts.
+const (
+	eventStateNormal uint32 = iota
+	eventStateFault
+	eventStateOffnormal
+	eventStateHighLimit
+	eventStateLowLimit
+)
+
+// Event types of the event algorithms run by objects. The services package
+// holds their exported counterparts.
+const (
+	eventTypeChangeOfState uint8 = 1
+	eventTypeOutOfRange    uint8 = 5
+)
+
+// timeStampDateTime is the context tag of the date and time choice of a
+// BACnetTimeStamp.
+const timeStampDateTime uint8 = 2
+
+// TransitionOf returns the transition into the event state toState.
+func TransitionOf(toState uint8) int {
+	switch uint32(toState) {
+	case eventStateNormal:
+		return TransitionToNormal
+	case eventStateFault:
+		return TransitionToFault
+	default:
+		return TransitionToOffnormal
+	}
+}
+
+// Acknowledge acknowledges the transition of an object into eventState made
+// at timeStamp, setting its bit of Acked_Transitions. The object must still be
+// in eventState, the transition must be left to be acknowledged and timeStamp
+// must be the one in its Event_Time_Stamps.
+func (db *Database) Acknowledge(objectType uint16, instN uint32, eventState uint8, timeStamp time.Time) error {
+	if uint32(eventState) > eventStateLowLimit {
+		return NewBACnetError(ErrorClassService, ErrorCodeInvalidEventState)
+	}
+
+	return db.update(func() error {
+		o, p, err := db.property(objectType, instN, PropertyIdAckedTransitions)
+		if err != nil {
+			return err
+		}
+		transition := TransitionOf(eventState)
+		acked, _ := DecBitString(p.value[0])
+		if o.eventState() != uint32(eventState) || acked[transition] {
+			return NewBACnetError(ErrorClassService, ErrorCodeInvalidEventState)
+		}
+		if !o.eventTimes[transition].Equal(timeStamp) {
+			return NewBACnetError(ErrorClassService, ErrorCodeInvalidTimeStamp)
+		}
+		db.setAcked(o, p, transition, true)
+		return nil
+	})
+}
+
+// EventTimeStamp returns the time of the last transition of an object, zero
+// if it didn't happen yet.
+func (db *Database) EventTimeStamp(objectType uint16, instN uint32, transition int) (time.Time, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	o, err := db.object(objectType, instN)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !o.reportsEvents() || transition < 0 || transition >= len(o.eventTimes) {
+		return time.Time{}, NewBACnetError(ErrorClassObject, ErrorCodeOptionalFunctionalityNotSupported)
+	}
+	return o.eventTimes[transition], nil
+}
+
+// EventType returns the event type of the event algorithm run by an object.
+func (db *Database) EventType(objectType uint16, instN uint32) (uint8, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	o, err := db.object(objectType, instN)
+	if err != nil {
+		return 0, err
+	}
+	eventType, ok := o.eventType()
+	if !ok {
+		return 0, NewBACnetError(ErrorClassObject, ErrorCodeOptionalFunctionalityNotSupported)
+	}
+	return eventType, nil
+}
+
+// eventType returns the event type of the event algorithm o runs, if any:
+// OUT_OF_RANGE for analog objects and CHANGE_OF_STATE for the binary and
+// multi-state ones with alarm values.
+func (o *dbObject) eventType() (uint8, bool) {
+	if _, ok := o.properties[PropertyIdLimitEnable]; ok {
+		return eventTypeOutOfRange, true
+	}
+	if _, ok := o.properties[PropertyIdAlarmValue]; ok {
+		return eventTypeChangeOfState, true
+	}
+	if _, ok := o.properties[PropertyIdAlarmValues]; ok {
+		return eventTypeChangeOfState, true
+	}
+	return 0, false
+}
+
+// reportsEvents tells whether o runs an event algorithm.
+func (o *dbObject) reportsEvents() bool {
+	_, ok := o.eventType()
+	return ok
+}
+
+// eventTrigger tells whether a change of the property propertyId may change
+// the event state of objects reporting events.
+func eventTrigger(propertyId uint8) bool {
+	switch propertyId {
+	case PropertyIdPresentValue, PropertyIdHighLimit, PropertyIdLowLimit, PropertyIdDeadband, PropertyIdLimitEnable,
+		PropertyIdAlarmValue, PropertyIdAlarmValues:
+		return true
+	}
+	return false
+}
+
+// eventTimeStamps is the Event_Time_Stamps of o: the date and time of its
+// last transitions to offnormal, fault and normal, unspecified for those which
+// didn't happen yet.
+func (o *dbObject) eventTimeStamps() []APDUPayload {
+	objs := make([]APDUPayload, 0, 4*len(o.eventTimes))
+	for _, t := range o.eventTimes {
+		objs = append(objs, EncOpeningTag(timeStampDateTime))
+		if t.IsZero() {
+			objs = append(objs, encUnspecified(TagDate), encUnspecified(TagTime))
+		} else {
+			objs = append(objs, EncDateTime(t)...)
+		}
+		objs = append(objs, EncClosingTag(timeStampDateTime))
+	}
+	return objs
+}
+
+func (o *dbObject) eventState() uint32 {
+	state, _ := DecEnumerated(o.properties[PropertyIdEventState].value[0])
+	return state
+}
+
+// outOfRange returns the event state o should be in, now in state, according
+// to the OUT_OF_RANGE algorithm: a Present_Value above High_Limit or below
+// Low_Limit is offnormal, and back to normal once it's Deadband inside the
+// limit it exceeded. Limit_Enable turns either limit off.
+func (o *dbObject) outOfRange(state uint32) uint32 {
+	pv, _ := DecReal(o.properties[PropertyIdPresentValue].value[0])
+	high, _ := DecReal(o.properties[PropertyIdHighLimit].value[0])
+	low, _ := DecReal(o.properties[PropertyIdLowLimit].value[0])
+	deadband, _ := DecReal(o.properties[PropertyIdDeadband].value[0])
+	enable, _ := DecBitString(o.properties[PropertyIdLimitEnable].value[0])
+	lowEnable, highEnable := enable[0], enable[1]
+
+	switch state {
+	case eventStateNormal:
+		switch {
+		case highEnable && pv > high:
+			return eventStateHighLimit
+		case lowEnable && pv < low:
+			return eventStateLowLimit
+		}
+	case eventStateHighLimit:
+		switch {
+		case lowEnable && pv < low:
+			return eventStateLowLimit
+		case !highEnable || pv < high-deadband:
+			return eventStateNormal
+		}
+	case eventStateLowLimit:
+		switch {
+		case highEnable && pv > high:
+			return eventStateHighLimit
+		case !lowEnable || pv > low+deadband:
+			return eventStateNormal
+		}
+	}
+	return state
+}
+
+// changeOfState returns the event state o should be in, now in state,
+// according to the CHANGE_OF_STATE algorithm: a Present_Value equal to the
+// Alarm_Value, or to one of the Alarm_Values, is offnormal.
+func (o *dbObject) changeOfState(state uint32) uint32 {
+	pv := o.properties[PropertyIdPresentValue].value[0]
+
+	var alarm bool
+	if p, ok := o.properties[PropertyIdAlarmValue]; ok {
+		value, _ := DecEnumerated(p.value[0])
+		current, _ := DecEnumerated(pv)
+		alarm = current == value
+	} else {
+		current, _ := DecUnisgnedInteger(pv)
+		for _, v := range o.properties[PropertyIdAlarmValues].value {
+			if value, err := DecUnisgnedInteger(v); err == nil && current == value {
+				alarm = true
+			}
+		}
+	}
+
+	switch {
+	case state == eventStateNormal && alarm:
+		return eventStateOffnormal
+	case state == eventStateOffnormal && !alarm:
+		return eventStateNormal
+	}
+	return state
+}
+
+// target returns the event state o should be in according to its event
+// algorithm.
+func (o *dbObject) target() uint32 {
+	if eventType, _ := o.eventType(); eventType == eventTypeChangeOfState {
+		return o.changeOfState(o.eventState())
+	}
+	return o.outOfRange(o.eventState())
+}
+
+// evaluate runs the event algorithm of o. A transition happens once its
+// condition held for Time_Delay seconds, and is dropped when the condition
+// goes away meanwhile.
+func (db *Database) evaluate(o *dbObject) {
+	if !o.reportsEvents() {
+		return
+	}
+
+	target := o.target()
+	if target == o.eventState() {
+		o.stopEventTimer()
+		return
+	}
+	if o.eventTimer != nil && o.eventTarget == target {
+		return
+	}
+	o.stopEventTimer()
+
+	seconds, _ := DecUnisgnedInteger(o.properties[PropertyIdTimeDelay].value[0])
+	if seconds == 0 {
+		db.transition(o, target)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(seconds)*time.Second, func() {
+		db.update(func() error {
+			if o.eventTimer != timer {
+				return nil
+			}
+			o.eventTimer = nil
+			if o.target() == target {
+				db.transition(o, target)
+			}
+			return nil
+		})
+	})
+	o.eventTimer, o.eventTarget = timer, target
+}
+
+func (o *dbObject) stopEventTimer() {
+	if o.eventTimer != nil {
+		o.eventTimer.Stop()
+		o.eventTimer = nil
+	}
+}
+
+// transition moves o into the event state to, recording the time of the
+// transition to the hundredth of a second a Time holds. The transition is
+// left to be acknowledged when it's enabled by the Event_Enable of o and the
+// Notification Class of o requires it.
+func (db *Database) transition(o *dbObject, to uint32) {
+	transition := TransitionOf(uint8(to))
+	o.eventTimes[transition] = time.Now().Truncate(10 * time.Millisecond)
+
+	p := o.properties[PropertyIdEventState]
+	old := p.read()
+	p.value = one(EncEnumerated32(to))
+	db.changed(o, PropertyIdEventState, p, old)
+
+	db.setAcked(o, o.properties[PropertyIdAckedTransitions], transition, !db.ackRequired(o, transition))
+}
+
+// setAcked sets the bit of transition in the Acked_Transitions of o, p.
+func (db *Database) setAcked(o *dbObject, p *dbProperty, transition int, acked bool) {
+	old := p.read()
+	bits, _ := DecBitString(p.value[0])
+	bits = append([]bool{}, bits...)
+	bits[transition] = acked
+	p.value = one(EncBitString(bits))
+	db.changed(o, PropertyIdAckedTransitions, p, old)
+}
+
+// ackRequired tells whether transition of o is to be acknowledged: it must be
+// enabled by the Event_Enable of o and required by its Notification Class,
+// which doesn't when it's missing.
+func (db *Database) ackRequired(o *dbObject, transition int) bool {
+	if enable, _ := DecBitString(o.properties[PropertyIdEventEnable].value[0]); !enable[transition] {
+		return false
+	}
+	class, _ := DecUnisgnedInteger(o.properties[PropertyIdNotificationClass].value[0])
+	nc, ok := db.objects[ObjectIdentifier{ObjectType: ObjectTypeNotificationClass, InstanceNumber: class}]
+	if !ok {
+		return false
+	}
+	bits, _ := DecBitString(nc.properties[PropertyIdAckRequired].value[0])
+	return bits[transition]
+}
@@ -0,0 +1,280 @@
+package objects_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// setProperty sets a property of an object of db on behalf of the
+// application.
+func setProperty(t *testing.T, db *objects.Database, objectType uint16, instN uint32, propertyId uint8, value ...objects.APDUPayload) {
+	t.Helper()
+	if err := db.SetProperty(objectType, instN, propertyId, value); err != nil {
+		t.Fatalf("failed to set property %d: %v", propertyId, err)
+	}
+}
+
+func readEventState(t *testing.T, db *objects.Database, objectType uint16, instN uint32) uint8 {
+	t.Helper()
+	value, err := db.ReadProperty(objectType, instN, objects.PropertyIdEventState, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := objects.DecEnumerated(value[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uint8(state)
+}
+
+func readAckedTransitions(t *testing.T, db *objects.Database, objectType uint16, instN uint32) []bool {
+	t.Helper()
+	value, err := db.ReadProperty(objectType, instN, objects.PropertyIdAckedTransitions, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bits, err := objects.DecBitString(value[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bits
+}
+
+// eventTimeStamp returns the time of the last transition of Analog Input 0
+// into state.
+func eventTimeStamp(t *testing.T, db *objects.Database, state uint8) time.Time {
+	t.Helper()
+	stamp, err := db.EventTimeStamp(objects.ObjectTypeAnalogInput, 0, objects.TransitionOf(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stamp
+}
+
+// newEventDatabase creates a Database holding Analog Input 0, limited to 10
+// to 50 with a Deadband of 5, and Notification Class 1 it reports to.
+func newEventDatabase(t *testing.T) *objects.Database {
+	t.Helper()
+	db := objects.NewDatabase(1, "test", 0)
+	if err := db.Add(objects.ObjectTypeNotificationClass, 1, "NC-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(objects.ObjectTypeAnalogInput, 0, "AI-0"); err != nil {
+		t.Fatal(err)
+	}
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdNotificationClass, objects.EncUnsignedInteger32(1))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(30))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdHighLimit, objects.EncReal(50))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdLowLimit, objects.EncReal(10))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdDeadband, objects.EncReal(5))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdLimitEnable, objects.EncBitString([]bool{true, true}))
+	return db
+}
+
+func TestOutOfRange(t *testing.T) {
+	db := newEventDatabase(t)
+
+	steps := []struct {
+		propertyId uint8
+		value      objects.APDUPayload
+		want       uint8
+	}{
+		{objects.PropertyIdPresentValue, objects.EncReal(50), services.EventStateNormal},
+		{objects.PropertyIdPresentValue, objects.EncReal(51), services.EventStateHighLimit},
+		// Back to normal once Deadband below High_Limit only.
+		{objects.PropertyIdPresentValue, objects.EncReal(46), services.EventStateHighLimit},
+		{objects.PropertyIdPresentValue, objects.EncReal(44), services.EventStateNormal},
+		{objects.PropertyIdPresentValue, objects.EncReal(9), services.EventStateLowLimit},
+		{objects.PropertyIdPresentValue, objects.EncReal(14), services.EventStateLowLimit},
+		{objects.PropertyIdPresentValue, objects.EncReal(16), services.EventStateNormal},
+		{objects.PropertyIdPresentValue, objects.EncReal(60), services.EventStateHighLimit},
+		{objects.PropertyIdPresentValue, objects.EncReal(5), services.EventStateLowLimit},
+		// Disabling the low limit clears the low limit state.
+		{objects.PropertyIdLimitEnable, objects.EncBitString([]bool{false, true}), services.EventStateNormal},
+		{objects.PropertyIdPresentValue, objects.EncReal(0), services.EventStateNormal},
+		// Moving the limit triggers the algorithm too.
+		{objects.PropertyIdHighLimit, objects.EncReal(-1), services.EventStateHighLimit},
+	}
+	for i, s := range steps {
+		setProperty(t, db, objects.ObjectTypeAnalogInput, 0, s.propertyId, s.value)
+		if got := readEventState(t, db, objects.ObjectTypeAnalogInput, 0); got != s.want {
+			t.Errorf("step %d: event state %d, want %d", i, got, s.want)
+		}
+	}
+}
+
+func TestChangeOfState(t *testing.T) {
+	db := objects.NewDatabase(1, "test", 0)
+	if err := db.Add(objects.ObjectTypeBinaryInput, 0, "BI-0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(objects.ObjectTypeMultiStateValue, 0, "MSV-0"); err != nil {
+		t.Fatal(err)
+	}
+	setProperty(t, db, objects.ObjectTypeMultiStateValue, 0, objects.PropertyIdNumberOfStates, objects.EncUnsignedInteger32(4))
+	setProperty(t, db, objects.ObjectTypeMultiStateValue, 0, objects.PropertyIdAlarmValues,
+		objects.EncUnsignedInteger32(2), objects.EncUnsignedInteger32(3))
+
+	steps := []struct {
+		objectType uint16
+		propertyId uint8
+		value      objects.APDUPayload
+		want       uint8
+	}{
+		{objects.ObjectTypeBinaryInput, objects.PropertyIdPresentValue, objects.EncEnumerated(1), services.EventStateOffnormal},
+		{objects.ObjectTypeBinaryInput, objects.PropertyIdPresentValue, objects.EncEnumerated(0), services.EventStateNormal},
+		{objects.ObjectTypeBinaryInput, objects.PropertyIdAlarmValue, objects.EncEnumerated(0), services.EventStateOffnormal},
+		{objects.ObjectTypeMultiStateValue, objects.PropertyIdPresentValue, objects.EncUnsignedInteger32(2), services.EventStateOffnormal},
+		{objects.ObjectTypeMultiStateValue, objects.PropertyIdPresentValue, objects.EncUnsignedInteger32(3), services.EventStateOffnormal},
+		{objects.ObjectTypeMultiStateValue, objects.PropertyIdPresentValue, objects.EncUnsignedInteger32(4), services.EventStateNormal},
+	}
+	for i, s := range steps {
+		setProperty(t, db, s.objectType, 0, s.propertyId, s.value)
+		if got := readEventState(t, db, s.objectType, 0); got != s.want {
+			t.Errorf("step %d: event state %d, want %d", i, got, s.want)
+		}
+	}
+
+	if err := db.SetProperty(objects.ObjectTypeMultiStateValue, 0, objects.PropertyIdAlarmValues,
+		[]objects.APDUPayload{objects.EncUnsignedInteger32(5)}); err == nil {
+		t.Error("alarm value beyond Number_Of_States accepted")
+	}
+}
+
+func TestEventTimeDelay(t *testing.T) {
+	db := newEventDatabase(t)
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdTimeDelay, objects.EncUnsignedInteger32(1))
+
+	// The transition waits for Time_Delay, and is dropped when the
+	// condition clears meanwhile.
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(60))
+	if got := readEventState(t, db, objects.ObjectTypeAnalogInput, 0); got != services.EventStateNormal {
+		t.Fatalf("event state %d before Time_Delay", got)
+	}
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(30))
+	time.Sleep(1200 * time.Millisecond)
+	if got := readEventState(t, db, objects.ObjectTypeAnalogInput, 0); got != services.EventStateNormal {
+		t.Fatalf("event state %d after the condition cleared", got)
+	}
+
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(60))
+	waitFor(t, 3*time.Second, func() bool {
+		return readEventState(t, db, objects.ObjectTypeAnalogInput, 0) == services.EventStateHighLimit
+	})
+}
+
+func TestAckedTransitions(t *testing.T) {
+	db := newEventDatabase(t)
+	setProperty(t, db, objects.ObjectTypeNotificationClass, 1, objects.PropertyIdAckRequired,
+		objects.EncBitString([]bool{true, false, true}))
+
+	state := func(s uint8) *uint8 { return &s }
+	steps := []struct {
+		pv float32
+		// ack is the event state acknowledged after the write, if any.
+		ack  *uint8
+		want []bool
+	}{
+		{60, nil, []bool{false, true, true}},
+		{60, state(services.EventStateHighLimit), []bool{true, true, true}},
+		{30, nil, []bool{true, true, false}},
+		// An unacknowledged transition stays so across later ones.
+		{0, nil, []bool{false, true, false}},
+		{30, state(services.EventStateNormal), []bool{false, true, true}},
+	}
+	for i, s := range steps {
+		setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(s.pv))
+		if s.ack != nil {
+			if err := db.Acknowledge(objects.ObjectTypeAnalogInput, 0, *s.ack, eventTimeStamp(t, db, *s.ack)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if diff := cmp.Diff(s.want, readAckedTransitions(t, db, objects.ObjectTypeAnalogInput, 0)); diff != "" {
+			t.Errorf("step %d: Acked_Transitions differs: (-want +got)\n%s", i, diff)
+		}
+	}
+
+	// A transition disabled by Event_Enable needn't be acknowledged.
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdEventEnable, objects.EncBitString([]bool{false, true, true}))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(60))
+	if diff := cmp.Diff([]bool{true, true, true}, readAckedTransitions(t, db, objects.ObjectTypeAnalogInput, 0)); diff != "" {
+		t.Errorf("Acked_Transitions differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestAcknowledgeErrors(t *testing.T) {
+	db := newEventDatabase(t)
+	setProperty(t, db, objects.ObjectTypeNotificationClass, 1, objects.PropertyIdAckRequired,
+		objects.EncBitString([]bool{true, true, true}))
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(60))
+	stamp := eventTimeStamp(t, db, services.EventStateHighLimit)
+
+	cases := []struct {
+		name      string
+		state     uint8
+		timeStamp time.Time
+		want      uint8
+	}{
+		{"unknown state", services.EventStateLifeSafetyAlarm, stamp, objects.ErrorCodeInvalidEventState},
+		{"not the current state", services.EventStateLowLimit, stamp, objects.ErrorCodeInvalidEventState},
+		{"wrong time stamp", services.EventStateHighLimit, stamp.Add(time.Second), objects.ErrorCodeInvalidTimeStamp},
+	}
+	for _, c := range cases {
+		err := db.Acknowledge(objects.ObjectTypeAnalogInput, 0, c.state, c.timeStamp)
+		if bErr, ok := errors.Cause(err).(*objects.BACnetError); !ok || bErr.Code != c.want {
+			t.Errorf("%s: got %v, want error code %d", c.name, err, c.want)
+		}
+	}
+
+	if err := db.Acknowledge(objects.ObjectTypeAnalogInput, 0, services.EventStateHighLimit, stamp); err != nil {
+		t.Fatal(err)
+	}
+	err := db.Acknowledge(objects.ObjectTypeAnalogInput, 0, services.EventStateHighLimit, stamp)
+	if bErr, ok := errors.Cause(err).(*objects.BACnetError); !ok || bErr.Code != objects.ErrorCodeInvalidEventState {
+		t.Errorf("acknowledged twice: got %v, want invalid-event-state", err)
+	}
+}
+
+func TestEventTimeStamps(t *testing.T) {
+	db := newEventDatabase(t)
+
+	if length, err := db.ReadProperty(objects.ObjectTypeAnalogInput, 0, objects.PropertyIdEventTimeStamps, 0); err != nil {
+		t.Fatal(err)
+	} else if n, _ := objects.DecUnisgnedInteger(length[0]); n != 3 {
+		t.Fatalf("Event_Time_Stamps holds %d elements, want 3", n)
+	}
+
+	before := time.Now().Truncate(10 * time.Millisecond)
+	setProperty(t, db, objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue, objects.EncReal(60))
+	after := time.Now()
+
+	// The transition to offnormal is stamped with its date and time, the
+	// others which didn't happen are unspecified.
+	offnormal, err := db.ReadProperty(objects.ObjectTypeAnalogInput, 0, objects.PropertyIdEventTimeStamps, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offnormal) != 4 {
+		t.Fatalf("time stamp made of %d objects, want 4", len(offnormal))
+	}
+	stamp, err := objects.DecDateTime(offnormal[1], offnormal[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stamp.Before(before) || stamp.After(after) {
+		t.Errorf("transition stamped %v, want between %v and %v", stamp, before, after)
+	}
+
+	normal, err := db.ReadProperty(objects.ObjectTypeAnalogInput, 0, objects.PropertyIdEventTimeStamps, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := objects.DecDateTime(normal[1], normal[2]); err == nil {
+		t.Error("transition to normal stamped before happening")
+	}
+}
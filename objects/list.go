@@ -0,0 +1,188 @@
+package objects
+
+import (
+	"bytes"
+	"sync"
+)
+
+// ListElementHandler is implemented by objects whose list properties can be
+// changed with AddListElement and RemoveListElement requests. Errors are
+// reported as *BACnetError or *ElementError.
+type ListElementHandler interface {
+	AddListElement(propertyId uint8, arrayIndex uint32, elements []APDUPayload) error
+	RemoveListElement(propertyId uint8, arrayIndex uint32, elements []APDUPayload) error
+}
+
+// ListProperty is the value of a list property. Every element is made of
+// ItemsPerElement items, an item being either a primitive object or a whole
+// constructed value: a Recipient_List element takes 7 items whereas a
+// Date_List element takes a single one.
+type ListProperty struct {
+	ItemsPerElement int
+	Elements        [][]APDUPayload
+
+	mu sync.Mutex
+}
+
+// NewListProperty creates an empty ListProperty.
+func NewListProperty(itemsPerElement int) *ListProperty {
+	return &ListProperty{
+		ItemsPerElement: itemsPerElement,
+	}
+}
+
+// Value returns the objects of every element, in order.
+func (l *ListProperty) Value() []APDUPayload {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	objs := []APDUPayload{}
+	for _, e := range l.Elements {
+		objs = append(objs, e...)
+	}
+	return objs
+}
+
+// Add appends the elements carried by objs which aren't in the list yet.
+// Nothing is added if any element is malformed.
+func (l *ListProperty) Add(objs []APDUPayload) error {
+	elements, err := l.split(objs)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range elements {
+		if l.index(e) < 0 {
+			l.Elements = append(l.Elements, e)
+		}
+	}
+	return nil
+}
+
+// Remove drops the elements carried by objs from the list. Nothing is removed
+// if any element is malformed or not found.
+func (l *ListProperty) Remove(objs []APDUPayload) error {
+	elements, err := l.split(objs)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, e := range elements {
+		if l.index(e) < 0 {
+			return NewElementError(ErrorClassService, ErrorCodeListElementNotFound, uint32(i+1))
+		}
+	}
+	for _, e := range elements {
+		i := l.index(e)
+		l.Elements = append(l.Elements[:i], l.Elements[i+1:]...)
+	}
+	return nil
+}
+
+// index returns the position of element in the list or -1.
+func (l *ListProperty) index(element []APDUPayload) int {
+	want, err := marshalObjects(element)
+	if err != nil {
+		return -1
+	}
+	for i, e := range l.Elements {
+		if got, err := marshalObjects(e); err == nil && bytes.Equal(want, got) {
+			return i
+		}
+	}
+	return -1
+}
+
+// split groups objs into elements of ItemsPerElement items.
+func (l *ListProperty) split(objs []APDUPayload) ([][]APDUPayload, error) {
+	elements := [][]APDUPayload{}
+
+	start, items := 0, 0
+	for i := 0; i < len(objs); i++ {
+		if ok, err := DecOpeningTab(objs[i]); err == nil && ok {
+			end := constructedEnd(objs, i)
+			if end < 0 {
+				return nil, NewElementError(ErrorClassProperty, ErrorCodeInvalidDataType, uint32(len(elements)+1))
+			}
+			i = end
+		}
+		items++
+
+		if items == l.ItemsPerElement {
+			elements = append(elements, objs[start:i+1])
+			start, items = i+1, 0
+		}
+	}
+	if items != 0 {
+		return nil, NewElementError(ErrorClassProperty, ErrorCodeInvalidDataType, uint32(len(elements)+1))
+	}
+
+	return elements, nil
+}
+
+// ListProperties maps property identifiers to the list properties of an
+// object and applies AddListElement and RemoveListElement requests to them.
+type ListProperties map[uint8]*ListProperty
+
+func (lp ListProperties) AddListElement(propertyId uint8, arrayIndex uint32, elements []APDUPayload) error {
+	l, err := lp.list(propertyId, arrayIndex)
+	if err != nil {
+		return err
+	}
+	return l.Add(elements)
+}
+
+func (lp ListProperties) RemoveListElement(propertyId uint8, arrayIndex uint32, elements []APDUPayload) error {
+	l, err := lp.list(propertyId, arrayIndex)
+	if err != nil {
+		return err
+	}
+	return l.Remove(elements)
+}
+
+func (lp ListProperties) list(propertyId uint8, arrayIndex uint32) (*ListProperty, error) {
+	l, ok := lp[propertyId]
+	if !ok {
+		return nil, NewBACnetError(ErrorClassProperty, ErrorCodeUnknownProperty)
+	}
+	if arrayIndex != ArrayAll {
+		return nil, NewBACnetError(ErrorClassProperty, ErrorCodePropertyIsNotAnArray)
+	}
+	return l, nil
+}
+
+// constructedEnd returns the index of the closing tag matching the opening
+// tag at objs[start] or -1 if there's none.
+func constructedEnd(objs []APDUPayload, start int) int {
+	depth := 0
+	for i := start; i < len(objs); i++ {
+		if ok, err := DecOpeningTab(objs[i]); err == nil && ok {
+			depth++
+		} else if ok, err := DecClosingTab(objs[i]); err == nil && ok {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// marshalObjects concatenates the encoding of objs.
+func marshalObjects(objs []APDUPayload) ([]byte, error) {
+	b := []byte{}
+	for _, o := range objs {
+		ob, err := o.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, ob...)
+	}
+	return b, nil
+}
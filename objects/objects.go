@@ -1,6 +1,7 @@
 package objects
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"log"
@@ -20,7 +21,7 @@ type APDUPayload interface {
 type Object struct {
 	TagNumber uint8
 	TagClass  bool
-	Length    uint8
+	Length    uint32
 	Data      []byte
 }
 
@@ -29,7 +30,7 @@ func NewObject(number uint8, class bool, data []byte) *Object {
 	obj := &Object{
 		TagNumber: number,
 		TagClass:  class,
-		Length:    uint8(len(data)),
+		Length:    uint32(len(data)),
 		Data:      data,
 	}
 
@@ -37,7 +38,17 @@ func NewObject(number uint8, class bool, data []byte) *Object {
 	return obj
 }
 
-const objLenMin int = 2
+// objLenMin is the length of the shortest object, a lone tag octet.
+const objLenMin int = 1
+
+// Tag numbers above 14 and lengths above 4 are carried in the
+// octets following the initial tag octet.
+const (
+	tagNumberExtended uint8 = 0xF
+	lengthExtended    uint8 = 0x5
+	lengthExtended16  uint8 = 0xFE
+	lengthExtended32  uint8 = 0xFF
+)
 
 // UnmarshalBinary sets the values retrieved from byte sequence in a Object frame.
 func (o *Object) UnmarshalBinary(b []byte) error {
@@ -50,17 +61,67 @@ func (o *Object) UnmarshalBinary(b []byte) error {
 
 	o.TagNumber = b[0] >> 4
 	o.TagClass = common.IntToBool(int(b[0]) & 0x8 >> 3)
-	o.Length = b[0] & 0x7
+	o.Length = uint32(b[0] & 0x7)
+
+	offset := 1
+	// Handle extended tag number case
+	if o.TagNumber == tagNumberExtended {
+		if l := len(b); l <= offset {
+			return errors.Wrap(
+				common.ErrTooShortToParse,
+				fmt.Sprintf("failed to unmarshal object - binary %x - missing tag number", b),
+			)
+		}
+		o.TagNumber = b[offset]
+		offset++
+	}
+
+	// Application tagged booleans carry their value in the length field
+	if !o.TagClass && o.TagNumber == TagBoolean {
+		o.Data = []byte{uint8(o.Length)}
+		o.Length = 1
+		return nil
+	}
+
+	// Handle extended length case
+	if o.Length == uint32(lengthExtended) {
+		if l := len(b); l <= offset {
+			return errors.Wrap(
+				common.ErrTooShortToParse,
+				fmt.Sprintf("failed to unmarshal object - binary %x - missing extended length", b),
+			)
+		}
+		o.Length = uint32(b[offset])
+		offset++
+
+		var n int
+		switch uint8(o.Length) {
+		case lengthExtended16:
+			n = 2
+		case lengthExtended32:
+			n = 4
+		}
+		if n > 0 {
+			if l := len(b); l < offset+n {
+				return errors.Wrap(
+					common.ErrTooShortToParse,
+					fmt.Sprintf("failed to unmarshal object - binary %x - missing extended length", b),
+				)
+			}
+			o.Length = decUnsigned(b[offset : offset+n])
+			offset += n
+		}
+	}
 	log.Println("UnmarshalBinary: TagNumber:", o.TagNumber, "TagClass:", o.TagClass, "Length:", o.Length)
 
-	if l := len(b); l < int(o.Length) {
+	if l := len(b); l < offset+int(o.Length) {
 		return errors.Wrap(
 			common.ErrTooShortToParse,
 			fmt.Sprintf("failed to unmarshal object - binary %x - marshal length too short", b),
 		)
 	}
 
-	o.Data = b[1:o.Length]
+	o.Data = b[offset : offset+int(o.Length)]
 	log.Println("UnmarshalBinary: Data:", o.Data)
 
 	return nil
@@ -84,15 +145,71 @@ func (o *Object) MarshalTo(b []byte) error {
 			fmt.Sprintf("failed to marshal object - binary %x - marshal length too short", b),
 		)
 	}
-	b[0] = o.TagNumber<<4 | uint8(common.BoolToInt(o.TagClass))<<3 | o.Length
+
+	tagN, lvt := o.TagNumber, uint8(o.Length)
+	if o.TagNumber >= tagNumberExtended {
+		tagN = tagNumberExtended
+	}
+	if o.Length >= uint32(lengthExtended) {
+		lvt = lengthExtended
+	}
+	if o.isAppBoolean() {
+		lvt = o.Data[0]
+	}
+	b[0] = tagN<<4 | uint8(common.BoolToInt(o.TagClass))<<3 | lvt
+
+	offset := 1
+	if tagN == tagNumberExtended {
+		b[offset] = o.TagNumber
+		offset++
+	}
+	if o.isAppBoolean() {
+		return nil
+	}
+	if lvt == lengthExtended {
+		switch {
+		case o.Length < uint32(lengthExtended16):
+			b[offset] = uint8(o.Length)
+			offset++
+		case o.Length <= 0xFFFF:
+			b[offset] = lengthExtended16
+			binary.BigEndian.PutUint16(b[offset+1:offset+3], uint16(o.Length))
+			offset += 3
+		default:
+			b[offset] = lengthExtended32
+			binary.BigEndian.PutUint32(b[offset+1:offset+5], o.Length)
+			offset += 5
+		}
+	}
 	if o.Length > 0 {
-		copy(b[1:o.Length+1], o.Data)
+		copy(b[offset:offset+int(o.Length)], o.Data)
 	}
 	return nil
 }
 
 // MarshalLen returns the serial length of Object.
 func (o *Object) MarshalLen() int {
-	log.Println(o.Data)
-	return 1 + int(o.Length)
+	l := 1
+	if o.TagNumber >= tagNumberExtended {
+		l++
+	}
+	if o.isAppBoolean() {
+		return l
+	}
+	switch {
+	case o.Length < uint32(lengthExtended):
+	case o.Length < uint32(lengthExtended16):
+		l++
+	case o.Length <= 0xFFFF:
+		l += 3
+	default:
+		l += 5
+	}
+	return l + int(o.Length)
+}
+
+// isAppBoolean tells whether o is an application tagged Boolean, whose value
+// is held in Data but encoded within the tag itself.
+func (o *Object) isAppBoolean() bool {
+	return !o.TagClass && o.TagNumber == TagBoolean && len(o.Data) == 1
 }
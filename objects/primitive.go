@@ -17,21 +17,34 @@ func DecString(rawPayload APDUPayload) (string, error) {
 			fmt.Sprintf("DecString not ok: %v", rawPayload),
 		)
 	}
-	if rawObject.TagNumber != TagCharacterString || rawObject.TagClass {
+	if !rawObject.TagClass && rawObject.TagNumber != TagCharacterString {
 		return "", errors.Wrap(
 			common.ErrWrongStructure,
 			fmt.Sprintf("DecString wrong tag number: %v", rawObject.TagNumber),
 		)
 	}
-	return string(rawObject.Data), nil
+	if len(rawObject.Data) == 0 {
+		return "", errors.Wrap(
+			common.ErrWrongStructure,
+			"DecString missing character set",
+		)
+	}
+	if rawObject.Data[0] != CharacterSetUTF8 {
+		return "", errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("DecString character set: %d", rawObject.Data[0]),
+		)
+	}
+	return string(rawObject.Data[1:]), nil
 }
 
+// EncString encodes value as an UTF-8 CharacterString.
 func EncString(value string) *Object {
 	newObj := Object{}
 	newObj.TagNumber = TagCharacterString
 	newObj.TagClass = false
-	newObj.Data = []byte(value)
-	newObj.Length = uint8(len(newObj.Data))
+	newObj.Data = append([]byte{CharacterSetUTF8}, []byte(value)...)
+	newObj.Length = uint32(len(newObj.Data))
 	return &newObj
 }
 
@@ -44,28 +57,21 @@ func DecUnisgnedInteger(rawPayload APDUPayload) (uint32, error) {
 		)
 	}
 
-	if rawObject.TagNumber != TagUnsignedInteger || rawObject.TagClass {
+	if !rawObject.TagClass && rawObject.TagNumber != TagUnsignedInteger {
 		return 0, errors.Wrap(
 			common.ErrWrongStructure,
 			fmt.Sprintf("failed to decode UnsignedInteger - wrong tag number - %v", rawObject.TagNumber),
 		)
 	}
 
-	switch rawObject.Length {
-	case 1:
-		return uint32(rawObject.Data[0]), nil
-	case 2:
-		return uint32(binary.BigEndian.Uint16(rawObject.Data)), nil
-	case 3:
-		return uint32(uint16(uint32(rawObject.Data[0])<<16) | binary.BigEndian.Uint16(rawObject.Data[1:])), nil
-	case 4:
-		return binary.BigEndian.Uint32(rawObject.Data), nil
+	if rawObject.Length < 1 || rawObject.Length > 4 {
+		return 0, errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("failed to decode UnsignedInteger - %v", rawObject.Data),
+		)
 	}
 
-	return 0, errors.Wrap(
-		common.ErrNotImplemented,
-		fmt.Sprintf("failed to decode UnsignedInteger - %v", rawObject.Data),
-	)
+	return decUnsigned(rawObject.Data), nil
 }
 
 func DecSignedInteger(rawPayload APDUPayload) (int32, error) {
@@ -77,28 +83,23 @@ func DecSignedInteger(rawPayload APDUPayload) (int32, error) {
 		)
 	}
 
-	if rawObject.TagNumber != TagSignedInteger || rawObject.TagClass {
+	if !rawObject.TagClass && rawObject.TagNumber != TagSignedInteger {
 		return 0, errors.Wrap(
 			common.ErrWrongStructure,
 			fmt.Sprintf("failed to decode SignedInteger - wrong tag number - %v", rawObject.TagNumber),
 		)
 	}
 
-	switch rawObject.Length {
-	case 1:
-		return int32(rawObject.Data[0]), nil
-	case 2:
-		return int32(binary.BigEndian.Uint32([]byte{0x00, 0x00, rawObject.Data[0], rawObject.Data[1]})), nil
-	case 3:
-		return int32(binary.BigEndian.Uint32([]byte{0x00, rawObject.Data[0], rawObject.Data[1], rawObject.Data[2]})), nil
-	case 4:
-		return int32(binary.BigEndian.Uint32(rawObject.Data)), nil
+	if rawObject.Length < 1 || rawObject.Length > 4 {
+		return 0, errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("failed to decode SignedInteger - %v", rawObject.Data),
+		)
 	}
 
-	return 0, errors.Wrap(
-		common.ErrNotImplemented,
-		fmt.Sprintf("failed to decode UnsignedInteger - %v", rawObject.Data),
-	)
+	// Sign-extend the two's complement value to 32 bits.
+	shift := 32 - 8*uint(rawObject.Length)
+	return int32(decUnsigned(rawObject.Data)<<shift) >> shift, nil
 }
 
 func EncUnsignedInteger8(value uint8) *Object {
@@ -110,7 +111,7 @@ func EncUnsignedInteger8(value uint8) *Object {
 	newObj.TagNumber = TagUnsignedInteger
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -124,7 +125,41 @@ func EncUnsignedInteger16(value uint16) *Object {
 	newObj.TagNumber = TagUnsignedInteger
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+// EncUnsignedInteger32 encodes value as an UnsignedInteger using
+// as few octets as possible.
+func EncUnsignedInteger32(value uint32) *Object {
+	newObj := Object{}
+
+	data := encUnsigned(value)
+
+	newObj.TagNumber = TagUnsignedInteger
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+// EncSignedInteger encodes value as a SignedInteger using as few
+// octets as possible.
+func EncSignedInteger(value int32) *Object {
+	newObj := Object{}
+
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(value))
+	for len(data) > 1 && ((data[0] == 0x00 && data[1]&0x80 == 0) || (data[0] == 0xFF && data[1]&0x80 != 0)) {
+		data = data[1:]
+	}
+
+	newObj.TagNumber = TagSignedInteger
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -138,28 +173,21 @@ func DecEnumerated(rawPayload APDUPayload) (uint32, error) {
 		)
 	}
 
-	if rawObject.TagNumber != TagEnumerated || rawObject.TagClass {
+	if !rawObject.TagClass && rawObject.TagNumber != TagEnumerated {
 		return 0, errors.Wrap(
 			common.ErrWrongStructure,
 			fmt.Sprintf("failed to decode EnumObject - wrong tag number - %v", rawObject.TagNumber),
 		)
 	}
 
-	switch rawObject.Length {
-	case 1:
-		return uint32(rawObject.Data[0]), nil
-	case 2:
-		return uint32(binary.BigEndian.Uint16(rawObject.Data)), nil
-	case 3:
-		return uint32(uint16(uint32(rawObject.Data[0])<<16) | binary.BigEndian.Uint16(rawObject.Data[1:])), nil
-	case 4:
-		return binary.BigEndian.Uint32(rawObject.Data), nil
+	if rawObject.Length < 1 || rawObject.Length > 4 {
+		return 0, errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("failed to decode EnumObject - %v", rawObject.Data),
+		)
 	}
 
-	return 0, errors.Wrap(
-		common.ErrNotImplemented,
-		fmt.Sprintf("failed to decode EnumObject - %v", rawObject.Data),
-	)
+	return decUnsigned(rawObject.Data), nil
 }
 
 func EncEnumerated(value uint8) *Object {
@@ -171,7 +199,22 @@ func EncEnumerated(value uint8) *Object {
 	newObj.TagNumber = TagEnumerated
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+// EncEnumerated32 encodes value as an Enumerated using as few octets
+// as possible.
+func EncEnumerated32(value uint32) *Object {
+	newObj := Object{}
+
+	data := encUnsigned(value)
+
+	newObj.TagNumber = TagEnumerated
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -185,7 +228,7 @@ func DecReal(rawPayload APDUPayload) (float32, error) {
 		)
 	}
 
-	if rawObject.TagNumber != TagReal {
+	if (!rawObject.TagClass && rawObject.TagNumber != TagReal) || rawObject.Length != 4 {
 		return 0, errors.Wrap(
 			common.ErrWrongStructure,
 			fmt.Sprintf("failed to decode real - wrong tag number - %v", rawObject.TagNumber),
@@ -204,7 +247,7 @@ func EncReal(value float32) *Object {
 	newObj.TagNumber = TagReal
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -218,7 +261,7 @@ func DecNull(rawPayload APDUPayload) (bool, error) {
 		)
 	}
 
-	if rawObject.TagNumber != TagReal {
+	if rawObject.TagNumber != TagNull {
 		return false, errors.Wrap(
 			common.ErrWrongStructure,
 			fmt.Sprintf("failed to decode Null - wrong tag number - %v", rawObject.TagNumber),
@@ -238,3 +281,184 @@ func EncNull() *Object {
 
 	return &newObj
 }
+
+// EncContextTag turns an application tagged object into a context
+// tagged one carrying tag number tagN.
+func EncContextTag(tagN uint8, appObj *Object) *Object {
+	return &Object{
+		TagNumber: tagN,
+		TagClass:  true,
+		Length:    appObj.Length,
+		Data:      appObj.Data,
+	}
+}
+
+// encUnsigned returns value in big endian order, dropping leading zero octets.
+func encUnsigned(value uint32) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, value)
+	for len(data) > 1 && data[0] == 0x00 {
+		data = data[1:]
+	}
+	return data
+}
+
+// decUnsigned joins up to 4 big endian octets.
+func decUnsigned(data []byte) uint32 {
+	var value uint32
+	for _, b := range data {
+		value = value<<8 | uint32(b)
+	}
+	return value
+}
+
+func DecBoolean(rawPayload APDUPayload) (bool, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return false, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode Boolean - %v", rawPayload),
+		)
+	}
+
+	if (!rawObject.TagClass && rawObject.TagNumber != TagBoolean) || rawObject.Length != 1 {
+		return false, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode Boolean - wrong tag number - %v", rawObject.TagNumber),
+		)
+	}
+
+	return rawObject.Data[0] != 0, nil
+}
+
+// EncBoolean encodes value as a Boolean. Once context tagged, the value is
+// carried as a single content octet instead of in the tag itself.
+func EncBoolean(value bool) *Object {
+	newObj := Object{}
+
+	data := make([]byte, 1)
+	data[0] = uint8(common.BoolToInt(value))
+
+	newObj.TagNumber = TagBoolean
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+func DecOctetString(rawPayload APDUPayload) ([]byte, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return nil, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode OctetString - %v", rawPayload),
+		)
+	}
+
+	if !rawObject.TagClass && rawObject.TagNumber != TagOctetString {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode OctetString - wrong tag number - %v", rawObject.TagNumber),
+		)
+	}
+
+	return rawObject.Data, nil
+}
+
+func EncOctetString(value []byte) *Object {
+	newObj := Object{}
+
+	newObj.TagNumber = TagOctetString
+	newObj.TagClass = false
+	newObj.Data = value
+	newObj.Length = uint32(len(value))
+
+	return &newObj
+}
+
+// DecBitString decodes a BitString into its bits, the first bit coming first.
+func DecBitString(rawPayload APDUPayload) ([]bool, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return nil, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode BitString - %v", rawPayload),
+		)
+	}
+
+	if (!rawObject.TagClass && rawObject.TagNumber != TagBitString) || rawObject.Length < 1 {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode BitString - wrong tag number - %v", rawObject.TagNumber),
+		)
+	}
+
+	unused := int(rawObject.Data[0])
+	n := (len(rawObject.Data)-1)*8 - unused
+	if unused > 7 || n < 0 {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode BitString - %d unused bits", unused),
+		)
+	}
+
+	bits := make([]bool, n)
+	for i := range bits {
+		bits[i] = rawObject.Data[1+i/8]&(0x80>>(i%8)) != 0
+	}
+
+	return bits, nil
+}
+
+func EncBitString(bits []bool) *Object {
+	newObj := Object{}
+
+	data := make([]byte, 1+(len(bits)+7)/8)
+	data[0] = uint8((len(data)-1)*8 - len(bits))
+	for i, bit := range bits {
+		if bit {
+			data[1+i/8] |= 0x80 >> (i % 8)
+		}
+	}
+
+	newObj.TagNumber = TagBitString
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
+
+func DecDouble(rawPayload APDUPayload) (float64, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return 0, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode Double - %v", rawPayload),
+		)
+	}
+
+	if (!rawObject.TagClass && rawObject.TagNumber != TagDouble) || rawObject.Length != 8 {
+		return 0, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode Double - wrong tag number - %v", rawObject.TagNumber),
+		)
+	}
+
+	return math.Float64frombits(binary.BigEndian.Uint64(rawObject.Data)), nil
+}
+
+func EncDouble(value float64) *Object {
+	newObj := Object{}
+
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(value))
+
+	newObj.TagNumber = TagDouble
+	newObj.TagClass = false
+	newObj.Data = data
+	newObj.Length = uint32(len(data))
+
+	return &newObj
+}
@@ -43,7 +43,7 @@ func EncPriority(contextTag bool, tagN uint8, priority uint8) *Object {
 	newObj.TagNumber = tagN
 	newObj.TagClass = contextTag
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -44,7 +44,7 @@ func EncPropertyIdentifier(contextTag bool, tagN uint8, propId uint8) *Object {
 	newObj.TagNumber = tagN
 	newObj.TagClass = contextTag
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -22,7 +22,7 @@ func NewNamedTag(number uint8, class bool, name uint8) *NamedTag {
 }
 
 func (n *NamedTag) UnmarshalBinary(b []byte) error {
-	if l := len(b); l < objLenMin {
+	if l := len(b); l < 1 {
 		return errors.Wrap(
 			common.ErrTooShortToParse,
 			fmt.Sprintf("failed to unmarshal NamedTag - binary too short - %x", b),
@@ -32,11 +32,15 @@ func (n *NamedTag) UnmarshalBinary(b []byte) error {
 	n.TagClass = common.IntToBool(int(b[0]) & 0x8 >> 3)
 	n.Name = b[0] & 0x7
 
-	if l := len(b); l < 1 {
-		return errors.Wrap(
-			common.ErrTooShortToParse,
-			fmt.Sprintf("failed to unmarshal NamedTag - missing data - %v", n),
-		)
+	// Handle extended tag number case
+	if n.TagNumber == tagNumberExtended {
+		if l := len(b); l < 2 {
+			return errors.Wrap(
+				common.ErrTooShortToParse,
+				fmt.Sprintf("failed to unmarshal NamedTag - missing tag number - %v", n),
+			)
+		}
+		n.TagNumber = b[1]
 	}
 
 	return nil
@@ -55,12 +59,20 @@ func (n *NamedTag) MarshalTo(b []byte) error {
 	if len(b) < n.MarshalLen() {
 		return errors.Wrap(common.ErrTooShortToMarshalBinary, "failed to marshall NamedTag - marshal length too short")
 	}
+	if n.TagNumber >= tagNumberExtended {
+		b[0] = tagNumberExtended<<4 | uint8(common.BoolToInt(n.TagClass))<<3 | n.Name
+		b[1] = n.TagNumber
+		return nil
+	}
 	b[0] = n.TagNumber<<4 | uint8(common.BoolToInt(n.TagClass))<<3 | n.Name
 
 	return nil
 }
 
 func (n *NamedTag) MarshalLen() int {
+	if n.TagNumber >= tagNumberExtended {
+		return 2
+	}
 	return 1
 }
 
@@ -0,0 +1,62 @@
+package objects
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pkg/errors"
+)
+
+// DecAppValue decodes the application tagged primitive value rawPayload into
+// the Go type its tag maps to: nil for Null, bool, uint32 for unsigned and
+// enumerated values, int32, float32, float64, []byte, string, []bool,
+// time.Time for a date, time.Duration for a time or an ObjectIdentifier.
+func DecAppValue(rawPayload APDUPayload) (interface{}, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return nil, errors.Wrap(
+			common.ErrWrongPayload,
+			fmt.Sprintf("failed to decode application value - %v", rawPayload),
+		)
+	}
+	if rawObject.TagClass {
+		return nil, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("failed to decode application value - context tag %d", rawObject.TagNumber),
+		)
+	}
+
+	switch rawObject.TagNumber {
+	case TagNull:
+		return nil, nil
+	case TagBoolean:
+		return DecBoolean(rawPayload)
+	case TagUnsignedInteger:
+		return DecUnisgnedInteger(rawPayload)
+	case TagSignedInteger:
+		return DecSignedInteger(rawPayload)
+	case TagReal:
+		return DecReal(rawPayload)
+	case TagDouble:
+		return DecDouble(rawPayload)
+	case TagOctetString:
+		return DecOctetString(rawPayload)
+	case TagCharacterString:
+		return DecString(rawPayload)
+	case TagBitString:
+		return DecBitString(rawPayload)
+	case TagEnumerated:
+		return DecEnumerated(rawPayload)
+	case TagDate:
+		return DecDate(rawPayload)
+	case TagTime:
+		return DecTime(rawPayload)
+	case TagBACnetObjectIdentifier:
+		return DecObjectIdentifier(rawPayload)
+	default:
+		return nil, errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("failed to decode application value - tag number %d", rawObject.TagNumber),
+		)
+	}
+}
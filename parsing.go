@@ -59,9 +59,27 @@ func Parse(b []byte) (plumbing.BACnet, error) {
 	case plumbing.UnConfirmedReq:
 		c = combine(b[offset], b[offset+1])
 	case plumbing.ConfirmedReq:
-		c = combine(b[offset], b[offset+3]) // We need to skip the PDU flags and the InvokeID
-	case plumbing.ComplexAck, plumbing.SimpleAck, plumbing.Error, plumbing.SegmentAck:
+		if len(b) < offset+4 {
+			return nil, errors.Wrap(
+				common.ErrTooShortToParse,
+				fmt.Sprintf("Parsing confirmed request length %d", len(b)),
+			)
+		}
+		// We need to skip the PDU flags, which vary with the segmentation
+		// the client accepts, the max APDU and the InvokeID.
+		c = combine(PDUType<<4, b[offset+3])
+	case plumbing.ComplexAck:
+		if len(b) < offset+3 {
+			return nil, errors.Wrap(
+				common.ErrTooShortToParse,
+				fmt.Sprintf("Parsing CACK length %d", len(b)),
+			)
+		}
+		c = combine(PDUType<<4, b[offset+2]) // We need to skip the PDU flags and the InvokeID
+	case plumbing.SimpleAck, plumbing.Error, plumbing.SegmentAck:
 		c = combine(b[offset], 0) // We need to skip the PDU flags and the InvokeID
+	case plumbing.Reject, plumbing.Abort:
+		c = combine(PDUType<<4, 0) // Abort PDUs carry the server flag
 	}
 
 	log.Printf("PDUType %+v | c %+v\n", PDUType, c)
@@ -73,23 +91,109 @@ func Parse(b []byte) (plumbing.BACnet, error) {
 		bacnet = services.NewUnconfirmedWhoIs(&bvlc, &npdu)
 	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedIAm):
 		bacnet = services.NewUnconfirmedIAm(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedCOVNotification):
+		bacnet = services.NewUnconfirmedCOVNotification(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedEventNotification):
+		bacnet = services.NewUnconfirmedEventNotification(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedPrivateTransfer):
+		bacnet = services.NewUnconfirmedPrivateTransfer(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedTextMessage):
+		bacnet = services.NewUnconfirmedTextMessage(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedWriteGroup):
+		bacnet = services.NewUnconfirmedWriteGroup(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedWhoAmI):
+		bacnet = services.NewUnconfirmedWhoAmI(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedYouAre):
+		bacnet = services.NewUnconfirmedYouAre(&bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedAuditNotification):
+		bacnet = services.NewUnconfirmedAuditNotification(&bvlc, &npdu)
 	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedReadProperty):
 		bacnet = services.NewConfirmedReadProperty(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedReadPropMultiple):
+		bacnet = services.NewConfirmedReadPropertyMultiple(&bvlc, &npdu)
 	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedWriteProperty):
 		bacnet = services.NewConfirmedWriteProperty(&bvlc, &npdu)
-	case combine(plumbing.ComplexAck<<4, 0):
-		bacnet = services.NewComplexACK(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedDeviceCommunicationControl):
+		bacnet = services.NewConfirmedDeviceCommunicationControl(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedReinitializeDevice):
+		bacnet = services.NewConfirmedReinitializeDevice(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedAtomicReadFile):
+		bacnet = services.NewConfirmedAtomicReadFile(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedAtomicWriteFile):
+		bacnet = services.NewConfirmedAtomicWriteFile(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedCreateObject):
+		bacnet = services.NewConfirmedCreateObject(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedDeleteObject):
+		bacnet = services.NewConfirmedDeleteObject(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedAddListElement):
+		bacnet = services.NewConfirmedAddListElement(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedRemoveListElement):
+		bacnet = services.NewConfirmedRemoveListElement(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedReadRange):
+		bacnet = services.NewConfirmedReadRange(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedSubscribeCOV):
+		bacnet = services.NewConfirmedSubscribeCOV(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedSubscribeCOVProperty):
+		bacnet = services.NewConfirmedSubscribeCOVProperty(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedCOVNotification):
+		bacnet = services.NewConfirmedCOVNotification(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedEventNotification):
+		bacnet = services.NewConfirmedEventNotification(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedAcknowledgeAlarm):
+		bacnet = services.NewConfirmedAcknowledgeAlarm(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedGetEventInformation):
+		bacnet = services.NewConfirmedGetEventInformation(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedGetAlarmSummary):
+		bacnet = services.NewConfirmedGetAlarmSummary(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedGetEnrollmentSummary):
+		bacnet = services.NewConfirmedGetEnrollmentSummary(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedPrivateTransfer):
+		bacnet = services.NewConfirmedPrivateTransfer(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedTextMessage):
+		bacnet = services.NewConfirmedTextMessage(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedAuditNotification):
+		bacnet = services.NewConfirmedAuditNotification(&bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedAuditLogQuery):
+		bacnet = services.NewConfirmedAuditLogQuery(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedAtomicReadFile):
+		bacnet = services.NewAtomicReadFileACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedAtomicWriteFile):
+		bacnet = services.NewAtomicWriteFileACK(&bvlc, &npdu)
 	case combine(plumbing.SimpleAck<<4, 0):
 		bacnet = services.NewSimpleACK(&bvlc, &npdu)
 	case combine(plumbing.Error<<4, 0):
 		bacnet = services.NewError(&bvlc, &npdu)
 	case combine(plumbing.SegmentAck<<4, 0):
 		bacnet = services.NewSegmentAck(&bvlc, &npdu)
+	case combine(plumbing.Reject<<4, 0):
+		bacnet = services.NewReject(&bvlc, &npdu)
+	case combine(plumbing.Abort<<4, 0):
+		bacnet = services.NewAbort(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedCreateObject):
+		bacnet = services.NewCreateObjectACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedReadRange):
+		bacnet = services.NewReadRangeACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedGetEventInformation):
+		bacnet = services.NewGetEventInformationACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedGetAlarmSummary):
+		bacnet = services.NewGetAlarmSummaryACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedGetEnrollmentSummary):
+		bacnet = services.NewGetEnrollmentSummaryACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedPrivateTransfer):
+		bacnet = services.NewConfirmedPrivateTransferACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedReadPropMultiple):
+		bacnet = services.NewReadPropertyMultipleACK(&bvlc, &npdu)
+	case combine(plumbing.ComplexAck<<4, services.ServiceConfirmedAuditLogQuery):
+		bacnet = services.NewAuditLogQueryACK(&bvlc, &npdu)
 	default:
-		return nil, errors.Wrap(
-			common.ErrNotImplemented,
-			fmt.Sprintf("Parsing service: %x", c),
-		)
+		if PDUType != plumbing.ComplexAck {
+			return nil, errors.Wrap(
+				common.ErrNotImplemented,
+				fmt.Sprintf("Parsing service: %x", c),
+			)
+		}
+		// Acknowledgements without a dedicated type, such as ReadProperty's.
+		bacnet = services.NewComplexACK(&bvlc, &npdu)
 	}
 
 	log.Printf("type %s\n", reflect.TypeOf(bacnet))
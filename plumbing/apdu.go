@@ -29,6 +29,19 @@ func NewAPDU(t, s uint8, objs []objects.APDUPayload) *APDU {
 	}
 }
 
+// maxAPDULengths are the lengths encoded by the MaxSize of confirmed requests.
+var maxAPDULengths = []int{50, 128, 206, 480, 1024, 1476}
+
+// MaxAPDULength returns the maximum APDU length the sender of a confirmed
+// request accepts, encoded by MaxSize. Reserved values are taken as the
+// smallest length.
+func (a *APDU) MaxAPDULength() int {
+	if int(a.MaxSize) >= len(maxAPDULengths) {
+		return maxAPDULengths[0]
+	}
+	return maxAPDULengths[a.MaxSize]
+}
+
 // UnmarshalBinary sets the values retrieved from byte sequence in a APDU frame.
 func (a *APDU) UnmarshalBinary(b []byte) error {
 	if l := len(b); l < a.MarshalLen() {
@@ -47,118 +60,61 @@ func (a *APDU) UnmarshalBinary(b []byte) error {
 	case UnConfirmedReq:
 		a.Service = b[offset]
 		offset++
-		if len(b) > 2 {
-			objs := []objects.APDUPayload{}
-			for {
-				o := objects.Object{
-					TagNumber: b[offset] >> 4,
-					TagClass:  common.IntToBool(int(b[offset]) & 0x8 >> 3),
-					Length:    b[offset] & 0x7,
-				}
-
-				// Handle extended value case
-				if o.Length == 5 {
-					offset++
-					o.Length = uint8(b[offset])
-				}
-
-				o.Data = b[offset+1 : offset+int(o.Length)+1]
-				objs = append(objs, &o)
-				offset += int(o.Length) + 1
-
-				if offset >= len(b) {
-					break
-				}
-			}
-			a.Objects = objs
-		}
 	case ConfirmedReq:
+		a.MaxSeg = b[offset] >> 4 & 0x7
+		a.MaxSize = b[offset] & 0xF
 		offset++
 		a.InvokeID = b[offset]
 		offset++
 		a.Service = b[offset]
 		offset++
-		if len(b) > 2 {
-			objs := []objects.APDUPayload{}
-			for {
-				o := objects.Object{
-					TagNumber: b[offset] >> 4,
-					TagClass:  common.IntToBool(int(b[offset]) & 0x8 >> 3),
-					Length:    b[offset] & 0x7,
-				}
-
-				// Handle extended value case
-				if o.Length == 5 {
-					offset++
-					o.Length = uint8(b[offset])
-				}
-
-				// Drop tags so that they don't get in the way!
-				if b[offset] == objects.TagOpening || b[offset] == objects.TagClosing {
-					offset++
-					if offset >= len(b) {
-						break
-					}
-					continue
-				}
-
-				o.Data = b[offset+1 : offset+int(o.Length)+1]
-				objs = append(objs, &o)
-				offset += int(o.Length) + 1
-
-				if offset >= len(b) {
-					break
-				}
-			}
-			a.Objects = objs
-		}
-	case ComplexAck, SimpleAck, Error, SegmentAck:
+	case ComplexAck, SimpleAck, Error, SegmentAck, Reject, Abort:
+		// Reject and Abort PDUs carry their reason in place of the service.
 		a.InvokeID = b[offset]
 		offset++
 		a.Service = b[offset]
 		offset++
-		if len(b) > 3 {
-			objs := []objects.APDUPayload{}
-			for {
-				// Drop tags so that they don't get in the way!
-				if b[offset] == objects.TagOpening || b[offset] == objects.TagClosing {
-					log.Print("tag opening/closing\n")
-					offset++
-					if offset >= len(b) {
-						break
-					}
-					continue
-				}
-
-				o := objects.Object{
-					TagNumber: b[offset] >> 4,
-					TagClass:  common.IntToBool(int(b[offset]) & 0x8 >> 3),
-					Length:    b[offset] & 0x7,
-				}
+	}
 
-				// Handle extended value case
-				if o.Length == 5 {
-					offset++
-					o.Length = uint8(b[offset])
-				}
+	if offset < len(b) {
+		objs, err := unmarshalObjects(b[offset:])
+		if err != nil {
+			return errors.Wrap(err, "failed to unmarshal APDU objects")
+		}
+		log.Println("Objects: ", len(objs))
+		for i, o := range objs {
+			log.Printf("obj[%d]: %+v\n", i, o)
+		}
+		a.Objects = objs
+	}
 
-				o.Data = b[offset+1 : offset+int(o.Length)+1]
-				objs = append(objs, &o)
-				offset += int(o.Length) + 1
+	return nil
+}
 
-				if offset >= len(b) {
-					break
-				}
-			}
-			log.Println("Objects: ", len(objs))
-			for i, o := range objs {
-				log.Printf("obj[%d]: %+v\n", i, o)
+// unmarshalObjects splits b into the tagged objects it carries. Opening and
+// closing tags are kept as NamedTags so that constructed values can be told
+// apart when decoding.
+func unmarshalObjects(b []byte) ([]objects.APDUPayload, error) {
+	objs := []objects.APDUPayload{}
+	for offset := 0; offset < len(b); {
+		if b[offset]&0x8 != 0 && b[offset]&0x6 == 0x6 {
+			t := &objects.NamedTag{}
+			if err := t.UnmarshalBinary(b[offset:]); err != nil {
+				return nil, err
 			}
-			a.Objects = objs
+			objs = append(objs, t)
+			offset += t.MarshalLen()
+			continue
 		}
-	}
 
-	return nil
+		o := &objects.Object{}
+		if err := o.UnmarshalBinary(b[offset:]); err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+		offset += o.MarshalLen()
+	}
+	return objs, nil
 }
 
 // MarshalTo puts the byte sequence in the byte array given as b.
@@ -196,7 +152,7 @@ func (a *APDU) MarshalTo(b []byte) error {
 				}
 			}
 		}
-	case ComplexAck, SimpleAck, Error:
+	case ComplexAck, SimpleAck, Error, Reject, Abort:
 		b[offset] = a.InvokeID
 		offset++
 		b[offset] = a.Service
@@ -256,7 +212,7 @@ func (a *APDU) MarshalLen() int {
 	switch a.Type {
 	case ConfirmedReq:
 		l += 4
-	case ComplexAck, SimpleAck, Error, SegmentAck:
+	case ComplexAck, SimpleAck, Error, SegmentAck, Reject, Abort:
 		l += 3
 	case UnConfirmedReq:
 		l += 2
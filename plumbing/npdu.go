@@ -8,12 +8,19 @@ import (
 	"github.com/pkg/errors"
 )
 
-// NPDU is a Network Protocol Data Units.
+// NPDU is a Network Protocol Data Units. DNET, DLEN, DADR and Hop are only
+// carried when the destination specifier is set, and SNET, SLEN and SADR when
+// the source specifier is: a router sets them to tell where a message it
+// forwards comes from.
 type NPDU struct {
 	Version uint8
 	Control uint8
 	DNET    uint16
 	DLEN    uint8
+	DADR    []byte
+	SNET    uint16
+	SLEN    uint8
+	SADR    []byte
 	Hop     uint8
 }
 
@@ -34,20 +41,62 @@ func (n *NPDU) SetControlFlags(nsduContain bool, dstSpecifier bool, srcSpecifier
 	)
 }
 
+func (n *NPDU) hasDestination() bool {
+	return n.Control&0x20 != 0
+}
+
+func (n *NPDU) hasSource() bool {
+	return n.Control&0x08 != 0
+}
+
 // UnmarshalBinary sets the values retrieved from byte sequence in a NPDU frame.
 func (n *NPDU) UnmarshalBinary(b []byte) error {
-	if l := len(b); l < n.MarshalLen() {
+	if l := len(b); l < npduLenMin {
 		return errors.Wrap(
 			common.ErrTooShortToParse,
-			fmt.Sprintf("failed to unmarshal NPDU - marshal length %d binary length %d", n.MarshalLen(), l),
+			fmt.Sprintf("failed to unmarshal NPDU - marshal length %d binary length %d", npduLenMin, l),
 		)
 	}
 	n.Version = b[0]
 	n.Control = b[1]
-	if flagDNET := n.Control & 0x20 >> 5; flagDNET == 1 {
-		n.DNET = binary.BigEndian.Uint16(b[2:4])
-		n.DLEN = b[4]
-		n.Hop = b[5]
+	n.DNET, n.DLEN, n.DADR = 0, 0, nil
+	n.SNET, n.SLEN, n.SADR = 0, 0, nil
+	n.Hop = 0
+
+	offset := npduLenMin
+	if n.hasDestination() {
+		if len(b) < offset+3 {
+			return errors.Wrap(common.ErrTooShortToParse, "failed to unmarshal NPDU - missing destination")
+		}
+		n.DNET = binary.BigEndian.Uint16(b[offset:])
+		n.DLEN = b[offset+2]
+		offset += 3
+		if len(b) < offset+int(n.DLEN) {
+			return errors.Wrap(common.ErrTooShortToParse, "failed to unmarshal NPDU - missing DADR")
+		}
+		if n.DLEN != 0 {
+			n.DADR = append([]byte{}, b[offset:offset+int(n.DLEN)]...)
+		}
+		offset += int(n.DLEN)
+	}
+	if n.hasSource() {
+		if len(b) < offset+3 {
+			return errors.Wrap(common.ErrTooShortToParse, "failed to unmarshal NPDU - missing source")
+		}
+		n.SNET = binary.BigEndian.Uint16(b[offset:])
+		n.SLEN = b[offset+2]
+		offset += 3
+		if len(b) < offset+int(n.SLEN) {
+			return errors.Wrap(common.ErrTooShortToParse, "failed to unmarshal NPDU - missing SADR")
+		}
+		n.SADR = append([]byte{}, b[offset:offset+int(n.SLEN)]...)
+		offset += int(n.SLEN)
+	}
+	if n.hasDestination() {
+		if len(b) < offset+1 {
+			return errors.Wrap(common.ErrTooShortToParse, "failed to unmarshal NPDU - missing hop count")
+		}
+		n.Hop = b[offset]
 	}
 
 	return nil
@@ -63,10 +112,24 @@ func (n *NPDU) MarshalTo(b []byte) error {
 	}
 	b[0] = n.Version
 	b[1] = n.Control
-	if flagDNET := n.Control & 0x20 >> 5; flagDNET == 1 {
-		binary.BigEndian.PutUint16(b[2:4], n.DNET)
-		b[4] = n.DLEN
-		b[5] = n.Hop
+
+	offset := npduLenMin
+	if n.hasDestination() {
+		binary.BigEndian.PutUint16(b[offset:], n.DNET)
+		b[offset+2] = n.DLEN
+		offset += 3
+		copy(b[offset:offset+int(n.DLEN)], n.DADR)
+		offset += int(n.DLEN)
+	}
+	if n.hasSource() {
+		binary.BigEndian.PutUint16(b[offset:], n.SNET)
+		b[offset+2] = n.SLEN
+		offset += 3
+		copy(b[offset:offset+int(n.SLEN)], n.SADR)
+		offset += int(n.SLEN)
+	}
+	if n.hasDestination() {
+		b[offset] = n.Hop
 	}
 	return nil
 }
@@ -75,9 +138,12 @@ const npduLenMin = 2
 
 // MarshalLen returns the serial length of NPDU.
 func (n *NPDU) MarshalLen() int {
-	flagDNET := n.Control & 0x20 >> 5
-	if flagDNET == 1 {
-		return npduLenMin + 4
+	l := npduLenMin
+	if n.hasDestination() {
+		l += 3 + int(n.DLEN) + 1
+	}
+	if n.hasSource() {
+		l += 3 + int(n.SLEN)
 	}
-	return npduLenMin
+	return l
 }
@@ -0,0 +1,326 @@
+package bacnet
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/objects"
+)
+
+// Quality of a polled value.
+const (
+	// QualityGood is the quality of a value read by the last poll.
+	QualityGood uint8 = iota
+	// QualityStale is the quality of a value the last poll failed to
+	// refresh, or of a point not read yet.
+	QualityStale
+	// QualityCommFail is the quality of the values of a device which failed
+	// to answer FailThreshold polls in a row.
+	QualityCommFail
+)
+
+// Default values of the Poller settings.
+const (
+	DefaultFailThreshold = 3
+	DefaultMaxBackoff    = 5 * time.Minute
+	DefaultPollInterval  = time.Minute
+)
+
+// Point is a property polled every Interval, DefaultPollInterval when left
+// zero, from a device. Changes of numeric values by at most Deadband are
+// ignored.
+type Point struct {
+	Device   DeviceRecord
+	Ref      objects.DeviceObjectPropertyReference
+	Interval time.Duration
+	Deadband float64
+}
+
+// PointValue is the last known value of a point, decoded by
+// objects.DecAppValue, along with its quality and the time it was read at.
+// Err holds the error met by the last poll, if any.
+type PointValue struct {
+	Value   interface{}
+	Quality uint8
+	Time    time.Time
+	Err     error
+}
+
+// PointHandler is called with the identifier of a point and its value when
+// the value changes by more than the deadband of the point, or its quality
+// changes.
+type PointHandler func(id int, v PointValue)
+
+// Poller reads points periodically. The points of a device sharing the same
+// interval are read together, with ReadPropertyMultiple where possible, and
+// the devices polled at the same interval are given evenly spread start
+// times so that they aren't all read at once. A device which stops answering
+// is polled again after a delay doubling with each failure, up to MaxBackoff,
+// and its points turn to QualityCommFail after FailThreshold failures.
+type Poller struct {
+	FailThreshold int
+	MaxBackoff    time.Duration
+
+	client  *Client
+	handler PointHandler
+	points  []*polledPoint
+
+	mu sync.Mutex
+}
+
+type polledPoint struct {
+	Point
+	value PointValue
+	read  bool
+}
+
+// pollGroup gathers the points of a device read by the same request.
+type pollGroup struct {
+	interval time.Duration
+	ids      []int
+	next     time.Time
+}
+
+// NewPoller creates a Poller reading through c and calling h with the
+// changes of the points. h may be called concurrently for points of
+// different devices.
+func NewPoller(c *Client, h PointHandler) *Poller {
+	return &Poller{
+		FailThreshold: DefaultFailThreshold,
+		MaxBackoff:    DefaultMaxBackoff,
+		client:        c,
+		handler:       h,
+	}
+}
+
+// Add adds the point pt, which must be done before Run, and returns its
+// identifier.
+func (p *Poller) Add(pt Point) int {
+	if pt.Interval <= 0 {
+		pt.Interval = DefaultPollInterval
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.points = append(p.points, &polledPoint{
+		Point: pt,
+		value: PointValue{Quality: QualityStale},
+	})
+	return len(p.points) - 1
+}
+
+// Value returns the last known value of the point id.
+func (p *Poller) Value(id int) PointValue {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.points[id].value
+}
+
+// Run polls the points until ctx is done, and returns ctx.Err().
+func (p *Poller) Run(ctx context.Context) error {
+	devices := map[string]DeviceRecord{}
+	groups := map[string][]*pollGroup{}
+	byInterval := map[time.Duration][]*pollGroup{}
+
+	p.mu.Lock()
+	for id, pt := range p.points {
+		key := pt.Device.bindingKey()
+		devices[key] = pt.Device
+
+		var g *pollGroup
+		for _, dg := range groups[key] {
+			if dg.interval == pt.Interval {
+				g = dg
+			}
+		}
+		if g == nil {
+			g = &pollGroup{interval: pt.Interval}
+			groups[key] = append(groups[key], g)
+			byInterval[pt.Interval] = append(byInterval[pt.Interval], g)
+		}
+		g.ids = append(g.ids, id)
+	}
+	p.mu.Unlock()
+
+	start := time.Now()
+	for interval, gs := range byInterval {
+		for i, g := range gs {
+			g.next = start.Add(interval * time.Duration(i) / time.Duration(len(gs)))
+		}
+	}
+
+	var wg sync.WaitGroup
+	for key, d := range devices {
+		wg.Add(1)
+		go func(d DeviceRecord, gs []*pollGroup) {
+			defer wg.Done()
+			p.pollDevice(ctx, d, gs)
+		}(d, groups[key])
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// pollDevice polls the groups of points of the device d until ctx is done.
+func (p *Poller) pollDevice(ctx context.Context, d DeviceRecord, groups []*pollGroup) {
+	b := NewBatchReader(p.client, d)
+	failures := 0
+
+	for {
+		next := groups[0].next
+		for _, g := range groups[1:] {
+			if g.next.Before(next) {
+				next = g.next
+			}
+		}
+		if err := sleepContext(ctx, time.Until(next)); err != nil {
+			return
+		}
+
+		now := time.Now()
+		var due []*pollGroup
+		var ids []int
+		var refs []objects.DeviceObjectPropertyReference
+		for _, g := range groups {
+			if !g.next.After(now) {
+				due = append(due, g)
+				for _, id := range g.ids {
+					ids = append(ids, id)
+					refs = append(refs, p.points[id].Ref)
+				}
+			}
+		}
+
+		results, err := b.Read(ctx, refs)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			failures++
+			quality := QualityStale
+			if failures >= p.FailThreshold {
+				quality = QualityCommFail
+			}
+			for _, id := range ids {
+				p.fail(id, quality, err)
+			}
+			for _, g := range due {
+				g.next = now.Add(p.backoff(g.interval, failures))
+			}
+			continue
+		}
+
+		failures = 0
+		for i, id := range ids {
+			if results[i].Err != nil {
+				p.fail(id, QualityStale, results[i].Err)
+				continue
+			}
+			value, err := decPointValue(results[i].Value)
+			if err != nil {
+				p.fail(id, QualityStale, err)
+				continue
+			}
+			p.update(id, PointValue{Value: value, Quality: QualityGood, Time: now})
+		}
+		for _, g := range due {
+			g.next = g.next.Add(g.interval)
+			if g.next.Before(now) {
+				// Polls were missed: don't try to catch up.
+				g.next = now.Add(g.interval)
+			}
+		}
+	}
+}
+
+// backoff returns the delay before polling again a device which failed to
+// answer failures times in a row.
+func (p *Poller) backoff(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 1; i < failures && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// fail keeps the value of the point id, with quality and err.
+func (p *Poller) fail(id int, quality uint8, err error) {
+	p.mu.Lock()
+	v := p.points[id].value
+	p.mu.Unlock()
+
+	v.Quality = quality
+	v.Err = err
+	p.update(id, v)
+}
+
+// update sets the value of the point id to v, calling the handler when it
+// changed.
+func (p *Poller) update(id int, v PointValue) {
+	p.mu.Lock()
+	pt := p.points[id]
+	old := pt.value
+	first := v.Quality == QualityGood && !pt.read
+	if v.Quality == QualityGood {
+		pt.read = true
+	}
+
+	notify := first || old.Quality != v.Quality ||
+		(v.Quality == QualityGood && valueChanged(old.Value, v.Value, pt.Deadband))
+	if notify || v.Quality != QualityGood {
+		pt.value = v
+	} else {
+		// Keep the value the deadband is measured from.
+		pt.value.Time = v.Time
+	}
+	p.mu.Unlock()
+
+	if notify && p.handler != nil {
+		p.handler(id, v)
+	}
+}
+
+// decPointValue decodes a property value read as application tagged
+// objects, which are kept as they are when there are several of them.
+func decPointValue(value []objects.APDUPayload) (interface{}, error) {
+	if len(value) != 1 {
+		return value, nil
+	}
+	return objects.DecAppValue(value[0])
+}
+
+// valueChanged tells whether a value changed from old to new, numeric values
+// having to change by more than deadband.
+func valueChanged(old, new interface{}, deadband float64) bool {
+	o, oNum := toFloat(old)
+	n, nNum := toFloat(new)
+	if oNum && nNum {
+		return math.Abs(n-o) > deadband
+	}
+	return !reflect.DeepEqual(old, new)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case uint32:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
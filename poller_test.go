@@ -0,0 +1,150 @@
+package bacnet_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+// presentValue returns the Point of the Present_Value of AV-0 of the device
+// d, polled every interval with deadband.
+func presentValue(d bacnet.DeviceRecord, interval time.Duration, deadband float64) bacnet.Point {
+	return bacnet.Point{
+		Device:   d,
+		Ref:      presentValues(0)[0],
+		Interval: interval,
+		Deadband: deadband,
+	}
+}
+
+// nextChange returns the next value passed to the PointHandler sending to
+// changes, failing the test when none comes within a second.
+func nextChange(t *testing.T, changes <-chan bacnet.PointValue) bacnet.PointValue {
+	t.Helper()
+	select {
+	case v := <-changes:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no change received")
+		return bacnet.PointValue{}
+	}
+}
+
+func TestPollerDeadband(t *testing.T) {
+	db := newBatchDatabase(t, 1)
+	s, addr := newTestServer(t)
+	bacnet.ServeDatabase(s, db, nil)
+
+	changes := make(chan bacnet.PointValue, 16)
+	p := bacnet.NewPoller(newTestClient(t), func(id int, v bacnet.PointValue) { changes <- v })
+	id := p.Add(presentValue(bacnet.DeviceRecord{DeviceId: 1, Addr: addr}, 20*time.Millisecond, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	if v := nextChange(t, changes); v.Quality != bacnet.QualityGood || v.Value != float32(0) {
+		t.Errorf("got %+v, want a good 0", v)
+	}
+
+	// Changes within the deadband aren't reported, and the deadband is
+	// measured from the last value reported rather than the last one read.
+	for _, value := range []float32{0.6, 1} {
+		setProperty(t, db, objects.ObjectTypeAnalogValue, 0, objects.PropertyIdPresentValue, objects.EncReal(value))
+		select {
+		case v := <-changes:
+			t.Fatalf("unexpected change %+v", v)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if v := p.Value(id); v.Quality != bacnet.QualityGood || v.Value != float32(0) {
+		t.Errorf("got %+v, want a good 0", v)
+	}
+	setProperty(t, db, objects.ObjectTypeAnalogValue, 0, objects.PropertyIdPresentValue, objects.EncReal(1.5))
+	if v := nextChange(t, changes); v.Quality != bacnet.QualityGood || v.Value != float32(1.5) {
+		t.Errorf("got %+v, want a good 1.5", v)
+	}
+}
+
+func TestPollerFailures(t *testing.T) {
+	db := newBatchDatabase(t, 1)
+	s, addr := newTestServer(t)
+	s.Handle(services.ServiceConfirmedReadPropMultiple, func(req bacnet.Request) ([]byte, error) {
+		return nil, objects.NewRejectError(objects.RejectReasonUnrecognizedService)
+	})
+	// The device stops answering while down, the polls it gets being sent to
+	// polls and left unanswered until the test ends.
+	var down atomic.Bool
+	polls := make(chan time.Time, 64)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	s.Handle(services.ServiceConfirmedReadProperty, func(req bacnet.Request) ([]byte, error) {
+		if down.Load() {
+			polls <- time.Now()
+			<-release
+			return nil, objects.NewAbortError(objects.AbortReasonOther, true)
+		}
+		value, err := db.ReadProperty(objects.ObjectTypeAnalogValue, 0, objects.PropertyIdPresentValue, objects.ArrayAll)
+		if err != nil {
+			return nil, err
+		}
+		return bacnet.NewReadPropertyACK(req.InvokeID, objects.ObjectTypeAnalogValue, 0, objects.PropertyIdPresentValue, objects.ArrayAll, value)
+	})
+
+	client := newTestClient(t)
+	client.APDUTimeout = 10 * time.Millisecond
+	client.APDURetries = 0
+	changes := make(chan bacnet.PointValue, 16)
+	p := bacnet.NewPoller(client, func(id int, v bacnet.PointValue) { changes <- v })
+	p.MaxBackoff = 160 * time.Millisecond
+	interval := 20 * time.Millisecond
+	p.Add(presentValue(bacnet.DeviceRecord{DeviceId: 1, Addr: addr}, interval, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	if v := nextChange(t, changes); v.Quality != bacnet.QualityGood {
+		t.Fatalf("got %+v, want a good value", v)
+	}
+
+	// Devices which don't answer are polled again after a delay doubling with
+	// each failure, up to MaxBackoff, counted from the start of the failed
+	// poll. Their points turn stale then to QualityCommFail, keeping their
+	// last value.
+	down.Store(true)
+	nextPoll := func() time.Time {
+		t.Helper()
+		select {
+		case poll := <-polls:
+			return poll
+		case <-time.After(time.Second):
+			t.Fatal("no poll received")
+			return time.Time{}
+		}
+	}
+	last := nextPoll()
+	for i, backoff := range []time.Duration{interval, 2 * interval, 4 * interval, 8 * interval, 8 * interval} {
+		// Polls are timed as they reach the device, give or take a few
+		// milliseconds.
+		poll := nextPoll()
+		if gap := poll.Sub(last); gap < backoff-5*time.Millisecond || gap > backoff+100*time.Millisecond {
+			t.Errorf("poll %d came %v after the previous one, want %v", i+1, gap, backoff)
+		}
+		last = poll
+	}
+	for _, quality := range []uint8{bacnet.QualityStale, bacnet.QualityCommFail} {
+		if v := nextChange(t, changes); v.Quality != quality || v.Value != float32(0) || v.Err == nil {
+			t.Errorf("got %+v, want 0 of quality %d with an error", v, quality)
+		}
+	}
+
+	// Their points turn good again once they answer.
+	down.Store(false)
+	if v := nextChange(t, changes); v.Quality != bacnet.QualityGood || v.Err != nil {
+		t.Errorf("got %+v, want a good value", v)
+	}
+}
@@ -0,0 +1,95 @@
+package bacnet
+
+import (
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// NewConfirmedPrivateTransfer builds a ConfirmedPrivateTransfer request of the
+// service serviceNumber of vendor vendorId carrying payload, encoded by the
+// codec registered in services.PrivateTransfers.
+func NewConfirmedPrivateTransfer(vendorId uint16, serviceNumber uint32, payload interface{}) ([]byte, error) {
+	objs, err := services.PrivateTransfers.ParametersObjects(vendorId, serviceNumber, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedPrivateTransfer(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = objs
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewUnconfirmedPrivateTransfer builds an UnconfirmedPrivateTransfer request
+// of the service serviceNumber of vendor vendorId carrying payload, encoded by
+// the codec registered in services.PrivateTransfers.
+func NewUnconfirmedPrivateTransfer(vendorId uint16, serviceNumber uint32, payload interface{}) ([]byte, error) {
+	objs, err := services.PrivateTransfers.ParametersObjects(vendorId, serviceNumber, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	u := services.NewUnconfirmedPrivateTransfer(bvlc, npdu)
+	u.APDU.Objects = objs
+
+	u.SetLength()
+
+	return u.MarshalBinary()
+}
+
+// NewPrivateTransferACK serves the ConfirmedPrivateTransfer request req,
+// identified by invokeID, with the handler registered in
+// services.PrivateTransfers and builds the acknowledgement. The error returned
+// when the request can't be served can be turned into a reply with
+// NewPrivateTransferError.
+func NewPrivateTransferACK(invokeID uint8, req services.PrivateTransferDec) ([]byte, error) {
+	objs, err := services.PrivateTransfers.Serve(req)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	c := services.NewConfirmedPrivateTransferACK(bvlc, npdu)
+	c.APDU.InvokeID = invokeID
+	c.APDU.Objects = objs
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewPrivateTransferError answers the ConfirmedPrivateTransfer request req,
+// identified by invokeID, with the Error PDU matching err. Errors other than
+// *objects.BACnetError are reported as generic device errors.
+func NewPrivateTransferError(invokeID uint8, req services.PrivateTransferDec, err error) ([]byte, error) {
+	bacErr := objects.NewBACnetError(objects.ErrorClassDevice, objects.ErrorCodeOther)
+	errors.As(err, &bacErr)
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	e := services.NewError(bvlc, npdu)
+
+	e.APDU.Service = services.ServiceConfirmedPrivateTransfer
+	e.APDU.InvokeID = invokeID
+	e.APDU.Objects = services.PrivateTransferErrorObjects(bacErr.Class, bacErr.Code, req.VendorId, req.ServiceNumber, nil)
+
+	e.SetLength()
+
+	return e.MarshalBinary()
+}
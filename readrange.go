@@ -0,0 +1,83 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// ReadLogBuffer reads every record of the Log_Buffer of the Trend Log object
+// instanceNumber held by the device at addr, pageSize records per ReadRange
+// request. Paging relies on sequence numbers when the device reports them, so
+// that records added or dropped while reading don't shift the pages, and on
+// positions otherwise.
+func (c *Client) ReadLogBuffer(ctx context.Context, addr net.Addr, objectType uint16, instanceNumber uint32, pageSize int32) ([]services.LogRecord, error) {
+	if pageSize <= 0 {
+		return nil, errors.Wrap(common.ErrWrongStructure, fmt.Sprintf("page size %d must be positive", pageSize))
+	}
+
+	records := []services.LogRecord{}
+
+	var nextSeq uint32
+	bySeq := false
+	for {
+		msg := services.NewConfirmedReadRange(
+			plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+			plumbing.NewNPDU(false, false, false, true),
+		)
+		msg.APDU.MaxSize = 5
+		if bySeq {
+			msg.APDU.Objects = services.ConfirmedReadRangeBySequenceNumberObjects(
+				objectType, instanceNumber, objects.PropertyIdLogBuffer, nextSeq, pageSize)
+		} else {
+			msg.APDU.Objects = services.ConfirmedReadRangeByPositionObjects(
+				objectType, instanceNumber, objects.PropertyIdLogBuffer, uint32(len(records)+1), pageSize)
+		}
+		msg.SetLength()
+
+		req, err := msg.MarshalBinary()
+		if err != nil {
+			return records, errors.Wrap(err, "failed to build ReadRange")
+		}
+
+		reply, err := c.Request(ctx, addr, req)
+		if err != nil {
+			return records, errors.Wrap(err, "ReadRange failed")
+		}
+
+		r, ok := reply.(*services.ReadRangeACK)
+		if !ok {
+			return records, errors.Wrap(common.ErrWrongPayload, "unexpected ReadRange reply")
+		}
+		ack, err := r.Decode()
+		if err != nil {
+			return records, errors.Wrap(err, "failed to decode ReadRange reply")
+		}
+
+		page, err := services.DecLogRecords(ack.ItemData)
+		if err != nil {
+			return records, errors.Wrap(err, "failed to decode Log_Buffer")
+		}
+		if uint32(len(page)) != ack.ItemCount {
+			return records, errors.Wrap(
+				common.ErrWrongObjectCount,
+				fmt.Sprintf("ReadRange reply announces %d items and carries %d", ack.ItemCount, len(page)),
+			)
+		}
+		records = append(records, page...)
+
+		if !ack.MoreItems || len(page) == 0 {
+			return records, nil
+		}
+		if ack.SequenceNumbered {
+			bySeq = true
+			nextSeq = ack.FirstSequenceNumber + ack.ItemCount
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// DefaultBindingTTL is how long a Resolver trusts an address binding before
+// asking the device for it again.
+const DefaultBindingTTL = 10 * time.Minute
+
+// Resolver is an address binding cache in front of a Client, letting requests
+// be addressed to device instances. A device missing from the cache, or whose
+// binding expired after TTL, is looked up with a Who-Is sent to Addr, usually
+// the broadcast address, limited to its instance. Every I-Am the Client hears
+// updates the cache, and a request which times out has its device looked up
+// again and is retried once if it moved. A zero TTL keeps bindings until they
+// are forgotten.
+type Resolver struct {
+	TTL  time.Duration
+	Addr net.Addr
+
+	client   *Client
+	bindings map[uint32]binding
+	waiting  map[uint32][]chan DeviceRecord
+	stop     func()
+
+	mu sync.Mutex
+}
+
+type binding struct {
+	record  DeviceRecord
+	expires time.Time
+}
+
+// NewResolver creates a Resolver looking devices up through c with Who-Is
+// requests sent to addr.
+func NewResolver(c *Client, addr net.Addr) *Resolver {
+	r := &Resolver{
+		TTL:      DefaultBindingTTL,
+		Addr:     addr,
+		client:   c,
+		bindings: map[uint32]binding{},
+		waiting:  map[uint32][]chan DeviceRecord{},
+	}
+	r.stop = c.Listen(r.hear)
+
+	return r
+}
+
+// Close stops the Resolver from listening to I-Am replies. Its Client is left
+// open.
+func (r *Resolver) Close() {
+	r.stop()
+}
+
+// Bind adds a binding to the cache, such as the ones found by Discover.
+func (r *Resolver) Bind(d DeviceRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bind(d)
+}
+
+// Lookup returns the binding of the device deviceId if the cache holds one
+// which hasn't expired.
+func (r *Resolver) Lookup(deviceId uint32) (DeviceRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.bindings[deviceId]
+	if !ok || (!b.expires.IsZero() && time.Now().After(b.expires)) {
+		return DeviceRecord{}, false
+	}
+	return b.record, true
+}
+
+// Forget removes the binding of the device deviceId from the cache.
+func (r *Resolver) Forget(deviceId uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.bindings, deviceId)
+}
+
+// Resolve returns the binding of the device deviceId, sending Who-Is
+// requests for it when the cache doesn't hold one. They are sent again every
+// APDUTimeout of the Client, up to APDURetries times, or share the time left
+// until the deadline of ctx if it has one.
+func (r *Resolver) Resolve(ctx context.Context, deviceId uint32) (DeviceRecord, error) {
+	if d, ok := r.Lookup(deviceId); ok {
+		return d, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return DeviceRecord{}, err
+	}
+
+	found := make(chan DeviceRecord, 1)
+	r.mu.Lock()
+	r.waiting[deviceId] = append(r.waiting[deviceId], found)
+	r.mu.Unlock()
+	defer r.unwait(deviceId, found)
+
+	req, err := NewWhoIsRange(deviceId, deviceId)
+	if err != nil {
+		return DeviceRecord{}, err
+	}
+
+	for attempt := 0; attempt <= r.client.APDURetries; attempt++ {
+		if err := r.client.Send(ctx, r.Addr, req); err != nil {
+			return DeviceRecord{}, err
+		}
+
+		timer := attemptTimer(ctx, r.client.APDUTimeout, r.client.APDURetries, attempt)
+		select {
+		case d := <-found:
+			stopTimer(timer)
+			return d, nil
+		case <-ctx.Done():
+			stopTimer(timer)
+			return DeviceRecord{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return DeviceRecord{}, errors.Wrap(
+		common.ErrTimeout,
+		fmt.Sprintf("no I-Am from device %d", deviceId),
+	)
+}
+
+// Request sends the confirmed request req to the device deviceId, through
+// the router it sits behind if any, and returns the reply like
+// Client.Request. When ctx has a deadline, the first request is given half
+// of the time left so that the other half is kept for looking the device up
+// again and retrying.
+func (r *Resolver) Request(ctx context.Context, deviceId uint32, req []byte) (plumbing.BACnet, error) {
+	d, err := r.Resolve(ctx, deviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	first := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		first, cancel = context.WithTimeout(ctx, time.Until(deadline)/2)
+		defer cancel()
+	}
+	reply, err := r.client.RequestDevice(first, d, req)
+	if errors.Cause(err) == context.DeadlineExceeded && ctx.Err() == nil {
+		err = common.ErrTimeout
+	}
+	if errors.Cause(err) != common.ErrTimeout {
+		return reply, err
+	}
+
+	// The device may have been given another address: look it up again and
+	// retry if so.
+	r.Forget(deviceId)
+	moved, rErr := r.Resolve(ctx, deviceId)
+	if rErr != nil || moved.bindingKey() == d.bindingKey() {
+		return nil, err
+	}
+	return r.client.RequestDevice(ctx, moved, req)
+}
+
+// ReadProperty reads the property propertyId of an object of the device
+// deviceId.
+func (r *Resolver) ReadProperty(ctx context.Context, deviceId uint32, objectType uint16, instN uint32, propertyId uint8) (services.ComplexACKDec, error) {
+	req, err := NewReadProperty(objectType, instN, propertyId)
+	if err != nil {
+		return services.ComplexACKDec{}, err
+	}
+
+	reply, err := r.Request(ctx, deviceId, req)
+	if err != nil {
+		return services.ComplexACKDec{}, err
+	}
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		return services.ComplexACKDec{}, errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected ReadProperty reply %T", reply),
+		)
+	}
+	return cack.Decode()
+}
+
+// WriteProperty writes value, application tagged objects, at priority to the
+// property propertyId of an object of the device deviceId. Writing a NULL value
+// relinquishes the priority of a commandable property.
+func (r *Resolver) WriteProperty(ctx context.Context, deviceId uint32, objectType uint16, instN uint32, propertyId uint8, value []objects.APDUPayload, priority uint8) error {
+	req, err := NewWritePropertyValue(services.WritePropertyValue{
+		ObjectType: objectType,
+		InstanceId: instN,
+		PropertyId: propertyId,
+		ArrayIndex: objects.ArrayAll,
+		Value:      value,
+		Priority:   priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	reply, err := r.Request(ctx, deviceId, req)
+	if err != nil {
+		return err
+	}
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		return errors.Wrap(
+			common.ErrWrongStructure,
+			fmt.Sprintf("unexpected WriteProperty reply %T", reply),
+		)
+	}
+	return nil
+}
+
+// hear updates the cache with the I-Am replies heard by the Client.
+func (r *Resolver) hear(src net.Addr, msg plumbing.BACnet) {
+	iAm, ok := msg.(*services.UnconfirmedIAm)
+	if !ok {
+		return
+	}
+	d, err := NewDeviceRecord(src, iAm)
+	if err != nil {
+		log.Printf("resolver ignoring I-Am from %s: %v\n", src, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bind(d)
+	for _, found := range r.waiting[d.DeviceId] {
+		select {
+		case found <- d:
+		default:
+		}
+	}
+}
+
+func (r *Resolver) bind(d DeviceRecord) {
+	b := binding{record: d}
+	if r.TTL > 0 {
+		b.expires = time.Now().Add(r.TTL)
+	}
+	r.bindings[d.DeviceId] = b
+}
+
+func (r *Resolver) unwait(deviceId uint32, found chan DeviceRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	waiting := r.waiting[deviceId]
+	for i, w := range waiting {
+		if w == found {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(waiting) == 0 {
+		delete(r.waiting, deviceId)
+	} else {
+		r.waiting[deviceId] = waiting
+	}
+}
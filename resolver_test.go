@@ -0,0 +1,126 @@
+package bacnet_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+)
+
+func TestResolverCache(t *testing.T) {
+	peer := newTestPeer(t)
+	r := bacnet.NewResolver(newTestClient(t), peer.conn.LocalAddr())
+	defer r.Close()
+
+	resolve := func() <-chan error {
+		errs := make(chan error, 1)
+		go func() {
+			d, err := r.Resolve(context.Background(), 7)
+			if err == nil && d.Addr.String() != peer.conn.LocalAddr().String() {
+				t.Errorf("device 7 resolved at %s, want %s", d.Addr, peer.conn.LocalAddr())
+			}
+			errs <- err
+		}()
+		return errs
+	}
+
+	errs := resolve()
+	w := peer.nextWhoIs(t)
+	if !w.Limited || w.LowLimit != 7 || w.HighLimit != 7 {
+		t.Errorf("got Who-Is %+v, want one limited to device 7", w.UnconfirmedWhoIsDec)
+	}
+	peer.iAm(t, 7, w.src)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	// The binding is now cached.
+	if err := <-resolve(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-peer.whoIs:
+		t.Error("Who-Is sent for a cached device")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestResolverTTL(t *testing.T) {
+	peer := newTestPeer(t)
+	r := bacnet.NewResolver(newTestClient(t), peer.conn.LocalAddr())
+	defer r.Close()
+	r.TTL = 100 * time.Millisecond
+
+	r.Bind(bacnet.DeviceRecord{DeviceId: 7, Addr: peer.conn.LocalAddr()})
+	if _, ok := r.Lookup(7); !ok {
+		t.Fatal("device 7 missing right after being bound")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, ok := r.Lookup(7); ok {
+		t.Fatal("device 7 still bound after its TTL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.Resolve(ctx, 7); err == nil {
+		t.Error("expired binding resolved without an I-Am")
+	}
+	peer.nextWhoIs(t)
+}
+
+func TestResolverMoved(t *testing.T) {
+	cases := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"retries", 0},
+		{"deadline", time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			old, moved := newTestPeer(t), newTestPeer(t)
+			client := newTestClient(t)
+			client.APDUTimeout = 50 * time.Millisecond
+			client.APDURetries = 0
+
+			r := bacnet.NewResolver(client, moved.conn.LocalAddr())
+			defer r.Close()
+			r.Bind(bacnet.DeviceRecord{DeviceId: 7, Addr: old.conn.LocalAddr()})
+
+			ctx := context.Background()
+			if c.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.timeout)
+				defer cancel()
+			}
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- r.WriteProperty(ctx, 7, objects.ObjectTypeAnalogOutput, 0, objects.PropertyIdPresentValue,
+					[]objects.APDUPayload{objects.EncReal(1)}, 16)
+			}()
+
+			// The device doesn't answer at its old address anymore: it is
+			// looked up again and the request retried at its new one.
+			old.next(t)
+			w := moved.nextWhoIs(t)
+			moved.iAm(t, 7, w.src)
+			moved.ack(t, moved.next(t))
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+
+			d, ok := r.Lookup(7)
+			if !ok {
+				t.Fatal("device 7 missing after moving")
+			}
+			if d.Addr.String() != moved.conn.LocalAddr().String() {
+				t.Errorf("device 7 bound to %s, want %s", d.Addr, moved.conn.LocalAddr())
+			}
+		})
+	}
+}
@@ -0,0 +1,463 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// servicesSupportedLen is the number of bits of BACnetServicesSupported.
+const servicesSupportedLen = 49
+
+// Positions in BACnetServicesSupported of the services whose choice isn't
+// their position.
+var (
+	confirmedServiceBits = map[uint8]int{
+		services.ServiceConfirmedReadRange:                    35,
+		services.ServiceConfirmedLifeSafetyOperation:          37,
+		services.ServiceConfirmedSubscribeCOVProperty:         38,
+		services.ServiceConfirmedGetEventInformation:          39,
+		services.ServiceConfirmedSubscribeCOVPropertyMultiple: 41,
+		services.ServiceConfirmedCOVNotificationMultiple:      42,
+		services.ServiceConfirmedAuditNotification:            44,
+		services.ServiceConfirmedAuditLogQuery:                45,
+	}
+	unconfirmedServiceBits = []int{26, 27, 28, 29, 30, 31, 32, 33, 34, 36, 40, 43, 46, 47, 48}
+)
+
+// Request is a confirmed request received by a Server from Src, Msg being
+// the parsed message.
+type Request struct {
+	Src      net.Addr
+	Msg      plumbing.BACnet
+	InvokeID uint8
+	Service  uint8
+}
+
+// Handler serves the confirmed requests of a service, returning the reply to
+// req built for its invoke ID, with NewSimpleACKReply for instance. The
+// errors returned are answered with NewErrorReply, except for
+// *objects.RejectError and *objects.AbortError which are answered with a
+// Reject and an Abort.
+type Handler func(req Request) ([]byte, error)
+
+// UnconfirmedHandler serves the unconfirmed requests of a service.
+type UnconfirmedHandler func(src net.Addr, msg plumbing.BACnet)
+
+// Server is a BACnet/IP device owning a socket. It answers the Who-Is
+// requests matching DeviceId with an I-Am, broadcast to BroadcastAddr when
+// set and sent back to the device asking otherwise, and passes the other
+// requests to the handlers registered for their service. Confirmed requests
+// without a handler are rejected, as are the ones which can't be parsed, and
+// segmented requests are aborted, so that any request gets an answer.
+// Requests are served concurrently.
+//
+// The confirmed requests a Server sends itself, such as notifications, are
+// sent again when no reply comes within APDUTimeout, up to APDURetries times.
+type Server struct {
+	DeviceId      uint32
+	VendorId      uint16
+	BroadcastAddr net.Addr
+	APDUTimeout   time.Duration
+	APDURetries   int
+
+	conn        net.PacketConn
+	confirmed   map[uint8]Handler
+	unconfirmed map[uint8]UnconfirmedHandler
+	peers       map[string]*peer
+
+	mu sync.RWMutex
+}
+
+// NewServer creates the Server of the device deviceId, serving on conn which
+// is closed with the Server.
+func NewServer(conn net.PacketConn, deviceId uint32, vendorId uint16) *Server {
+	return &Server{
+		DeviceId:    deviceId,
+		VendorId:    vendorId,
+		APDUTimeout: DefaultAPDUTimeout,
+		APDURetries: DefaultAPDURetries,
+		conn:        conn,
+		confirmed:   map[uint8]Handler{},
+		unconfirmed: map[uint8]UnconfirmedHandler{},
+		peers:       map[string]*peer{},
+	}
+}
+
+// Close closes the socket of the Server. Outstanding requests fail.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	for _, p := range s.peers {
+		for _, reply := range p.transactions {
+			close(reply)
+		}
+	}
+	s.peers = map[string]*peer{}
+	s.mu.Unlock()
+
+	return s.conn.Close()
+}
+
+// Handle registers h to serve the confirmed requests of service.
+func (s *Server) Handle(service uint8, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.confirmed[service] = h
+}
+
+// HandleUnconfirmed registers h to serve the unconfirmed requests of
+// service. Who-Is requests are answered by the Server before reaching h.
+func (s *Server) HandleUnconfirmed(service uint8, h UnconfirmedHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unconfirmed[service] = h
+}
+
+// ServicesSupported returns the Protocol_Services_Supported of the device:
+// Who-Is and the services handlers are registered for.
+func (s *Server) ServicesSupported() []bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bits := make([]bool, servicesSupportedLen)
+	bits[unconfirmedServiceBits[services.ServiceUnconfirmedWhoIs]] = true
+	for service := range s.confirmed {
+		if bit, ok := confirmedServiceBits[service]; ok {
+			bits[bit] = true
+		} else if int(service) < servicesSupportedLen {
+			bits[service] = true
+		}
+	}
+	for service := range s.unconfirmed {
+		if int(service) < len(unconfirmedServiceBits) {
+			bits[unconfirmedServiceBits[service]] = true
+		}
+	}
+	return bits
+}
+
+// Announce broadcasts an I-Am to BroadcastAddr, if set.
+func (s *Server) Announce() error {
+	if s.BroadcastAddr == nil {
+		return nil
+	}
+	return s.sendIAm(s.BroadcastAddr)
+}
+
+// Send sends the unconfirmed request req to dst.
+func (s *Server) Send(dst net.Addr, req []byte) error {
+	if _, err := s.conn.WriteTo(req, dst); err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	return nil
+}
+
+// Request sends the confirmed request req to dst, under an invoke ID of its
+// own, and returns the reply like Client.Request. Replies are received by
+// Serve, which must be running.
+func (s *Server) Request(ctx context.Context, dst net.Addr, req []byte) (plumbing.BACnet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key := dst.String()
+	s.mu.Lock()
+	p, ok := s.peers[key]
+	if !ok {
+		p = &peer{transactions: map[uint8]chan plumbing.BACnet{}}
+		s.peers[key] = p
+	}
+	if len(p.transactions) >= 256 {
+		s.mu.Unlock()
+		return nil, errors.Wrap(common.ErrNoInvokeID, fmt.Sprintf("request to %s", key))
+	}
+	invokeID, reply := p.allocate()
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if p, ok := s.peers[key]; ok {
+			delete(p.transactions, invokeID)
+			if len(p.transactions) == 0 {
+				delete(s.peers, key)
+			}
+		}
+	}()
+
+	req = append([]byte{}, req...)
+	if err := setInvokeID(req, invokeID); err != nil {
+		return nil, err
+	}
+
+	msg, err := transact(ctx, s.conn, dst, req, reply, s.APDUTimeout, s.APDURetries)
+	if errors.Cause(err) == common.ErrTimeout {
+		return nil, errors.Wrap(err, fmt.Sprintf("request %d to %s", invokeID, key))
+	}
+	return msg, err
+}
+
+// Serve announces the device when BroadcastAddr is set, then serves the
+// requests received until ctx is done, returning ctx.Err(), or the socket
+// fails.
+func (s *Server) Serve(ctx context.Context) error {
+	if err := s.Announce(); err != nil {
+		return err
+	}
+
+	// Unblock the read below once ctx is done.
+	if err := s.conn.SetReadDeadline(time.Time{}); err != nil {
+		return errors.Wrap(err, "failed to clear read deadline")
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.conn.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	buf := make([]byte, maxBIPFrame)
+	for {
+		nBytes, src, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Wrap(err, "failed to read request")
+		}
+
+		frame := append([]byte{}, buf[:nBytes]...)
+		go s.serve(src, frame)
+	}
+}
+
+// serve answers the request b received from src.
+func (s *Server) serve(src net.Addr, b []byte) {
+	pduType, invokeID, err := apduHeader(b)
+	if err != nil {
+		log.Printf("server ignoring message from %s: %v\n", src, err)
+		return
+	}
+
+	switch pduType {
+	case plumbing.UnConfirmedReq:
+		if err := s.serveUnconfirmed(src, b); err != nil {
+			log.Printf("server ignoring message from %s: %v\n", src, err)
+		}
+	case plumbing.ConfirmedReq:
+		reply, err := s.serveConfirmed(src, b, invokeID)
+		if err != nil {
+			log.Printf("server failed to answer request %d from %s: %v\n", invokeID, src, err)
+			return
+		}
+		if err := s.send(src, b, reply); err != nil {
+			log.Printf("server failed to answer request %d from %s: %v\n", invokeID, src, err)
+		}
+	case plumbing.SimpleAck, plumbing.ComplexAck, plumbing.Error, plumbing.Reject, plumbing.Abort:
+		s.deliver(src, b, invokeID)
+	}
+}
+
+// deliver passes the reply b, received from src, to the request it answers.
+func (s *Server) deliver(src net.Addr, b []byte, invokeID uint8) {
+	msg, err := Parse(b)
+	if err != nil {
+		log.Printf("server ignoring reply from %s: %v\n", src, err)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if p, ok := s.peers[src.String()]; ok {
+		if reply, ok := p.transactions[invokeID]; ok {
+			select {
+			case reply <- msg:
+			default:
+				// A duplicate reply to a retried request.
+			}
+		}
+	}
+}
+
+// serveUnconfirmed passes the unconfirmed request b, received from src, to
+// its handler.
+func (s *Server) serveUnconfirmed(src net.Addr, b []byte) error {
+	offset, err := apduOffset(b)
+	if err != nil {
+		return err
+	}
+	if offset+1 >= len(b) {
+		return errors.Wrap(common.ErrTooShortToParse, "missing service choice")
+	}
+	service := b[offset+1]
+
+	s.mu.RLock()
+	h, ok := s.unconfirmed[service]
+	s.mu.RUnlock()
+	if !ok && service != services.ServiceUnconfirmedWhoIs {
+		return nil
+	}
+
+	msg, err := Parse(b)
+	if err != nil {
+		return err
+	}
+	if whoIs, isWhoIs := msg.(*services.UnconfirmedWhoIs); isWhoIs {
+		if err := s.answerWhoIs(src, whoIs); err != nil {
+			return errors.Wrap(err, "failed to answer Who-Is")
+		}
+	}
+	if ok {
+		h(src, msg)
+	}
+	return nil
+}
+
+// serveConfirmed returns the reply to the confirmed request b, identified by
+// invokeID.
+func (s *Server) serveConfirmed(src net.Addr, b []byte, invokeID uint8) ([]byte, error) {
+	offset, err := apduOffset(b)
+	if err != nil {
+		return nil, err
+	}
+	if b[offset]&0x08 != 0 {
+		return NewAbortReply(invokeID, objects.AbortReasonSegmentationNotSupported, true)
+	}
+	if offset+3 >= len(b) {
+		return NewRejectReply(invokeID, objects.RejectReasonMissingRequiredParameter)
+	}
+	service := b[offset+3]
+
+	s.mu.RLock()
+	h, ok := s.confirmed[service]
+	s.mu.RUnlock()
+	if !ok {
+		return NewRejectReply(invokeID, objects.RejectReasonUnrecognizedService)
+	}
+
+	msg, err := Parse(b)
+	if err != nil {
+		log.Printf("server rejecting request %d from %s: %v\n", invokeID, src, err)
+		return NewRejectReply(invokeID, objects.RejectReasonInvalidTag)
+	}
+
+	reply, err := callHandler(h, Request{Src: src, Msg: msg, InvokeID: invokeID, Service: service})
+	if err == nil {
+		return reply, nil
+	}
+
+	var rejectErr *objects.RejectError
+	var abortErr *objects.AbortError
+	switch {
+	case errors.As(err, &rejectErr):
+		return NewRejectReply(invokeID, rejectErr.Reason)
+	case errors.As(err, &abortErr):
+		return NewAbortReply(invokeID, abortErr.Reason, true)
+	default:
+		return NewErrorReply(invokeID, service, err)
+	}
+}
+
+// callHandler calls h, turning its panics into an Abort.
+func callHandler(h Handler, req Request) (reply []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("server handler for service %d panicked: %v\n", req.Service, r)
+			reply, err = nil, objects.NewAbortError(objects.AbortReasonOther, true)
+		}
+	}()
+	return h(req)
+}
+
+// answerWhoIs sends an I-Am when the Who-Is w, received from src, matches the
+// device. Without BroadcastAddr, the I-Am is sent back to the device asking,
+// through the router it sits behind if any.
+func (s *Server) answerWhoIs(src net.Addr, w *services.UnconfirmedWhoIs) error {
+	dec, err := w.Decode()
+	if err != nil {
+		return err
+	}
+	if !dec.Matches(s.DeviceId) {
+		return nil
+	}
+
+	if s.BroadcastAddr != nil {
+		return s.sendIAm(s.BroadcastAddr)
+	}
+
+	iAm, err := unicastIAm(s.DeviceId, s.VendorId)
+	if err != nil {
+		return err
+	}
+	if w.NPDU.SNET != 0 {
+		if iAm, err = setDestination(iAm, w.NPDU.SNET, w.NPDU.SADR); err != nil {
+			return err
+		}
+	}
+	if _, err := s.conn.WriteTo(iAm, src); err != nil {
+		return errors.Wrap(err, "failed to send I-Am")
+	}
+	return nil
+}
+
+// unicastIAm builds the I-Am of the device deviceId sent to a single device,
+// without the global broadcast NPDU of NewIAm.
+func unicastIAm(deviceId uint32, vendorId uint16) ([]byte, error) {
+	u := services.NewUnconfirmedIAm(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.IAmObjects(deviceId, DEFAULT_ACCEPTED_SIZE, DEFAULT_SEGMENTATION_SUPPORT, vendorId)
+	u.SetLength()
+
+	return u.MarshalBinary()
+}
+
+func (s *Server) sendIAm(dst net.Addr) error {
+	iAm, err := NewIAm(s.DeviceId, s.VendorId)
+	if err != nil {
+		return err
+	}
+	if _, err := s.conn.WriteTo(iAm, dst); err != nil {
+		return errors.Wrap(err, "failed to send I-Am")
+	}
+	return nil
+}
+
+// send sends reply to the request b received from src, through the router
+// it came from if any.
+func (s *Server) send(src net.Addr, b []byte, reply []byte) error {
+	var bvlc plumbing.BVLC
+	var npdu plumbing.NPDU
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return err
+	}
+	if err := npdu.UnmarshalBinary(b[bvlc.MarshalLen():]); err != nil {
+		return err
+	}
+
+	if npdu.SNET != 0 {
+		routed, err := setDestination(reply, npdu.SNET, npdu.SADR)
+		if err != nil {
+			return err
+		}
+		reply = routed
+	}
+	if _, err := s.conn.WriteTo(reply, src); err != nil {
+		return errors.Wrap(err, "failed to send reply")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package bacnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pierreyves258/bacnet"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// newTestServer creates the Server of device 1 on the loopback interface,
+// serving until the test ends, and returns it along with its address.
+func newTestServer(t *testing.T) (*bacnet.Server, net.Addr) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := bacnet.NewServer(conn, 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	go s.Serve(ctx)
+	return s, conn.LocalAddr()
+}
+
+func TestServerReplies(t *testing.T) {
+	readProperty, err := bacnet.NewReadProperty(objects.ObjectTypeAnalogInput, 0, objects.PropertyIdPresentValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The APDU of the request follows a 4 octets BVLC and a 2 octets NPDU.
+	const apduOffset = 6
+	segmented := append([]byte{}, readProperty...)
+	segmented[apduOffset] |= 0x08
+	// The object identifier of this one is cut short.
+	truncated := append([]byte{}, readProperty[:apduOffset+6]...)
+	truncated[3] = byte(len(truncated))
+
+	ack := func(req bacnet.Request) ([]byte, error) {
+		return bacnet.NewSimpleACKReply(req.InvokeID, req.Service)
+	}
+	cases := []struct {
+		name    string
+		handler bacnet.Handler
+		req     []byte
+		want    error
+	}{
+		{"unknown service", nil, readProperty, objects.NewRejectError(objects.RejectReasonUnrecognizedService)},
+		{"unparseable", ack, truncated, objects.NewRejectError(objects.RejectReasonInvalidTag)},
+		{"segmented", ack, segmented, objects.NewAbortError(objects.AbortReasonSegmentationNotSupported, true)},
+		{"panic", func(bacnet.Request) ([]byte, error) {
+			panic("handler bug")
+		}, readProperty, objects.NewAbortError(objects.AbortReasonOther, true)},
+		{"error", func(bacnet.Request) ([]byte, error) {
+			return nil, objects.NewBACnetError(objects.ErrorClassObject, objects.ErrorCodeUnknownObject)
+		}, readProperty, objects.NewBACnetError(objects.ErrorClassObject, objects.ErrorCodeUnknownObject)},
+		{"reject", func(bacnet.Request) ([]byte, error) {
+			return nil, errors.Wrap(objects.NewRejectError(objects.RejectReasonParameterOutOfRange), "bad request")
+		}, readProperty, objects.NewRejectError(objects.RejectReasonParameterOutOfRange)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, addr := newTestServer(t)
+			if c.handler != nil {
+				s.Handle(services.ServiceConfirmedReadProperty, c.handler)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_, err := newTestClient(t).Request(ctx, addr, c.req)
+			if diff := cmp.Diff(c.want, errors.Cause(err)); diff != "" {
+				t.Errorf("differs: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServerWhoIs(t *testing.T) {
+	_, addr := newTestServer(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	whoIs := func(low, high uint32, snet uint16, sadr []byte) {
+		t.Helper()
+		npdu := plumbing.NewNPDU(false, false, snet != 0, false)
+		npdu.SNET, npdu.SLEN, npdu.SADR = snet, uint8(len(sadr)), sadr
+		w := services.NewUnconfirmedWhoIs(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+		w.APDU.Objects = services.WhoIsObjects(low, high)
+		w.SetLength()
+		b, err := w.MarshalBinary()
+		if err == nil {
+			_, err = conn.WriteTo(b, addr)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// receive returns the next message received, nil when none comes within
+	// d.
+	receive := func(d time.Duration) plumbing.BACnet {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(d))
+		buf := make([]byte, 1500)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil
+		}
+		msg, err := bacnet.Parse(buf[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	// A Who-Is the device doesn't match goes unanswered.
+	whoIs(5, 9, 0, nil)
+	if msg := receive(100 * time.Millisecond); msg != nil {
+		t.Fatalf("unexpected reply %T", msg)
+	}
+
+	// The I-Am answering a directed Who-Is is sent back alone, rather than
+	// broadcast to every network.
+	whoIs(0, 9, 0, nil)
+	iAm, ok := receive(time.Second).(*services.UnconfirmedIAm)
+	if !ok {
+		t.Fatal("no I-Am received")
+	}
+	if iAm.BVLC.Function != plumbing.BVLCFuncUnicast || iAm.NPDU.DNET != 0 {
+		t.Errorf("I-Am sent with BVLC function %d to network %d, want a unicast one", iAm.BVLC.Function, iAm.NPDU.DNET)
+	}
+	dec, err := iAm.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.DeviceId != 1 {
+		t.Errorf("I-Am from device %d, want 1", dec.DeviceId)
+	}
+
+	// The one answering a Who-Is from a remote network goes back through the
+	// router.
+	whoIs(1, 1, 5, []byte{7})
+	iAm, ok = receive(time.Second).(*services.UnconfirmedIAm)
+	if !ok {
+		t.Fatal("no I-Am received")
+	}
+	if iAm.NPDU.DNET != 5 || !cmp.Equal(iAm.NPDU.DADR, []byte{7}) {
+		t.Errorf("I-Am sent to %d/%x, want 5/07", iAm.NPDU.DNET, iAm.NPDU.DADR)
+	}
+}
+
+func TestServerServicesSupported(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.Handle(services.ServiceConfirmedReadProperty, nil)
+	s.Handle(services.ServiceConfirmedSubscribeCOVProperty, nil)
+	s.HandleUnconfirmed(services.ServiceUnconfirmedTimeSync, nil)
+
+	want := make([]bool, 49)
+	for _, bit := range []int{
+		12, // ReadProperty
+		38, // SubscribeCOVProperty, whose choice is 28
+		32, // TimeSynchronization
+		34, // Who-Is, always answered
+	} {
+		want[bit] = true
+	}
+	if diff := cmp.Diff(want, s.ServicesSupported()); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
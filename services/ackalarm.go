@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedAcknowledgeAlarm is a BACnet message.
+type ConfirmedAcknowledgeAlarm struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedAcknowledgeAlarmDec holds a decoded AcknowledgeAlarm request.
+// TimeStamp is the one of the acknowledged transition and AckTime the time of
+// the acknowledgment.
+type ConfirmedAcknowledgeAlarmDec struct {
+	ProcessId   uint32
+	EventObject objects.ObjectIdentifier
+	EventState  uint8
+	TimeStamp   TimeStamp
+	Source      string
+	AckTime     TimeStamp
+}
+
+// ConfirmedAcknowledgeAlarmObjects creates the objects of the AcknowledgeAlarm
+// request described by a.
+func ConfirmedAcknowledgeAlarmObjects(a ConfirmedAcknowledgeAlarmDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		ctxUnsigned(0, a.ProcessId),
+		ctxObjectId(1, a.EventObject),
+		ctxEnumerated(2, uint32(a.EventState)),
+	}
+	objs = append(objs, encTimeStamp(3, a.TimeStamp)...)
+	objs = append(objs, ctxString(4, a.Source))
+	return append(objs, encTimeStamp(5, a.AckTime)...)
+}
+
+func NewConfirmedAcknowledgeAlarm(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedAcknowledgeAlarm {
+	c := &ConfirmedAcknowledgeAlarm{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedAcknowledgeAlarm, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedAcknowledgeAlarm) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedAA - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAA %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAA %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAA %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAcknowledgeAlarm) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedAcknowledgeAlarm) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedAA - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAA")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAA")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAA")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAcknowledgeAlarm) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedAcknowledgeAlarm) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedAcknowledgeAlarm) Decode() (ConfirmedAcknowledgeAlarmDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	decAA := ConfirmedAcknowledgeAlarmDec{}
+
+	decAA.ProcessId = r.unsigned(0)
+	decAA.EventObject = r.objectId(1)
+	decAA.EventState = uint8(r.enumerated(2))
+	decAA.TimeStamp = r.timeStamp(3)
+	decAA.Source = r.str(4)
+	decAA.AckTime = r.timeStamp(5)
+
+	if err := r.end(); err != nil {
+		return decAA, errors.Wrap(err, "decoding ConfirmedAA")
+	}
+	return decAA, nil
+}
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedGetAlarmSummary is a BACnet message.
+type ConfirmedGetAlarmSummary struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedGetAlarmSummaryObjects creates the objects of a GetAlarmSummary
+// request, which has no parameters.
+func ConfirmedGetAlarmSummaryObjects() []objects.APDUPayload {
+	return nil
+}
+
+func NewConfirmedGetAlarmSummary(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedGetAlarmSummary {
+	c := &ConfirmedGetAlarmSummary{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedGetAlarmSummary, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedGetAlarmSummary) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedGAS - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGAS %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGAS %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGAS %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedGetAlarmSummary) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedGetAlarmSummary) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedGAS - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGAS")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGAS")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGAS")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedGetAlarmSummary) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedGetAlarmSummary) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+// GetAlarmSummaryACK is the ComplexACK answering a GetAlarmSummary request.
+type GetAlarmSummaryACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// AlarmSummary describes an object in alarm in a GetAlarmSummary
+// acknowledgement.
+type AlarmSummary struct {
+	Object           objects.ObjectIdentifier
+	AlarmState       uint8
+	AckedTransitions []bool
+}
+
+// GetAlarmSummaryACKObjects creates the objects of a GetAlarmSummary
+// acknowledgement.
+func GetAlarmSummaryACKObjects(alarms []AlarmSummary) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, 3*len(alarms))
+	for _, a := range alarms {
+		objs = append(objs,
+			objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, a.Object.ObjectType, a.Object.InstanceNumber),
+			objects.EncEnumerated(a.AlarmState),
+			objects.EncBitString(a.AckedTransitions),
+		)
+	}
+	return objs
+}
+
+func NewGetAlarmSummaryACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *GetAlarmSummaryACK {
+	c := &GetAlarmSummaryACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedGetAlarmSummary, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *GetAlarmSummaryACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal GetAlarmSummaryACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetAlarmSummaryACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetAlarmSummaryACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetAlarmSummaryACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *GetAlarmSummaryACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *GetAlarmSummaryACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal GetAlarmSummaryACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetAlarmSummaryACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetAlarmSummaryACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetAlarmSummaryACK")
+	}
+
+	return nil
+}
+
+func (c *GetAlarmSummaryACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *GetAlarmSummaryACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *GetAlarmSummaryACK) Decode() ([]AlarmSummary, error) {
+	alarms := []AlarmSummary{}
+
+	objs := c.APDU.Objects
+	if len(objs)%3 != 0 {
+		return alarms, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode GetAlarmSummaryACK - object count %d", len(objs)),
+		)
+	}
+
+	for i := 0; i < len(objs); i += 3 {
+		objId, err := objects.DecObjectIdentifier(objs[i])
+		if err != nil {
+			return alarms, errors.Wrap(err, "decoding GetAlarmSummaryACK")
+		}
+		state, err := objects.DecEnumerated(objs[i+1])
+		if err != nil {
+			return alarms, errors.Wrap(err, "decoding GetAlarmSummaryACK")
+		}
+		acked, err := objects.DecBitString(objs[i+2])
+		if err != nil {
+			return alarms, errors.Wrap(err, "decoding GetAlarmSummaryACK")
+		}
+		alarms = append(alarms, AlarmSummary{Object: objId, AlarmState: uint8(state), AckedTransitions: acked})
+	}
+
+	return alarms, nil
+}
+
+// ConfirmedGetEnrollmentSummary is a BACnet message.
+type ConfirmedGetEnrollmentSummary struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedGetEnrollmentSummaryDec holds a decoded GetEnrollmentSummary
+// request. The filters left nil don't restrict the enrollments reported.
+type ConfirmedGetEnrollmentSummaryDec struct {
+	AcknowledgmentFilter    uint8
+	EnrollmentFilter        *RecipientProcess
+	EventStateFilter        *uint8
+	EventTypeFilter         *uint8
+	PriorityFilter          *PriorityFilter
+	NotificationClassFilter *uint32
+}
+
+// PriorityFilter restricts GetEnrollmentSummary to the enrollments of
+// priority within [MinPriority, MaxPriority].
+type PriorityFilter struct {
+	MinPriority uint8
+	MaxPriority uint8
+}
+
+// ConfirmedGetEnrollmentSummaryObjects creates the objects of the
+// GetEnrollmentSummary request described by f.
+func ConfirmedGetEnrollmentSummaryObjects(f ConfirmedGetEnrollmentSummaryDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxEnumerated(0, uint32(f.AcknowledgmentFilter))}
+	if f.EnrollmentFilter != nil {
+		objs = append(objs, encRecipientProcess(1, *f.EnrollmentFilter)...)
+	}
+	if f.EventStateFilter != nil {
+		objs = append(objs, ctxEnumerated(2, uint32(*f.EventStateFilter)))
+	}
+	if f.EventTypeFilter != nil {
+		objs = append(objs, ctxEnumerated(3, uint32(*f.EventTypeFilter)))
+	}
+	if f.PriorityFilter != nil {
+		objs = append(objs, encConstructed(4,
+			ctxUnsigned(0, uint32(f.PriorityFilter.MinPriority)),
+			ctxUnsigned(1, uint32(f.PriorityFilter.MaxPriority)),
+		)...)
+	}
+	if f.NotificationClassFilter != nil {
+		objs = append(objs, ctxUnsigned(5, *f.NotificationClassFilter))
+	}
+	return objs
+}
+
+func NewConfirmedGetEnrollmentSummary(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedGetEnrollmentSummary {
+	c := &ConfirmedGetEnrollmentSummary{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedGetEnrollmentSummary, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedGetEnrollmentSummary) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedGES - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGES %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGES %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGES %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedGetEnrollmentSummary) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedGetEnrollmentSummary) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedGES - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGES")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGES")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGES")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedGetEnrollmentSummary) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedGetEnrollmentSummary) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedGetEnrollmentSummary) Decode() (ConfirmedGetEnrollmentSummaryDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	decGES := ConfirmedGetEnrollmentSummaryDec{}
+
+	decGES.AcknowledgmentFilter = uint8(r.enumerated(0))
+	if r.has(1) {
+		if inner := r.constructed(1); r.err == nil {
+			rp, err := decRecipientProcess(inner)
+			r.check(err, "enrollment filter", 1)
+			decGES.EnrollmentFilter = &rp
+		}
+	}
+	if r.has(2) {
+		state := uint8(r.enumerated(2))
+		decGES.EventStateFilter = &state
+	}
+	if r.has(3) {
+		eventType := uint8(r.enumerated(3))
+		decGES.EventTypeFilter = &eventType
+	}
+	if r.has(4) {
+		priorities := tagReader{objs: r.constructed(4)}
+		pf := PriorityFilter{
+			MinPriority: uint8(priorities.unsigned(0)),
+			MaxPriority: uint8(priorities.unsigned(1)),
+		}
+		r.check(priorities.end(), "priority filter", 4)
+		decGES.PriorityFilter = &pf
+	}
+	if r.has(5) {
+		class := r.unsigned(5)
+		decGES.NotificationClassFilter = &class
+	}
+
+	if err := r.end(); err != nil {
+		return decGES, errors.Wrap(err, "decoding ConfirmedGES")
+	}
+	return decGES, nil
+}
+
+// GetEnrollmentSummaryACK is the ComplexACK answering a GetEnrollmentSummary request.
+type GetEnrollmentSummaryACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// EnrollmentSummary describes an event enrollment in a GetEnrollmentSummary
+// acknowledgement. NotificationClass is nil when the device leaves it out.
+type EnrollmentSummary struct {
+	Object            objects.ObjectIdentifier
+	EventType         uint8
+	EventState        uint8
+	Priority          uint8
+	NotificationClass *uint32
+}
+
+// GetEnrollmentSummaryACKObjects creates the objects of a
+// GetEnrollmentSummary acknowledgement.
+func GetEnrollmentSummaryACKObjects(enrollments []EnrollmentSummary) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, 5*len(enrollments))
+	for _, e := range enrollments {
+		objs = append(objs,
+			objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, e.Object.ObjectType, e.Object.InstanceNumber),
+			objects.EncEnumerated(e.EventType),
+			objects.EncEnumerated(e.EventState),
+			objects.EncUnsignedInteger8(e.Priority),
+		)
+		if e.NotificationClass != nil {
+			objs = append(objs, objects.EncUnsignedInteger32(*e.NotificationClass))
+		}
+	}
+	return objs
+}
+
+func NewGetEnrollmentSummaryACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *GetEnrollmentSummaryACK {
+	c := &GetEnrollmentSummaryACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedGetEnrollmentSummary, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *GetEnrollmentSummaryACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal GetEnrollmentSummaryACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetEnrollmentSummaryACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetEnrollmentSummaryACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetEnrollmentSummaryACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *GetEnrollmentSummaryACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *GetEnrollmentSummaryACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal GetEnrollmentSummaryACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetEnrollmentSummaryACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetEnrollmentSummaryACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetEnrollmentSummaryACK")
+	}
+
+	return nil
+}
+
+func (c *GetEnrollmentSummaryACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *GetEnrollmentSummaryACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *GetEnrollmentSummaryACK) Decode() ([]EnrollmentSummary, error) {
+	enrollments := []EnrollmentSummary{}
+
+	objs := c.APDU.Objects
+	for i := 0; i < len(objs); {
+		if len(objs) < i+4 {
+			return enrollments, errors.Wrap(
+				common.ErrWrongObjectCount,
+				fmt.Sprintf("failed to decode GetEnrollmentSummaryACK - object count %d", len(objs)),
+			)
+		}
+
+		e := EnrollmentSummary{}
+		objId, err := objects.DecObjectIdentifier(objs[i])
+		if err != nil {
+			return enrollments, errors.Wrap(err, "decoding GetEnrollmentSummaryACK")
+		}
+		e.Object = objId
+		eventType, err := objects.DecEnumerated(objs[i+1])
+		if err != nil {
+			return enrollments, errors.Wrap(err, "decoding GetEnrollmentSummaryACK")
+		}
+		e.EventType = uint8(eventType)
+		state, err := objects.DecEnumerated(objs[i+2])
+		if err != nil {
+			return enrollments, errors.Wrap(err, "decoding GetEnrollmentSummaryACK")
+		}
+		e.EventState = uint8(state)
+		priority, err := objects.DecUnisgnedInteger(objs[i+3])
+		if err != nil {
+			return enrollments, errors.Wrap(err, "decoding GetEnrollmentSummaryACK")
+		}
+		e.Priority = uint8(priority)
+		i += 4
+
+		// The optional notification class is told apart from the object
+		// identifier starting the next enrollment by its tag.
+		if i < len(objs) {
+			if class, err := objects.DecUnisgnedInteger(objs[i]); err == nil {
+				e.NotificationClass = &class
+				i++
+			}
+		}
+
+		enrollments = append(enrollments, e)
+	}
+
+	return enrollments, nil
+}
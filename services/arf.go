@@ -0,0 +1,420 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedAtomicReadFile is a BACnet message.
+type ConfirmedAtomicReadFile struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedAtomicReadFileDec holds a decoded AtomicReadFile request. Start and
+// Count are expressed in octets for stream access and in records otherwise.
+type ConfirmedAtomicReadFileDec struct {
+	ObjectType   uint16
+	InstanceId   uint32
+	RecordAccess bool
+	Start        int32
+	Count        uint32
+}
+
+// ConfirmedAtomicReadFileStreamObjects creates the objects of an AtomicReadFile
+// request reading octetCount octets from position start.
+func ConfirmedAtomicReadFileStreamObjects(instN uint32, start int32, octetCount uint32) []objects.APDUPayload {
+	return atomicFileRequestObjects(instN, fileStreamAccess,
+		objects.EncSignedInteger(start), objects.EncUnsignedInteger32(octetCount))
+}
+
+// ConfirmedAtomicReadFileRecordObjects creates the objects of an AtomicReadFile
+// request reading recordCount records from record start.
+func ConfirmedAtomicReadFileRecordObjects(instN uint32, start int32, recordCount uint32) []objects.APDUPayload {
+	return atomicFileRequestObjects(instN, fileRecordAccess,
+		objects.EncSignedInteger(start), objects.EncUnsignedInteger32(recordCount))
+}
+
+// atomicFileRequestObjects wraps the parameters of the given access method
+// after the file identifier.
+func atomicFileRequestObjects(instN uint32, accessTag uint8, params ...objects.APDUPayload) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, len(params)+3)
+
+	objs = append(objs, objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objects.ObjectTypeFile, instN))
+	objs = append(objs, objects.EncOpeningTag(accessTag))
+	objs = append(objs, params...)
+	objs = append(objs, objects.EncClosingTag(accessTag))
+
+	return objs
+}
+
+func NewConfirmedAtomicReadFile(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedAtomicReadFile {
+	c := &ConfirmedAtomicReadFile{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedAtomicReadFile, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedAtomicReadFile) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedARF - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedARF %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedARF %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedARF %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAtomicReadFile) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedAtomicReadFile) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedARF - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedARF")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedARF")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedARF")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAtomicReadFile) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedAtomicReadFile) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedAtomicReadFile) Decode() (ConfirmedAtomicReadFileDec, error) {
+	decARF := ConfirmedAtomicReadFileDec{}
+
+	if len(c.APDU.Objects) != 5 {
+		return decARF, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ConfirmedARF - object count %d", len(c.APDU.Objects)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(c.APDU.Objects[0])
+	if err != nil {
+		return decARF, errors.Wrap(err, "decoding ConfirmedARF")
+	}
+	decARF.ObjectType = objId.ObjectType
+	decARF.InstanceId = objId.InstanceNumber
+
+	recordAccess, err := decFileAccessMethod(c.APDU.Objects[1:])
+	if err != nil {
+		return decARF, errors.Wrap(err, "decoding ConfirmedARF")
+	}
+	decARF.RecordAccess = recordAccess
+
+	start, err := objects.DecSignedInteger(c.APDU.Objects[2])
+	if err != nil {
+		return decARF, errors.Wrap(err, "decoding ConfirmedARF")
+	}
+	decARF.Start = start
+
+	count, err := objects.DecUnisgnedInteger(c.APDU.Objects[3])
+	if err != nil {
+		return decARF, errors.Wrap(err, "decoding ConfirmedARF")
+	}
+	decARF.Count = count
+
+	return decARF, nil
+}
+
+// AtomicReadFileACK is the ComplexACK answering an AtomicReadFile request.
+type AtomicReadFileACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// AtomicReadFileACKDec holds a decoded AtomicReadFile acknowledgement. Data is
+// set for stream access and Records for record access.
+type AtomicReadFileACKDec struct {
+	EndOfFile    bool
+	RecordAccess bool
+	Start        int32
+	Data         []byte
+	Records      [][]byte
+}
+
+// AtomicReadFileStreamACKObjects creates the objects of an AtomicReadFile
+// acknowledgement returning data read from position start.
+func AtomicReadFileStreamACKObjects(endOfFile bool, start int32, data []byte) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 5)
+
+	objs[0] = objects.EncBoolean(endOfFile)
+	objs[1] = objects.EncOpeningTag(fileStreamAccess)
+	objs[2] = objects.EncSignedInteger(start)
+	objs[3] = objects.EncOctetString(data)
+	objs[4] = objects.EncClosingTag(fileStreamAccess)
+
+	return objs
+}
+
+// AtomicReadFileRecordACKObjects creates the objects of an AtomicReadFile
+// acknowledgement returning records read from record start.
+func AtomicReadFileRecordACKObjects(endOfFile bool, start int32, records [][]byte) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, len(records)+5)
+
+	objs = append(objs, objects.EncBoolean(endOfFile))
+	objs = append(objs, objects.EncOpeningTag(fileRecordAccess))
+	objs = append(objs, objects.EncSignedInteger(start))
+	objs = append(objs, objects.EncUnsignedInteger32(uint32(len(records))))
+	for _, r := range records {
+		objs = append(objs, objects.EncOctetString(r))
+	}
+	objs = append(objs, objects.EncClosingTag(fileRecordAccess))
+
+	return objs
+}
+
+func NewAtomicReadFileACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *AtomicReadFileACK {
+	c := &AtomicReadFileACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedAtomicReadFile, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *AtomicReadFileACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal AtomicReadFileACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AtomicReadFileACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AtomicReadFileACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AtomicReadFileACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *AtomicReadFileACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *AtomicReadFileACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal AtomicReadFileACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AtomicReadFileACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AtomicReadFileACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AtomicReadFileACK")
+	}
+
+	return nil
+}
+
+func (c *AtomicReadFileACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *AtomicReadFileACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *AtomicReadFileACK) Decode() (AtomicReadFileACKDec, error) {
+	decACK := AtomicReadFileACKDec{}
+
+	if len(c.APDU.Objects) < 5 {
+		return decACK, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode AtomicReadFileACK - object count %d", len(c.APDU.Objects)),
+		)
+	}
+
+	endOfFile, err := objects.DecBoolean(c.APDU.Objects[0])
+	if err != nil {
+		return decACK, errors.Wrap(err, "decoding AtomicReadFileACK")
+	}
+	decACK.EndOfFile = endOfFile
+
+	recordAccess, err := decFileAccessMethod(c.APDU.Objects[1:])
+	if err != nil {
+		return decACK, errors.Wrap(err, "decoding AtomicReadFileACK")
+	}
+	decACK.RecordAccess = recordAccess
+
+	start, err := objects.DecSignedInteger(c.APDU.Objects[2])
+	if err != nil {
+		return decACK, errors.Wrap(err, "decoding AtomicReadFileACK")
+	}
+	decACK.Start = start
+
+	if !recordAccess {
+		data, err := objects.DecOctetString(c.APDU.Objects[3])
+		if err != nil {
+			return decACK, errors.Wrap(err, "decoding AtomicReadFileACK")
+		}
+		decACK.Data = data
+		return decACK, nil
+	}
+
+	records, err := decFileRecords(c.APDU.Objects[3:])
+	if err != nil {
+		return decACK, errors.Wrap(err, "decoding AtomicReadFileACK")
+	}
+	decACK.Records = records
+
+	return decACK, nil
+}
+
+// decFileAccessMethod checks objs holds a single stream or record access
+// constructed value and tells which one it is.
+func decFileAccessMethod(objs []objects.APDUPayload) (bool, error) {
+	var recordAccess bool
+	switch {
+	case isOpeningTag(objs[0], fileStreamAccess):
+	case isOpeningTag(objs[0], fileRecordAccess):
+		recordAccess = true
+	default:
+		return false, errors.Wrap(common.ErrWrongStructure, "unknown file access method")
+	}
+
+	end, err := closingTagIndex(objs, 0)
+	if err != nil {
+		return false, err
+	}
+	if end != len(objs)-1 {
+		return false, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("unexpected objects after file access method - %d", len(objs)-1-end),
+		)
+	}
+
+	return recordAccess, nil
+}
+
+// decFileRecords decodes a record count followed by that many records and
+// the closing tag of the record access method.
+func decFileRecords(objs []objects.APDUPayload) ([][]byte, error) {
+	count, err := objects.DecUnisgnedInteger(objs[0])
+	if err != nil {
+		return nil, err
+	}
+	if int(count) != len(objs)-2 {
+		return nil, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("record count %d with %d records", count, len(objs)-2),
+		)
+	}
+
+	records := make([][]byte, 0, count)
+	for _, obj := range objs[1 : len(objs)-1] {
+		record, err := objects.DecOctetString(obj)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
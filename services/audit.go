@@ -0,0 +1,490 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// PropertyReference is a BACnetPropertyReference. ArrayIndex is nil when the
+// whole property is referred to.
+type PropertyReference struct {
+	PropertyId uint8
+	ArrayIndex *uint32
+}
+
+// AuditNotification is a BACnetAuditNotification, reporting an operation
+// SourceDevice made on TargetDevice. Nil pointers, empty comments, a zero
+// TargetPriority and nil values are left out.
+type AuditNotification struct {
+	SourceTimeStamp *TimeStamp
+	TargetTimeStamp *TimeStamp
+	SourceDevice    Recipient
+	SourceObject    *objects.ObjectIdentifier
+	Operation       uint8
+	SourceComment   string
+	TargetComment   string
+	InvokeId        *uint8
+	SourceUserId    *uint16
+	SourceUserRole  *uint8
+	TargetDevice    Recipient
+	TargetObject    *objects.ObjectIdentifier
+	TargetProperty  *PropertyReference
+	TargetPriority  uint8
+	TargetValue     []objects.APDUPayload
+	CurrentValue    []objects.APDUPayload
+	Result          *objects.BACnetError
+}
+
+func encAuditNotification(n AuditNotification) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+	if n.SourceTimeStamp != nil {
+		objs = append(objs, encTimeStamp(0, *n.SourceTimeStamp)...)
+	}
+	if n.TargetTimeStamp != nil {
+		objs = append(objs, encTimeStamp(1, *n.TargetTimeStamp)...)
+	}
+	objs = append(objs, encRecipient(2, n.SourceDevice)...)
+	if n.SourceObject != nil {
+		objs = append(objs, ctxObjectId(3, *n.SourceObject))
+	}
+	objs = append(objs, ctxEnumerated(4, uint32(n.Operation)))
+	if n.SourceComment != "" {
+		objs = append(objs, ctxString(5, n.SourceComment))
+	}
+	if n.TargetComment != "" {
+		objs = append(objs, ctxString(6, n.TargetComment))
+	}
+	if n.InvokeId != nil {
+		objs = append(objs, ctxUnsigned(7, uint32(*n.InvokeId)))
+	}
+	if n.SourceUserId != nil {
+		objs = append(objs, ctxUnsigned(8, uint32(*n.SourceUserId)))
+	}
+	if n.SourceUserRole != nil {
+		objs = append(objs, ctxUnsigned(9, uint32(*n.SourceUserRole)))
+	}
+	objs = append(objs, encRecipient(10, n.TargetDevice)...)
+	if n.TargetObject != nil {
+		objs = append(objs, ctxObjectId(11, *n.TargetObject))
+	}
+	if n.TargetProperty != nil {
+		objs = append(objs, encConstructed(12, encPropertyReference(*n.TargetProperty)...)...)
+	}
+	if n.TargetPriority != 0 {
+		objs = append(objs, ctxUnsigned(13, uint32(n.TargetPriority)))
+	}
+	if n.TargetValue != nil {
+		objs = append(objs, encConstructed(14, n.TargetValue...)...)
+	}
+	if n.CurrentValue != nil {
+		objs = append(objs, encConstructed(15, n.CurrentValue...)...)
+	}
+	if n.Result != nil {
+		objs = append(objs, encConstructed(16,
+			objects.EncEnumerated(n.Result.Class),
+			objects.EncEnumerated(n.Result.Code),
+		)...)
+	}
+	return objs
+}
+
+func decAuditNotification(objs []objects.APDUPayload) (AuditNotification, error) {
+	r := tagReader{objs: objs}
+	n := AuditNotification{}
+
+	if r.has(0) {
+		ts := r.timeStamp(0)
+		n.SourceTimeStamp = &ts
+	}
+	if r.has(1) {
+		ts := r.timeStamp(1)
+		n.TargetTimeStamp = &ts
+	}
+	if inner := r.constructed(2); r.err == nil {
+		n.SourceDevice, r.err = decRecipient(inner)
+	}
+	if r.has(3) {
+		id := r.objectId(3)
+		n.SourceObject = &id
+	}
+	n.Operation = uint8(r.enumerated(4))
+	if r.has(5) {
+		n.SourceComment = r.str(5)
+	}
+	if r.has(6) {
+		n.TargetComment = r.str(6)
+	}
+	if r.has(7) {
+		invokeId := uint8(r.unsigned(7))
+		n.InvokeId = &invokeId
+	}
+	if r.has(8) {
+		userId := uint16(r.unsigned(8))
+		n.SourceUserId = &userId
+	}
+	if r.has(9) {
+		role := uint8(r.unsigned(9))
+		n.SourceUserRole = &role
+	}
+	if inner := r.constructed(10); r.err == nil {
+		n.TargetDevice, r.err = decRecipient(inner)
+	}
+	if r.has(11) {
+		id := r.objectId(11)
+		n.TargetObject = &id
+	}
+	if r.has(12) {
+		inner := r.constructed(12)
+		if r.err == nil {
+			n.TargetProperty, r.err = decPropertyReference(inner)
+		}
+	}
+	if r.has(13) {
+		n.TargetPriority = uint8(r.unsigned(13))
+	}
+	if r.has(14) {
+		n.TargetValue = r.constructed(14)
+	}
+	if r.has(15) {
+		n.CurrentValue = r.constructed(15)
+	}
+	if r.has(16) {
+		inner := r.constructed(16)
+		if r.err == nil {
+			n.Result, r.err = decErrorPair(inner)
+		}
+	}
+
+	return n, r.end()
+}
+
+// encPropertyReference encodes the fields of the BACnetPropertyReference ref.
+func encPropertyReference(ref PropertyReference) []objects.APDUPayload {
+	objs := []objects.APDUPayload{objects.EncPropertyIdentifier(true, 0, ref.PropertyId)}
+	if ref.ArrayIndex != nil {
+		objs = append(objs, ctxUnsigned(1, *ref.ArrayIndex))
+	}
+	return objs
+}
+
+func decPropertyReference(objs []objects.APDUPayload) (*PropertyReference, error) {
+	r := tagReader{objs: objs}
+	ref := &PropertyReference{}
+
+	if obj := r.primitive(0); obj != nil {
+		propId, err := objects.DecPropertyIdentifier(obj)
+		r.check(err, "property identifier", 0)
+		ref.PropertyId = propId
+	}
+	if r.has(1) {
+		index := r.unsigned(1)
+		ref.ArrayIndex = &index
+	}
+	return ref, r.end()
+}
+
+// decErrorPair decodes the error class and code of an Error.
+func decErrorPair(objs []objects.APDUPayload) (*objects.BACnetError, error) {
+	if len(objs) != 2 {
+		return nil, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("error object count %d", len(objs)),
+		)
+	}
+	class, err := objects.DecEnumerated(objs[0])
+	if err != nil {
+		return nil, err
+	}
+	code, err := objects.DecEnumerated(objs[1])
+	if err != nil {
+		return nil, err
+	}
+	return objects.NewBACnetError(uint8(class), uint8(code)), nil
+}
+
+// AuditNotificationObjects creates the objects of an AuditNotification
+// request reporting ns.
+func AuditNotificationObjects(ns []AuditNotification) []objects.APDUPayload {
+	inner := []objects.APDUPayload{}
+	for _, n := range ns {
+		inner = append(inner, encAuditNotification(n)...)
+	}
+	return encConstructed(0, inner...)
+}
+
+// decAuditNotifications decodes the notifications of an AuditNotification
+// request. Notifications follow each other without delimiters, but the
+// context tags of the fields of one notification strictly increase: a field
+// which doesn't carry a higher tag than the previous one starts a new one.
+func decAuditNotifications(objs []objects.APDUPayload) ([]AuditNotification, error) {
+	r := tagReader{objs: objs}
+	inner := r.constructed(0)
+	if err := r.end(); err != nil {
+		return nil, err
+	}
+
+	ns := []AuditNotification{}
+	start, prev := 0, -1
+	for i := 0; i < len(inner); i++ {
+		tagN, ok := fieldTag(inner[i])
+		if !ok {
+			return nil, errors.Wrap(
+				common.ErrWrongStructure,
+				fmt.Sprintf("audit notification field at index %d isn't context tagged", i),
+			)
+		}
+		if int(tagN) <= prev {
+			n, err := decAuditNotification(inner[start:i])
+			if err != nil {
+				return nil, err
+			}
+			ns = append(ns, n)
+			start = i
+		}
+		prev = int(tagN)
+
+		if isOpeningTag(inner[i], tagN) {
+			end, err := closingTagIndex(inner, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		}
+	}
+	if start < len(inner) {
+		n, err := decAuditNotification(inner[start:])
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+// fieldTag returns the context tag of a primitive field or of the opening tag
+// of a constructed one.
+func fieldTag(obj objects.APDUPayload) (uint8, bool) {
+	switch o := obj.(type) {
+	case *objects.Object:
+		return o.TagNumber, o.TagClass
+	case *objects.NamedTag:
+		return o.TagNumber, isOpeningTag(o, o.TagNumber)
+	}
+	return 0, false
+}
+
+// ConfirmedAuditNotification is a BACnet message.
+type ConfirmedAuditNotification struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewConfirmedAuditNotification(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedAuditNotification {
+	c := &ConfirmedAuditNotification{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedAuditNotification, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedAuditNotification) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedAN - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAN %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAN %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAN %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAuditNotification) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedAuditNotification) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedAN - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAN")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAN")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAN")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAuditNotification) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedAuditNotification) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedAuditNotification) Decode() ([]AuditNotification, error) {
+	ns, err := decAuditNotifications(c.APDU.Objects)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding ConfirmedAN")
+	}
+	return ns, nil
+}
+
+// UnconfirmedAuditNotification is a BACnet message.
+type UnconfirmedAuditNotification struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewUnconfirmedAuditNotification(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedAuditNotification {
+	u := &UnconfirmedAuditNotification{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedAuditNotification, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedAuditNotification) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedAN - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedAN %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedAN %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedAN %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedAuditNotification) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedAuditNotification) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedAN - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedAN")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedAN")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedAN")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedAuditNotification) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedAuditNotification) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedAuditNotification) Decode() ([]AuditNotification, error) {
+	ns, err := decAuditNotifications(u.APDU.Objects)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding UnconfirmedAN")
+	}
+	return ns, nil
+}
@@ -0,0 +1,491 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedAuditLogQuery is a BACnet message.
+type ConfirmedAuditLogQuery struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// AuditQueryByTarget selects the audit records of the operations made on
+// Device. Nil pointers, a zero Priority and nil Operations are left out and
+// match any record.
+type AuditQueryByTarget struct {
+	Device       objects.ObjectIdentifier
+	Address      *Address
+	Object       *objects.ObjectIdentifier
+	PropertyId   *uint8
+	ArrayIndex   *uint32
+	Priority     uint8
+	Operations   []bool
+	ResultFilter uint8
+}
+
+// AuditQueryBySource selects the audit records of the operations made by
+// Device. Nil pointers and nil Operations are left out and match any record.
+type AuditQueryBySource struct {
+	Device       objects.ObjectIdentifier
+	Address      *Address
+	Object       *objects.ObjectIdentifier
+	Operations   []bool
+	ResultFilter uint8
+}
+
+// ConfirmedAuditLogQueryDec holds a decoded AuditLogQuery request. Exactly one
+// of ByTarget and BySource is set.
+type ConfirmedAuditLogQueryDec struct {
+	AuditLog              objects.ObjectIdentifier
+	ByTarget              *AuditQueryByTarget
+	BySource              *AuditQueryBySource
+	StartAtSequenceNumber *uint32
+	RequestedCount        uint16
+}
+
+// ConfirmedAuditLogQueryObjects creates the objects of the AuditLogQuery
+// request described by q.
+func ConfirmedAuditLogQueryObjects(q ConfirmedAuditLogQueryDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxObjectId(0, q.AuditLog)}
+
+	switch {
+	case q.ByTarget != nil:
+		t := q.ByTarget
+		inner := []objects.APDUPayload{ctxObjectId(0, t.Device)}
+		if t.Address != nil {
+			inner = append(inner, encAddress(1, *t.Address)...)
+		}
+		if t.Object != nil {
+			inner = append(inner, ctxObjectId(2, *t.Object))
+		}
+		if t.PropertyId != nil {
+			inner = append(inner, objects.EncPropertyIdentifier(true, 3, *t.PropertyId))
+			if t.ArrayIndex != nil {
+				inner = append(inner, ctxUnsigned(4, *t.ArrayIndex))
+			}
+		}
+		if t.Priority != 0 {
+			inner = append(inner, ctxUnsigned(5, uint32(t.Priority)))
+		}
+		if t.Operations != nil {
+			inner = append(inner, ctxBits(6, t.Operations))
+		}
+		if t.ResultFilter != SuccessFilterAll {
+			inner = append(inner, ctxEnumerated(7, uint32(t.ResultFilter)))
+		}
+		objs = append(objs, encConstructed(1, inner...)...)
+	case q.BySource != nil:
+		s := q.BySource
+		inner := []objects.APDUPayload{ctxObjectId(0, s.Device)}
+		if s.Address != nil {
+			inner = append(inner, encAddress(1, *s.Address)...)
+		}
+		if s.Object != nil {
+			inner = append(inner, ctxObjectId(2, *s.Object))
+		}
+		if s.Operations != nil {
+			inner = append(inner, ctxBits(3, s.Operations))
+		}
+		if s.ResultFilter != SuccessFilterAll {
+			inner = append(inner, ctxEnumerated(4, uint32(s.ResultFilter)))
+		}
+		objs = append(objs, encConstructed(2, inner...)...)
+	}
+
+	if q.StartAtSequenceNumber != nil {
+		objs = append(objs, ctxUnsigned(3, *q.StartAtSequenceNumber))
+	}
+	return append(objs, ctxUnsigned(4, uint32(q.RequestedCount)))
+}
+
+func NewConfirmedAuditLogQuery(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedAuditLogQuery {
+	c := &ConfirmedAuditLogQuery{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedAuditLogQuery, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedAuditLogQuery) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedALQ - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedALQ %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedALQ %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedALQ %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAuditLogQuery) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedAuditLogQuery) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedALQ - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedALQ")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedALQ")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedALQ")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAuditLogQuery) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedAuditLogQuery) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedAuditLogQuery) Decode() (ConfirmedAuditLogQueryDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	decALQ := ConfirmedAuditLogQueryDec{}
+
+	decALQ.AuditLog = r.objectId(0)
+
+	switch {
+	case r.has(1):
+		t, err := decAuditQueryByTarget(r.constructed(1))
+		r.check(err, "query by target", 1)
+		decALQ.ByTarget = t
+	default:
+		s, err := decAuditQueryBySource(r.constructed(2))
+		r.check(err, "query by source", 2)
+		decALQ.BySource = s
+	}
+
+	if r.has(3) {
+		start := r.unsigned(3)
+		decALQ.StartAtSequenceNumber = &start
+	}
+	decALQ.RequestedCount = uint16(r.unsigned(4))
+
+	if err := r.end(); err != nil {
+		return decALQ, errors.Wrap(err, "decoding ConfirmedALQ")
+	}
+	return decALQ, nil
+}
+
+func decAuditQueryByTarget(objs []objects.APDUPayload) (*AuditQueryByTarget, error) {
+	r := tagReader{objs: objs}
+	t := &AuditQueryByTarget{}
+
+	t.Device = r.objectId(0)
+	if r.has(1) {
+		inner := r.constructed(1)
+		if r.err == nil {
+			a, err := decAddress(inner)
+			r.check(err, "address", 1)
+			t.Address = &a
+		}
+	}
+	if r.has(2) {
+		id := r.objectId(2)
+		t.Object = &id
+	}
+	if r.has(3) {
+		if obj := r.primitive(3); obj != nil {
+			propId, err := objects.DecPropertyIdentifier(obj)
+			r.check(err, "property identifier", 3)
+			t.PropertyId = &propId
+		}
+	}
+	if r.has(4) {
+		index := r.unsigned(4)
+		t.ArrayIndex = &index
+	}
+	if r.has(5) {
+		t.Priority = uint8(r.unsigned(5))
+	}
+	if r.has(6) {
+		t.Operations = r.bits(6)
+	}
+	if r.has(7) {
+		t.ResultFilter = uint8(r.enumerated(7))
+	}
+
+	return t, r.end()
+}
+
+func decAuditQueryBySource(objs []objects.APDUPayload) (*AuditQueryBySource, error) {
+	r := tagReader{objs: objs}
+	s := &AuditQueryBySource{}
+
+	s.Device = r.objectId(0)
+	if r.has(1) {
+		inner := r.constructed(1)
+		if r.err == nil {
+			a, err := decAddress(inner)
+			r.check(err, "address", 1)
+			s.Address = &a
+		}
+	}
+	if r.has(2) {
+		id := r.objectId(2)
+		s.Object = &id
+	}
+	if r.has(3) {
+		s.Operations = r.bits(3)
+	}
+	if r.has(4) {
+		s.ResultFilter = uint8(r.enumerated(4))
+	}
+
+	return s, r.end()
+}
+
+// AuditLogQueryACK is the ComplexACK answering an AuditLogQuery request.
+type AuditLogQueryACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// AuditLogRecord is a record of the Log_Buffer of an Audit Log object, along
+// with its sequence number. Datum tells which of LogStatus, Notification and
+// TimeChange is set.
+type AuditLogRecord struct {
+	SequenceNumber uint32
+	Timestamp      time.Time
+	Datum          uint8
+	LogStatus      []bool
+	Notification   AuditNotification
+	TimeChange     float32
+}
+
+// AuditLogQueryACKDec holds a decoded AuditLogQuery acknowledgement.
+type AuditLogQueryACKDec struct {
+	AuditLog    objects.ObjectIdentifier
+	Records     []AuditLogRecord
+	NoMoreItems bool
+}
+
+// AuditLogQueryACKObjects creates the objects of the acknowledgement ack.
+func AuditLogQueryACKObjects(ack AuditLogQueryACKDec) ([]objects.APDUPayload, error) {
+	records := []objects.APDUPayload{}
+	for _, rec := range ack.Records {
+		var datum []objects.APDUPayload
+		switch rec.Datum {
+		case AuditLogDatumLogStatus:
+			datum = []objects.APDUPayload{ctxBits(AuditLogDatumLogStatus, rec.LogStatus)}
+		case AuditLogDatumNotification:
+			datum = encConstructed(AuditLogDatumNotification, encAuditNotification(rec.Notification)...)
+		case AuditLogDatumTimeChange:
+			datum = []objects.APDUPayload{ctxReal(AuditLogDatumTimeChange, rec.TimeChange)}
+		default:
+			return nil, errors.Wrap(
+				common.ErrNotImplemented,
+				fmt.Sprintf("audit log datum %d", rec.Datum),
+			)
+		}
+
+		logRecord := append(encDateTime(0, rec.Timestamp), encConstructed(1, datum...)...)
+		records = append(records, ctxUnsigned(0, rec.SequenceNumber))
+		records = append(records, encConstructed(1, logRecord...)...)
+	}
+
+	objs := []objects.APDUPayload{ctxObjectId(0, ack.AuditLog)}
+	objs = append(objs, encConstructed(1, records...)...)
+	return append(objs, ctxBoolean(2, ack.NoMoreItems)), nil
+}
+
+func NewAuditLogQueryACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *AuditLogQueryACK {
+	c := &AuditLogQueryACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedAuditLogQuery, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *AuditLogQueryACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal AuditLogQueryACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AuditLogQueryACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AuditLogQueryACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AuditLogQueryACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *AuditLogQueryACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *AuditLogQueryACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal AuditLogQueryACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AuditLogQueryACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AuditLogQueryACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AuditLogQueryACK")
+	}
+
+	return nil
+}
+
+func (c *AuditLogQueryACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *AuditLogQueryACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *AuditLogQueryACK) Decode() (AuditLogQueryACKDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	decACK := AuditLogQueryACKDec{}
+
+	decACK.AuditLog = r.objectId(0)
+	records := tagReader{objs: r.constructed(1)}
+	decACK.NoMoreItems = r.boolean(2)
+	if err := r.end(); err != nil {
+		return decACK, errors.Wrap(err, "decoding AuditLogQueryACK")
+	}
+
+	decACK.Records = []AuditLogRecord{}
+	for len(records.objs) > 0 && records.err == nil {
+		rec := AuditLogRecord{}
+		rec.SequenceNumber = records.unsigned(0)
+
+		logRecord := tagReader{objs: records.constructed(1)}
+		rec.Timestamp = logRecord.dateTime(0)
+		datum := tagReader{objs: logRecord.constructed(1)}
+		if err := logRecord.end(); err != nil {
+			return decACK, errors.Wrap(err, "decoding AuditLogQueryACK record")
+		}
+
+		switch {
+		case datum.has(AuditLogDatumLogStatus):
+			rec.Datum = AuditLogDatumLogStatus
+			rec.LogStatus = datum.bits(AuditLogDatumLogStatus)
+		case datum.has(AuditLogDatumNotification):
+			rec.Datum = AuditLogDatumNotification
+			inner := datum.constructed(AuditLogDatumNotification)
+			if datum.err == nil {
+				rec.Notification, datum.err = decAuditNotification(inner)
+			}
+		default:
+			rec.Datum = AuditLogDatumTimeChange
+			rec.TimeChange = datum.real(AuditLogDatumTimeChange)
+		}
+		if err := datum.end(); err != nil {
+			return decACK, errors.Wrap(err, "decoding AuditLogQueryACK record")
+		}
+
+		decACK.Records = append(decACK.Records, rec)
+	}
+	if err := records.end(); err != nil {
+		return decACK, errors.Wrap(err, "decoding AuditLogQueryACK records")
+	}
+
+	return decACK, nil
+}
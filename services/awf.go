@@ -0,0 +1,333 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedAtomicWriteFile is a BACnet message.
+type ConfirmedAtomicWriteFile struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedAtomicWriteFileDec holds a decoded AtomicWriteFile request. Data is
+// set for stream access and Records for record access. A Start of -1 appends
+// to the end of the file.
+type ConfirmedAtomicWriteFileDec struct {
+	ObjectType   uint16
+	InstanceId   uint32
+	RecordAccess bool
+	Start        int32
+	Data         []byte
+	Records      [][]byte
+}
+
+// ConfirmedAtomicWriteFileStreamObjects creates the objects of an AtomicWriteFile
+// request writing data from position start.
+func ConfirmedAtomicWriteFileStreamObjects(instN uint32, start int32, data []byte) []objects.APDUPayload {
+	return atomicFileRequestObjects(instN, fileStreamAccess,
+		objects.EncSignedInteger(start), objects.EncOctetString(data))
+}
+
+// ConfirmedAtomicWriteFileRecordObjects creates the objects of an AtomicWriteFile
+// request writing records from record start.
+func ConfirmedAtomicWriteFileRecordObjects(instN uint32, start int32, records [][]byte) []objects.APDUPayload {
+	params := make([]objects.APDUPayload, 0, len(records)+2)
+
+	params = append(params, objects.EncSignedInteger(start))
+	params = append(params, objects.EncUnsignedInteger32(uint32(len(records))))
+	for _, r := range records {
+		params = append(params, objects.EncOctetString(r))
+	}
+
+	return atomicFileRequestObjects(instN, fileRecordAccess, params...)
+}
+
+func NewConfirmedAtomicWriteFile(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedAtomicWriteFile {
+	c := &ConfirmedAtomicWriteFile{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedAtomicWriteFile, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedAtomicWriteFile) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedAWF - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAWF %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAWF %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedAWF %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAtomicWriteFile) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedAtomicWriteFile) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedAWF - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAWF")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAWF")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedAWF")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAtomicWriteFile) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedAtomicWriteFile) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedAtomicWriteFile) Decode() (ConfirmedAtomicWriteFileDec, error) {
+	decAWF := ConfirmedAtomicWriteFileDec{}
+
+	if len(c.APDU.Objects) < 5 {
+		return decAWF, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ConfirmedAWF - object count %d", len(c.APDU.Objects)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(c.APDU.Objects[0])
+	if err != nil {
+		return decAWF, errors.Wrap(err, "decoding ConfirmedAWF")
+	}
+	decAWF.ObjectType = objId.ObjectType
+	decAWF.InstanceId = objId.InstanceNumber
+
+	recordAccess, err := decFileAccessMethod(c.APDU.Objects[1:])
+	if err != nil {
+		return decAWF, errors.Wrap(err, "decoding ConfirmedAWF")
+	}
+	decAWF.RecordAccess = recordAccess
+
+	start, err := objects.DecSignedInteger(c.APDU.Objects[2])
+	if err != nil {
+		return decAWF, errors.Wrap(err, "decoding ConfirmedAWF")
+	}
+	decAWF.Start = start
+
+	if !recordAccess {
+		data, err := objects.DecOctetString(c.APDU.Objects[3])
+		if err != nil {
+			return decAWF, errors.Wrap(err, "decoding ConfirmedAWF")
+		}
+		decAWF.Data = data
+		return decAWF, nil
+	}
+
+	records, err := decFileRecords(c.APDU.Objects[3:])
+	if err != nil {
+		return decAWF, errors.Wrap(err, "decoding ConfirmedAWF")
+	}
+	decAWF.Records = records
+
+	return decAWF, nil
+}
+
+// AtomicWriteFileACK is the ComplexACK answering an AtomicWriteFile request.
+type AtomicWriteFileACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// AtomicWriteFileACKDec holds a decoded AtomicWriteFile acknowledgement.
+type AtomicWriteFileACKDec struct {
+	RecordAccess bool
+	Start        int32
+}
+
+// AtomicWriteFileACKObjects creates the objects of an AtomicWriteFile
+// acknowledgement reporting where the data was written.
+func AtomicWriteFileACKObjects(recordAccess bool, start int32) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 1)
+
+	tagN := fileStreamAccess
+	if recordAccess {
+		tagN = fileRecordAccess
+	}
+	objs[0] = objects.EncContextTag(tagN, objects.EncSignedInteger(start))
+
+	return objs
+}
+
+func NewAtomicWriteFileACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *AtomicWriteFileACK {
+	c := &AtomicWriteFileACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedAtomicWriteFile, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *AtomicWriteFileACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal AtomicWriteFileACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AtomicWriteFileACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AtomicWriteFileACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling AtomicWriteFileACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *AtomicWriteFileACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *AtomicWriteFileACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal AtomicWriteFileACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AtomicWriteFileACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AtomicWriteFileACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal AtomicWriteFileACK")
+	}
+
+	return nil
+}
+
+func (c *AtomicWriteFileACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *AtomicWriteFileACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *AtomicWriteFileACK) Decode() (AtomicWriteFileACKDec, error) {
+	decACK := AtomicWriteFileACKDec{}
+
+	if len(c.APDU.Objects) != 1 {
+		return decACK, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode AtomicWriteFileACK - object count %d", len(c.APDU.Objects)),
+		)
+	}
+
+	switch obj := c.APDU.Objects[0]; {
+	case isContextTag(obj, fileStreamAccess):
+	case isContextTag(obj, fileRecordAccess):
+		decACK.RecordAccess = true
+	default:
+		return decACK, errors.Wrap(common.ErrWrongStructure, "decoding AtomicWriteFileACK - unknown file access method")
+	}
+
+	start, err := objects.DecSignedInteger(c.APDU.Objects[0])
+	if err != nil {
+		return decACK, errors.Wrap(err, "decoding AtomicWriteFileACK")
+	}
+	decACK.Start = start
+
+	return decACK, nil
+}
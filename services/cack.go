@@ -129,14 +129,15 @@ func (u *ComplexACK) SetLength() {
 func (c *ComplexACK) Decode() (ComplexACKDec, error) {
 	decCACK := ComplexACKDec{}
 
-	if len(c.APDU.Objects) != 3 {
+	objs := withoutNamedTags(c.APDU.Objects)
+	if len(objs) != 3 {
 		return decCACK, errors.Wrap(
 			common.ErrWrongObjectCount,
-			fmt.Sprintf("failed to decode CACK - objects count: %d", len(c.APDU.Objects)),
+			fmt.Sprintf("failed to decode CACK - objects count: %d", len(objs)),
 		)
 	}
 
-	for i, obj := range c.APDU.Objects {
+	for i, obj := range objs {
 		enc_obj, ok := obj.(*objects.Object)
 		if !ok {
 			return decCACK, errors.Wrap(
@@ -204,3 +205,42 @@ func (c *ComplexACK) Decode() (ComplexACKDec, error) {
 
 	return decCACK, nil
 }
+
+// ReadPropertyACKObjects creates the objects of the acknowledgement of a
+// ReadProperty request, value holding the application tagged objects of the
+// property, or of its element arrayIndex unless it's objects.ArrayAll.
+func ReadPropertyACKObjects(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, value []objects.APDUPayload) []objects.APDUPayload {
+	objs := ConfirmedReadPropertyObjects(objectType, instN, propertyId)
+	if arrayIndex != objects.ArrayAll {
+		objs = append(objs, ctxUnsigned(2, arrayIndex))
+	}
+	return append(objs, encConstructed(3, value...)...)
+}
+
+// DecodeResult decodes a ReadProperty acknowledgement as the result of
+// reading a single property, keeping its value, arrays included, as
+// application tagged objects.
+func (c *ComplexACK) DecodeResult() (ReadAccessResult, error) {
+	res := ReadAccessResult{}
+	rr := ReadResult{}
+
+	r := tagReader{objs: c.APDU.Objects}
+	id := r.objectId(0)
+	res.ObjectType, res.InstanceId = id.ObjectType, id.InstanceNumber
+	if obj := r.primitive(1); obj != nil {
+		propId, err := objects.DecPropertyIdentifier(obj)
+		r.check(err, "property identifier", 1)
+		rr.PropertyId = propId
+	}
+	if r.has(2) {
+		index := r.unsigned(2)
+		rr.ArrayIndex = &index
+	}
+	rr.Value = r.constructed(3)
+	if err := r.end(); err != nil {
+		return res, errors.Wrap(err, "decoding CACK")
+	}
+
+	res.Results = []ReadResult{rr}
+	return res, nil
+}
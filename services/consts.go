@@ -13,6 +13,10 @@ const (
 	ServiceUnconfirmedWhoIs
 	ServiceUnconfirmedUTCTimeSync
 	ServiceUnconfirmedWriteGroup
+	ServiceUnconfirmedCOVNotificationMultiple
+	ServiceUnconfirmedAuditNotification
+	ServiceUnconfirmedWhoAmI
+	ServiceUnconfirmedYouAre
 )
 
 // Services in APDU of which type is confirmed request.
@@ -43,4 +47,188 @@ const (
 	ServiceConfirmedVTData
 	ServiceConfirmedAuthenticate
 	ServiceConfirmedRequestKey
+	ServiceConfirmedReadRange
+	ServiceConfirmedLifeSafetyOperation
+	ServiceConfirmedSubscribeCOVProperty
+	ServiceConfirmedGetEventInformation
+	ServiceConfirmedSubscribeCOVPropertyMultiple
+	ServiceConfirmedCOVNotificationMultiple
+	ServiceConfirmedAuditNotification
+	ServiceConfirmedAuditLogQuery
+)
+
+// States of DeviceCommunicationControl requests.
+const (
+	CommunicationEnable uint8 = iota
+	CommunicationDisable
+	CommunicationDisableInitiation
+)
+
+// MaxPasswordLen is the number of characters the passwords of
+// DeviceCommunicationControl and ReinitializeDevice requests are limited to.
+const MaxPasswordLen = 20
+
+// States of ReinitializeDevice requests.
+const (
+	ReinitializeColdstart uint8 = iota
+	ReinitializeWarmstart
+	ReinitializeStartBackup
+	ReinitializeEndBackup
+	ReinitializeStartRestore
+	ReinitializeEndRestore
+	ReinitializeAbortRestore
+	ReinitializeActivateChanges
+)
+
+// Access methods of AtomicReadFile and AtomicWriteFile requests.
+const (
+	fileStreamAccess uint8 = iota
+	fileRecordAccess
+)
+
+// Ranges of ReadRange requests. Except for ReadRangeAll, they match the
+// context tag of the range in the request.
+const (
+	ReadRangeAll              uint8 = 0
+	ReadRangeByPosition       uint8 = 3
+	ReadRangeBySequenceNumber uint8 = 6
+	ReadRangeByTime           uint8 = 7
+)
+
+// Choices of the datum of BACnetLogRecord items.
+const (
+	LogDatumLogStatus uint8 = iota
+	LogDatumBoolean
+	LogDatumReal
+	LogDatumEnumerated
+	LogDatumUnsigned
+	LogDatumSigned
+	LogDatumBitString
+	LogDatumNull
+	LogDatumFailure
+	LogDatumTimeChange
+	LogDatumAny
+)
+
+// Bits of the BACnetLogStatus of BACnetLogRecord items.
+const (
+	LogStatusLogDisabled = iota
+	LogStatusBufferPurged
+	LogStatusLogInterrupted
+)
+
+// Event types, also the context tags of the matching notification parameters.
+const (
+	EventTypeChangeOfBitstring       uint8 = 0
+	EventTypeChangeOfState           uint8 = 1
+	EventTypeChangeOfValue           uint8 = 2
+	EventTypeCommandFailure          uint8 = 3
+	EventTypeFloatingLimit           uint8 = 4
+	EventTypeOutOfRange              uint8 = 5
+	EventTypeComplexEventType        uint8 = 6
+	EventTypeChangeOfLifeSafety      uint8 = 8
+	EventTypeExtended                uint8 = 9
+	EventTypeBufferReady             uint8 = 10
+	EventTypeUnsignedRange           uint8 = 11
+	EventTypeAccessEvent             uint8 = 13
+	EventTypeDoubleOutOfRange        uint8 = 14
+	EventTypeSignedOutOfRange        uint8 = 15
+	EventTypeUnsignedOutOfRange      uint8 = 16
+	EventTypeChangeOfCharacterString uint8 = 17
+	EventTypeChangeOfStatusFlags     uint8 = 18
+	EventTypeChangeOfReliability     uint8 = 19
+	EventTypeNone                    uint8 = 20
+	EventTypeChangeOfDiscreteValue   uint8 = 21
+	EventTypeChangeOfTimer           uint8 = 22
+)
+
+// Event states.
+const (
+	EventStateNormal uint8 = iota
+	EventStateFault
+	EventStateOffnormal
+	EventStateHighLimit
+	EventStateLowLimit
+	EventStateLifeSafetyAlarm
+)
+
+// Notify types of event notifications.
+const (
+	NotifyTypeAlarm uint8 = iota
+	NotifyTypeEvent
+	NotifyTypeAckNotification
+)
+
+// Choices of BACnetTimeStamp.
+const (
+	TimeStampTime uint8 = iota
+	TimeStampSequenceNumber
+	TimeStampDateTime
+)
+
+// Some choices of BACnetPropertyStates.
+const (
+	PropertyStateBoolean     uint8 = 0
+	PropertyStateBinaryValue uint8 = 1
+	PropertyStateEventType   uint8 = 2
+	PropertyStateReliability uint8 = 7
+	PropertyStateEventState  uint8 = 8
+	PropertyStateUnsigned    uint8 = 11
+)
+
+// Acknowledgment filters of GetEnrollmentSummary requests.
+const (
+	AcknowledgmentFilterAll uint8 = iota
+	AcknowledgmentFilterAcked
+	AcknowledgmentFilterNotAcked
+)
+
+// Event state filters of GetEnrollmentSummary requests.
+const (
+	EventStateFilterOffnormal uint8 = iota
+	EventStateFilterFault
+	EventStateFilterNormal
+	EventStateFilterAll
+	EventStateFilterActive
+)
+
+// Priorities of TextMessage requests.
+const (
+	TextMessagePriorityNormal uint8 = iota
+	TextMessagePriorityUrgent
+)
+
+// Operations reported by audit notifications. They are also the positions of
+// the bits of BACnetAuditOperationFlags.
+const (
+	AuditOperationRead uint8 = iota
+	AuditOperationWrite
+	AuditOperationCreate
+	AuditOperationDelete
+	AuditOperationLifeSafety
+	AuditOperationAcknowledgeAlarm
+	AuditOperationDeviceDisableComm
+	AuditOperationDeviceEnableComm
+	AuditOperationDeviceReset
+	AuditOperationDeviceBackup
+	AuditOperationDeviceRestore
+	AuditOperationSubscription
+	AuditOperationNotification
+	AuditOperationAuditingFailure
+	AuditOperationNetworkChanges
+	AuditOperationGeneral
+)
+
+// Result filters of AuditLogQuery requests.
+const (
+	SuccessFilterAll uint8 = iota
+	SuccessFilterSuccessesOnly
+	SuccessFilterFailuresOnly
+)
+
+// Choices of the datum of BACnetAuditLogRecord.
+const (
+	AuditLogDatumLogStatus uint8 = iota
+	AuditLogDatumNotification
+	AuditLogDatumTimeChange
 )
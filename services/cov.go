@@ -0,0 +1,556 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// SubscribeCOVDec holds a decoded SubscribeCOV request. A request with Cancel
+// set cancels the subscription of ProcessId to MonitoredObject and carries
+// neither IssueConfirmed nor Lifetime, a zero Lifetime otherwise standing for
+// an indefinite subscription.
+type SubscribeCOVDec struct {
+	ProcessId       uint32
+	MonitoredObject objects.ObjectIdentifier
+	Cancel          bool
+	IssueConfirmed  bool
+	Lifetime        uint32
+}
+
+// SubscribeCOVObjects creates the objects of the SubscribeCOV request
+// described by s.
+func SubscribeCOVObjects(s SubscribeCOVDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		ctxUnsigned(0, s.ProcessId),
+		ctxObjectId(1, s.MonitoredObject),
+	}
+	if !s.Cancel {
+		objs = append(objs, ctxBoolean(2, s.IssueConfirmed), ctxUnsigned(3, s.Lifetime))
+	}
+	return objs
+}
+
+// COVNotificationDec holds a decoded COVNotification request. TimeRemaining
+// is the lifetime left to the subscription, in seconds, and Values holds the
+// values of the properties reported, usually Present_Value and Status_Flags.
+type COVNotificationDec struct {
+	ProcessId        uint32
+	InitiatingDevice objects.ObjectIdentifier
+	MonitoredObject  objects.ObjectIdentifier
+	TimeRemaining    uint32
+	Values           []PropertyValue
+}
+
+// COVNotificationObjects creates the objects of the COVNotification request
+// described by n.
+func COVNotificationObjects(n COVNotificationDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		ctxUnsigned(0, n.ProcessId),
+		ctxObjectId(1, n.InitiatingDevice),
+		ctxObjectId(2, n.MonitoredObject),
+		ctxUnsigned(3, n.TimeRemaining),
+	}
+	return append(objs, encPropertyValues(4, n.Values)...)
+}
+
+func decCOVNotification(objs []objects.APDUPayload) (COVNotificationDec, error) {
+	r := tagReader{objs: objs}
+	n := COVNotificationDec{}
+
+	n.ProcessId = r.unsigned(0)
+	n.InitiatingDevice = r.objectId(1)
+	n.MonitoredObject = r.objectId(2)
+	n.TimeRemaining = r.unsigned(3)
+	if inner := r.constructed(4); r.err == nil {
+		n.Values, r.err = decPropertyValues(inner)
+	}
+
+	return n, r.end()
+}
+
+// ConfirmedSubscribeCOV is a BACnet message.
+type ConfirmedSubscribeCOV struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewConfirmedSubscribeCOV(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedSubscribeCOV {
+	c := &ConfirmedSubscribeCOV{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedSubscribeCOV, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedSubscribeCOV) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedSCOV - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedSCOV %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedSCOV %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedSCOV %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedSubscribeCOV) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedSubscribeCOV) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedSCOV - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedSCOV")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedSCOV")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedSCOV")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedSubscribeCOV) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedSubscribeCOV) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedSubscribeCOV) Decode() (SubscribeCOVDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	s := SubscribeCOVDec{}
+
+	s.ProcessId = r.unsigned(0)
+	s.MonitoredObject = r.objectId(1)
+	s.Cancel = true
+	if r.has(2) {
+		s.IssueConfirmed = r.boolean(2)
+		s.Cancel = false
+	}
+	if r.has(3) {
+		s.Lifetime = r.unsigned(3)
+		s.Cancel = false
+	}
+
+	if err := r.end(); err != nil {
+		return s, errors.Wrap(err, "decoding ConfirmedSCOV")
+	}
+	return s, nil
+}
+
+// SubscribeCOVPropertyDec holds a decoded SubscribeCOVProperty request, which
+// subscribes to the changes of MonitoredProperty. COVIncrement is nil when
+// the increment of the monitored object applies.
+type SubscribeCOVPropertyDec struct {
+	SubscribeCOVDec
+	MonitoredProperty PropertyReference
+	COVIncrement      *float32
+}
+
+// SubscribeCOVPropertyObjects creates the objects of the SubscribeCOVProperty
+// request described by s.
+func SubscribeCOVPropertyObjects(s SubscribeCOVPropertyDec) []objects.APDUPayload {
+	objs := SubscribeCOVObjects(s.SubscribeCOVDec)
+	objs = append(objs, encConstructed(4, encPropertyReference(s.MonitoredProperty)...)...)
+	if s.COVIncrement != nil {
+		objs = append(objs, ctxReal(5, *s.COVIncrement))
+	}
+	return objs
+}
+
+// ConfirmedSubscribeCOVProperty is a BACnet message.
+type ConfirmedSubscribeCOVProperty struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewConfirmedSubscribeCOVProperty(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedSubscribeCOVProperty {
+	c := &ConfirmedSubscribeCOVProperty{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedSubscribeCOVProperty, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedSubscribeCOVProperty) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedSCOVP - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedSCOVP %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedSCOVP %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedSCOVP %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedSubscribeCOVProperty) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedSubscribeCOVProperty) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedSCOVP - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedSCOVP")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedSCOVP")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedSCOVP")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedSubscribeCOVProperty) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedSubscribeCOVProperty) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedSubscribeCOVProperty) Decode() (SubscribeCOVPropertyDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	s := SubscribeCOVPropertyDec{}
+
+	s.ProcessId = r.unsigned(0)
+	s.MonitoredObject = r.objectId(1)
+	s.Cancel = true
+	if r.has(2) {
+		s.IssueConfirmed = r.boolean(2)
+		s.Cancel = false
+	}
+	if r.has(3) {
+		s.Lifetime = r.unsigned(3)
+		s.Cancel = false
+	}
+	if inner := r.constructed(4); r.err == nil {
+		var ref *PropertyReference
+		ref, r.err = decPropertyReference(inner)
+		if ref != nil {
+			s.MonitoredProperty = *ref
+		}
+	}
+	if r.has(5) {
+		increment := r.real(5)
+		s.COVIncrement = &increment
+	}
+
+	if err := r.end(); err != nil {
+		return s, errors.Wrap(err, "decoding ConfirmedSCOVP")
+	}
+	return s, nil
+}
+
+// ConfirmedCOVNotification is a BACnet message.
+type ConfirmedCOVNotification struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewConfirmedCOVNotification(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedCOVNotification {
+	c := &ConfirmedCOVNotification{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedCOVNotification, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedCOVNotification) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedCOVN - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedCOVN %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedCOVN %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedCOVN %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedCOVNotification) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedCOVNotification) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedCOVN - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedCOVN")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedCOVN")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedCOVN")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedCOVNotification) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedCOVNotification) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedCOVNotification) Decode() (COVNotificationDec, error) {
+	decCOVN, err := decCOVNotification(c.APDU.Objects)
+	if err != nil {
+		return decCOVN, errors.Wrap(err, "decoding ConfirmedCOVN")
+	}
+	return decCOVN, nil
+}
+
+// UnconfirmedCOVNotification is a BACnet message.
+type UnconfirmedCOVNotification struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewUnconfirmedCOVNotification(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedCOVNotification {
+	u := &UnconfirmedCOVNotification{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedCOVNotification, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedCOVNotification) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedCOVN - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedCOVN %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedCOVN %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedCOVN %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedCOVNotification) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedCOVNotification) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedCOVN - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedCOVN")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedCOVN")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedCOVN")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedCOVNotification) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedCOVNotification) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedCOVNotification) Decode() (COVNotificationDec, error) {
+	decCOVN, err := decCOVNotification(u.APDU.Objects)
+	if err != nil {
+		return decCOVN, errors.Wrap(err, "decoding UnconfirmedCOVN")
+	}
+	return decCOVN, nil
+}
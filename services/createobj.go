@@ -0,0 +1,333 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedCreateObject is a BACnet message.
+type ConfirmedCreateObject struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedCreateObjectDec holds a decoded CreateObject request. InstanceId is
+// only meaningful when the request names the object to create, as reported by
+// InstanceSpecified.
+type ConfirmedCreateObjectDec struct {
+	ObjectType        uint16
+	InstanceId        uint32
+	InstanceSpecified bool
+	InitialValues     []PropertyValue
+}
+
+// ConfirmedCreateObjectByTypeObjects creates the objects of a CreateObject
+// request leaving it to the device to pick the instance number.
+func ConfirmedCreateObjectByTypeObjects(objectType uint16, initialValues []PropertyValue) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		objects.EncOpeningTag(0),
+		objects.EncContextTag(0, objects.EncEnumerated32(uint32(objectType))),
+		objects.EncClosingTag(0),
+	}
+
+	if len(initialValues) > 0 {
+		objs = append(objs, encPropertyValues(1, initialValues)...)
+	}
+
+	return objs
+}
+
+// ConfirmedCreateObjectObjects creates the objects of a CreateObject request
+// for the given object identifier.
+func ConfirmedCreateObjectObjects(objectType uint16, instN uint32, initialValues []PropertyValue) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		objects.EncOpeningTag(0),
+		objects.EncObjectIdentifier(true, 1, objectType, instN),
+		objects.EncClosingTag(0),
+	}
+
+	if len(initialValues) > 0 {
+		objs = append(objs, encPropertyValues(1, initialValues)...)
+	}
+
+	return objs
+}
+
+func NewConfirmedCreateObject(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedCreateObject {
+	c := &ConfirmedCreateObject{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedCreateObject, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedCreateObject) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedCO - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedCO %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedCO %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedCO %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedCreateObject) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedCreateObject) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedCO - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedCO")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedCO")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedCO")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedCreateObject) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedCreateObject) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedCreateObject) Decode() (ConfirmedCreateObjectDec, error) {
+	decCO := ConfirmedCreateObjectDec{}
+
+	objs := c.APDU.Objects
+	if len(objs) != 3 && len(objs) < 5 {
+		return decCO, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ConfirmedCO - object count %d", len(objs)),
+		)
+	}
+
+	if !isOpeningTag(objs[0], 0) || !isClosingTag(objs[2], 0) {
+		return decCO, errors.Wrap(common.ErrWrongStructure, "decoding ConfirmedCO - missing object specifier")
+	}
+	switch {
+	case isContextTag(objs[1], 0):
+		objectType, err := objects.DecEnumerated(objs[1])
+		if err != nil {
+			return decCO, errors.Wrap(err, "decoding ConfirmedCO")
+		}
+		decCO.ObjectType = uint16(objectType)
+	case isContextTag(objs[1], 1):
+		objId, err := objects.DecObjectIdentifier(objs[1])
+		if err != nil {
+			return decCO, errors.Wrap(err, "decoding ConfirmedCO")
+		}
+		decCO.ObjectType = objId.ObjectType
+		decCO.InstanceId = objId.InstanceNumber
+		decCO.InstanceSpecified = true
+	default:
+		return decCO, errors.Wrap(common.ErrWrongStructure, "decoding ConfirmedCO - unknown object specifier")
+	}
+
+	if len(objs) == 3 {
+		return decCO, nil
+	}
+
+	if !isOpeningTag(objs[3], 1) || !isClosingTag(objs[len(objs)-1], 1) {
+		return decCO, errors.Wrap(common.ErrWrongStructure, "decoding ConfirmedCO - malformed list of initial values")
+	}
+	values, err := decPropertyValues(objs[4 : len(objs)-1])
+	if err != nil {
+		return decCO, errors.Wrap(err, "decoding ConfirmedCO")
+	}
+	decCO.InitialValues = values
+
+	return decCO, nil
+}
+
+// CreateObjectACK is the ComplexACK answering a CreateObject request.
+type CreateObjectACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// CreateObjectACKDec holds a decoded CreateObject acknowledgement.
+type CreateObjectACKDec struct {
+	ObjectType uint16
+	InstanceId uint32
+}
+
+// CreateObjectACKObjects creates the objects of a CreateObject
+// acknowledgement naming the created object.
+func CreateObjectACKObjects(objectType uint16, instN uint32) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 1)
+
+	objs[0] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objectType, instN)
+
+	return objs
+}
+
+func NewCreateObjectACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *CreateObjectACK {
+	c := &CreateObjectACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedCreateObject, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *CreateObjectACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal CreateObjectACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling CreateObjectACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling CreateObjectACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling CreateObjectACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *CreateObjectACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *CreateObjectACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal CreateObjectACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal CreateObjectACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal CreateObjectACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal CreateObjectACK")
+	}
+
+	return nil
+}
+
+func (c *CreateObjectACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *CreateObjectACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *CreateObjectACK) Decode() (CreateObjectACKDec, error) {
+	decACK := CreateObjectACKDec{}
+
+	if len(c.APDU.Objects) != 1 {
+		return decACK, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode CreateObjectACK - object count %d", len(c.APDU.Objects)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(c.APDU.Objects[0])
+	if err != nil {
+		return decACK, errors.Wrap(err, "decoding CreateObjectACK")
+	}
+	decACK.ObjectType = objId.ObjectType
+	decACK.InstanceId = objId.InstanceNumber
+
+	return decACK, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedDeviceCommunicationControl is a BACnet message.
+type ConfirmedDeviceCommunicationControl struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedDeviceCommunicationControlDec holds a decoded DeviceCommunicationControl
+// request. A zero Duration means communication stays in the requested state
+// indefinitely and an empty Password means none was sent.
+type ConfirmedDeviceCommunicationControlDec struct {
+	Duration      uint16
+	EnableDisable uint8
+	Password      string
+}
+
+// ConfirmedDeviceCommunicationControlObjects creates the objects of a
+// DeviceCommunicationControl request. Duration is expressed in minutes and is
+// omitted when zero, as is an empty password.
+func ConfirmedDeviceCommunicationControlObjects(duration uint16, enableDisable uint8, password string) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+
+	if duration != 0 {
+		objs = append(objs, objects.EncContextTag(0, objects.EncUnsignedInteger32(uint32(duration))))
+	}
+	objs = append(objs, objects.EncContextTag(1, objects.EncEnumerated(enableDisable)))
+	if password != "" {
+		objs = append(objs, objects.EncContextTag(2, objects.EncString(password)))
+	}
+
+	return objs
+}
+
+// CheckPassword checks that password fits the password of a
+// DeviceCommunicationControl or ReinitializeDevice request.
+func CheckPassword(password string) error {
+	if n := utf8.RuneCountInString(password); n > MaxPasswordLen {
+		return errors.Wrap(
+			common.ErrTooBigValue,
+			fmt.Sprintf("password of %d characters, more than %d", n, MaxPasswordLen),
+		)
+	}
+	return nil
+}
+
+func NewConfirmedDeviceCommunicationControl(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedDeviceCommunicationControl {
+	c := &ConfirmedDeviceCommunicationControl{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedDeviceCommunicationControl, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedDeviceCommunicationControl) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedDCC - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedDCC %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedDCC %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedDCC %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedDeviceCommunicationControl) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedDeviceCommunicationControl) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedDCC - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedDCC")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedDCC")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedDCC")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedDeviceCommunicationControl) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedDeviceCommunicationControl) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedDeviceCommunicationControl) Decode() (ConfirmedDeviceCommunicationControlDec, error) {
+	decDCC := ConfirmedDeviceCommunicationControlDec{}
+
+	r := tagReader{objs: c.APDU.Objects}
+	if r.has(0) {
+		duration := r.unsigned(0)
+		if duration > 0xFFFF {
+			r.check(common.ErrTooBigValue, "time duration", 0)
+		}
+		decDCC.Duration = uint16(duration)
+	}
+	decDCC.EnableDisable = uint8(r.enumerated(1))
+	if r.has(2) {
+		decDCC.Password = r.str(2)
+		r.check(CheckPassword(decDCC.Password), "password", 2)
+	}
+	if err := r.end(); err != nil {
+		return ConfirmedDeviceCommunicationControlDec{}, errors.Wrap(err, "failed to decode ConfirmedDCC")
+	}
+
+	return decDCC, nil
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pkg/errors"
+)
+
+// withoutNamedTags drops the opening and closing tags from objs so that
+// primitive only payloads can be decoded by index.
+func withoutNamedTags(objs []objects.APDUPayload) []objects.APDUPayload {
+	prims := []objects.APDUPayload{}
+	for _, o := range objs {
+		if _, ok := o.(*objects.NamedTag); !ok {
+			prims = append(prims, o)
+		}
+	}
+	return prims
+}
+
+// isOpeningTag tells whether obj is the opening tag with number tagN.
+func isOpeningTag(obj objects.APDUPayload, tagN uint8) bool {
+	ok, err := objects.DecOpeningTab(obj)
+	return err == nil && ok && obj.(*objects.NamedTag).TagNumber == tagN
+}
+
+// isClosingTag tells whether obj is the closing tag with number tagN.
+func isClosingTag(obj objects.APDUPayload, tagN uint8) bool {
+	ok, err := objects.DecClosingTab(obj)
+	return err == nil && ok && obj.(*objects.NamedTag).TagNumber == tagN
+}
+
+// isContextTag tells whether obj is a primitive context tagged object with
+// number tagN.
+func isContextTag(obj objects.APDUPayload, tagN uint8) bool {
+	o, ok := obj.(*objects.Object)
+	return ok && o.TagClass && o.TagNumber == tagN
+}
+
+// closingTagIndex returns the index of the closing tag matching the opening
+// tag found at objs[start], accounting for nested constructed values.
+func closingTagIndex(objs []objects.APDUPayload, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(objs); i++ {
+		if ok, err := objects.DecOpeningTab(objs[i]); err == nil && ok {
+			depth++
+			continue
+		}
+		if ok, err := objects.DecClosingTab(objs[i]); err == nil && ok {
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.Wrap(
+		common.ErrWrongStructure,
+		fmt.Sprintf("missing closing tag for object at index %d", start),
+	)
+}
+
+// tagReader decodes in order the context tagged fields of a sequence. The
+// first error met is kept and every later read is skipped, so that callers
+// check err once all the fields are read.
+type tagReader struct {
+	objs []objects.APDUPayload
+	err  error
+}
+
+// has tells whether the next field carries the context tag tagN, either as a
+// primitive value or as a constructed one.
+func (r *tagReader) has(tagN uint8) bool {
+	return r.err == nil && len(r.objs) > 0 && (isContextTag(r.objs[0], tagN) || isOpeningTag(r.objs[0], tagN))
+}
+
+// primitive pops the primitive field carrying the context tag tagN.
+func (r *tagReader) primitive(tagN uint8) objects.APDUPayload {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.objs) == 0 || !isContextTag(r.objs[0], tagN) {
+		r.err = errors.Wrap(common.ErrWrongStructure, fmt.Sprintf("missing context tag %d", tagN))
+		return nil
+	}
+	obj := r.objs[0]
+	r.objs = r.objs[1:]
+	return obj
+}
+
+// constructed pops the constructed field carrying the context tag tagN and
+// returns the objects found between its opening and closing tags.
+func (r *tagReader) constructed(tagN uint8) []objects.APDUPayload {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.objs) == 0 || !isOpeningTag(r.objs[0], tagN) {
+		r.err = errors.Wrap(common.ErrWrongStructure, fmt.Sprintf("missing opening tag %d", tagN))
+		return nil
+	}
+	end, err := closingTagIndex(r.objs, 0)
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	inner := r.objs[1:end]
+	r.objs = r.objs[end+1:]
+	return inner
+}
+
+func (r *tagReader) check(err error, field string, tagN uint8) {
+	if err != nil && r.err == nil {
+		r.err = errors.Wrap(err, fmt.Sprintf("decoding %s at context tag %d", field, tagN))
+	}
+}
+
+func (r *tagReader) unsigned(tagN uint8) uint32 {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return 0
+	}
+	v, err := objects.DecUnisgnedInteger(obj)
+	r.check(err, "unsigned", tagN)
+	return v
+}
+
+func (r *tagReader) signed(tagN uint8) int32 {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return 0
+	}
+	v, err := objects.DecSignedInteger(obj)
+	r.check(err, "signed", tagN)
+	return v
+}
+
+func (r *tagReader) enumerated(tagN uint8) uint32 {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return 0
+	}
+	v, err := objects.DecEnumerated(obj)
+	r.check(err, "enumerated", tagN)
+	return v
+}
+
+func (r *tagReader) boolean(tagN uint8) bool {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return false
+	}
+	v, err := objects.DecBoolean(obj)
+	r.check(err, "boolean", tagN)
+	return v
+}
+
+func (r *tagReader) real(tagN uint8) float32 {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return 0
+	}
+	v, err := objects.DecReal(obj)
+	r.check(err, "real", tagN)
+	return v
+}
+
+func (r *tagReader) double(tagN uint8) float64 {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return 0
+	}
+	v, err := objects.DecDouble(obj)
+	r.check(err, "double", tagN)
+	return v
+}
+
+func (r *tagReader) bits(tagN uint8) []bool {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return nil
+	}
+	v, err := objects.DecBitString(obj)
+	r.check(err, "bit string", tagN)
+	return v
+}
+
+func (r *tagReader) str(tagN uint8) string {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return ""
+	}
+	v, err := objects.DecString(obj)
+	r.check(err, "character string", tagN)
+	return v
+}
+
+func (r *tagReader) objectId(tagN uint8) objects.ObjectIdentifier {
+	obj := r.primitive(tagN)
+	if obj == nil {
+		return objects.ObjectIdentifier{}
+	}
+	v, err := objects.DecObjectIdentifier(obj)
+	r.check(err, "object identifier", tagN)
+	return v
+}
+
+// dateTime pops the BACnetDateTime carrying the context tag tagN.
+func (r *tagReader) dateTime(tagN uint8) time.Time {
+	inner := r.constructed(tagN)
+	if r.err != nil {
+		return time.Time{}
+	}
+	if len(inner) != 2 {
+		r.check(common.ErrWrongObjectCount, "date time", tagN)
+		return time.Time{}
+	}
+	v, err := objects.DecDateTime(inner[0], inner[1])
+	r.check(err, "date time", tagN)
+	return v
+}
+
+// timeStamp pops the BACnetTimeStamp carrying the context tag tagN.
+func (r *tagReader) timeStamp(tagN uint8) TimeStamp {
+	inner := r.constructed(tagN)
+	if r.err != nil {
+		return TimeStamp{}
+	}
+	ts, err := decTimeStamp(inner)
+	r.check(err, "time stamp", tagN)
+	return ts
+}
+
+// timeStampChoice pops a BACnetTimeStamp that isn't enclosed in tags.
+func (r *tagReader) timeStampChoice() TimeStamp {
+	ts := TimeStamp{}
+	switch {
+	case r.has(TimeStampTime):
+		ts.Kind = TimeStampTime
+		if obj := r.primitive(TimeStampTime); obj != nil {
+			tod, err := objects.DecTime(obj)
+			r.check(err, "time", TimeStampTime)
+			ts.TimeOfDay = tod
+		}
+	case r.has(TimeStampSequenceNumber):
+		ts.Kind = TimeStampSequenceNumber
+		ts.SequenceNumber = r.unsigned(TimeStampSequenceNumber)
+	default:
+		ts.Kind = TimeStampDateTime
+		ts.DateTime = r.dateTime(TimeStampDateTime)
+	}
+	return ts
+}
+
+// end reports the first error met, or the objects left unread.
+func (r *tagReader) end() error {
+	if r.err == nil && len(r.objs) != 0 {
+		return errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("%d unexpected objects", len(r.objs)),
+		)
+	}
+	return r.err
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedDeleteObject is a BACnet message.
+type ConfirmedDeleteObject struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+type ConfirmedDeleteObjectDec struct {
+	ObjectType uint16
+	InstanceId uint32
+}
+
+func ConfirmedDeleteObjectObjects(objectType uint16, instN uint32) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 1)
+
+	objs[0] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objectType, instN)
+
+	return objs
+}
+
+func NewConfirmedDeleteObject(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedDeleteObject {
+	c := &ConfirmedDeleteObject{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedDeleteObject, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedDeleteObject) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedDO - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedDO %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedDO %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedDO %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedDeleteObject) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedDeleteObject) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedDO - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedDO")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedDO")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedDO")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedDeleteObject) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedDeleteObject) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedDeleteObject) Decode() (ConfirmedDeleteObjectDec, error) {
+	decDO := ConfirmedDeleteObjectDec{}
+
+	if len(c.APDU.Objects) != 1 {
+		return decDO, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ConfirmedDO - object count %d", len(c.APDU.Objects)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(c.APDU.Objects[0])
+	if err != nil {
+		return decDO, errors.Wrap(err, "decoding ConfirmedDO")
+	}
+	decDO.ObjectType = objId.ObjectType
+	decDO.InstanceId = objId.InstanceNumber
+
+	return decDO, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"time"
+
+	"github.com/pierreyves258/bacnet/objects"
+)
+
+// The ctx helpers encode a primitive value carrying the context tag tagN.
+
+func ctxUnsigned(tagN uint8, value uint32) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncUnsignedInteger32(value))
+}
+
+func ctxSigned(tagN uint8, value int32) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncSignedInteger(value))
+}
+
+func ctxEnumerated(tagN uint8, value uint32) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncEnumerated32(value))
+}
+
+func ctxBoolean(tagN uint8, value bool) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncBoolean(value))
+}
+
+func ctxReal(tagN uint8, value float32) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncReal(value))
+}
+
+func ctxDouble(tagN uint8, value float64) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncDouble(value))
+}
+
+func ctxBits(tagN uint8, bits []bool) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncBitString(bits))
+}
+
+func ctxString(tagN uint8, value string) *objects.Object {
+	return objects.EncContextTag(tagN, objects.EncString(value))
+}
+
+func ctxObjectId(tagN uint8, id objects.ObjectIdentifier) *objects.Object {
+	return objects.EncObjectIdentifier(true, tagN, id.ObjectType, id.InstanceNumber)
+}
+
+// encConstructed wraps inner between the opening and closing tags tagN.
+func encConstructed(tagN uint8, inner ...objects.APDUPayload) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, len(inner)+2)
+	objs = append(objs, objects.EncOpeningTag(tagN))
+	objs = append(objs, inner...)
+	return append(objs, objects.EncClosingTag(tagN))
+}
+
+// encDateTime encodes t as a BACnetDateTime carrying the context tag tagN.
+func encDateTime(tagN uint8, t time.Time) []objects.APDUPayload {
+	return encConstructed(tagN, objects.EncDateTime(t)...)
+}
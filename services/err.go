@@ -19,6 +19,12 @@ type Error struct {
 type ErrorDec struct {
 	ErrorClass uint8
 	ErrorCode  uint8
+	// FirstFailedElement is reported by the errors of services acting on
+	// several elements such as CreateObject. Elements are numbered from 1.
+	FirstFailedElement uint32
+	// PrivateTransfer is reported by ConfirmedPrivateTransfer errors, along
+	// with the vendor's error parameters.
+	PrivateTransfer *PrivateTransferDec
 }
 
 // IAmObjects creates an instance of UnconfirmedIAm objects.
@@ -31,6 +37,20 @@ func ErrorObjects(errClass, errCode uint8) []objects.APDUPayload {
 	return objs
 }
 
+// ElementErrorObjects creates the objects of a CreateObject-Error or
+// ChangeList-Error, reporting the first element that couldn't be applied.
+func ElementErrorObjects(errClass, errCode uint8, firstFailedElement uint32) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 5)
+
+	objs[0] = objects.EncOpeningTag(0)
+	objs[1] = objects.EncEnumerated(errClass)
+	objs[2] = objects.EncEnumerated(errCode)
+	objs[3] = objects.EncClosingTag(0)
+	objs[4] = objects.EncContextTag(1, objects.EncUnsignedInteger32(firstFailedElement))
+
+	return objs
+}
+
 // NewUnconfirmedIAm creates a UnconfirmedIam.
 func NewError(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *Error {
 	e := &Error{
@@ -133,14 +153,32 @@ func (e *Error) SetLength() {
 func (e *Error) Decode() (ErrorDec, error) {
 	decErr := ErrorDec{}
 
-	if len(e.APDU.Objects) != 2 {
+	objs := e.APDU.Objects
+	if len(objs) > 4 && isOpeningTag(objs[0], 0) && isClosingTag(objs[3], 0) {
+		r := tagReader{objs: objs[4:]}
+		if e.APDU.Service == ServiceConfirmedPrivateTransfer {
+			pt := PrivateTransferDec{VendorId: uint16(r.unsigned(1)), ServiceNumber: r.unsigned(2)}
+			if r.has(3) {
+				pt.Parameters = r.constructed(3)
+			}
+			decErr.PrivateTransfer = &pt
+		} else {
+			decErr.FirstFailedElement = r.unsigned(1)
+		}
+		if err := r.end(); err != nil {
+			return decErr, errors.Wrap(err, "failed to decode Error parameters")
+		}
+		objs = objs[1:3]
+	}
+
+	if len(objs) != 2 {
 		return decErr, errors.Wrap(
 			common.ErrWrongObjectCount,
-			fmt.Sprintf("failed to decode Error - object count: %d", len(e.APDU.Objects)),
+			fmt.Sprintf("failed to decode Error - object count: %d", len(objs)),
 		)
 	}
 
-	for i, obj := range e.APDU.Objects {
+	for i, obj := range objs {
 		switch i {
 		case 0:
 			errClass, err := objects.DecEnumerated(obj)
@@ -0,0 +1,544 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pkg/errors"
+)
+
+// TimeStamp is a BACnetTimeStamp. Kind tells which of TimeOfDay, the time
+// elapsed since midnight, SequenceNumber or DateTime is set.
+type TimeStamp struct {
+	Kind           uint8
+	TimeOfDay      time.Duration
+	SequenceNumber uint32
+	DateTime       time.Time
+}
+
+func encTimeStamp(tagN uint8, ts TimeStamp) []objects.APDUPayload {
+	return encConstructed(tagN, encTimeStampChoice(ts)...)
+}
+
+// encTimeStampChoice encodes ts without enclosing tags, as found in lists of
+// time stamps.
+func encTimeStampChoice(ts TimeStamp) []objects.APDUPayload {
+	switch ts.Kind {
+	case TimeStampTime:
+		return []objects.APDUPayload{objects.EncContextTag(TimeStampTime, objects.EncTime(ts.TimeOfDay))}
+	case TimeStampSequenceNumber:
+		return []objects.APDUPayload{ctxUnsigned(TimeStampSequenceNumber, ts.SequenceNumber)}
+	default:
+		return encDateTime(TimeStampDateTime, ts.DateTime)
+	}
+}
+
+func decTimeStamp(objs []objects.APDUPayload) (TimeStamp, error) {
+	r := tagReader{objs: objs}
+	ts := r.timeStampChoice()
+	return ts, r.end()
+}
+
+// PropertyState is a BACnetPropertyStates, Kind being the choice among the
+// PropertyState constants. Every choice but the signed integer one carries an
+// unsigned, enumerated or boolean value, held in Value.
+type PropertyState struct {
+	Kind  uint8
+	Value uint32
+}
+
+func (p PropertyState) encode(tagN uint8) []objects.APDUPayload {
+	return encConstructed(tagN, ctxUnsigned(p.Kind, p.Value))
+}
+
+func decPropertyState(objs []objects.APDUPayload) (PropertyState, error) {
+	if len(objs) != 1 {
+		return PropertyState{}, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("property state object count %d", len(objs)),
+		)
+	}
+	obj, ok := objs[0].(*objects.Object)
+	if !ok || !obj.TagClass {
+		return PropertyState{}, errors.Wrap(common.ErrWrongStructure, "property state isn't context tagged")
+	}
+	value, err := objects.DecUnisgnedInteger(obj)
+	if err != nil {
+		return PropertyState{}, err
+	}
+	return PropertyState{Kind: obj.TagNumber, Value: value}, nil
+}
+
+// EventValues are the BACnetNotificationParameters of an event notification.
+// Every event type has its own implementation, named after the event type.
+// Values that are themselves of any type are kept as the objects encoding them.
+type EventValues interface {
+	EventType() uint8
+	encode() []objects.APDUPayload
+}
+
+type ChangeOfBitstringValues struct {
+	ReferencedBitstring []bool
+	StatusFlags         []bool
+}
+
+func (v *ChangeOfBitstringValues) EventType() uint8 { return EventTypeChangeOfBitstring }
+
+func (v *ChangeOfBitstringValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{ctxBits(0, v.ReferencedBitstring), ctxBits(1, v.StatusFlags)}
+}
+
+type ChangeOfStateValues struct {
+	NewState    PropertyState
+	StatusFlags []bool
+}
+
+func (v *ChangeOfStateValues) EventType() uint8 { return EventTypeChangeOfState }
+
+func (v *ChangeOfStateValues) encode() []objects.APDUPayload {
+	return append(v.NewState.encode(0), ctxBits(1, v.StatusFlags))
+}
+
+// ChangeOfValueValues holds ChangedBits for bit string values and ChangedValue
+// otherwise.
+type ChangeOfValueValues struct {
+	ChangedBits  []bool
+	ChangedValue float32
+	StatusFlags  []bool
+}
+
+func (v *ChangeOfValueValues) EventType() uint8 { return EventTypeChangeOfValue }
+
+func (v *ChangeOfValueValues) encode() []objects.APDUPayload {
+	var newValue []objects.APDUPayload
+	if v.ChangedBits != nil {
+		newValue = encConstructed(0, ctxBits(0, v.ChangedBits))
+	} else {
+		newValue = encConstructed(0, ctxReal(1, v.ChangedValue))
+	}
+	return append(newValue, ctxBits(1, v.StatusFlags))
+}
+
+type CommandFailureValues struct {
+	CommandValue  []objects.APDUPayload
+	StatusFlags   []bool
+	FeedbackValue []objects.APDUPayload
+}
+
+func (v *CommandFailureValues) EventType() uint8 { return EventTypeCommandFailure }
+
+func (v *CommandFailureValues) encode() []objects.APDUPayload {
+	objs := encConstructed(0, v.CommandValue...)
+	objs = append(objs, ctxBits(1, v.StatusFlags))
+	return append(objs, encConstructed(2, v.FeedbackValue...)...)
+}
+
+type FloatingLimitValues struct {
+	ReferenceValue float32
+	StatusFlags    []bool
+	SetpointValue  float32
+	ErrorLimit     float32
+}
+
+func (v *FloatingLimitValues) EventType() uint8 { return EventTypeFloatingLimit }
+
+func (v *FloatingLimitValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxReal(0, v.ReferenceValue), ctxBits(1, v.StatusFlags),
+		ctxReal(2, v.SetpointValue), ctxReal(3, v.ErrorLimit),
+	}
+}
+
+type OutOfRangeValues struct {
+	ExceedingValue float32
+	StatusFlags    []bool
+	Deadband       float32
+	ExceededLimit  float32
+}
+
+func (v *OutOfRangeValues) EventType() uint8 { return EventTypeOutOfRange }
+
+func (v *OutOfRangeValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxReal(0, v.ExceedingValue), ctxBits(1, v.StatusFlags),
+		ctxReal(2, v.Deadband), ctxReal(3, v.ExceededLimit),
+	}
+}
+
+type ComplexEventTypeValues struct {
+	Values []PropertyValue
+}
+
+func (v *ComplexEventTypeValues) EventType() uint8 { return EventTypeComplexEventType }
+
+func (v *ComplexEventTypeValues) encode() []objects.APDUPayload {
+	objs := encPropertyValues(0, v.Values)
+	return objs[1 : len(objs)-1]
+}
+
+type ChangeOfLifeSafetyValues struct {
+	NewState          uint32
+	NewMode           uint32
+	StatusFlags       []bool
+	OperationExpected uint32
+}
+
+func (v *ChangeOfLifeSafetyValues) EventType() uint8 { return EventTypeChangeOfLifeSafety }
+
+func (v *ChangeOfLifeSafetyValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxEnumerated(0, v.NewState), ctxEnumerated(1, v.NewMode),
+		ctxBits(2, v.StatusFlags), ctxEnumerated(3, v.OperationExpected),
+	}
+}
+
+type ExtendedValues struct {
+	VendorId          uint16
+	ExtendedEventType uint32
+	Parameters        []objects.APDUPayload
+}
+
+func (v *ExtendedValues) EventType() uint8 { return EventTypeExtended }
+
+func (v *ExtendedValues) encode() []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxUnsigned(0, uint32(v.VendorId)), ctxUnsigned(1, v.ExtendedEventType)}
+	return append(objs, encConstructed(2, v.Parameters...)...)
+}
+
+// BufferReadyValues holds the BACnetDeviceObjectPropertyReference of the
+// buffer property as the objects encoding it.
+type BufferReadyValues struct {
+	BufferProperty       []objects.APDUPayload
+	PreviousNotification uint32
+	CurrentNotification  uint32
+}
+
+func (v *BufferReadyValues) EventType() uint8 { return EventTypeBufferReady }
+
+func (v *BufferReadyValues) encode() []objects.APDUPayload {
+	objs := encConstructed(0, v.BufferProperty...)
+	return append(objs, ctxUnsigned(1, v.PreviousNotification), ctxUnsigned(2, v.CurrentNotification))
+}
+
+type UnsignedRangeValues struct {
+	ExceedingValue uint32
+	StatusFlags    []bool
+	ExceededLimit  uint32
+}
+
+func (v *UnsignedRangeValues) EventType() uint8 { return EventTypeUnsignedRange }
+
+func (v *UnsignedRangeValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxUnsigned(0, v.ExceedingValue), ctxBits(1, v.StatusFlags), ctxUnsigned(2, v.ExceededLimit),
+	}
+}
+
+// AccessEventValues holds the access credential and the optional
+// authentication factor as the objects encoding them.
+type AccessEventValues struct {
+	AccessEvent          uint32
+	StatusFlags          []bool
+	AccessEventTag       uint32
+	AccessEventTime      TimeStamp
+	AccessCredential     []objects.APDUPayload
+	AuthenticationFactor []objects.APDUPayload
+}
+
+func (v *AccessEventValues) EventType() uint8 { return EventTypeAccessEvent }
+
+func (v *AccessEventValues) encode() []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		ctxEnumerated(0, v.AccessEvent), ctxBits(1, v.StatusFlags), ctxUnsigned(2, v.AccessEventTag),
+	}
+	objs = append(objs, encTimeStamp(3, v.AccessEventTime)...)
+	objs = append(objs, encConstructed(4, v.AccessCredential...)...)
+	if v.AuthenticationFactor != nil {
+		objs = append(objs, encConstructed(5, v.AuthenticationFactor...)...)
+	}
+	return objs
+}
+
+type DoubleOutOfRangeValues struct {
+	ExceedingValue float64
+	StatusFlags    []bool
+	Deadband       float64
+	ExceededLimit  float64
+}
+
+func (v *DoubleOutOfRangeValues) EventType() uint8 { return EventTypeDoubleOutOfRange }
+
+func (v *DoubleOutOfRangeValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxDouble(0, v.ExceedingValue), ctxBits(1, v.StatusFlags),
+		ctxDouble(2, v.Deadband), ctxDouble(3, v.ExceededLimit),
+	}
+}
+
+type SignedOutOfRangeValues struct {
+	ExceedingValue int32
+	StatusFlags    []bool
+	Deadband       uint32
+	ExceededLimit  int32
+}
+
+func (v *SignedOutOfRangeValues) EventType() uint8 { return EventTypeSignedOutOfRange }
+
+func (v *SignedOutOfRangeValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxSigned(0, v.ExceedingValue), ctxBits(1, v.StatusFlags),
+		ctxUnsigned(2, v.Deadband), ctxSigned(3, v.ExceededLimit),
+	}
+}
+
+type UnsignedOutOfRangeValues struct {
+	ExceedingValue uint32
+	StatusFlags    []bool
+	Deadband       uint32
+	ExceededLimit  uint32
+}
+
+func (v *UnsignedOutOfRangeValues) EventType() uint8 { return EventTypeUnsignedOutOfRange }
+
+func (v *UnsignedOutOfRangeValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxUnsigned(0, v.ExceedingValue), ctxBits(1, v.StatusFlags),
+		ctxUnsigned(2, v.Deadband), ctxUnsigned(3, v.ExceededLimit),
+	}
+}
+
+type ChangeOfCharacterStringValues struct {
+	ChangedValue string
+	StatusFlags  []bool
+	AlarmValue   string
+}
+
+func (v *ChangeOfCharacterStringValues) EventType() uint8 { return EventTypeChangeOfCharacterString }
+
+func (v *ChangeOfCharacterStringValues) encode() []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxString(0, v.ChangedValue), ctxBits(1, v.StatusFlags), ctxString(2, v.AlarmValue),
+	}
+}
+
+// ChangeOfStatusFlagsValues holds a nil PresentValue when the notification
+// doesn't carry it.
+type ChangeOfStatusFlagsValues struct {
+	PresentValue    []objects.APDUPayload
+	ReferencedFlags []bool
+}
+
+func (v *ChangeOfStatusFlagsValues) EventType() uint8 { return EventTypeChangeOfStatusFlags }
+
+func (v *ChangeOfStatusFlagsValues) encode() []objects.APDUPayload {
+	var objs []objects.APDUPayload
+	if v.PresentValue != nil {
+		objs = encConstructed(0, v.PresentValue...)
+	}
+	return append(objs, ctxBits(1, v.ReferencedFlags))
+}
+
+type ChangeOfReliabilityValues struct {
+	Reliability    uint32
+	StatusFlags    []bool
+	PropertyValues []PropertyValue
+}
+
+func (v *ChangeOfReliabilityValues) EventType() uint8 { return EventTypeChangeOfReliability }
+
+func (v *ChangeOfReliabilityValues) encode() []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxEnumerated(0, v.Reliability), ctxBits(1, v.StatusFlags)}
+	return append(objs, encPropertyValues(2, v.PropertyValues)...)
+}
+
+// NoneValues are the empty notification parameters of the NONE event type.
+type NoneValues struct{}
+
+func (v *NoneValues) EventType() uint8 { return EventTypeNone }
+
+func (v *NoneValues) encode() []objects.APDUPayload { return nil }
+
+// ChangeOfDiscreteValueValues holds the new value as the objects encoding it,
+// either a single application tagged value or a context tagged date time.
+type ChangeOfDiscreteValueValues struct {
+	NewValue    []objects.APDUPayload
+	StatusFlags []bool
+}
+
+func (v *ChangeOfDiscreteValueValues) EventType() uint8 { return EventTypeChangeOfDiscreteValue }
+
+func (v *ChangeOfDiscreteValueValues) encode() []objects.APDUPayload {
+	return append(encConstructed(0, v.NewValue...), ctxBits(1, v.StatusFlags))
+}
+
+// ChangeOfTimerValues leaves LastStateChange and InitialTimeout nil, and
+// ExpirationTime zero, when the notification doesn't carry them.
+type ChangeOfTimerValues struct {
+	NewState        uint32
+	StatusFlags     []bool
+	UpdateTime      time.Time
+	LastStateChange *uint32
+	InitialTimeout  *uint32
+	ExpirationTime  time.Time
+}
+
+func (v *ChangeOfTimerValues) EventType() uint8 { return EventTypeChangeOfTimer }
+
+func (v *ChangeOfTimerValues) encode() []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxEnumerated(0, v.NewState), ctxBits(1, v.StatusFlags)}
+	objs = append(objs, encDateTime(2, v.UpdateTime)...)
+	if v.LastStateChange != nil {
+		objs = append(objs, ctxEnumerated(3, *v.LastStateChange))
+	}
+	if v.InitialTimeout != nil {
+		objs = append(objs, ctxUnsigned(4, *v.InitialTimeout))
+	}
+	if !v.ExpirationTime.IsZero() {
+		objs = append(objs, encDateTime(5, v.ExpirationTime)...)
+	}
+	return objs
+}
+
+// ProprietaryValues are the notification parameters of the event types this
+// package doesn't know of, kept as the objects encoding them.
+type ProprietaryValues struct {
+	Type    uint8
+	Objects []objects.APDUPayload
+}
+
+func (v *ProprietaryValues) EventType() uint8 { return v.Type }
+
+func (v *ProprietaryValues) encode() []objects.APDUPayload { return v.Objects }
+
+// encEventValues encodes v as BACnetNotificationParameters carrying the
+// context tag tagN.
+func encEventValues(tagN uint8, v EventValues) []objects.APDUPayload {
+	if _, ok := v.(*NoneValues); ok {
+		return encConstructed(tagN, objects.EncContextTag(EventTypeNone, objects.EncNull()))
+	}
+	return encConstructed(tagN, encConstructed(v.EventType(), v.encode()...)...)
+}
+
+// decEventValues decodes the objects found between the opening and closing
+// tags of BACnetNotificationParameters.
+func decEventValues(objs []objects.APDUPayload) (EventValues, error) {
+	if len(objs) == 1 && isContextTag(objs[0], EventTypeNone) {
+		return &NoneValues{}, nil
+	}
+	if len(objs) < 2 {
+		return nil, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("notification parameters object count %d", len(objs)),
+		)
+	}
+	tag, ok := objs[0].(*objects.NamedTag)
+	if !ok || !isOpeningTag(tag, tag.TagNumber) {
+		return nil, errors.Wrap(common.ErrWrongStructure, "notification parameters aren't constructed")
+	}
+
+	outer := tagReader{objs: objs}
+	r := tagReader{objs: outer.constructed(tag.TagNumber)}
+	if err := outer.end(); err != nil {
+		return nil, errors.Wrap(err, "decoding notification parameters")
+	}
+
+	var v EventValues
+	switch tag.TagNumber {
+	case EventTypeChangeOfBitstring:
+		v = &ChangeOfBitstringValues{ReferencedBitstring: r.bits(0), StatusFlags: r.bits(1)}
+	case EventTypeChangeOfState:
+		s := &ChangeOfStateValues{}
+		if inner := r.constructed(0); r.err == nil {
+			s.NewState, r.err = decPropertyState(inner)
+		}
+		s.StatusFlags = r.bits(1)
+		v = s
+	case EventTypeChangeOfValue:
+		c := &ChangeOfValueValues{}
+		newValue := tagReader{objs: r.constructed(0)}
+		if newValue.has(0) {
+			c.ChangedBits = newValue.bits(0)
+		} else {
+			c.ChangedValue = newValue.real(1)
+		}
+		r.check(newValue.end(), "new value", 0)
+		c.StatusFlags = r.bits(1)
+		v = c
+	case EventTypeCommandFailure:
+		v = &CommandFailureValues{CommandValue: r.constructed(0), StatusFlags: r.bits(1), FeedbackValue: r.constructed(2)}
+	case EventTypeFloatingLimit:
+		v = &FloatingLimitValues{ReferenceValue: r.real(0), StatusFlags: r.bits(1), SetpointValue: r.real(2), ErrorLimit: r.real(3)}
+	case EventTypeOutOfRange:
+		v = &OutOfRangeValues{ExceedingValue: r.real(0), StatusFlags: r.bits(1), Deadband: r.real(2), ExceededLimit: r.real(3)}
+	case EventTypeComplexEventType:
+		values, err := decPropertyValues(r.objs)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding complex event type")
+		}
+		r.objs = nil
+		v = &ComplexEventTypeValues{Values: values}
+	case EventTypeChangeOfLifeSafety:
+		v = &ChangeOfLifeSafetyValues{NewState: r.enumerated(0), NewMode: r.enumerated(1), StatusFlags: r.bits(2), OperationExpected: r.enumerated(3)}
+	case EventTypeExtended:
+		v = &ExtendedValues{VendorId: uint16(r.unsigned(0)), ExtendedEventType: r.unsigned(1), Parameters: r.constructed(2)}
+	case EventTypeBufferReady:
+		v = &BufferReadyValues{BufferProperty: r.constructed(0), PreviousNotification: r.unsigned(1), CurrentNotification: r.unsigned(2)}
+	case EventTypeUnsignedRange:
+		v = &UnsignedRangeValues{ExceedingValue: r.unsigned(0), StatusFlags: r.bits(1), ExceededLimit: r.unsigned(2)}
+	case EventTypeAccessEvent:
+		a := &AccessEventValues{AccessEvent: r.enumerated(0), StatusFlags: r.bits(1), AccessEventTag: r.unsigned(2)}
+		a.AccessEventTime = r.timeStamp(3)
+		a.AccessCredential = r.constructed(4)
+		if r.has(5) {
+			a.AuthenticationFactor = r.constructed(5)
+		}
+		v = a
+	case EventTypeDoubleOutOfRange:
+		v = &DoubleOutOfRangeValues{ExceedingValue: r.double(0), StatusFlags: r.bits(1), Deadband: r.double(2), ExceededLimit: r.double(3)}
+	case EventTypeSignedOutOfRange:
+		v = &SignedOutOfRangeValues{ExceedingValue: r.signed(0), StatusFlags: r.bits(1), Deadband: r.unsigned(2), ExceededLimit: r.signed(3)}
+	case EventTypeUnsignedOutOfRange:
+		v = &UnsignedOutOfRangeValues{ExceedingValue: r.unsigned(0), StatusFlags: r.bits(1), Deadband: r.unsigned(2), ExceededLimit: r.unsigned(3)}
+	case EventTypeChangeOfCharacterString:
+		v = &ChangeOfCharacterStringValues{ChangedValue: r.str(0), StatusFlags: r.bits(1), AlarmValue: r.str(2)}
+	case EventTypeChangeOfStatusFlags:
+		s := &ChangeOfStatusFlagsValues{}
+		if r.has(0) {
+			s.PresentValue = r.constructed(0)
+		}
+		s.ReferencedFlags = r.bits(1)
+		v = s
+	case EventTypeChangeOfReliability:
+		c := &ChangeOfReliabilityValues{Reliability: r.enumerated(0), StatusFlags: r.bits(1)}
+		if inner := r.constructed(2); r.err == nil {
+			c.PropertyValues, r.err = decPropertyValues(inner)
+		}
+		v = c
+	case EventTypeChangeOfDiscreteValue:
+		v = &ChangeOfDiscreteValueValues{NewValue: r.constructed(0), StatusFlags: r.bits(1)}
+	case EventTypeChangeOfTimer:
+		c := &ChangeOfTimerValues{NewState: r.enumerated(0), StatusFlags: r.bits(1), UpdateTime: r.dateTime(2)}
+		if r.has(3) {
+			last := r.enumerated(3)
+			c.LastStateChange = &last
+		}
+		if r.has(4) {
+			timeout := r.unsigned(4)
+			c.InitialTimeout = &timeout
+		}
+		if r.has(5) {
+			c.ExpirationTime = r.dateTime(5)
+		}
+		v = c
+	default:
+		v = &ProprietaryValues{Type: tag.TagNumber, Objects: r.objs}
+		r.objs = nil
+	}
+
+	if err := r.end(); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("decoding notification parameters of event type %d", tag.TagNumber))
+	}
+	return v, nil
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// EventNotificationDec holds a decoded EventNotification request.
+// AckRequired, FromState and EventValues are only carried by alarm and event
+// notifications, EventValues being nil when absent.
+type EventNotificationDec struct {
+	ProcessId         uint32
+	InitiatingDevice  objects.ObjectIdentifier
+	EventObject       objects.ObjectIdentifier
+	TimeStamp         TimeStamp
+	NotificationClass uint32
+	Priority          uint8
+	EventType         uint8
+	MessageText       string
+	NotifyType        uint8
+	AckRequired       bool
+	FromState         uint8
+	ToState           uint8
+	EventValues       EventValues
+}
+
+// EventNotificationObjects creates the objects of the EventNotification
+// request described by n. An empty MessageText is left out.
+func EventNotificationObjects(n EventNotificationDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		ctxUnsigned(0, n.ProcessId),
+		ctxObjectId(1, n.InitiatingDevice),
+		ctxObjectId(2, n.EventObject),
+	}
+	objs = append(objs, encTimeStamp(3, n.TimeStamp)...)
+	objs = append(objs,
+		ctxUnsigned(4, n.NotificationClass),
+		ctxUnsigned(5, uint32(n.Priority)),
+		ctxEnumerated(6, uint32(n.EventType)),
+	)
+	if n.MessageText != "" {
+		objs = append(objs, ctxString(7, n.MessageText))
+	}
+	objs = append(objs, ctxEnumerated(8, uint32(n.NotifyType)))
+	if n.NotifyType != NotifyTypeAckNotification {
+		objs = append(objs, ctxBoolean(9, n.AckRequired), ctxEnumerated(10, uint32(n.FromState)))
+	}
+	objs = append(objs, ctxEnumerated(11, uint32(n.ToState)))
+	if n.NotifyType != NotifyTypeAckNotification && n.EventValues != nil {
+		objs = append(objs, encEventValues(12, n.EventValues)...)
+	}
+	return objs
+}
+
+func decEventNotification(objs []objects.APDUPayload) (EventNotificationDec, error) {
+	r := tagReader{objs: objs}
+	n := EventNotificationDec{}
+
+	n.ProcessId = r.unsigned(0)
+	n.InitiatingDevice = r.objectId(1)
+	n.EventObject = r.objectId(2)
+	n.TimeStamp = r.timeStamp(3)
+	n.NotificationClass = r.unsigned(4)
+	n.Priority = uint8(r.unsigned(5))
+	n.EventType = uint8(r.enumerated(6))
+	if r.has(7) {
+		n.MessageText = r.str(7)
+	}
+	n.NotifyType = uint8(r.enumerated(8))
+	if r.has(9) {
+		n.AckRequired = r.boolean(9)
+	}
+	if r.has(10) {
+		n.FromState = uint8(r.enumerated(10))
+	}
+	n.ToState = uint8(r.enumerated(11))
+	if r.has(12) {
+		if inner := r.constructed(12); r.err == nil {
+			n.EventValues, r.err = decEventValues(inner)
+		}
+	}
+
+	return n, r.end()
+}
+
+// ConfirmedEventNotification is a BACnet message.
+type ConfirmedEventNotification struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewConfirmedEventNotification(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedEventNotification {
+	c := &ConfirmedEventNotification{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedEventNotification, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedEventNotification) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedEN - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedEN %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedEN %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedEN %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedEventNotification) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedEventNotification) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedEN - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedEN")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedEN")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedEN")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedEventNotification) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedEventNotification) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedEventNotification) Decode() (EventNotificationDec, error) {
+	decEN, err := decEventNotification(c.APDU.Objects)
+	if err != nil {
+		return decEN, errors.Wrap(err, "decoding ConfirmedEN")
+	}
+	return decEN, nil
+}
+
+// UnconfirmedEventNotification is a BACnet message.
+type UnconfirmedEventNotification struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewUnconfirmedEventNotification(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedEventNotification {
+	u := &UnconfirmedEventNotification{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedEventNotification, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedEventNotification) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedEN - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedEN %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedEN %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedEN %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedEventNotification) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedEventNotification) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedEN - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedEN")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedEN")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedEN")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedEventNotification) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedEventNotification) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedEventNotification) Decode() (EventNotificationDec, error) {
+	decEN, err := decEventNotification(u.APDU.Objects)
+	if err != nil {
+		return decEN, errors.Wrap(err, "decoding UnconfirmedEN")
+	}
+	return decEN, nil
+}
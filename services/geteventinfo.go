@@ -0,0 +1,333 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedGetEventInformation is a BACnet message.
+type ConfirmedGetEventInformation struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedGetEventInformationDec holds a decoded GetEventInformation request.
+// LastReceived is only meaningful when Continued is set, for the requests
+// following an acknowledgement with more events.
+type ConfirmedGetEventInformationDec struct {
+	Continued    bool
+	LastReceived objects.ObjectIdentifier
+}
+
+// ConfirmedGetEventInformationObjects creates the objects of a
+// GetEventInformation request asking for the first events.
+func ConfirmedGetEventInformationObjects() []objects.APDUPayload {
+	return nil
+}
+
+// ConfirmedGetEventInformationAfterObjects creates the objects of a
+// GetEventInformation request asking for the events following the one of the
+// object lastReceived.
+func ConfirmedGetEventInformationAfterObjects(lastReceived objects.ObjectIdentifier) []objects.APDUPayload {
+	return []objects.APDUPayload{ctxObjectId(0, lastReceived)}
+}
+
+func NewConfirmedGetEventInformation(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedGetEventInformation {
+	c := &ConfirmedGetEventInformation{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedGetEventInformation, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedGetEventInformation) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedGEI - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGEI %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGEI %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedGEI %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedGetEventInformation) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedGetEventInformation) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedGEI - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGEI")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGEI")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedGEI")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedGetEventInformation) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedGetEventInformation) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedGetEventInformation) Decode() (ConfirmedGetEventInformationDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	decGEI := ConfirmedGetEventInformationDec{}
+
+	if r.has(0) {
+		decGEI.Continued = true
+		decGEI.LastReceived = r.objectId(0)
+	}
+
+	if err := r.end(); err != nil {
+		return decGEI, errors.Wrap(err, "decoding ConfirmedGEI")
+	}
+	return decGEI, nil
+}
+
+// GetEventInformationACK is the ComplexACK answering a GetEventInformation request.
+type GetEventInformationACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// EventSummary describes the event state of an object in a
+// GetEventInformation acknowledgement. EventTimeStamps and EventPriorities
+// are ordered as the TO-OFFNORMAL, TO-FAULT and TO-NORMAL transitions.
+type EventSummary struct {
+	Object           objects.ObjectIdentifier
+	EventState       uint8
+	AckedTransitions []bool
+	EventTimeStamps  [3]TimeStamp
+	NotifyType       uint8
+	EventEnable      []bool
+	EventPriorities  [3]uint32
+}
+
+// GetEventInformationACKDec holds a decoded GetEventInformation
+// acknowledgement. MoreEvents tells whether another request, continuing after
+// the last object of Events, is needed to get every event.
+type GetEventInformationACKDec struct {
+	Events     []EventSummary
+	MoreEvents bool
+}
+
+// GetEventInformationACKObjects creates the objects of a GetEventInformation
+// acknowledgement.
+func GetEventInformationACKObjects(events []EventSummary, moreEvents bool) []objects.APDUPayload {
+	objs := []objects.APDUPayload{objects.EncOpeningTag(0)}
+	for _, e := range events {
+		objs = append(objs,
+			ctxObjectId(0, e.Object),
+			ctxEnumerated(1, uint32(e.EventState)),
+			ctxBits(2, e.AckedTransitions),
+			objects.EncOpeningTag(3),
+		)
+		for _, ts := range e.EventTimeStamps {
+			objs = append(objs, encTimeStampChoice(ts)...)
+		}
+		objs = append(objs,
+			objects.EncClosingTag(3),
+			ctxEnumerated(4, uint32(e.NotifyType)),
+			ctxBits(5, e.EventEnable),
+		)
+		objs = append(objs, encConstructed(6,
+			objects.EncUnsignedInteger32(e.EventPriorities[0]),
+			objects.EncUnsignedInteger32(e.EventPriorities[1]),
+			objects.EncUnsignedInteger32(e.EventPriorities[2]),
+		)...)
+	}
+	objs = append(objs, objects.EncClosingTag(0))
+	return append(objs, ctxBoolean(1, moreEvents))
+}
+
+func NewGetEventInformationACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *GetEventInformationACK {
+	c := &GetEventInformationACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedGetEventInformation, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *GetEventInformationACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal GetEventInformationACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetEventInformationACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetEventInformationACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling GetEventInformationACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *GetEventInformationACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *GetEventInformationACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal GetEventInformationACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetEventInformationACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetEventInformationACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal GetEventInformationACK")
+	}
+
+	return nil
+}
+
+func (c *GetEventInformationACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *GetEventInformationACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *GetEventInformationACK) Decode() (GetEventInformationACKDec, error) {
+	r := tagReader{objs: c.APDU.Objects}
+	decGEI := GetEventInformationACKDec{Events: []EventSummary{}}
+
+	list := tagReader{objs: r.constructed(0)}
+	for r.err == nil && list.err == nil && len(list.objs) > 0 {
+		e := EventSummary{}
+		e.Object = list.objectId(0)
+		e.EventState = uint8(list.enumerated(1))
+		e.AckedTransitions = list.bits(2)
+
+		timeStamps := tagReader{objs: list.constructed(3)}
+		for i := range e.EventTimeStamps {
+			e.EventTimeStamps[i] = timeStamps.timeStampChoice()
+		}
+		list.check(timeStamps.end(), "event time stamps", 3)
+
+		e.NotifyType = uint8(list.enumerated(4))
+		e.EventEnable = list.bits(5)
+
+		priorities := list.constructed(6)
+		if list.err == nil && len(priorities) != 3 {
+			list.check(common.ErrWrongObjectCount, "event priorities", 6)
+		}
+		for i := 0; list.err == nil && i < 3; i++ {
+			priority, err := objects.DecUnisgnedInteger(priorities[i])
+			list.check(err, "event priorities", 6)
+			e.EventPriorities[i] = priority
+		}
+
+		decGEI.Events = append(decGEI.Events, e)
+	}
+	r.check(list.end(), "list of event summaries", 0)
+	decGEI.MoreEvents = r.boolean(1)
+
+	if err := r.end(); err != nil {
+		return decGEI, errors.Wrap(err, "decoding GetEventInformationACK")
+	}
+	return decGEI, nil
+}
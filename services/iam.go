@@ -29,7 +29,7 @@ type UnconfirmedIAmDec struct {
 func IAmObjects(insNum uint32, acceptedSize uint16, supportedSeg uint8, vendorID uint16) []objects.APDUPayload {
 	objs := make([]objects.APDUPayload, 4)
 
-	objs[0] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objects.ObjectTypeDevice, 321)
+	objs[0] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objects.ObjectTypeDevice, insNum)
 	objs[1] = objects.EncUnsignedInteger16(acceptedSize)
 	objs[2] = objects.EncEnumerated(supportedSeg)
 	if vendorID < 256 {
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ListElementDec holds a decoded AddListElement or RemoveListElement
+// request. Elements holds the application tagged objects of every element.
+type ListElementDec struct {
+	ObjectType uint16
+	InstanceId uint32
+	PropertyId uint8
+	ArrayIndex uint32
+	Elements   []objects.APDUPayload
+}
+
+// ConfirmedAddListElement is a BACnet message.
+type ConfirmedAddListElement struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedAddListElementObjects creates the objects of an AddListElement
+// request. arrayIndex is objects.ArrayAll unless the list is an array element.
+func ConfirmedAddListElementObjects(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, elements []objects.APDUPayload) []objects.APDUPayload {
+	return listElementObjects(objectType, instN, propertyId, arrayIndex, elements)
+}
+
+func NewConfirmedAddListElement(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedAddListElement {
+	c := &ConfirmedAddListElement{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedAddListElement, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedAddListElement) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedALE - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedALE %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedALE %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedALE %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAddListElement) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedAddListElement) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedALE - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedALE")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedALE")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedALE")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedAddListElement) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedAddListElement) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedAddListElement) Decode() (ListElementDec, error) {
+	decALE, err := decListElement(c.APDU.Objects)
+	if err != nil {
+		return decALE, errors.Wrap(err, "decoding ConfirmedALE")
+	}
+	return decALE, nil
+}
+
+// ConfirmedRemoveListElement is a BACnet message.
+type ConfirmedRemoveListElement struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedRemoveListElementObjects creates the objects of a RemoveListElement
+// request. arrayIndex is objects.ArrayAll unless the list is an array element.
+func ConfirmedRemoveListElementObjects(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, elements []objects.APDUPayload) []objects.APDUPayload {
+	return listElementObjects(objectType, instN, propertyId, arrayIndex, elements)
+}
+
+func NewConfirmedRemoveListElement(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedRemoveListElement {
+	c := &ConfirmedRemoveListElement{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedRemoveListElement, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedRemoveListElement) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedRLE - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRLE %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRLE %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRLE %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedRemoveListElement) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedRemoveListElement) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedRLE - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRLE")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRLE")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRLE")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedRemoveListElement) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedRemoveListElement) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedRemoveListElement) Decode() (ListElementDec, error) {
+	decRLE, err := decListElement(c.APDU.Objects)
+	if err != nil {
+		return decRLE, errors.Wrap(err, "decoding ConfirmedRLE")
+	}
+	return decRLE, nil
+}
+
+func listElementObjects(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32, elements []objects.APDUPayload) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, len(elements)+5)
+
+	objs = append(objs, objects.EncObjectIdentifier(true, 0, objectType, instN))
+	objs = append(objs, objects.EncPropertyIdentifier(true, 1, propertyId))
+	if arrayIndex != objects.ArrayAll {
+		objs = append(objs, objects.EncContextTag(2, objects.EncUnsignedInteger32(arrayIndex)))
+	}
+	objs = append(objs, objects.EncOpeningTag(3))
+	objs = append(objs, elements...)
+	objs = append(objs, objects.EncClosingTag(3))
+
+	return objs
+}
+
+func decListElement(objs []objects.APDUPayload) (ListElementDec, error) {
+	decLE := ListElementDec{ArrayIndex: objects.ArrayAll}
+
+	if len(objs) < 4 {
+		return decLE, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("object count %d", len(objs)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(objs[0])
+	if err != nil {
+		return decLE, err
+	}
+	decLE.ObjectType = objId.ObjectType
+	decLE.InstanceId = objId.InstanceNumber
+
+	propId, err := objects.DecPropertyIdentifier(objs[1])
+	if err != nil {
+		return decLE, err
+	}
+	decLE.PropertyId = propId
+
+	i := 2
+	if isContextTag(objs[i], 2) {
+		index, err := objects.DecUnisgnedInteger(objs[i])
+		if err != nil {
+			return decLE, err
+		}
+		decLE.ArrayIndex = index
+		i++
+	}
+
+	if !isOpeningTag(objs[i], 3) || !isClosingTag(objs[len(objs)-1], 3) {
+		return decLE, errors.Wrap(common.ErrWrongStructure, "malformed list of elements")
+	}
+	decLE.Elements = objs[i+1 : len(objs)-1]
+
+	return decLE, nil
+}
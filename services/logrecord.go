@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pkg/errors"
+)
+
+// LogRecord is a BACnetLogRecord, an item of the Log_Buffer of Trend Log
+// objects.
+//
+// Datum tells which of the other fields is set: LogStatus for
+// LogDatumLogStatus, TimeChange for LogDatumTimeChange and Value otherwise.
+// Value is a bool, float32, uint32 (enumerated or unsigned), int32, []bool,
+// nil, a *objects.BACnetError for LogDatumFailure or the []objects.APDUPayload
+// of any other value. StatusFlags is nil unless the record carries them.
+type LogRecord struct {
+	Timestamp   time.Time
+	Datum       uint8
+	LogStatus   []bool
+	Value       interface{}
+	TimeChange  float32
+	StatusFlags []bool
+}
+
+// LogRecordObjects creates the objects of the Log_Buffer items records.
+func LogRecordObjects(records []LogRecord) ([]objects.APDUPayload, error) {
+	objs := []objects.APDUPayload{}
+
+	for _, r := range records {
+		datum, err := encLogDatum(r)
+		if err != nil {
+			return nil, err
+		}
+
+		objs = append(objs, objects.EncOpeningTag(0))
+		objs = append(objs, objects.EncDateTime(r.Timestamp)...)
+		objs = append(objs, objects.EncClosingTag(0))
+		objs = append(objs, objects.EncOpeningTag(1))
+		objs = append(objs, datum...)
+		objs = append(objs, objects.EncClosingTag(1))
+		if r.StatusFlags != nil {
+			objs = append(objs, objects.EncContextTag(2, objects.EncBitString(r.StatusFlags)))
+		}
+	}
+
+	return objs, nil
+}
+
+func encLogDatum(r LogRecord) ([]objects.APDUPayload, error) {
+	var obj *objects.Object
+	switch v := r.Value.(type) {
+	case nil:
+		switch r.Datum {
+		case LogDatumLogStatus:
+			obj = objects.EncBitString(r.LogStatus)
+		case LogDatumTimeChange:
+			obj = objects.EncReal(r.TimeChange)
+		case LogDatumNull:
+			obj = objects.EncNull()
+		}
+	case bool:
+		obj = objects.EncBoolean(v)
+	case float32:
+		obj = objects.EncReal(v)
+	case uint32:
+		if r.Datum == LogDatumEnumerated {
+			obj = objects.EncEnumerated32(v)
+		} else {
+			obj = objects.EncUnsignedInteger32(v)
+		}
+	case int32:
+		obj = objects.EncSignedInteger(v)
+	case []bool:
+		obj = objects.EncBitString(v)
+	case *objects.BACnetError:
+		return []objects.APDUPayload{
+			objects.EncOpeningTag(LogDatumFailure),
+			objects.EncEnumerated(v.Class),
+			objects.EncEnumerated(v.Code),
+			objects.EncClosingTag(LogDatumFailure),
+		}, nil
+	case []objects.APDUPayload:
+		objs := []objects.APDUPayload{objects.EncOpeningTag(LogDatumAny)}
+		objs = append(objs, v...)
+		return append(objs, objects.EncClosingTag(LogDatumAny)), nil
+	}
+	if obj == nil {
+		return nil, errors.Wrap(
+			common.ErrNotImplemented,
+			fmt.Sprintf("log datum %d with value %T", r.Datum, r.Value),
+		)
+	}
+
+	return []objects.APDUPayload{objects.EncContextTag(r.Datum, obj)}, nil
+}
+
+// DecLogRecords decodes the Log_Buffer items found in the ItemData of a
+// ReadRange acknowledgement.
+func DecLogRecords(objs []objects.APDUPayload) ([]LogRecord, error) {
+	records := []LogRecord{}
+
+	for i := 0; i < len(objs); {
+		var r LogRecord
+
+		if len(objs) < i+4 || !isOpeningTag(objs[i], 0) || !isClosingTag(objs[i+3], 0) {
+			return nil, errors.Wrap(
+				common.ErrWrongStructure,
+				fmt.Sprintf("log record %d - malformed timestamp", len(records)),
+			)
+		}
+		timestamp, err := objects.DecDateTime(objs[i+1], objs[i+2])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("log record %d", len(records)))
+		}
+		r.Timestamp = timestamp
+		i += 4
+
+		if i >= len(objs) || !isOpeningTag(objs[i], 1) {
+			return nil, errors.Wrap(
+				common.ErrWrongStructure,
+				fmt.Sprintf("log record %d - missing log datum", len(records)),
+			)
+		}
+		end, err := closingTagIndex(objs, i)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("log record %d", len(records)))
+		}
+		if err := decLogDatum(&r, objs[i+1:end]); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("log record %d", len(records)))
+		}
+		i = end + 1
+
+		if i < len(objs) && isContextTag(objs[i], 2) {
+			if r.StatusFlags, err = objects.DecBitString(objs[i]); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("log record %d", len(records)))
+			}
+			i++
+		}
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+func decLogDatum(r *LogRecord, objs []objects.APDUPayload) error {
+	if len(objs) == 0 {
+		return errors.Wrap(common.ErrWrongObjectCount, "empty log datum")
+	}
+
+	if tag, ok := objs[0].(*objects.NamedTag); ok {
+		if !isClosingTag(objs[len(objs)-1], tag.TagNumber) {
+			return errors.Wrap(common.ErrWrongStructure, "malformed log datum")
+		}
+		r.Datum = tag.TagNumber
+		inner := objs[1 : len(objs)-1]
+
+		switch r.Datum {
+		case LogDatumFailure:
+			if len(inner) != 2 {
+				return errors.Wrap(common.ErrWrongObjectCount, "malformed failure log datum")
+			}
+			class, err := objects.DecEnumerated(inner[0])
+			if err != nil {
+				return err
+			}
+			code, err := objects.DecEnumerated(inner[1])
+			if err != nil {
+				return err
+			}
+			r.Value = objects.NewBACnetError(uint8(class), uint8(code))
+		case LogDatumAny:
+			r.Value = inner
+		default:
+			return errors.Wrap(common.ErrNotImplemented, fmt.Sprintf("constructed log datum %d", r.Datum))
+		}
+		return nil
+	}
+
+	if len(objs) != 1 {
+		return errors.Wrap(common.ErrWrongObjectCount, fmt.Sprintf("log datum object count %d", len(objs)))
+	}
+	obj, ok := objs[0].(*objects.Object)
+	if !ok || !obj.TagClass {
+		return errors.Wrap(common.ErrWrongStructure, "log datum isn't context tagged")
+	}
+	r.Datum = obj.TagNumber
+
+	var err error
+	switch r.Datum {
+	case LogDatumLogStatus:
+		r.LogStatus, err = objects.DecBitString(obj)
+	case LogDatumBoolean:
+		r.Value, err = objects.DecBoolean(obj)
+	case LogDatumReal:
+		r.Value, err = objects.DecReal(obj)
+	case LogDatumEnumerated:
+		r.Value, err = objects.DecEnumerated(obj)
+	case LogDatumUnsigned:
+		r.Value, err = objects.DecUnisgnedInteger(obj)
+	case LogDatumSigned:
+		r.Value, err = objects.DecSignedInteger(obj)
+	case LogDatumBitString:
+		r.Value, err = objects.DecBitString(obj)
+	case LogDatumNull:
+		if obj.Length != 0 {
+			err = errors.Wrap(common.ErrWrongStructure, "null log datum carries data")
+		}
+	case LogDatumTimeChange:
+		r.TimeChange, err = objects.DecReal(obj)
+	default:
+		err = errors.Wrap(common.ErrNotImplemented, fmt.Sprintf("log datum %d", r.Datum))
+	}
+
+	return err
+}
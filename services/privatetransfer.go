@@ -0,0 +1,551 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// PrivateTransferDec holds a decoded PrivateTransfer request or
+// acknowledgement. Parameters holds the objects of the service parameters, or
+// of the result block, and is nil when they are left out. Payload holds them
+// once decoded by the codec registered in PrivateTransfers, if any.
+type PrivateTransferDec struct {
+	VendorId      uint16
+	ServiceNumber uint32
+	Parameters    []objects.APDUPayload
+	Payload       interface{}
+}
+
+// PrivateTransferObjects creates the objects of a PrivateTransfer request or
+// acknowledgement. Nil parameters are left out.
+func PrivateTransferObjects(vendorId uint16, serviceNumber uint32, parameters []objects.APDUPayload) []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxUnsigned(0, uint32(vendorId)), ctxUnsigned(1, serviceNumber)}
+	if parameters != nil {
+		objs = append(objs, encConstructed(2, parameters...)...)
+	}
+	return objs
+}
+
+// PrivateTransferErrorObjects creates the objects of the error answering a
+// ConfirmedPrivateTransfer request. Nil parameters are left out.
+func PrivateTransferErrorObjects(errClass, errCode uint8, vendorId uint16, serviceNumber uint32, parameters []objects.APDUPayload) []objects.APDUPayload {
+	objs := encConstructed(0, objects.EncEnumerated(errClass), objects.EncEnumerated(errCode))
+	objs = append(objs, ctxUnsigned(1, uint32(vendorId)), ctxUnsigned(2, serviceNumber))
+	if parameters != nil {
+		objs = append(objs, encConstructed(3, parameters...)...)
+	}
+	return objs
+}
+
+func decPrivateTransfer(objs []objects.APDUPayload) (PrivateTransferDec, error) {
+	r := tagReader{objs: objs}
+	decPT := PrivateTransferDec{}
+
+	decPT.VendorId = uint16(r.unsigned(0))
+	decPT.ServiceNumber = r.unsigned(1)
+	if r.has(2) {
+		decPT.Parameters = r.constructed(2)
+	}
+
+	return decPT, r.end()
+}
+
+// PrivateTransferCodec converts the payload of a vendor's PrivateTransfer
+// service from and to the objects of its service parameters and result block.
+type PrivateTransferCodec interface {
+	EncodeParameters(payload interface{}) ([]objects.APDUPayload, error)
+	DecodeParameters(objs []objects.APDUPayload) (interface{}, error)
+	EncodeResult(result interface{}) ([]objects.APDUPayload, error)
+	DecodeResult(objs []objects.APDUPayload) (interface{}, error)
+}
+
+// PrivateTransferHandler serves a vendor's PrivateTransfer service. It gets the
+// decoded service parameters and returns the result to acknowledge, which is
+// ignored for unconfirmed requests. Without codec, both are the objects of the
+// service parameters and result block.
+type PrivateTransferHandler func(payload interface{}) (interface{}, error)
+
+type privateTransferKey struct {
+	vendorId      uint16
+	serviceNumber uint32
+}
+
+type privateTransferEntry struct {
+	codec   PrivateTransferCodec
+	handler PrivateTransferHandler
+}
+
+// PrivateTransferRegistry holds the codecs and handlers of vendors'
+// PrivateTransfer services.
+type PrivateTransferRegistry struct {
+	mu      sync.RWMutex
+	entries map[privateTransferKey]privateTransferEntry
+}
+
+// PrivateTransfers is the registry used when decoding PrivateTransfer messages.
+var PrivateTransfers = NewPrivateTransferRegistry()
+
+func NewPrivateTransferRegistry() *PrivateTransferRegistry {
+	return &PrivateTransferRegistry{entries: map[privateTransferKey]privateTransferEntry{}}
+}
+
+// Register plugs in the codec and the handler of the service serviceNumber of
+// vendor vendorId, replacing any previous ones. Either may be nil.
+func (r *PrivateTransferRegistry) Register(vendorId uint16, serviceNumber uint32, codec PrivateTransferCodec, handler PrivateTransferHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[privateTransferKey{vendorId, serviceNumber}] = privateTransferEntry{codec, handler}
+}
+
+// Unregister removes the codec and the handler of a vendor's service.
+func (r *PrivateTransferRegistry) Unregister(vendorId uint16, serviceNumber uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, privateTransferKey{vendorId, serviceNumber})
+}
+
+func (r *PrivateTransferRegistry) entry(vendorId uint16, serviceNumber uint32) privateTransferEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.entries[privateTransferKey{vendorId, serviceNumber}]
+}
+
+// ParametersObjects creates the objects of a PrivateTransfer request carrying
+// payload, encoded by the registered codec. Without codec, payload must be the
+// objects of the service parameters.
+func (r *PrivateTransferRegistry) ParametersObjects(vendorId uint16, serviceNumber uint32, payload interface{}) ([]objects.APDUPayload, error) {
+	params, err := encodePrivateTransferPayload(r.entry(vendorId, serviceNumber).codec, payload, false)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("encoding parameters of vendor %d service %d", vendorId, serviceNumber))
+	}
+	return PrivateTransferObjects(vendorId, serviceNumber, params), nil
+}
+
+// ResultObjects creates the objects of a ConfirmedPrivateTransfer
+// acknowledgement carrying result, encoded by the registered codec. Without
+// codec, result must be the objects of the result block.
+func (r *PrivateTransferRegistry) ResultObjects(vendorId uint16, serviceNumber uint32, result interface{}) ([]objects.APDUPayload, error) {
+	block, err := encodePrivateTransferPayload(r.entry(vendorId, serviceNumber).codec, result, true)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("encoding result of vendor %d service %d", vendorId, serviceNumber))
+	}
+	return PrivateTransferObjects(vendorId, serviceNumber, block), nil
+}
+
+// Serve runs the handler registered for the request req and returns the
+// objects of the acknowledgement. The error returned when no handler is
+// registered is a *objects.BACnetError.
+func (r *PrivateTransferRegistry) Serve(req PrivateTransferDec) ([]objects.APDUPayload, error) {
+	e := r.entry(req.VendorId, req.ServiceNumber)
+	if e.handler == nil {
+		return nil, objects.NewBACnetError(objects.ErrorClassService, objects.ErrorCodeOptionalFunctionalityNotSupported)
+	}
+
+	payload := req.Payload
+	if e.codec == nil {
+		payload = req.Parameters
+	}
+	result, err := e.handler(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ResultObjects(req.VendorId, req.ServiceNumber, result)
+}
+
+func (r *PrivateTransferRegistry) decodeParameters(objs []objects.APDUPayload) (interface{}, error) {
+	return r.decode(objs, false)
+}
+
+func (r *PrivateTransferRegistry) decodeResult(objs []objects.APDUPayload) (interface{}, error) {
+	return r.decode(objs, true)
+}
+
+// decode returns the payload of the PrivateTransfer objects objs, or nil when
+// no codec is registered for the service.
+func (r *PrivateTransferRegistry) decode(objs []objects.APDUPayload, result bool) (interface{}, error) {
+	decPT, err := decPrivateTransfer(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := r.entry(decPT.VendorId, decPT.ServiceNumber).codec
+	if codec == nil {
+		return nil, nil
+	}
+	if result {
+		return codec.DecodeResult(decPT.Parameters)
+	}
+	return codec.DecodeParameters(decPT.Parameters)
+}
+
+func encodePrivateTransferPayload(codec PrivateTransferCodec, payload interface{}, result bool) ([]objects.APDUPayload, error) {
+	switch {
+	case codec == nil:
+		if payload == nil {
+			return nil, nil
+		}
+		objs, ok := payload.([]objects.APDUPayload)
+		if !ok {
+			return nil, errors.Wrap(common.ErrWrongPayload, fmt.Sprintf("no codec for payload %T", payload))
+		}
+		return objs, nil
+	case result:
+		return codec.EncodeResult(payload)
+	default:
+		return codec.EncodeParameters(payload)
+	}
+}
+
+// ConfirmedPrivateTransfer is a BACnet message.
+type ConfirmedPrivateTransfer struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+	// Payload holds the service parameters decoded by the codec registered
+	// in PrivateTransfers, if any.
+	Payload interface{}
+}
+
+func NewConfirmedPrivateTransfer(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedPrivateTransfer {
+	c := &ConfirmedPrivateTransfer{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedPrivateTransfer, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedPrivateTransfer) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedPT - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedPT %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedPT %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedPT %v", c),
+		)
+	}
+
+	payload, err := PrivateTransfers.decodeParameters(c.APDU.Objects)
+	if err != nil {
+		return errors.Wrap(err, "decoding ConfirmedPT payload")
+	}
+	c.Payload = payload
+
+	return nil
+}
+
+func (c *ConfirmedPrivateTransfer) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedPrivateTransfer) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedPT - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedPT")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedPT")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedPT")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedPrivateTransfer) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedPrivateTransfer) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedPrivateTransfer) Decode() (PrivateTransferDec, error) {
+	decPT, err := decPrivateTransfer(c.APDU.Objects)
+	if err != nil {
+		return decPT, errors.Wrap(err, "decoding ConfirmedPT")
+	}
+	decPT.Payload = c.Payload
+	return decPT, nil
+}
+
+// UnconfirmedPrivateTransfer is a BACnet message.
+type UnconfirmedPrivateTransfer struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+	// Payload holds the service parameters decoded by the codec registered
+	// in PrivateTransfers, if any.
+	Payload interface{}
+}
+
+func NewUnconfirmedPrivateTransfer(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedPrivateTransfer {
+	u := &UnconfirmedPrivateTransfer{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedPrivateTransfer, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedPrivateTransfer) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedPT - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedPT %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedPT %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedPT %v", u),
+		)
+	}
+
+	payload, err := PrivateTransfers.decodeParameters(u.APDU.Objects)
+	if err != nil {
+		return errors.Wrap(err, "decoding UnconfirmedPT payload")
+	}
+	u.Payload = payload
+
+	return nil
+}
+
+func (u *UnconfirmedPrivateTransfer) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedPrivateTransfer) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedPT - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedPT")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedPT")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedPT")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedPrivateTransfer) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedPrivateTransfer) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedPrivateTransfer) Decode() (PrivateTransferDec, error) {
+	decPT, err := decPrivateTransfer(u.APDU.Objects)
+	if err != nil {
+		return decPT, errors.Wrap(err, "decoding UnconfirmedPT")
+	}
+	decPT.Payload = u.Payload
+	return decPT, nil
+}
+
+// ConfirmedPrivateTransferACK is the ComplexACK answering a ConfirmedPrivateTransfer request.
+type ConfirmedPrivateTransferACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+	// Payload holds the result block decoded by the codec registered
+	// in PrivateTransfers, if any.
+	Payload interface{}
+}
+
+func NewConfirmedPrivateTransferACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedPrivateTransferACK {
+	c := &ConfirmedPrivateTransferACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedPrivateTransfer, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedPrivateTransferACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedPrivateTransferACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedPrivateTransferACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedPrivateTransferACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedPrivateTransferACK %v", c),
+		)
+	}
+
+	payload, err := PrivateTransfers.decodeResult(c.APDU.Objects)
+	if err != nil {
+		return errors.Wrap(err, "decoding ConfirmedPrivateTransferACK payload")
+	}
+	c.Payload = payload
+
+	return nil
+}
+
+func (c *ConfirmedPrivateTransferACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedPrivateTransferACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedPrivateTransferACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedPrivateTransferACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedPrivateTransferACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedPrivateTransferACK")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedPrivateTransferACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedPrivateTransferACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedPrivateTransferACK) Decode() (PrivateTransferDec, error) {
+	decPT, err := decPrivateTransfer(c.APDU.Objects)
+	if err != nil {
+		return decPT, errors.Wrap(err, "decoding ConfirmedPrivateTransferACK")
+	}
+	decPT.Payload = c.Payload
+	return decPT, nil
+}
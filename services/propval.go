@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pkg/errors"
+)
+
+// PropertyValue is a BACnetPropertyValue. Value holds the application tagged
+// objects making up the property value. ArrayIndex is objects.ArrayAll when the whole
+// property is meant and a zero Priority means none was given.
+type PropertyValue struct {
+	PropertyId uint8
+	ArrayIndex uint32
+	Value      []objects.APDUPayload
+	Priority   uint8
+}
+
+// encPropertyValues encodes values as a SEQUENCE OF BACnetPropertyValue
+// enclosed in tag tagN.
+func encPropertyValues(tagN uint8, values []PropertyValue) []objects.APDUPayload {
+	objs := []objects.APDUPayload{objects.EncOpeningTag(tagN)}
+
+	for _, pv := range values {
+		objs = append(objs, objects.EncPropertyIdentifier(true, 0, pv.PropertyId))
+		if pv.ArrayIndex != objects.ArrayAll {
+			objs = append(objs, objects.EncContextTag(1, objects.EncUnsignedInteger32(pv.ArrayIndex)))
+		}
+		objs = append(objs, objects.EncOpeningTag(2))
+		objs = append(objs, pv.Value...)
+		objs = append(objs, objects.EncClosingTag(2))
+		if pv.Priority != 0 {
+			objs = append(objs, objects.EncContextTag(3, objects.EncUnsignedInteger8(pv.Priority)))
+		}
+	}
+
+	return append(objs, objects.EncClosingTag(tagN))
+}
+
+// decPropertyValues decodes a SEQUENCE OF BACnetPropertyValue, objs holding
+// the values without their enclosing tags.
+func decPropertyValues(objs []objects.APDUPayload) ([]PropertyValue, error) {
+	values := []PropertyValue{}
+
+	for i := 0; i < len(objs); {
+		pv := PropertyValue{ArrayIndex: objects.ArrayAll}
+
+		propId, err := objects.DecPropertyIdentifier(objs[i])
+		if err != nil || !isContextTag(objs[i], 0) {
+			return nil, errors.Wrap(
+				common.ErrWrongStructure,
+				fmt.Sprintf("expected property identifier at index %d", i),
+			)
+		}
+		pv.PropertyId = propId
+		i++
+
+		if i < len(objs) && isContextTag(objs[i], 1) {
+			index, err := objects.DecUnisgnedInteger(objs[i])
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding property array index")
+			}
+			pv.ArrayIndex = index
+			i++
+		}
+
+		if i >= len(objs) || !isOpeningTag(objs[i], 2) {
+			return nil, errors.Wrap(
+				common.ErrWrongStructure,
+				fmt.Sprintf("expected property value at index %d", i),
+			)
+		}
+		end, err := closingTagIndex(objs, i)
+		if err != nil {
+			return nil, err
+		}
+		pv.Value = objs[i+1 : end]
+		i = end + 1
+
+		if i < len(objs) && isContextTag(objs[i], 3) {
+			priority, err := objects.DecUnisgnedInteger(objs[i])
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding priority")
+			}
+			pv.Priority = uint8(priority)
+			i++
+		}
+
+		values = append(values, pv)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedReadRange is a BACnet message.
+type ConfirmedReadRange struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedReadRangeDec holds a decoded ReadRange request. Reference is the
+// index or the sequence number the range starts at, and Time the time it
+// starts at for ReadRangeByTime. A negative Count reads the items before the
+// reference.
+type ConfirmedReadRangeDec struct {
+	ObjectType uint16
+	InstanceId uint32
+	PropertyId uint8
+	ArrayIndex uint32
+	RangeType  uint8
+	Reference  uint32
+	Time       time.Time
+	Count      int32
+}
+
+// ConfirmedReadRangeObjects creates the objects of a ReadRange request reading
+// every item of the list.
+func ConfirmedReadRangeObjects(objectType uint16, instN uint32, propertyId uint8) []objects.APDUPayload {
+	return readRangeObjects(objectType, instN, propertyId)
+}
+
+// ConfirmedReadRangeByPositionObjects creates the objects of a ReadRange
+// request reading count items from the 1 based index.
+func ConfirmedReadRangeByPositionObjects(objectType uint16, instN uint32, propertyId uint8, index uint32, count int32) []objects.APDUPayload {
+	return readRangeObjects(objectType, instN, propertyId,
+		objects.EncOpeningTag(ReadRangeByPosition),
+		objects.EncUnsignedInteger32(index),
+		objects.EncSignedInteger(count),
+		objects.EncClosingTag(ReadRangeByPosition),
+	)
+}
+
+// ConfirmedReadRangeBySequenceNumberObjects creates the objects of a ReadRange
+// request reading count items from sequence number seq.
+func ConfirmedReadRangeBySequenceNumberObjects(objectType uint16, instN uint32, propertyId uint8, seq uint32, count int32) []objects.APDUPayload {
+	return readRangeObjects(objectType, instN, propertyId,
+		objects.EncOpeningTag(ReadRangeBySequenceNumber),
+		objects.EncUnsignedInteger32(seq),
+		objects.EncSignedInteger(count),
+		objects.EncClosingTag(ReadRangeBySequenceNumber),
+	)
+}
+
+// ConfirmedReadRangeByTimeObjects creates the objects of a ReadRange request
+// reading count items timestamped after t, or before t when count is negative.
+func ConfirmedReadRangeByTimeObjects(objectType uint16, instN uint32, propertyId uint8, t time.Time, count int32) []objects.APDUPayload {
+	objs := []objects.APDUPayload{objects.EncOpeningTag(ReadRangeByTime)}
+	objs = append(objs, objects.EncDateTime(t)...)
+	objs = append(objs, objects.EncSignedInteger(count), objects.EncClosingTag(ReadRangeByTime))
+	return readRangeObjects(objectType, instN, propertyId, objs...)
+}
+
+func readRangeObjects(objectType uint16, instN uint32, propertyId uint8, rng ...objects.APDUPayload) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, len(rng)+2)
+	objs = append(objs, objects.EncObjectIdentifier(true, 0, objectType, instN))
+	objs = append(objs, objects.EncPropertyIdentifier(true, 1, propertyId))
+	return append(objs, rng...)
+}
+
+func NewConfirmedReadRange(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedReadRange {
+	c := &ConfirmedReadRange{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedReadRange, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedReadRange) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedRR - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRR %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRR %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRR %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedReadRange) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedReadRange) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedRR - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRR")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRR")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRR")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedReadRange) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedReadRange) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedReadRange) Decode() (ConfirmedReadRangeDec, error) {
+	decRR := ConfirmedReadRangeDec{ArrayIndex: objects.ArrayAll}
+
+	objs := c.APDU.Objects
+	if len(objs) < 2 {
+		return decRR, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ConfirmedRR - object count %d", len(objs)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(objs[0])
+	if err != nil {
+		return decRR, errors.Wrap(err, "decoding ConfirmedRR")
+	}
+	decRR.ObjectType = objId.ObjectType
+	decRR.InstanceId = objId.InstanceNumber
+
+	propId, err := objects.DecPropertyIdentifier(objs[1])
+	if err != nil {
+		return decRR, errors.Wrap(err, "decoding ConfirmedRR")
+	}
+	decRR.PropertyId = propId
+
+	objs = objs[2:]
+	if len(objs) > 0 && isContextTag(objs[0], 2) {
+		index, err := objects.DecUnisgnedInteger(objs[0])
+		if err != nil {
+			return decRR, errors.Wrap(err, "decoding ConfirmedRR")
+		}
+		decRR.ArrayIndex = index
+		objs = objs[1:]
+	}
+	if len(objs) == 0 {
+		return decRR, nil
+	}
+
+	var rangeLen int
+	switch {
+	case isOpeningTag(objs[0], ReadRangeByPosition):
+		decRR.RangeType = ReadRangeByPosition
+		rangeLen = 4
+	case isOpeningTag(objs[0], ReadRangeBySequenceNumber):
+		decRR.RangeType = ReadRangeBySequenceNumber
+		rangeLen = 4
+	case isOpeningTag(objs[0], ReadRangeByTime):
+		decRR.RangeType = ReadRangeByTime
+		rangeLen = 5
+	default:
+		return decRR, errors.Wrap(common.ErrWrongStructure, "decoding ConfirmedRR - unknown range")
+	}
+	if len(objs) != rangeLen || !isClosingTag(objs[rangeLen-1], decRR.RangeType) {
+		return decRR, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ConfirmedRR - range object count %d", len(objs)),
+		)
+	}
+
+	if decRR.RangeType == ReadRangeByTime {
+		if decRR.Time, err = objects.DecDateTime(objs[1], objs[2]); err != nil {
+			return decRR, errors.Wrap(err, "decoding ConfirmedRR")
+		}
+	} else if decRR.Reference, err = objects.DecUnisgnedInteger(objs[1]); err != nil {
+		return decRR, errors.Wrap(err, "decoding ConfirmedRR")
+	}
+
+	if decRR.Count, err = objects.DecSignedInteger(objs[rangeLen-2]); err != nil {
+		return decRR, errors.Wrap(err, "decoding ConfirmedRR")
+	}
+
+	return decRR, nil
+}
+
+// ReadRangeACK is the ComplexACK answering a ReadRange request.
+type ReadRangeACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ReadRangeACKDec holds a decoded ReadRange acknowledgement. ItemData holds the
+// application tagged objects of the items read, see DecLogRecords to decode
+// the Log_Buffer items. FirstSequenceNumber is only meaningful when
+// SequenceNumbered is set, as it is for lists of sequence numbered items.
+type ReadRangeACKDec struct {
+	ObjectType          uint16
+	InstanceId          uint32
+	PropertyId          uint8
+	ArrayIndex          uint32
+	FirstItem           bool
+	LastItem            bool
+	MoreItems           bool
+	ItemCount           uint32
+	ItemData            []objects.APDUPayload
+	SequenceNumbered    bool
+	FirstSequenceNumber uint32
+}
+
+// ReadRangeACKObjects creates the objects of the ReadRange acknowledgement
+// described by ack.
+func ReadRangeACKObjects(ack ReadRangeACKDec) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, len(ack.ItemData)+8)
+
+	objs = append(objs, objects.EncObjectIdentifier(true, 0, ack.ObjectType, ack.InstanceId))
+	objs = append(objs, objects.EncPropertyIdentifier(true, 1, ack.PropertyId))
+	if ack.ArrayIndex != objects.ArrayAll {
+		objs = append(objs, objects.EncContextTag(2, objects.EncUnsignedInteger32(ack.ArrayIndex)))
+	}
+	objs = append(objs, objects.EncContextTag(3, objects.EncBitString([]bool{ack.FirstItem, ack.LastItem, ack.MoreItems})))
+	objs = append(objs, objects.EncContextTag(4, objects.EncUnsignedInteger32(ack.ItemCount)))
+	objs = append(objs, objects.EncOpeningTag(5))
+	objs = append(objs, ack.ItemData...)
+	objs = append(objs, objects.EncClosingTag(5))
+	if ack.SequenceNumbered {
+		objs = append(objs, objects.EncContextTag(6, objects.EncUnsignedInteger32(ack.FirstSequenceNumber)))
+	}
+
+	return objs
+}
+
+func NewReadRangeACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ReadRangeACK {
+	c := &ReadRangeACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedReadRange, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ReadRangeACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ReadRangeACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ReadRangeACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ReadRangeACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ReadRangeACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ReadRangeACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ReadRangeACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ReadRangeACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ReadRangeACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ReadRangeACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ReadRangeACK")
+	}
+
+	return nil
+}
+
+func (c *ReadRangeACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ReadRangeACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ReadRangeACK) Decode() (ReadRangeACKDec, error) {
+	decRR := ReadRangeACKDec{ArrayIndex: objects.ArrayAll}
+
+	objs := c.APDU.Objects
+	if len(objs) < 6 {
+		return decRR, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ReadRangeACK - object count %d", len(objs)),
+		)
+	}
+
+	objId, err := objects.DecObjectIdentifier(objs[0])
+	if err != nil {
+		return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+	}
+	decRR.ObjectType = objId.ObjectType
+	decRR.InstanceId = objId.InstanceNumber
+
+	propId, err := objects.DecPropertyIdentifier(objs[1])
+	if err != nil {
+		return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+	}
+	decRR.PropertyId = propId
+
+	i := 2
+	if isContextTag(objs[i], 2) {
+		index, err := objects.DecUnisgnedInteger(objs[i])
+		if err != nil {
+			return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+		}
+		decRR.ArrayIndex = index
+		i++
+	}
+
+	if len(objs) < i+4 || !isContextTag(objs[i], 3) || !isContextTag(objs[i+1], 4) || !isOpeningTag(objs[i+2], 5) {
+		return decRR, errors.Wrap(common.ErrWrongStructure, "decoding ReadRangeACK - malformed result")
+	}
+
+	flags, err := objects.DecBitString(objs[i])
+	if err != nil {
+		return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+	}
+	flags = append(flags, make([]bool, 3)...)
+	decRR.FirstItem, decRR.LastItem, decRR.MoreItems = flags[0], flags[1], flags[2]
+
+	if decRR.ItemCount, err = objects.DecUnisgnedInteger(objs[i+1]); err != nil {
+		return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+	}
+
+	end, err := closingTagIndex(objs, i+2)
+	if err != nil {
+		return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+	}
+	decRR.ItemData = objs[i+3 : end]
+
+	switch rest := objs[end+1:]; len(rest) {
+	case 0:
+	case 1:
+		if decRR.FirstSequenceNumber, err = objects.DecUnisgnedInteger(rest[0]); err != nil {
+			return decRR, errors.Wrap(err, "decoding ReadRangeACK")
+		}
+		decRR.SequenceNumbered = true
+	default:
+		return decRR, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode ReadRangeACK - %d objects after item data", len(rest)),
+		)
+	}
+
+	return decRR, nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pkg/errors"
+)
+
+// Recipient is a BACnetRecipient: the Device object of the recipient or, when
+// ByAddress is set, its network number and MAC address.
+type Recipient struct {
+	ByAddress bool
+	Device    objects.ObjectIdentifier
+	Network   uint16
+	MAC       []byte
+}
+
+// RecipientProcess is a BACnetRecipientProcess.
+type RecipientProcess struct {
+	Recipient Recipient
+	ProcessId uint32
+}
+
+// encRecipient encodes rcp as a BACnetRecipient carrying the context tag tagN.
+func encRecipient(tagN uint8, rcp Recipient) []objects.APDUPayload {
+	return encConstructed(tagN, encRecipientChoice(rcp)...)
+}
+
+// encRecipientChoice encodes rcp without enclosing tags, as found in
+// BACnetDestination.
+func encRecipientChoice(rcp Recipient) []objects.APDUPayload {
+	if !rcp.ByAddress {
+		return []objects.APDUPayload{ctxObjectId(0, rcp.Device)}
+	}
+	return encAddress(1, Address{Network: rcp.Network, MAC: rcp.MAC})
+}
+
+func decRecipient(objs []objects.APDUPayload) (Recipient, error) {
+	r := tagReader{objs: objs}
+	rcp := r.recipientChoice()
+	return rcp, r.end()
+}
+
+func (r *tagReader) recipientChoice() Recipient {
+	rcp := Recipient{}
+
+	if r.has(0) {
+		rcp.Device = r.objectId(0)
+		return rcp
+	}
+
+	rcp.ByAddress = true
+	inner := r.constructed(1)
+	if r.err != nil {
+		return rcp
+	}
+	address, err := decAddress(inner)
+	r.check(err, "address", 1)
+	rcp.Network, rcp.MAC = address.Network, address.MAC
+	return rcp
+}
+
+// Address is a BACnetAddress. A Network of 0 stands for the local network.
+type Address struct {
+	Network uint16
+	MAC     []byte
+}
+
+func encAddress(tagN uint8, a Address) []objects.APDUPayload {
+	return encConstructed(tagN,
+		objects.EncUnsignedInteger32(uint32(a.Network)),
+		objects.EncOctetString(a.MAC),
+	)
+}
+
+func decAddress(objs []objects.APDUPayload) (Address, error) {
+	a := Address{}
+	if len(objs) != 2 {
+		return a, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("address object count %d", len(objs)),
+		)
+	}
+	network, err := objects.DecUnisgnedInteger(objs[0])
+	if err != nil {
+		return a, err
+	}
+	a.Network = uint16(network)
+	if a.MAC, err = objects.DecOctetString(objs[1]); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+func encRecipientProcess(tagN uint8, rp RecipientProcess) []objects.APDUPayload {
+	objs := encRecipient(0, rp.Recipient)
+	return encConstructed(tagN, append(objs, ctxUnsigned(1, rp.ProcessId))...)
+}
+
+func decRecipientProcess(objs []objects.APDUPayload) (RecipientProcess, error) {
+	r := tagReader{objs: objs}
+	rp := RecipientProcess{}
+
+	if inner := r.constructed(0); r.err == nil {
+		rp.Recipient, r.err = decRecipient(inner)
+	}
+	rp.ProcessId = r.unsigned(1)
+
+	return rp, r.end()
+}
+
+// Destination is a BACnetDestination, an entry of the Recipient_List of
+// Notification Class objects. ValidDays starts on Monday and FromTime and
+// ToTime are times of day, the window being inclusive. Transitions are the
+// TO-OFFNORMAL, TO-FAULT and TO-NORMAL ones.
+type Destination struct {
+	ValidDays      []bool
+	FromTime       time.Duration
+	ToTime         time.Duration
+	Recipient      Recipient
+	ProcessId      uint32
+	IssueConfirmed bool
+	Transitions    []bool
+}
+
+// Active tells whether d takes notifications at t.
+func (d Destination) Active(t time.Time) bool {
+	// time.Weekday starts on Sunday.
+	day := (int(t.Weekday()) + 6) % 7
+	if day >= len(d.ValidDays) || !d.ValidDays[day] {
+		return false
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	tod := t.Sub(midnight)
+	return tod >= d.FromTime && tod <= d.ToTime
+}
+
+// DestinationsObjects creates the objects of a Recipient_List holding dests.
+func DestinationsObjects(dests []Destination) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+	for _, d := range dests {
+		objs = append(objs,
+			objects.EncBitString(d.ValidDays),
+			objects.EncTime(d.FromTime),
+			objects.EncTime(d.ToTime),
+		)
+		objs = append(objs, encRecipientChoice(d.Recipient)...)
+		objs = append(objs,
+			objects.EncUnsignedInteger32(d.ProcessId),
+			objects.EncBoolean(d.IssueConfirmed),
+			objects.EncBitString(d.Transitions),
+		)
+	}
+	return objs
+}
+
+// DecodeDestinations decodes the objects of a Recipient_List.
+func DecodeDestinations(objs []objects.APDUPayload) ([]Destination, error) {
+	dests := []Destination{}
+
+	for len(objs) > 0 {
+		if len(objs) < 7 {
+			return dests, errors.Wrap(
+				common.ErrWrongObjectCount,
+				fmt.Sprintf("destination object count %d", len(objs)),
+			)
+		}
+
+		d := Destination{}
+		var err error
+		if d.ValidDays, err = objects.DecBitString(objs[0]); err != nil {
+			return dests, errors.Wrap(err, "decoding destination valid days")
+		}
+		if d.FromTime, err = objects.DecTime(objs[1]); err != nil {
+			return dests, errors.Wrap(err, "decoding destination from time")
+		}
+		if d.ToTime, err = objects.DecTime(objs[2]); err != nil {
+			return dests, errors.Wrap(err, "decoding destination to time")
+		}
+
+		r := tagReader{objs: objs[3:]}
+		d.Recipient = r.recipientChoice()
+		if r.err != nil {
+			return dests, errors.Wrap(r.err, "decoding destination recipient")
+		}
+		objs = r.objs
+		if len(objs) < 3 {
+			return dests, errors.Wrap(
+				common.ErrWrongObjectCount,
+				fmt.Sprintf("destination object count %d after recipient", len(objs)),
+			)
+		}
+
+		if d.ProcessId, err = objects.DecUnisgnedInteger(objs[0]); err != nil {
+			return dests, errors.Wrap(err, "decoding destination process identifier")
+		}
+		if d.IssueConfirmed, err = objects.DecBoolean(objs[1]); err != nil {
+			return dests, errors.Wrap(err, "decoding destination issue confirmed notifications")
+		}
+		if d.Transitions, err = objects.DecBitString(objs[2]); err != nil {
+			return dests, errors.Wrap(err, "decoding destination transitions")
+		}
+		objs = objs[3:]
+
+		dests = append(dests, d)
+	}
+
+	return dests, nil
+}
+
+// AddressBinding is an entry of the Device_Address_Binding of a Device
+// object: the address a device is bound to.
+type AddressBinding struct {
+	Device  objects.ObjectIdentifier
+	Address Address
+}
+
+// AddressBindingsObjects creates the objects of a Device_Address_Binding
+// holding bindings.
+func AddressBindingsObjects(bindings []AddressBinding) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+	for _, b := range bindings {
+		objs = append(objs,
+			objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, b.Device.ObjectType, b.Device.InstanceNumber),
+			objects.EncUnsignedInteger32(uint32(b.Address.Network)),
+			objects.EncOctetString(b.Address.MAC),
+		)
+	}
+	return objs
+}
+
+// DecodeAddressBindings decodes the objects of a Device_Address_Binding.
+func DecodeAddressBindings(objs []objects.APDUPayload) ([]AddressBinding, error) {
+	bindings := []AddressBinding{}
+
+	if len(objs)%3 != 0 {
+		return bindings, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("address binding object count %d", len(objs)),
+		)
+	}
+
+	for i := 0; i < len(objs); i += 3 {
+		device, err := objects.DecObjectIdentifier(objs[i])
+		if err != nil {
+			return bindings, errors.Wrap(err, "decoding address binding")
+		}
+		address, err := decAddress(objs[i+1 : i+3])
+		if err != nil {
+			return bindings, errors.Wrap(err, "decoding address binding")
+		}
+		bindings = append(bindings, AddressBinding{Device: device, Address: address})
+	}
+
+	return bindings, nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ConfirmedReinitializeDevice is a BACnet message.
+type ConfirmedReinitializeDevice struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// ConfirmedReinitializeDeviceDec holds a decoded ReinitializeDevice request.
+// An empty Password means none was sent.
+type ConfirmedReinitializeDeviceDec struct {
+	State    uint8
+	Password string
+}
+
+// ConfirmedReinitializeDeviceObjects creates the objects of a
+// ReinitializeDevice request. An empty password is omitted.
+func ConfirmedReinitializeDeviceObjects(state uint8, password string) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+
+	objs = append(objs, objects.EncContextTag(0, objects.EncEnumerated(state)))
+	if password != "" {
+		objs = append(objs, objects.EncContextTag(1, objects.EncString(password)))
+	}
+
+	return objs
+}
+
+func NewConfirmedReinitializeDevice(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedReinitializeDevice {
+	c := &ConfirmedReinitializeDevice{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedReinitializeDevice, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedReinitializeDevice) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedRD - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRD %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRD %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedRD %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedReinitializeDevice) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedReinitializeDevice) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedRD - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRD")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRD")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedRD")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedReinitializeDevice) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedReinitializeDevice) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedReinitializeDevice) Decode() (ConfirmedReinitializeDeviceDec, error) {
+	decRD := ConfirmedReinitializeDeviceDec{}
+
+	r := tagReader{objs: c.APDU.Objects}
+	decRD.State = uint8(r.enumerated(0))
+	if r.has(1) {
+		decRD.Password = r.str(1)
+		r.check(CheckPassword(decRD.Password), "password", 1)
+	}
+	if err := r.end(); err != nil {
+		return ConfirmedReinitializeDeviceDec{}, errors.Wrap(err, "failed to decode ConfirmedRD")
+	}
+
+	return decRD, nil
+}
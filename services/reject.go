@@ -0,0 +1,228 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// Reject is the PDU rejecting a confirmed request.
+type Reject struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewReject(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *Reject {
+	r := &Reject{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.Reject, objects.RejectReasonOther, nil),
+	}
+	r.SetLength()
+
+	return r
+}
+
+func (r *Reject) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < r.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal Reject - marshal length %d binary length %d", r.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := r.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling Reject %v", r),
+		)
+	}
+	offset += r.BVLC.MarshalLen()
+
+	if err := r.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling Reject %v", r),
+		)
+	}
+	offset += r.NPDU.MarshalLen()
+
+	if err := r.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling Reject %v", r),
+		)
+	}
+
+	return nil
+}
+
+func (r *Reject) MarshalBinary() ([]byte, error) {
+	b := make([]byte, r.MarshalLen())
+	if err := r.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (r *Reject) MarshalTo(b []byte) error {
+	if len(b) < r.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal Reject - marshal length %d binary length %d", r.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := r.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal Reject")
+	}
+	offset += r.BVLC.MarshalLen()
+
+	if err := r.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal Reject")
+	}
+	offset += r.NPDU.MarshalLen()
+
+	if err := r.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal Reject")
+	}
+
+	return nil
+}
+
+func (r *Reject) MarshalLen() int {
+	l := r.BVLC.MarshalLen()
+	l += r.NPDU.MarshalLen()
+	l += r.APDU.MarshalLen()
+
+	return l
+}
+
+func (r *Reject) SetLength() {
+	r.BVLC.Length = uint16(r.MarshalLen())
+}
+
+// Decode returns the error carrying the reason of the Reject.
+func (r *Reject) Decode() (*objects.RejectError, error) {
+	if len(r.APDU.Objects) != 0 {
+		return nil, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode Reject - object count %d", len(r.APDU.Objects)),
+		)
+	}
+	return objects.NewRejectError(r.APDU.Service), nil
+}
+
+// Abort is the PDU aborting a transaction.
+type Abort struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewAbort(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *Abort {
+	a := &Abort{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.Abort, objects.AbortReasonOther, nil),
+	}
+	a.SetLength()
+
+	return a
+}
+
+func (a *Abort) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < a.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal Abort - marshal length %d binary length %d", a.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := a.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling Abort %v", a),
+		)
+	}
+	offset += a.BVLC.MarshalLen()
+
+	if err := a.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling Abort %v", a),
+		)
+	}
+	offset += a.NPDU.MarshalLen()
+
+	if err := a.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling Abort %v", a),
+		)
+	}
+
+	return nil
+}
+
+func (a *Abort) MarshalBinary() ([]byte, error) {
+	b := make([]byte, a.MarshalLen())
+	if err := a.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (a *Abort) MarshalTo(b []byte) error {
+	if len(b) < a.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal Abort - marshal length %d binary length %d", a.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := a.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal Abort")
+	}
+	offset += a.BVLC.MarshalLen()
+
+	if err := a.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal Abort")
+	}
+	offset += a.NPDU.MarshalLen()
+
+	if err := a.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal Abort")
+	}
+
+	return nil
+}
+
+func (a *Abort) MarshalLen() int {
+	l := a.BVLC.MarshalLen()
+	l += a.NPDU.MarshalLen()
+	l += a.APDU.MarshalLen()
+
+	return l
+}
+
+func (a *Abort) SetLength() {
+	a.BVLC.Length = uint16(a.MarshalLen())
+}
+
+// Decode returns the error carrying the reason of the Abort.
+func (a *Abort) Decode() (*objects.AbortError, error) {
+	if len(a.APDU.Objects) != 0 {
+		return nil, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode Abort - object count %d", len(a.APDU.Objects)),
+		)
+	}
+	return objects.NewAbortError(a.APDU.Service, a.APDU.Flags&0x1 != 0), nil
+}
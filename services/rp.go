@@ -31,19 +31,23 @@ func ConfirmedReadPropertyObjects(objectType uint16, instN uint32, propertyId ui
 	return objs
 }
 
-func ConfirmedReadMultiplePropertyObjects(objectType uint16, instN uint32, propertyId []uint8) []objects.APDUPayload {
-	objs := make([]objects.APDUPayload, 3+len(propertyId))
-
-	objs[0] = objects.EncObjectIdentifier(true, 0, objectType, instN)
-	objs[1] = objects.EncOpeningTag(1)
+// ConfirmedReadPropertyIndexObjects creates the objects of a ReadProperty
+// request reading the element arrayIndex of an array property, its size
+// being element 0.
+func ConfirmedReadPropertyIndexObjects(objectType uint16, instN uint32, propertyId uint8, arrayIndex uint32) []objects.APDUPayload {
+	return append(
+		ConfirmedReadPropertyObjects(objectType, instN, propertyId),
+		ctxUnsigned(2, arrayIndex),
+	)
+}
 
-	for i := range propertyId {
-		objs[i+2] = objects.EncPropertyIdentifier(true, 1, propertyId[i])
+func ConfirmedReadMultiplePropertyObjects(objectType uint16, instN uint32, propertyId []uint8) []objects.APDUPayload {
+	spec := ReadAccessSpec{ObjectType: objectType, InstanceId: instN}
+	for _, id := range propertyId {
+		spec.Properties = append(spec.Properties, PropertyReference{PropertyId: id})
 	}
 
-	objs[len(propertyId)+2] = objects.EncClosingTag(1)
-
-	return objs
+	return ReadPropertyMultipleObjects([]ReadAccessSpec{spec})
 }
 
 func NewConfirmedReadProperty(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedReadProperty {
@@ -179,3 +183,26 @@ func (c *ConfirmedReadProperty) Decode() (ConfirmedReadPropertyDec, error) {
 
 	return decCRP, nil
 }
+
+// DecodeReference decodes a ReadProperty request, which may read an element
+// of an array: ArrayIndex is objects.ArrayAll when the whole property is read.
+func (c *ConfirmedReadProperty) DecodeReference() (objects.DeviceObjectPropertyReference, error) {
+	ref := objects.DeviceObjectPropertyReference{ArrayIndex: objects.ArrayAll}
+
+	r := tagReader{objs: c.APDU.Objects}
+	id := r.objectId(0)
+	ref.ObjectType, ref.InstanceNumber = id.ObjectType, id.InstanceNumber
+	if obj := r.primitive(1); obj != nil {
+		propId, err := objects.DecPropertyIdentifier(obj)
+		r.check(err, "property identifier", 1)
+		ref.PropertyId = propId
+	}
+	if r.has(2) {
+		ref.ArrayIndex = r.unsigned(2)
+	}
+	if err := r.end(); err != nil {
+		return ref, errors.Wrap(err, "decoding ConfirmedRP")
+	}
+
+	return ref, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ReadAccessSpec is a ReadAccessSpecification: the properties to read from an
+// object with ReadPropertyMultiple.
+type ReadAccessSpec struct {
+	ObjectType uint16
+	InstanceId uint32
+	Properties []PropertyReference
+}
+
+// ReadResult is the result of reading a property: its value, as application
+// tagged objects, or the error met reading it, in which case Value is nil.
+type ReadResult struct {
+	PropertyId uint8
+	ArrayIndex *uint32
+	Value      []objects.APDUPayload
+	Err        *objects.BACnetError
+}
+
+// ReadAccessResult holds the results of reading the properties of an object.
+type ReadAccessResult struct {
+	ObjectType uint16
+	InstanceId uint32
+	Results    []ReadResult
+}
+
+// ReadPropertyMultipleObjects creates the objects of a ReadPropertyMultiple
+// request reading specs.
+func ReadPropertyMultipleObjects(specs []ReadAccessSpec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+
+	for _, spec := range specs {
+		objs = append(objs, objects.EncObjectIdentifier(true, 0, spec.ObjectType, spec.InstanceId))
+		objs = append(objs, objects.EncOpeningTag(1))
+		for _, ref := range spec.Properties {
+			objs = append(objs, objects.EncPropertyIdentifier(true, 0, ref.PropertyId))
+			if ref.ArrayIndex != nil {
+				objs = append(objs, ctxUnsigned(1, *ref.ArrayIndex))
+			}
+		}
+		objs = append(objs, objects.EncClosingTag(1))
+	}
+
+	return objs
+}
+
+// DecodeMultiple decodes the read access specifications of a
+// ReadPropertyMultiple request.
+func (c *ConfirmedReadProperty) DecodeMultiple() ([]ReadAccessSpec, error) {
+	specs := []ReadAccessSpec{}
+
+	r := tagReader{objs: c.APDU.Objects}
+	for len(r.objs) > 0 && r.err == nil {
+		id := r.objectId(0)
+		spec := ReadAccessSpec{ObjectType: id.ObjectType, InstanceId: id.InstanceNumber}
+
+		refs := r.constructed(1)
+		for i := 0; i < len(refs) && r.err == nil; {
+			end := i + 1
+			if end < len(refs) && isContextTag(refs[end], 1) {
+				end++
+			}
+			ref, err := decPropertyReference(refs[i:end])
+			r.check(err, "property reference", 1)
+			if ref != nil {
+				spec.Properties = append(spec.Properties, *ref)
+			}
+			i = end
+		}
+
+		specs = append(specs, spec)
+	}
+	if err := r.end(); err != nil {
+		return nil, errors.Wrap(err, "decoding ConfirmedRPM")
+	}
+
+	return specs, nil
+}
+
+// ReadPropertyMultipleACKObjects creates the objects of a
+// ReadPropertyMultipleACK holding results.
+func ReadPropertyMultipleACKObjects(results []ReadAccessResult) []objects.APDUPayload {
+	objs := []objects.APDUPayload{}
+
+	for _, res := range results {
+		objs = append(objs, objects.EncObjectIdentifier(true, 0, res.ObjectType, res.InstanceId))
+		objs = append(objs, objects.EncOpeningTag(1))
+		for _, rr := range res.Results {
+			objs = append(objs, objects.EncPropertyIdentifier(true, 2, rr.PropertyId))
+			if rr.ArrayIndex != nil {
+				objs = append(objs, ctxUnsigned(3, *rr.ArrayIndex))
+			}
+			if rr.Err != nil {
+				objs = append(objs, encConstructed(5,
+					objects.EncEnumerated(rr.Err.Class),
+					objects.EncEnumerated(rr.Err.Code),
+				)...)
+			} else {
+				objs = append(objs, encConstructed(4, rr.Value...)...)
+			}
+		}
+		objs = append(objs, objects.EncClosingTag(1))
+	}
+
+	return objs
+}
+
+// ReadPropertyMultipleACK is the ComplexACK answering a ReadPropertyMultiple request.
+type ReadPropertyMultipleACK struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewReadPropertyMultipleACK(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ReadPropertyMultipleACK {
+	c := &ReadPropertyMultipleACK{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ComplexAck, ServiceConfirmedReadPropMultiple, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ReadPropertyMultipleACK) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ReadPropertyMultipleACK - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ReadPropertyMultipleACK %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ReadPropertyMultipleACK %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ReadPropertyMultipleACK %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ReadPropertyMultipleACK) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ReadPropertyMultipleACK) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ReadPropertyMultipleACK - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ReadPropertyMultipleACK")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ReadPropertyMultipleACK")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ReadPropertyMultipleACK")
+	}
+
+	return nil
+}
+
+func (c *ReadPropertyMultipleACK) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ReadPropertyMultipleACK) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+// Decode decodes the results held by a ReadPropertyMultipleACK.
+func (c *ReadPropertyMultipleACK) Decode() ([]ReadAccessResult, error) {
+	results := []ReadAccessResult{}
+
+	r := tagReader{objs: c.APDU.Objects}
+	for len(r.objs) > 0 && r.err == nil {
+		id := r.objectId(0)
+		res := ReadAccessResult{ObjectType: id.ObjectType, InstanceId: id.InstanceNumber}
+
+		inner := tagReader{objs: r.constructed(1)}
+		for len(inner.objs) > 0 && inner.err == nil {
+			rr := ReadResult{}
+			if obj := inner.primitive(2); obj != nil {
+				propId, err := objects.DecPropertyIdentifier(obj)
+				inner.check(err, "property identifier", 2)
+				rr.PropertyId = propId
+			}
+			if inner.has(3) {
+				index := inner.unsigned(3)
+				rr.ArrayIndex = &index
+			}
+			if inner.has(5) {
+				readErr, err := decErrorPair(inner.constructed(5))
+				inner.check(err, "property access error", 5)
+				rr.Err = readErr
+			} else {
+				rr.Value = inner.constructed(4)
+			}
+			res.Results = append(res.Results, rr)
+		}
+		r.check(inner.end(), "list of results", 1)
+
+		results = append(results, res)
+	}
+	if err := r.end(); err != nil {
+		return nil, errors.Wrap(err, "decoding ReadPropertyMultipleACK")
+	}
+
+	return results, nil
+}
@@ -6,12 +6,15 @@ package services_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pierreyves258/bacnet"
 	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
 	"github.com/pierreyves258/bacnet/plumbing"
 	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
 )
 
 type serializeable interface {
@@ -88,6 +91,25 @@ func TestUnconfirmedIAm(t *testing.T) {
 				0x21, 0x01, // Vendor ID
 			},
 		},
+		{
+			description: "Unconfirmed request IAm frame forwarded by a router",
+			structured: func() *services.UnconfirmedIAm {
+				npdu := plumbing.NewNPDU(false, false, true, false)
+				npdu.SNET = 5
+				npdu.SLEN = 1
+				npdu.SADR = []byte{0x0a}
+				return services.NewUnconfirmedIAm(plumbing.NewBVLC(plumbing.BVLCFuncBroadcast), npdu)
+			}(),
+			serialized: []byte{
+				0x81, 0x0b, 0x00, 0x18, // BVLC
+				0x01, 0x08, 0x00, 0x05, 0x01, 0x0a, // NPDU
+				0x10, 0x00, // APDU
+				0xc4, 0x02, 0x00, 0x00, 0x01, // device object
+				0x22, 0x04, 0x00, // Max APDU length accepted
+				0x91, 0x00, // Segmentation supported
+				0x21, 0x01, // Vendor ID
+			},
+		},
 	}
 
 	for _, c := range testcases {
@@ -172,3 +194,1610 @@ func TestIntToBool(t *testing.T) {
 		})
 	}
 }
+
+func TestConfirmedDeviceCommunicationControl(t *testing.T) {
+	t.Helper()
+	dcc := services.NewConfirmedDeviceCommunicationControl(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	dcc.APDU.MaxSize = 5
+	dcc.APDU.InvokeID = 1
+	dcc.APDU.Objects = services.ConfirmedDeviceCommunicationControlObjects(5, services.CommunicationDisable, "filister")
+	dcc.SetLength()
+
+	serialized := []byte{
+		0x81, 0x0a, 0x00, 0x19, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x01, 0x11, // APDU
+		0x09, 0x05, // Time duration
+		0x19, 0x01, // Enable-disable
+		0x2d, 0x09, 0x00, 0x66, 0x69, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x72, // Password
+	}
+
+	t.Run("Decode", func(t *testing.T) {
+		msg, err := bacnet.Parse(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(serializeable(dcc), msg); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+
+		dec, err := msg.(*services.ConfirmedDeviceCommunicationControl).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := services.ConfirmedDeviceCommunicationControlDec{
+			Duration: 5, EnableDisable: services.CommunicationDisable, Password: "filister",
+		}
+		if diff := cmp.Diff(want, dec); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+	})
+	t.Run("Serialize", func(t *testing.T) {
+		b, err := dcc.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(serialized, b); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+	})
+	t.Run("Malformed", func(t *testing.T) {
+		objs := services.ConfirmedDeviceCommunicationControlObjects(5, services.CommunicationDisable, "filister")
+		duration, enable, password := objs[0], objs[1], objs[2]
+		unknown := &objects.Object{TagNumber: 3, TagClass: true, Length: 1, Data: []byte{0}}
+
+		for name, objs := range map[string][]objects.APDUPayload{
+			"missing enable-disable": {duration, password},
+			"out of order":           {enable, duration},
+			"duplicate":              {enable, enable},
+			"unknown tag":            {enable, unknown},
+			"password only":          {password},
+		} {
+			msg := services.NewConfirmedDeviceCommunicationControl(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, true))
+			msg.APDU.Objects = objs
+			if dec, err := msg.Decode(); err == nil {
+				t.Errorf("%s: decoded as %+v", name, dec)
+			}
+		}
+	})
+}
+
+func TestConfirmedReinitializeDevice(t *testing.T) {
+	t.Helper()
+	rd := services.NewConfirmedReinitializeDevice(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	rd.APDU.MaxSize = 5
+	rd.APDU.InvokeID = 1
+	rd.APDU.Objects = services.ConfirmedReinitializeDeviceObjects(services.ReinitializeWarmstart, "AbCdE")
+	rd.SetLength()
+
+	serialized := []byte{
+		0x81, 0x0a, 0x00, 0x14, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x01, 0x14, // APDU
+		0x09, 0x01, // Reinitialized state of device
+		0x1d, 0x06, 0x00, 0x41, 0x62, 0x43, 0x64, 0x45, // Password
+	}
+
+	t.Run("Decode", func(t *testing.T) {
+		msg, err := bacnet.Parse(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(serializeable(rd), msg); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+
+		dec, err := msg.(*services.ConfirmedReinitializeDevice).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := services.ConfirmedReinitializeDeviceDec{State: services.ReinitializeWarmstart, Password: "AbCdE"}
+		if diff := cmp.Diff(want, dec); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+	})
+	t.Run("Serialize", func(t *testing.T) {
+		b, err := rd.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(serialized, b); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+	})
+	t.Run("Malformed", func(t *testing.T) {
+		objs := services.ConfirmedReinitializeDeviceObjects(services.ReinitializeWarmstart, "AbCdE")
+		state, password := objs[0], objs[1]
+		unknown := &objects.Object{TagNumber: 2, TagClass: true, Length: 1, Data: []byte{0}}
+
+		for name, objs := range map[string][]objects.APDUPayload{
+			"password only": {password},
+			"out of order":  {password, state},
+			"duplicate":     {state, state},
+			"unknown tag":   {state, unknown},
+			"empty":         {},
+		} {
+			msg := services.NewConfirmedReinitializeDevice(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, true))
+			msg.APDU.Objects = objs
+			if dec, err := msg.Decode(); err == nil {
+				t.Errorf("%s: decoded as %+v", name, dec)
+			}
+		}
+	})
+}
+
+func TestPasswordLength(t *testing.T) {
+	password := "abcdefghijklmnopqrstu"
+
+	if _, err := bacnet.NewDeviceCommunicationControl(0, services.CommunicationDisable, password); err == nil {
+		t.Errorf("DeviceCommunicationControl encoded with a %d characters password", len(password))
+	}
+	if _, err := bacnet.NewReinitializeDevice(services.ReinitializeWarmstart, password); err == nil {
+		t.Errorf("ReinitializeDevice encoded with a %d characters password", len(password))
+	}
+	if _, err := bacnet.NewReinitializeDevice(services.ReinitializeWarmstart, password[:services.MaxPasswordLen]); err != nil {
+		t.Errorf("ReinitializeDevice with a %d characters password: %v", services.MaxPasswordLen, err)
+	}
+
+	rd := services.NewConfirmedReinitializeDevice(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	rd.APDU.Objects = services.ConfirmedReinitializeDeviceObjects(services.ReinitializeWarmstart, password)
+	rd.SetLength()
+	b, err := rd.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := msg.(*services.ConfirmedReinitializeDevice).Decode(); errors.Cause(err) != common.ErrTooBigValue {
+		t.Errorf("decoding a %d characters password: got %v, want %v", len(password), err, common.ErrTooBigValue)
+	}
+}
+
+func TestObjectLoneTag(t *testing.T) {
+	cases := []struct {
+		name string
+		b    []byte
+		want objects.Object
+	}{
+		{"null", []byte{0x00}, objects.Object{TagNumber: objects.TagNull, Data: []byte{}}},
+		{"boolean", []byte{0x11}, objects.Object{TagNumber: objects.TagBoolean, Length: 1, Data: []byte{1}}},
+		{"empty octet string", []byte{0x60}, objects.Object{TagNumber: objects.TagOctetString, Data: []byte{}}},
+		{"empty context tag", []byte{0x28}, objects.Object{TagNumber: 2, TagClass: true, Data: []byte{}}},
+	}
+
+	for _, c := range cases {
+		o := objects.Object{}
+		if err := o.UnmarshalBinary(c.b); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, o); diff != "" {
+			t.Errorf("%s differs: (-want +got)\n%s", c.name, diff)
+		}
+	}
+
+	// The extended tag number is missing.
+	if err := (&objects.Object{}).UnmarshalBinary([]byte{0xf8}); errors.Cause(err) != common.ErrTooShortToParse {
+		t.Errorf("extended tag number missing: got %v, want %v", err, common.ErrTooShortToParse)
+	}
+}
+
+// testRoundTrip checks serialized parses back into structured and that
+// structured serializes into serialized. It returns the parsed message.
+func testRoundTrip(t *testing.T, structured serializeable, serialized []byte) plumbing.BACnet {
+	t.Helper()
+
+	msg, err := bacnet.Parse(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(structured, serializeable(msg)); diff != "" {
+		t.Errorf("decode differs: (-want +got)\n%s", diff)
+	}
+
+	b, err := structured.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(serialized, b); diff != "" {
+		t.Errorf("serialize differs: (-want +got)\n%s", diff)
+	}
+
+	return msg
+}
+
+func TestConfirmedAtomicReadFile(t *testing.T) {
+	arf := services.NewConfirmedAtomicReadFile(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	arf.APDU.MaxSize = 5
+	arf.APDU.InvokeID = 0
+	arf.APDU.Objects = services.ConfirmedAtomicReadFileStreamObjects(1, 0, 27)
+	arf.SetLength()
+
+	msg := testRoundTrip(t, arf, []byte{
+		0x81, 0x0a, 0x00, 0x15, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x00, 0x06, // APDU
+		0xc4, 0x02, 0x80, 0x00, 0x01, // File object
+		0x0e,       // Stream access
+		0x31, 0x00, // File start position
+		0x21, 0x1b, // Requested octet count
+		0x0f,
+	})
+
+	dec, err := msg.(*services.ConfirmedAtomicReadFile).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ConfirmedAtomicReadFileDec{
+		ObjectType: 10, InstanceId: 1, Start: 0, Count: 27,
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestAtomicReadFileACK(t *testing.T) {
+	data := make([]byte, 300)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	ack := services.NewAtomicReadFileACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = 7
+	ack.APDU.Objects = services.AtomicReadFileStreamACKObjects(true, 100, data)
+	ack.SetLength()
+
+	serialized := []byte{
+		0x81, 0x0a, 0x01, 0x3e, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x07, 0x06, // APDU
+		0x11,       // End of file
+		0x0e,       // Stream access
+		0x31, 0x64, // File start position
+		0x65, 0xfe, 0x01, 0x2c, // File data
+	}
+	serialized = append(serialized, data...)
+	serialized = append(serialized, 0x0f)
+
+	msg := testRoundTrip(t, ack, serialized)
+
+	dec, err := msg.(*services.AtomicReadFileACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.AtomicReadFileACKDec{EndOfFile: true, Start: 100, Data: data}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedAtomicWriteFileRecords(t *testing.T) {
+	awf := services.NewConfirmedAtomicWriteFile(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	awf.APDU.MaxSize = 5
+	awf.APDU.InvokeID = 85
+	awf.APDU.Objects = services.ConfirmedAtomicWriteFileRecordObjects(2, -1, [][]byte{{0x0c}, {0x0d, 0x0e}})
+	awf.SetLength()
+
+	msg := testRoundTrip(t, awf, []byte{
+		0x81, 0x0a, 0x00, 0x1a, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x55, 0x07, // APDU
+		0xc4, 0x02, 0x80, 0x00, 0x02, // File object
+		0x1e,       // Record access
+		0x31, 0xff, // File start record
+		0x21, 0x02, // Record count
+		0x61, 0x0c, // File record data
+		0x62, 0x0d, 0x0e,
+		0x1f,
+	})
+
+	dec, err := msg.(*services.ConfirmedAtomicWriteFile).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ConfirmedAtomicWriteFileDec{
+		ObjectType: 10, InstanceId: 2, RecordAccess: true, Start: -1, Records: [][]byte{{0x0c}, {0x0d, 0x0e}},
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedCreateObject(t *testing.T) {
+	co := services.NewConfirmedCreateObject(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	co.APDU.MaxSize = 5
+	co.APDU.InvokeID = 3
+	co.APDU.Objects = services.ConfirmedCreateObjectByTypeObjects(objects.ObjectTypeNotificationClass, []services.PropertyValue{
+		{
+			PropertyId: objects.PropertyIdObjectName,
+			ArrayIndex: objects.ArrayAll,
+			Value:      []objects.APDUPayload{objects.EncString("NC-1")},
+		},
+		{
+			PropertyId: objects.PropertyIdPresentValue,
+			ArrayIndex: objects.ArrayAll,
+			Value:      []objects.APDUPayload{objects.EncReal(1)},
+			Priority:   8,
+		},
+	})
+	co.SetLength()
+
+	msg := testRoundTrip(t, co, []byte{
+		0x81, 0x0a, 0x00, 0x26, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x03, 0x0a, // APDU
+		0x0e, 0x09, 0x0f, 0x0f, // Object type
+		0x1e,       // List of initial values
+		0x09, 0x4d, // Object name
+		0x2e, 0x75, 0x05, 0x00, 0x4e, 0x43, 0x2d, 0x31, 0x2f,
+		0x09, 0x55, // Present value
+		0x2e, 0x44, 0x3f, 0x80, 0x00, 0x00, 0x2f,
+		0x39, 0x08, // Priority
+		0x1f,
+	})
+
+	dec, err := msg.(*services.ConfirmedCreateObject).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.ObjectType != objects.ObjectTypeNotificationClass || dec.InstanceSpecified || len(dec.InitialValues) != 2 {
+		t.Fatalf("unexpected decoded request %+v", dec)
+	}
+	if name, err := objects.DecString(dec.InitialValues[0].Value[0]); err != nil || name != "NC-1" {
+		t.Errorf("unexpected object name %q (%v)", name, err)
+	}
+	if dec.InitialValues[1].Priority != 8 || dec.InitialValues[1].ArrayIndex != objects.ArrayAll {
+		t.Errorf("unexpected present value %+v", dec.InitialValues[1])
+	}
+}
+
+func TestCreateObjectACK(t *testing.T) {
+	ack := services.NewCreateObjectACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = 3
+	ack.APDU.Objects = services.CreateObjectACKObjects(objects.ObjectTypeSchedule, 4)
+	ack.SetLength()
+
+	msg := testRoundTrip(t, ack, []byte{
+		0x81, 0x0a, 0x00, 0x0e, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x03, 0x0a, // APDU
+		0xc4, 0x04, 0x40, 0x00, 0x04, // Schedule object
+	})
+
+	dec, err := msg.(*services.CreateObjectACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.CreateObjectACKDec{ObjectType: objects.ObjectTypeSchedule, InstanceId: 4}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestCreateObjectError(t *testing.T) {
+	e := services.NewError(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	e.APDU.InvokeID = 3
+	e.APDU.Service = services.ServiceConfirmedCreateObject
+	e.APDU.Objects = services.ElementErrorObjects(objects.ErrorClassProperty, objects.ErrorCodeWriteAccessDenied, 2)
+	e.SetLength()
+
+	msg := testRoundTrip(t, e, []byte{
+		0x81, 0x0a, 0x00, 0x11, // BVLC
+		0x01, 0x00, // NPDU
+		0x50, 0x03, 0x0a, // APDU
+		0x0e, 0x91, 0x02, 0x91, 0x28, 0x0f, // Error
+		0x19, 0x02, // First failed element number
+	})
+
+	dec, err := msg.(*services.Error).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ErrorDec{
+		ErrorClass: objects.ErrorClassProperty, ErrorCode: objects.ErrorCodeWriteAccessDenied, FirstFailedElement: 2,
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedDeleteObject(t *testing.T) {
+	do := services.NewConfirmedDeleteObject(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	do.APDU.MaxSize = 5
+	do.APDU.InvokeID = 4
+	do.APDU.Objects = services.ConfirmedDeleteObjectObjects(objects.ObjectTypeSchedule, 4)
+	do.SetLength()
+
+	msg := testRoundTrip(t, do, []byte{
+		0x81, 0x0a, 0x00, 0x0f, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x04, 0x0b, // APDU
+		0xc4, 0x04, 0x40, 0x00, 0x04, // Schedule object
+	})
+
+	dec, err := msg.(*services.ConfirmedDeleteObject).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ConfirmedDeleteObjectDec{ObjectType: objects.ObjectTypeSchedule, InstanceId: 4}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedReadPropertyIndex(t *testing.T) {
+	want := objects.DeviceObjectPropertyReference{
+		ObjectType:     objects.ObjectTypeDevice,
+		InstanceNumber: 321,
+		PropertyId:     objects.PropertyIdObjectList,
+		ArrayIndex:     2,
+	}
+
+	rp := services.NewConfirmedReadProperty(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	rp.APDU.MaxSize = 5
+	rp.APDU.InvokeID = 1
+	rp.APDU.Objects = services.ConfirmedReadPropertyIndexObjects(want.ObjectType, want.InstanceNumber, want.PropertyId, want.ArrayIndex)
+	rp.SetLength()
+
+	msg := testRoundTrip(t, rp, []byte{
+		0x81, 0x0a, 0x00, 0x13, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x01, 0x0c, // APDU
+		0x0c, 0x02, 0x00, 0x01, 0x41, // Device 321
+		0x19, 0x4c, // Object_List
+		0x29, 0x02, // element 2
+	})
+
+	dec, err := msg.(*services.ConfirmedReadProperty).DecodeReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedWritePropertyValue(t *testing.T) {
+	want := services.WritePropertyValue{
+		ObjectType: objects.ObjectTypeBinaryOutput,
+		InstanceId: 3,
+		PropertyId: objects.PropertyIdPresentValue,
+		ArrayIndex: objects.ArrayAll,
+		Value:      []objects.APDUPayload{objects.EncEnumerated(1)},
+		Priority:   8,
+	}
+
+	wp := services.NewConfirmedWriteProperty(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	wp.APDU.MaxSize = 5
+	wp.APDU.InvokeID = 1
+	wp.APDU.Objects = services.WritePropertyValueObjects(want)
+	wp.SetLength()
+
+	msg := testRoundTrip(t, wp, []byte{
+		0x81, 0x0a, 0x00, 0x17, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x01, 0x0f, // APDU
+		0x0c, 0x01, 0x00, 0x00, 0x03, // Binary Output 3
+		0x19, 0x55, // Present_Value
+		0x3e, 0x91, 0x01, 0x3f, // active
+		0x49, 0x08, // priority 8
+	})
+
+	dec, err := msg.(*services.ConfirmedWriteProperty).DecodeValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedSubscribeCOV(t *testing.T) {
+	want := services.SubscribeCOVDec{
+		ProcessId:       18,
+		MonitoredObject: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 10},
+		IssueConfirmed:  true,
+		Lifetime:        300,
+	}
+
+	scov := services.NewConfirmedSubscribeCOV(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	scov.APDU.MaxSize = 5
+	scov.APDU.InvokeID = 15
+	scov.APDU.Objects = services.SubscribeCOVObjects(want)
+	scov.SetLength()
+
+	msg := testRoundTrip(t, scov, []byte{
+		0x81, 0x0a, 0x00, 0x16, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x0f, 0x05, // APDU
+		0x09, 0x12, // process 18
+		0x1c, 0x00, 0x00, 0x00, 0x0a, // Analog Input 10
+		0x29, 0x01, // confirmed
+		0x3a, 0x01, 0x2c, // lifetime 300
+	})
+
+	dec, err := msg.(*services.ConfirmedSubscribeCOV).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	// A cancellation carries neither IssueConfirmed nor Lifetime.
+	want = services.SubscribeCOVDec{ProcessId: want.ProcessId, MonitoredObject: want.MonitoredObject, Cancel: true}
+	scov.APDU.Objects = services.SubscribeCOVObjects(want)
+	scov.SetLength()
+
+	msg = testRoundTrip(t, scov, []byte{
+		0x81, 0x0a, 0x00, 0x11, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x0f, 0x05, // APDU
+		0x09, 0x12, // process 18
+		0x1c, 0x00, 0x00, 0x00, 0x0a, // Analog Input 10
+	})
+
+	dec, err = msg.(*services.ConfirmedSubscribeCOV).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedSubscribeCOVProperty(t *testing.T) {
+	increment := float32(0.5)
+	want := services.SubscribeCOVPropertyDec{
+		SubscribeCOVDec: services.SubscribeCOVDec{
+			ProcessId:       18,
+			MonitoredObject: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 10},
+			IssueConfirmed:  true,
+			Lifetime:        300,
+		},
+		MonitoredProperty: services.PropertyReference{PropertyId: objects.PropertyIdPresentValue},
+		COVIncrement:      &increment,
+	}
+
+	scovp := services.NewConfirmedSubscribeCOVProperty(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	scovp.APDU.MaxSize = 5
+	scovp.APDU.InvokeID = 15
+	scovp.APDU.Objects = services.SubscribeCOVPropertyObjects(want)
+	scovp.SetLength()
+
+	msg := testRoundTrip(t, scovp, []byte{
+		0x81, 0x0a, 0x00, 0x1f, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x0f, 0x1c, // APDU
+		0x09, 0x12, // process 18
+		0x1c, 0x00, 0x00, 0x00, 0x0a, // Analog Input 10
+		0x29, 0x01, // confirmed
+		0x3a, 0x01, 0x2c, // lifetime 300
+		0x4e, 0x09, 0x55, 0x4f, // Present_Value
+		0x5c, 0x3f, 0x00, 0x00, 0x00, // increment 0.5
+	})
+
+	dec, err := msg.(*services.ConfirmedSubscribeCOVProperty).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUnconfirmedCOVNotification(t *testing.T) {
+	n := services.COVNotificationDec{
+		ProcessId:        18,
+		InitiatingDevice: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 100},
+		MonitoredObject:  objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 10},
+		TimeRemaining:    60,
+		Values: []services.PropertyValue{{
+			PropertyId: objects.PropertyIdPresentValue,
+			ArrayIndex: objects.ArrayAll,
+			Value:      []objects.APDUPayload{objects.EncReal(21.5)},
+		}},
+	}
+
+	covn := services.NewUnconfirmedCOVNotification(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	covn.APDU.Objects = services.COVNotificationObjects(n)
+	covn.SetLength()
+
+	msg := testRoundTrip(t, covn, []byte{
+		0x81, 0x0a, 0x00, 0x21, // BVLC
+		0x01, 0x00, // NPDU
+		0x10, 0x02, // APDU
+		0x09, 0x12, // process 18
+		0x1c, 0x02, 0x00, 0x00, 0x64, // Device 100
+		0x2c, 0x00, 0x00, 0x00, 0x0a, // Analog Input 10
+		0x39, 0x3c, // 60 s remaining
+		0x4e,
+		0x09, 0x55, // Present value
+		0x2e, 0x44, 0x41, 0xac, 0x00, 0x00, 0x2f, // 21.5
+		0x4f,
+	})
+
+	dec, err := msg.(*services.UnconfirmedCOVNotification).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.ProcessId != n.ProcessId || dec.InitiatingDevice != n.InitiatingDevice ||
+		dec.MonitoredObject != n.MonitoredObject || dec.TimeRemaining != n.TimeRemaining || len(dec.Values) != 1 {
+		t.Fatalf("unexpected decoded request %+v", dec)
+	}
+	if v, err := objects.DecReal(dec.Values[0].Value[0]); err != nil || v != 21.5 {
+		t.Errorf("unexpected present value %v (%v)", v, err)
+	}
+}
+
+func TestConfirmedAddListElement(t *testing.T) {
+	date := &objects.Object{
+		TagNumber: objects.TagDate,
+		Length:    4,
+		Data:      []byte{0x7a, 0x0c, 0x19, 0xff}, // 2022-12-25
+	}
+
+	ale := services.NewConfirmedAddListElement(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	ale.APDU.MaxSize = 5
+	ale.APDU.InvokeID = 6
+	ale.APDU.Objects = services.ConfirmedAddListElementObjects(
+		objects.ObjectTypeCalendar, 1, objects.PropertyIdDateList, objects.ArrayAll, []objects.APDUPayload{date})
+	ale.SetLength()
+
+	msg := testRoundTrip(t, ale, []byte{
+		0x81, 0x0a, 0x00, 0x18, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x06, 0x08, // APDU
+		0x0c, 0x01, 0x80, 0x00, 0x01, // Calendar object
+		0x19, 0x17, // Date_List
+		0x3e, 0xa4, 0x7a, 0x0c, 0x19, 0xff, 0x3f, // list of elements
+	})
+
+	dec, err := msg.(*services.ConfirmedAddListElement).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ListElementDec{
+		ObjectType: objects.ObjectTypeCalendar,
+		InstanceId: 1,
+		PropertyId: objects.PropertyIdDateList,
+		ArrayIndex: objects.ArrayAll,
+		Elements:   []objects.APDUPayload{date},
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedReadRangeByTime(t *testing.T) {
+	ref := time.Date(2022, time.December, 25, 10, 30, 0, 0, time.Local)
+
+	rr := services.NewConfirmedReadRange(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	rr.APDU.MaxSize = 5
+	rr.APDU.InvokeID = 7
+	rr.APDU.Objects = services.ConfirmedReadRangeByTimeObjects(
+		objects.ObjectTypeTrendLog, 1, objects.PropertyIdLogBuffer, ref, -10)
+	rr.SetLength()
+
+	msg := testRoundTrip(t, rr, []byte{
+		0x81, 0x0a, 0x00, 0x1f, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x07, 0x1a, // APDU
+		0x0c, 0x05, 0x00, 0x00, 0x01, // Trend Log object
+		0x19, 0x83, // Log_Buffer
+		0x7e,                         // by time
+		0xa4, 0x7a, 0x0c, 0x19, 0x07, // 2022-12-25
+		0xb4, 0x0a, 0x1e, 0x00, 0x00, // 10:30:00.00
+		0x31, 0xf6, // -10 items
+		0x7f,
+	})
+
+	dec, err := msg.(*services.ConfirmedReadRange).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ConfirmedReadRangeDec{
+		ObjectType: objects.ObjectTypeTrendLog,
+		InstanceId: 1,
+		PropertyId: objects.PropertyIdLogBuffer,
+		ArrayIndex: objects.ArrayAll,
+		RangeType:  services.ReadRangeByTime,
+		Time:       ref,
+		Count:      -10,
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestReadRangeACK(t *testing.T) {
+	records := []services.LogRecord{
+		{
+			Timestamp:   time.Date(2022, time.December, 25, 10, 30, 0, 0, time.Local),
+			Datum:       services.LogDatumReal,
+			Value:       float32(21.5),
+			StatusFlags: []bool{false, false, false, false},
+		},
+		{
+			Timestamp: time.Date(2022, time.December, 25, 10, 45, 0, 0, time.Local),
+			Datum:     services.LogDatumLogStatus,
+			LogStatus: []bool{false, true, false},
+		},
+	}
+	items, err := services.LogRecordObjects(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ack := services.NewReadRangeACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = 7
+	ack.APDU.Objects = services.ReadRangeACKObjects(services.ReadRangeACKDec{
+		ObjectType:          objects.ObjectTypeTrendLog,
+		InstanceId:          1,
+		PropertyId:          objects.PropertyIdLogBuffer,
+		ArrayIndex:          objects.ArrayAll,
+		FirstItem:           true,
+		LastItem:            true,
+		ItemCount:           2,
+		ItemData:            items,
+		SequenceNumbered:    true,
+		FirstSequenceNumber: 42,
+	})
+	ack.SetLength()
+
+	msg := testRoundTrip(t, ack, []byte{
+		0x81, 0x0a, 0x00, 0x40, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x07, 0x1a, // APDU
+		0x0c, 0x05, 0x00, 0x00, 0x01, // Trend Log object
+		0x19, 0x83, // Log_Buffer
+		0x3a, 0x05, 0xc0, // first and last item
+		0x49, 0x02, // 2 items
+		0x5e,
+		0x0e, 0xa4, 0x7a, 0x0c, 0x19, 0x07, 0xb4, 0x0a, 0x1e, 0x00, 0x00, 0x0f, // 2022-12-25 10:30:00.00
+		0x1e, 0x2c, 0x41, 0xac, 0x00, 0x00, 0x1f, // 21.5
+		0x2a, 0x04, 0x00, // status flags
+		0x0e, 0xa4, 0x7a, 0x0c, 0x19, 0x07, 0xb4, 0x0a, 0x2d, 0x00, 0x00, 0x0f, // 2022-12-25 10:45:00.00
+		0x1e, 0x0a, 0x05, 0x40, 0x1f, // buffer purged
+		0x5f,
+		0x69, 0x2a, // first sequence number 42
+	})
+
+	dec, err := msg.(*services.ReadRangeACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dec.FirstItem || !dec.LastItem || dec.MoreItems || dec.ItemCount != 2 ||
+		!dec.SequenceNumbered || dec.FirstSequenceNumber != 42 {
+		t.Errorf("unexpected result %+v", dec)
+	}
+
+	got, err := services.DecLogRecords(dec.ItemData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(records, got); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUnconfirmedEventNotification(t *testing.T) {
+	n := services.EventNotificationDec{
+		ProcessId:         1,
+		InitiatingDevice:  objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 100},
+		EventObject:       objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 3},
+		TimeStamp:         services.TimeStamp{Kind: services.TimeStampSequenceNumber, SequenceNumber: 7},
+		NotificationClass: 5,
+		Priority:          100,
+		EventType:         services.EventTypeOutOfRange,
+		MessageText:       "hi",
+		NotifyType:        services.NotifyTypeAlarm,
+		AckRequired:       true,
+		FromState:         services.EventStateNormal,
+		ToState:           services.EventStateHighLimit,
+		EventValues: &services.OutOfRangeValues{
+			ExceedingValue: 80.5,
+			StatusFlags:    []bool{true, false, false, false},
+			Deadband:       1,
+			ExceededLimit:  80,
+		},
+	}
+
+	en := services.NewUnconfirmedEventNotification(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	en.APDU.Objects = services.EventNotificationObjects(n)
+	en.SetLength()
+
+	msg := testRoundTrip(t, en, []byte{
+		0x81, 0x0a, 0x00, 0x40, // BVLC
+		0x01, 0x00, // NPDU
+		0x10, 0x03, // APDU
+		0x09, 0x01, // process 1
+		0x1c, 0x02, 0x00, 0x00, 0x64, // Device 100
+		0x2c, 0x00, 0x00, 0x00, 0x03, // Analog Input 3
+		0x3e, 0x19, 0x07, 0x3f, // sequence number 7
+		0x49, 0x05, // notification class 5
+		0x59, 0x64, // priority 100
+		0x69, 0x05, // out of range
+		0x7b, 0x00, 0x68, 0x69, // "hi"
+		0x89, 0x00, // alarm
+		0x99, 0x01, // ack required
+		0xa9, 0x00, // from normal
+		0xb9, 0x03, // to high limit
+		0xce, 0x5e,
+		0x0c, 0x42, 0xa1, 0x00, 0x00, // 80.5
+		0x1a, 0x04, 0x80, // in alarm
+		0x2c, 0x3f, 0x80, 0x00, 0x00, // deadband 1
+		0x3c, 0x42, 0xa0, 0x00, 0x00, // limit 80
+		0x5f, 0xcf,
+	})
+
+	dec, err := msg.(*services.UnconfirmedEventNotification).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(n, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestEventValues(t *testing.T) {
+	flags := []bool{false, true, false, false}
+	at := time.Date(2023, time.March, 1, 8, 15, 30, 0, time.Local)
+	lastState := uint32(2)
+	value := []objects.APDUPayload{objects.EncReal(3)}
+
+	cases := []services.EventValues{
+		&services.ChangeOfBitstringValues{ReferencedBitstring: []bool{true, false, true}, StatusFlags: flags},
+		&services.ChangeOfStateValues{NewState: services.PropertyState{Kind: services.PropertyStateBinaryValue, Value: 1}, StatusFlags: flags},
+		&services.ChangeOfValueValues{ChangedBits: []bool{true}, StatusFlags: flags},
+		&services.ChangeOfValueValues{ChangedValue: 2.5, StatusFlags: flags},
+		&services.CommandFailureValues{CommandValue: []objects.APDUPayload{objects.EncEnumerated(1)}, StatusFlags: flags, FeedbackValue: []objects.APDUPayload{objects.EncEnumerated(0)}},
+		&services.FloatingLimitValues{ReferenceValue: 1, StatusFlags: flags, SetpointValue: 2, ErrorLimit: 3},
+		&services.OutOfRangeValues{ExceedingValue: 1, StatusFlags: flags, Deadband: 2, ExceededLimit: 3},
+		&services.ComplexEventTypeValues{Values: []services.PropertyValue{{PropertyId: objects.PropertyIdPresentValue, ArrayIndex: objects.ArrayAll, Value: value}}},
+		&services.ChangeOfLifeSafetyValues{NewState: 1, NewMode: 2, StatusFlags: flags, OperationExpected: 3},
+		&services.ExtendedValues{VendorId: 260, ExtendedEventType: 4, Parameters: value},
+		&services.BufferReadyValues{BufferProperty: []objects.APDUPayload{objects.EncObjectIdentifier(true, 0, objects.ObjectTypeTrendLog, 1), objects.EncPropertyIdentifier(true, 1, objects.PropertyIdLogBuffer)}, PreviousNotification: 10, CurrentNotification: 20},
+		&services.UnsignedRangeValues{ExceedingValue: 12, StatusFlags: flags, ExceededLimit: 10},
+		&services.AccessEventValues{AccessEvent: 1, StatusFlags: flags, AccessEventTag: 2, AccessEventTime: services.TimeStamp{Kind: services.TimeStampDateTime, DateTime: at}, AccessCredential: []objects.APDUPayload{objects.EncObjectIdentifier(true, 1, 32, 1)}},
+		&services.DoubleOutOfRangeValues{ExceedingValue: 1.5, StatusFlags: flags, Deadband: 0.5, ExceededLimit: 1},
+		&services.SignedOutOfRangeValues{ExceedingValue: -12, StatusFlags: flags, Deadband: 1, ExceededLimit: -10},
+		&services.UnsignedOutOfRangeValues{ExceedingValue: 12, StatusFlags: flags, Deadband: 1, ExceededLimit: 10},
+		&services.ChangeOfCharacterStringValues{ChangedValue: "open", StatusFlags: flags, AlarmValue: "open"},
+		&services.ChangeOfStatusFlagsValues{PresentValue: value, ReferencedFlags: flags},
+		&services.ChangeOfStatusFlagsValues{ReferencedFlags: flags},
+		&services.ChangeOfReliabilityValues{Reliability: 1, StatusFlags: flags, PropertyValues: []services.PropertyValue{}},
+		&services.NoneValues{},
+		&services.ChangeOfDiscreteValueValues{NewValue: []objects.APDUPayload{objects.EncUnsignedInteger8(3)}, StatusFlags: flags},
+		&services.ChangeOfTimerValues{NewState: 1, StatusFlags: flags, UpdateTime: at, LastStateChange: &lastState, ExpirationTime: at.Add(time.Hour)},
+		&services.ProprietaryValues{Type: 64, Objects: []objects.APDUPayload{objects.EncContextTag(0, objects.EncUnsignedInteger8(1))}},
+	}
+
+	for _, v := range cases {
+		n := services.EventNotificationDec{
+			InitiatingDevice: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 1},
+			TimeStamp:        services.TimeStamp{Kind: services.TimeStampTime, TimeOfDay: 8 * time.Hour},
+			EventType:        v.EventType(),
+			NotifyType:       services.NotifyTypeEvent,
+			ToState:          services.EventStateOffnormal,
+			EventValues:      v,
+		}
+
+		en := services.NewConfirmedEventNotification(
+			plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+			plumbing.NewNPDU(false, false, false, true),
+		)
+		en.APDU.Objects = services.EventNotificationObjects(n)
+		en.SetLength()
+
+		b, err := en.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := bacnet.Parse(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := msg.(*services.ConfirmedEventNotification).Decode()
+		if err != nil {
+			t.Errorf("event type %d: %v", v.EventType(), err)
+			continue
+		}
+		if diff := cmp.Diff(n, dec); diff != "" {
+			t.Errorf("event type %d differs: (-want +got)\n%s", v.EventType(), diff)
+		}
+	}
+}
+
+func TestConfirmedAcknowledgeAlarm(t *testing.T) {
+	a := services.ConfirmedAcknowledgeAlarmDec{
+		ProcessId:   1,
+		EventObject: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 3},
+		EventState:  services.EventStateHighLimit,
+		TimeStamp:   services.TimeStamp{Kind: services.TimeStampSequenceNumber, SequenceNumber: 7},
+		Source:      "op",
+		AckTime:     services.TimeStamp{Kind: services.TimeStampTime, TimeOfDay: 10*time.Hour + 30*time.Minute},
+	}
+
+	aa := services.NewConfirmedAcknowledgeAlarm(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	aa.APDU.MaxSize = 5
+	aa.APDU.InvokeID = 8
+	aa.APDU.Objects = services.ConfirmedAcknowledgeAlarmObjects(a)
+	aa.SetLength()
+
+	msg := testRoundTrip(t, aa, []byte{
+		0x81, 0x0a, 0x00, 0x22, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x08, 0x00, // APDU
+		0x09, 0x01, // process 1
+		0x1c, 0x00, 0x00, 0x00, 0x03, // Analog Input 3
+		0x29, 0x03, // high limit
+		0x3e, 0x19, 0x07, 0x3f, // sequence number 7
+		0x4b, 0x00, 0x6f, 0x70, // "op"
+		0x5e, 0x0c, 0x0a, 0x1e, 0x00, 0x00, 0x5f, // 10:30:00.00
+	})
+
+	dec, err := msg.(*services.ConfirmedAcknowledgeAlarm).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(a, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestRecipientList(t *testing.T) {
+	weekdays := []bool{true, true, true, true, true, false, false}
+	want := []services.Destination{{
+		ValidDays:      weekdays,
+		FromTime:       8 * time.Hour,
+		ToTime:         18 * time.Hour,
+		Recipient:      services.Recipient{Device: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 100}},
+		ProcessId:      18,
+		IssueConfirmed: true,
+		Transitions:    []bool{true, true, false},
+	}, {
+		ValidDays:   []bool{true, true, true, true, true, true, true},
+		ToTime:      23*time.Hour + 59*time.Minute + 59*time.Second,
+		Recipient:   services.Recipient{ByAddress: true, Network: 5, MAC: []byte{10, 0, 0, 1, 0xba, 0xc0}},
+		Transitions: []bool{true, true, true},
+	}}
+
+	b, err := bacnet.NewWritePropertyValue(services.WritePropertyValue{
+		ObjectType: objects.ObjectTypeNotificationClass,
+		InstanceId: 1,
+		PropertyId: objects.PropertyIdRecipientList,
+		ArrayIndex: objects.ArrayAll,
+		Value:      services.DestinationsObjects(want),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := msg.(*services.ConfirmedWriteProperty).DecodeValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dests, err := services.DecodeDestinations(w.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, dests); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	// Wednesday, within then after the window of the first destination.
+	at := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.Local)
+	if !dests[0].Active(at) || dests[0].Active(at.Add(7*time.Hour)) {
+		t.Errorf("destination window %v to %v misapplied", dests[0].FromTime, dests[0].ToTime)
+	}
+	// Saturday.
+	if dests[0].Active(at.AddDate(0, 0, 3)) {
+		t.Errorf("destination valid on %v", at.AddDate(0, 0, 3).Weekday())
+	}
+}
+
+func TestGetEventInformationACK(t *testing.T) {
+	ts := services.TimeStamp{Kind: services.TimeStampSequenceNumber, SequenceNumber: 1}
+	events := []services.EventSummary{
+		{
+			Object:           objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 3},
+			EventState:       services.EventStateHighLimit,
+			AckedTransitions: []bool{false, true, true},
+			EventTimeStamps:  [3]services.TimeStamp{ts, ts, ts},
+			NotifyType:       services.NotifyTypeAlarm,
+			EventEnable:      []bool{true, true, true},
+			EventPriorities:  [3]uint32{100, 100, 200},
+		},
+	}
+
+	ack := services.NewGetEventInformationACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = 9
+	ack.APDU.Objects = services.GetEventInformationACKObjects(events, true)
+	ack.SetLength()
+
+	msg := testRoundTrip(t, ack, []byte{
+		0x81, 0x0a, 0x00, 0x2c, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x09, 0x1d, // APDU
+		0x0e,
+		0x0c, 0x00, 0x00, 0x00, 0x03, // Analog Input 3
+		0x19, 0x03, // high limit
+		0x2a, 0x05, 0x60, // acked transitions
+		0x3e, 0x19, 0x01, 0x19, 0x01, 0x19, 0x01, 0x3f, // time stamps
+		0x49, 0x00, // alarm
+		0x5a, 0x05, 0xe0, // event enable
+		0x6e, 0x21, 0x64, 0x21, 0x64, 0x21, 0xc8, 0x6f, // priorities
+		0x0f,
+		0x19, 0x01, // more events
+	})
+
+	dec, err := msg.(*services.GetEventInformationACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.GetEventInformationACKDec{Events: events, MoreEvents: true}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestGetAlarmSummaryACK(t *testing.T) {
+	alarms := []services.AlarmSummary{
+		{
+			Object:           objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 3},
+			AlarmState:       services.EventStateHighLimit,
+			AckedTransitions: []bool{false, true, true},
+		},
+	}
+
+	ack := services.NewGetAlarmSummaryACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = 10
+	ack.APDU.Objects = services.GetAlarmSummaryACKObjects(alarms)
+	ack.SetLength()
+
+	msg := testRoundTrip(t, ack, []byte{
+		0x81, 0x0a, 0x00, 0x13, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x0a, 0x03, // APDU
+		0xc4, 0x00, 0x00, 0x00, 0x03, // Analog Input 3
+		0x91, 0x03, // high limit
+		0x82, 0x05, 0x60, // acked transitions
+	})
+
+	dec, err := msg.(*services.GetAlarmSummaryACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(alarms, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestConfirmedGetEnrollmentSummary(t *testing.T) {
+	state := services.EventStateFilterActive
+	f := services.ConfirmedGetEnrollmentSummaryDec{
+		AcknowledgmentFilter: services.AcknowledgmentFilterNotAcked,
+		EnrollmentFilter: &services.RecipientProcess{
+			Recipient: services.Recipient{ByAddress: true, Network: 5, MAC: []byte{0x0a}},
+			ProcessId: 2,
+		},
+		EventStateFilter: &state,
+		PriorityFilter:   &services.PriorityFilter{MinPriority: 1, MaxPriority: 100},
+	}
+
+	ges := services.NewConfirmedGetEnrollmentSummary(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	ges.APDU.MaxSize = 5
+	ges.APDU.InvokeID = 11
+	ges.APDU.Objects = services.ConfirmedGetEnrollmentSummaryObjects(f)
+	ges.SetLength()
+
+	msg := testRoundTrip(t, ges, []byte{
+		0x81, 0x0a, 0x00, 0x20, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x0b, 0x04, // APDU
+		0x09, 0x02, // not acked
+		0x1e, 0x0e, 0x1e, 0x21, 0x05, 0x61, 0x0a, 0x1f, 0x0f, 0x19, 0x02, 0x1f, // network 5 MAC 0a, process 2
+		0x29, 0x04, // active
+		0x4e, 0x09, 0x01, 0x19, 0x64, 0x4f, // priorities 1 to 100
+	})
+
+	dec, err := msg.(*services.ConfirmedGetEnrollmentSummary).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(f, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+// counterCodec is the codec of a vendor service reading a counter.
+type counterCodec struct{}
+
+type counterRequest struct{ Counter uint32 }
+
+func (counterCodec) EncodeParameters(payload interface{}) ([]objects.APDUPayload, error) {
+	return []objects.APDUPayload{objects.EncUnsignedInteger32(payload.(counterRequest).Counter)}, nil
+}
+
+func (counterCodec) DecodeParameters(objs []objects.APDUPayload) (interface{}, error) {
+	counter, err := objects.DecUnisgnedInteger(objs[0])
+	return counterRequest{Counter: counter}, err
+}
+
+func (counterCodec) EncodeResult(result interface{}) ([]objects.APDUPayload, error) {
+	return []objects.APDUPayload{objects.EncReal(result.(float32))}, nil
+}
+
+func (counterCodec) DecodeResult(objs []objects.APDUPayload) (interface{}, error) {
+	return objects.DecReal(objs[0])
+}
+
+func TestConfirmedPrivateTransfer(t *testing.T) {
+	services.PrivateTransfers.Register(555, 1, counterCodec{}, func(payload interface{}) (interface{}, error) {
+		if payload.(counterRequest).Counter != 42 {
+			return nil, objects.NewBACnetError(objects.ErrorClassProperty, objects.ErrorCodeValueOutOfRange)
+		}
+		return float32(1.5), nil
+	})
+	defer services.PrivateTransfers.Unregister(555, 1)
+
+	objs, err := services.PrivateTransfers.ParametersObjects(555, 1, counterRequest{Counter: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pt := services.NewConfirmedPrivateTransfer(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	pt.APDU.MaxSize = 5
+	pt.APDU.InvokeID = 12
+	pt.APDU.Objects = objs
+	pt.Payload = counterRequest{Counter: 42}
+	pt.SetLength()
+
+	msg := testRoundTrip(t, pt, []byte{
+		0x81, 0x0a, 0x00, 0x13, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x0c, 0x12, // APDU
+		0x0a, 0x02, 0x2b, // vendor 555
+		0x19, 0x01, // service 1
+		0x2e, 0x21, 0x2a, 0x2f, // counter 42
+	})
+
+	dec, err := msg.(*services.ConfirmedPrivateTransfer).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.VendorId != 555 || dec.ServiceNumber != 1 || dec.Payload != (counterRequest{Counter: 42}) {
+		t.Errorf("unexpected request %+v", dec)
+	}
+
+	ackObjs, err := services.PrivateTransfers.Serve(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ack := services.NewConfirmedPrivateTransferACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ack.APDU.InvokeID = 12
+	ack.APDU.Objects = ackObjs
+	ack.SetLength()
+
+	b, err := ack.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := reply.(*services.ConfirmedPrivateTransferACK).Payload; result != float32(1.5) {
+		t.Errorf("unexpected result %v", result)
+	}
+
+	dec.Payload = counterRequest{Counter: 1}
+	if _, err := services.PrivateTransfers.Serve(dec); err == nil {
+		t.Error("expected the handler to fail")
+	}
+}
+
+func TestConfirmedTextMessage(t *testing.T) {
+	m := services.TextMessageDec{
+		SourceDevice: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 100},
+		HasClass:     true,
+		IsTextClass:  true,
+		TextClass:    "ops",
+		Priority:     services.TextMessagePriorityUrgent,
+		Message:      "Hi",
+	}
+
+	tm := services.NewConfirmedTextMessage(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, true),
+	)
+	tm.APDU.MaxSize = 5
+	tm.APDU.InvokeID = 13
+	tm.APDU.Objects = services.TextMessageObjects(m)
+	tm.SetLength()
+
+	msg := testRoundTrip(t, tm, []byte{
+		0x81, 0x0a, 0x00, 0x1c, // BVLC
+		0x01, 0x04, // NPDU
+		0x00, 0x05, 0x0d, 0x13, // APDU
+		0x0c, 0x02, 0x00, 0x00, 0x64, // Device 100
+		0x1e, 0x1c, 0x00, 0x6f, 0x70, 0x73, 0x1f, // class "ops"
+		0x29, 0x01, // urgent
+		0x3b, 0x00, 0x48, 0x69, // "Hi"
+	})
+
+	dec, err := msg.(*services.ConfirmedTextMessage).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUnconfirmedWriteGroup(t *testing.T) {
+	inhibit := true
+	w := services.UnconfirmedWriteGroupDec{
+		GroupNumber:   5,
+		WritePriority: 8,
+		ChangeList: []services.GroupChannelValue{
+			{Channel: 1, Value: []objects.APDUPayload{objects.EncReal(75)}},
+			{Channel: 2, OverridingPriority: 1, Value: []objects.APDUPayload{objects.EncEnumerated(1)}},
+		},
+		InhibitDelay: &inhibit,
+	}
+
+	wg := services.NewUnconfirmedWriteGroup(
+		plumbing.NewBVLC(plumbing.BVLCFuncBroadcast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	wg.APDU.Objects = services.UnconfirmedWriteGroupObjects(w)
+	wg.SetLength()
+
+	msg := testRoundTrip(t, wg, []byte{
+		0x81, 0x0b, 0x00, 0x1d, // BVLC
+		0x01, 0x00, // NPDU
+		0x10, 0x0a, // APDU
+		0x09, 0x05, // group 5
+		0x19, 0x08, // priority 8
+		0x2e,
+		0x09, 0x01, 0x44, 0x42, 0x96, 0x00, 0x00, // channel 1, 75.0
+		0x09, 0x02, 0x19, 0x01, 0x91, 0x01, // channel 2 at priority 1, enumerated 1
+		0x2f,
+		0x39, 0x01, // inhibit delay
+	})
+
+	dec, err := msg.(*services.UnconfirmedWriteGroup).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(w, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUnconfirmedYouAre(t *testing.T) {
+	deviceId := uint32(7)
+	y := services.UnconfirmedYouAreDec{
+		VendorId:     260,
+		ModelName:    "M",
+		SerialNumber: "42",
+		DeviceId:     &deviceId,
+		MACAddress:   []byte{0xc0, 0xa8, 0x01, 0x0a, 0xba, 0xc0},
+	}
+
+	ya := services.NewUnconfirmedYouAre(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	ya.APDU.Objects = services.YouAreObjects(y)
+	ya.SetLength()
+
+	msg := testRoundTrip(t, ya, []byte{
+		0x81, 0x0a, 0x00, 0x1f, // BVLC
+		0x01, 0x00, // NPDU
+		0x10, 0x0e, // APDU
+		0x22, 0x01, 0x04, // vendor 260
+		0x72, 0x00, 0x4d, // "M"
+		0x73, 0x00, 0x34, 0x32, // "42"
+		0xc4, 0x02, 0x00, 0x00, 0x07, // Device 7
+		0x65, 0x06, 0xc0, 0xa8, 0x01, 0x0a, 0xba, 0xc0, // 192.168.1.10:47808
+	})
+
+	dec, err := msg.(*services.UnconfirmedYouAre).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(y, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUnconfirmedAuditNotification(t *testing.T) {
+	invokeId := uint8(3)
+	target := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogOutput, InstanceNumber: 1}
+	source := services.Recipient{Device: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 5}}
+	device := services.Recipient{Device: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 321}}
+	ns := []services.AuditNotification{
+		{
+			SourceDevice:   source,
+			Operation:      services.AuditOperationWrite,
+			InvokeId:       &invokeId,
+			TargetDevice:   device,
+			TargetObject:   &target,
+			TargetProperty: &services.PropertyReference{PropertyId: objects.PropertyIdPresentValue},
+			TargetPriority: 8,
+			TargetValue:    []objects.APDUPayload{objects.EncReal(20)},
+			CurrentValue:   []objects.APDUPayload{objects.EncReal(0)},
+		},
+		{
+			SourceDevice:  source,
+			Operation:     services.AuditOperationGeneral,
+			SourceComment: "x",
+			TargetDevice:  device,
+			Result:        objects.NewBACnetError(objects.ErrorClassProperty, objects.ErrorCodeWriteAccessDenied),
+		},
+	}
+
+	an := services.NewUnconfirmedAuditNotification(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	an.APDU.Objects = services.AuditNotificationObjects(ns)
+	an.SetLength()
+
+	msg := testRoundTrip(t, an, []byte{
+		0x81, 0x0a, 0x00, 0x52, // BVLC
+		0x01, 0x00, // NPDU
+		0x10, 0x0c, // APDU
+		0x0e,
+		0x2e, 0x0c, 0x02, 0x00, 0x00, 0x05, 0x2f, // source Device 5
+		0x49, 0x01, // write
+		0x79, 0x03, // invoke ID 3
+		0xae, 0x0c, 0x02, 0x00, 0x01, 0x41, 0xaf, // target Device 321
+		0xbc, 0x00, 0x40, 0x00, 0x01, // AO 1
+		0xce, 0x09, 0x55, 0xcf, // Present_Value
+		0xd9, 0x08, // priority 8
+		0xee, 0x44, 0x41, 0xa0, 0x00, 0x00, 0xef, // 20.0
+		0xfe, 0x0f, 0x44, 0x00, 0x00, 0x00, 0x00, 0xff, 0x0f, // 0.0
+		0x2e, 0x0c, 0x02, 0x00, 0x00, 0x05, 0x2f, // source Device 5
+		0x49, 0x0f, // general
+		0x5a, 0x00, 0x78, // "x"
+		0xae, 0x0c, 0x02, 0x00, 0x01, 0x41, 0xaf, // target Device 321
+		0xfe, 0x10, 0x91, 0x02, 0x91, 0x28, 0xff, 0x10, // property, write access denied
+		0x0f,
+	})
+
+	dec, err := msg.(*services.UnconfirmedAuditNotification).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(ns, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestAuditLogQueryACK(t *testing.T) {
+	ack := services.AuditLogQueryACKDec{
+		AuditLog: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAuditLog, InstanceNumber: 1},
+		Records: []services.AuditLogRecord{
+			{
+				SequenceNumber: 7,
+				Timestamp:      time.Date(2021, time.March, 4, 10, 30, 0, 0, time.Local),
+				Datum:          services.AuditLogDatumNotification,
+				Notification: services.AuditNotification{
+					SourceDevice: services.Recipient{ByAddress: true, MAC: []byte{0x0a, 0x00, 0x00, 0x01, 0xba, 0xc0}},
+					Operation:    services.AuditOperationDeviceReset,
+					TargetDevice: services.Recipient{Device: objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 1}},
+				},
+			},
+			{
+				SequenceNumber: 8,
+				Timestamp:      time.Date(2021, time.March, 4, 10, 31, 0, 0, time.Local),
+				Datum:          services.AuditLogDatumTimeChange,
+				TimeChange:     -1.5,
+			},
+		},
+		NoMoreItems: true,
+	}
+
+	objs, err := services.AuditLogQueryACKObjects(ack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alq := services.NewAuditLogQueryACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	alq.APDU.InvokeID = 9
+	alq.APDU.Objects = objs
+	alq.SetLength()
+
+	msg := testRoundTrip(t, alq, []byte{
+		0x81, 0x0a, 0x00, 0x54, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x09, 0x21, // APDU
+		0x0c, 0x0f, 0x40, 0x00, 0x01, // Audit Log 1
+		0x1e,
+		0x09, 0x07, // sequence number 7
+		0x1e,
+		0x0e, 0xa4, 0x79, 0x03, 0x04, 0x04, 0xb4, 0x0a, 0x1e, 0x00, 0x00, 0x0f, // 2021-03-04 10:30
+		0x1e, 0x1e,
+		0x2e, 0x1e, 0x21, 0x00, 0x65, 0x06, 0x0a, 0x00, 0x00, 0x01, 0xba, 0xc0, 0x1f, 0x2f, // source 10.0.0.1
+		0x49, 0x08, // device reset
+		0xae, 0x0c, 0x02, 0x00, 0x00, 0x01, 0xaf, // target Device 1
+		0x1f, 0x1f,
+		0x1f,
+		0x09, 0x08, // sequence number 8
+		0x1e,
+		0x0e, 0xa4, 0x79, 0x03, 0x04, 0x04, 0xb4, 0x0a, 0x1f, 0x00, 0x00, 0x0f, // 2021-03-04 10:31
+		0x1e, 0x2c, 0xbf, 0xc0, 0x00, 0x00, 0x1f, // -1.5
+		0x1f,
+		0x1f,
+		0x29, 0x01, // no more items
+	})
+
+	dec, err := msg.(*services.AuditLogQueryACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(ack, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	a := services.NewAbort(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	a.APDU.Flags = 0x1
+	a.APDU.InvokeID = 4
+	a.APDU.Service = objects.AbortReasonSegmentationNotSupported
+	a.SetLength()
+
+	msg := testRoundTrip(t, a, []byte{
+		0x81, 0x0a, 0x00, 0x09, // BVLC
+		0x01, 0x00, // NPDU
+		0x71, 0x04, 0x04, // APDU
+	})
+
+	dec, err := msg.(*services.Abort).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(objects.NewAbortError(objects.AbortReasonSegmentationNotSupported, true), dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestReadPropertyMultipleACK(t *testing.T) {
+	results := []services.ReadAccessResult{
+		{
+			ObjectType: objects.ObjectTypeAnalogInput,
+			InstanceId: 1,
+			Results: []services.ReadResult{
+				{
+					PropertyId: objects.PropertyIdPresentValue,
+					Value:      []objects.APDUPayload{objects.EncReal(21.5)},
+				},
+				{
+					PropertyId: objects.PropertyIdDescription,
+					Err:        objects.NewBACnetError(objects.ErrorClassProperty, objects.ErrorCodeUnknownProperty),
+				},
+			},
+		},
+	}
+
+	rpm := services.NewReadPropertyMultipleACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	rpm.APDU.InvokeID = 3
+	rpm.APDU.Objects = services.ReadPropertyMultipleACKObjects(results)
+	rpm.SetLength()
+
+	msg := testRoundTrip(t, rpm, []byte{
+		0x81, 0x0a, 0x00, 0x21, // BVLC
+		0x01, 0x00, // NPDU
+		0x30, 0x03, 0x0e, // APDU
+		0x0c, 0x00, 0x00, 0x00, 0x01, // Analog Input 1
+		0x1e,
+		0x29, 0x55, // Present_Value
+		0x4e, 0x44, 0x41, 0xac, 0x00, 0x00, 0x4f, // 21.5
+		0x29, 0x1c, // Description
+		0x5e, 0x91, 0x02, 0x91, 0x20, 0x5f, // property, unknown-property
+		0x1f,
+	})
+
+	dec, err := msg.(*services.ReadPropertyMultipleACK).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(results, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
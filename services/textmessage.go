@@ -0,0 +1,271 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// TextMessageDec holds a decoded TextMessage request. The message class is
+// only carried when HasClass is set, in TextClass when IsTextClass is set and
+// in NumericClass otherwise.
+type TextMessageDec struct {
+	SourceDevice objects.ObjectIdentifier
+	HasClass     bool
+	IsTextClass  bool
+	NumericClass uint32
+	TextClass    string
+	Priority     uint8
+	Message      string
+}
+
+// TextMessageObjects creates the objects of the TextMessage request described
+// by m.
+func TextMessageObjects(m TextMessageDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{ctxObjectId(0, m.SourceDevice)}
+	if m.HasClass {
+		if m.IsTextClass {
+			objs = append(objs, encConstructed(1, ctxString(1, m.TextClass))...)
+		} else {
+			objs = append(objs, encConstructed(1, ctxUnsigned(0, m.NumericClass))...)
+		}
+	}
+	return append(objs, ctxEnumerated(2, uint32(m.Priority)), ctxString(3, m.Message))
+}
+
+func decTextMessage(objs []objects.APDUPayload) (TextMessageDec, error) {
+	r := tagReader{objs: objs}
+	m := TextMessageDec{}
+
+	m.SourceDevice = r.objectId(0)
+	if r.has(1) {
+		m.HasClass = true
+		class := tagReader{objs: r.constructed(1)}
+		if class.has(1) {
+			m.IsTextClass = true
+			m.TextClass = class.str(1)
+		} else {
+			m.NumericClass = class.unsigned(0)
+		}
+		r.check(class.end(), "message class", 1)
+	}
+	m.Priority = uint8(r.enumerated(2))
+	m.Message = r.str(3)
+
+	return m, r.end()
+}
+
+// ConfirmedTextMessage is a BACnet message.
+type ConfirmedTextMessage struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewConfirmedTextMessage(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedTextMessage {
+	c := &ConfirmedTextMessage{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedTextMessage, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedTextMessage) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal ConfirmedTM - marshal length %d binary length %d", c.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedTM %v", c),
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedTM %v", c),
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling ConfirmedTM %v", c),
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedTextMessage) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (c *ConfirmedTextMessage) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal ConfirmedTM - marshal length %d binary length %d", c.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedTM")
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedTM")
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal ConfirmedTM")
+	}
+
+	return nil
+}
+
+func (c *ConfirmedTextMessage) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedTextMessage) SetLength() {
+	c.BVLC.Length = uint16(c.MarshalLen())
+}
+
+func (c *ConfirmedTextMessage) Decode() (TextMessageDec, error) {
+	decTM, err := decTextMessage(c.APDU.Objects)
+	if err != nil {
+		return decTM, errors.Wrap(err, "decoding ConfirmedTM")
+	}
+	return decTM, nil
+}
+
+// UnconfirmedTextMessage is a BACnet message.
+type UnconfirmedTextMessage struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+func NewUnconfirmedTextMessage(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedTextMessage {
+	u := &UnconfirmedTextMessage{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedTextMessage, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedTextMessage) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedTM - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedTM %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedTM %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedTM %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedTextMessage) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedTextMessage) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedTM - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedTM")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedTM")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedTM")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedTextMessage) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedTextMessage) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedTextMessage) Decode() (TextMessageDec, error) {
+	decTM, err := decTextMessage(u.APDU.Objects)
+	if err != nil {
+		return decTM, errors.Wrap(err, "decoding UnconfirmedTM")
+	}
+	return decTM, nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// UnconfirmedWhoAmI is a BACnet message.
+type UnconfirmedWhoAmI struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// UnconfirmedWhoAmIDec holds a decoded Who-Am-I request, by which a device
+// without an instance number asks a supervisor for one.
+type UnconfirmedWhoAmIDec struct {
+	VendorId     uint16
+	ModelName    string
+	SerialNumber string
+}
+
+// WhoAmIObjects creates the objects of a Who-Am-I request.
+func WhoAmIObjects(w UnconfirmedWhoAmIDec) []objects.APDUPayload {
+	return []objects.APDUPayload{
+		objects.EncUnsignedInteger16(w.VendorId),
+		objects.EncString(w.ModelName),
+		objects.EncString(w.SerialNumber),
+	}
+}
+
+func NewUnconfirmedWhoAmI(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedWhoAmI {
+	u := &UnconfirmedWhoAmI{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedWhoAmI, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedWhoAmI) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedWhoAmI - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedWhoAmI %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedWhoAmI %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedWhoAmI %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedWhoAmI) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedWhoAmI) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedWhoAmI - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedWhoAmI")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedWhoAmI")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedWhoAmI")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedWhoAmI) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedWhoAmI) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedWhoAmI) Decode() (UnconfirmedWhoAmIDec, error) {
+	decWAI := UnconfirmedWhoAmIDec{}
+
+	if len(u.APDU.Objects) != 3 {
+		return decWAI, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode UnconfirmedWhoAmI %d - wrong object count", len(u.APDU.Objects)),
+		)
+	}
+
+	vendorId, err := objects.DecUnisgnedInteger(u.APDU.Objects[0])
+	if err != nil {
+		return decWAI, errors.Wrap(err, "decoding UnconfirmedWhoAmI")
+	}
+	decWAI.VendorId = uint16(vendorId)
+
+	if decWAI.ModelName, err = objects.DecString(u.APDU.Objects[1]); err != nil {
+		return decWAI, errors.Wrap(err, "decoding UnconfirmedWhoAmI")
+	}
+	if decWAI.SerialNumber, err = objects.DecString(u.APDU.Objects[2]); err != nil {
+		return decWAI, errors.Wrap(err, "decoding UnconfirmedWhoAmI")
+	}
+
+	return decWAI, nil
+}
+
+// UnconfirmedYouAre is a BACnet message.
+type UnconfirmedYouAre struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// UnconfirmedYouAreDec holds a decoded You-Are request, which gives the device
+// matching VendorId, ModelName and SerialNumber its instance number and MAC
+// address. DeviceId is nil and MACAddress empty when they are left out.
+type UnconfirmedYouAreDec struct {
+	VendorId     uint16
+	ModelName    string
+	SerialNumber string
+	DeviceId     *uint32
+	MACAddress   []byte
+}
+
+// YouAreObjects creates the objects of a You-Are request.
+func YouAreObjects(y UnconfirmedYouAreDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		objects.EncUnsignedInteger16(y.VendorId),
+		objects.EncString(y.ModelName),
+		objects.EncString(y.SerialNumber),
+	}
+	if y.DeviceId != nil {
+		objs = append(objs, objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objects.ObjectTypeDevice, *y.DeviceId))
+	}
+	if len(y.MACAddress) != 0 {
+		objs = append(objs, objects.EncOctetString(y.MACAddress))
+	}
+	return objs
+}
+
+func NewUnconfirmedYouAre(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedYouAre {
+	u := &UnconfirmedYouAre{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedYouAre, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedYouAre) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedYouAre - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedYouAre %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedYouAre %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedYouAre %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedYouAre) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedYouAre) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedYouAre - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedYouAre")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedYouAre")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedYouAre")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedYouAre) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedYouAre) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedYouAre) Decode() (UnconfirmedYouAreDec, error) {
+	decYA := UnconfirmedYouAreDec{}
+
+	if len(u.APDU.Objects) < 3 || len(u.APDU.Objects) > 5 {
+		return decYA, errors.Wrap(
+			common.ErrWrongObjectCount,
+			fmt.Sprintf("failed to decode UnconfirmedYouAre %d - wrong object count", len(u.APDU.Objects)),
+		)
+	}
+
+	vendorId, err := objects.DecUnisgnedInteger(u.APDU.Objects[0])
+	if err != nil {
+		return decYA, errors.Wrap(err, "decoding UnconfirmedYouAre")
+	}
+	decYA.VendorId = uint16(vendorId)
+
+	if decYA.ModelName, err = objects.DecString(u.APDU.Objects[1]); err != nil {
+		return decYA, errors.Wrap(err, "decoding UnconfirmedYouAre")
+	}
+	if decYA.SerialNumber, err = objects.DecString(u.APDU.Objects[2]); err != nil {
+		return decYA, errors.Wrap(err, "decoding UnconfirmedYouAre")
+	}
+
+	rest := u.APDU.Objects[3:]
+	if len(rest) > 0 {
+		if o, ok := rest[0].(*objects.Object); ok && !o.TagClass && o.TagNumber == objects.TagBACnetObjectIdentifier {
+			objId, err := objects.DecObjectIdentifier(o)
+			if err != nil {
+				return decYA, errors.Wrap(err, "decoding UnconfirmedYouAre")
+			}
+			if objId.ObjectType != objects.ObjectTypeDevice {
+				return decYA, errors.Wrap(
+					common.ErrInvalidObjectType,
+					fmt.Sprintf("decoding UnconfirmedYouAre - object type %d", objId.ObjectType),
+				)
+			}
+			decYA.DeviceId = &objId.InstanceNumber
+			rest = rest[1:]
+		}
+	}
+	if len(rest) > 0 {
+		if decYA.MACAddress, err = objects.DecOctetString(rest[0]); err != nil {
+			return decYA, errors.Wrap(err, "decoding UnconfirmedYouAre")
+		}
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return decYA, errors.Wrap(common.ErrWrongStructure, "decoding UnconfirmedYouAre - unexpected trailing objects")
+	}
+
+	return decYA, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
 	"github.com/pierreyves258/bacnet/plumbing"
 	"github.com/pkg/errors"
 )
@@ -15,6 +16,29 @@ type UnconfirmedWhoIs struct {
 	*plumbing.APDU
 }
 
+// UnconfirmedWhoIsDec holds the device instance range a Who-Is is limited to.
+// Only devices whose instance lies within [LowLimit, HighLimit] answer when
+// Limited is set, and every device does otherwise.
+type UnconfirmedWhoIsDec struct {
+	Limited   bool
+	LowLimit  uint32
+	HighLimit uint32
+}
+
+// Matches tells whether the device deviceId should answer the Who-Is.
+func (w UnconfirmedWhoIsDec) Matches(deviceId uint32) bool {
+	return !w.Limited || (w.LowLimit <= deviceId && deviceId <= w.HighLimit)
+}
+
+// WhoIsObjects creates the objects of a Who-Is limited to the device
+// instances within [low, high].
+func WhoIsObjects(low, high uint32) []objects.APDUPayload {
+	return []objects.APDUPayload{
+		ctxUnsigned(0, low),
+		ctxUnsigned(1, high),
+	}
+}
+
 // NewUnconfirmedWhoIs creates a UnconfirmedWhoIs.
 func NewUnconfirmedWhoIs(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedWhoIs {
 	u := &UnconfirmedWhoIs{
@@ -110,3 +134,21 @@ func (u *UnconfirmedWhoIs) MarshalLen() int {
 func (u *UnconfirmedWhoIs) SetLength() {
 	u.BVLC.Length = uint16(u.MarshalLen())
 }
+
+// Decode decodes the instance range of a UnconfirmedWhoIs, if any.
+func (u *UnconfirmedWhoIs) Decode() (UnconfirmedWhoIsDec, error) {
+	decWhoIs := UnconfirmedWhoIsDec{}
+	if len(u.APDU.Objects) == 0 {
+		return decWhoIs, nil
+	}
+
+	r := tagReader{objs: u.APDU.Objects}
+	decWhoIs.Limited = true
+	decWhoIs.LowLimit = r.unsigned(0)
+	decWhoIs.HighLimit = r.unsigned(1)
+	if err := r.end(); err != nil {
+		return decWhoIs, errors.Wrap(err, "decoding UnconfirmedWhoIs")
+	}
+
+	return decWhoIs, nil
+}
@@ -24,6 +24,18 @@ type ConfirmedWritePropertyDec struct {
 	Priority   uint8
 }
 
+// WritePropertyValue is a WriteProperty request whatever the datatype of its
+// value, made of application tagged objects. ArrayIndex is objects.ArrayAll
+// when the whole property is written and Priority is 0 when there is none.
+type WritePropertyValue struct {
+	ObjectType uint16
+	InstanceId uint32
+	PropertyId uint8
+	ArrayIndex uint32
+	Value      []objects.APDUPayload
+	Priority   uint8
+}
+
 func ConfirmedWritePropertyObjects(objectType uint16, instN uint32, propertyId uint8, value float32) []objects.APDUPayload {
 	objs := make([]objects.APDUPayload, 7)
 
@@ -38,6 +50,32 @@ func ConfirmedWritePropertyObjects(objectType uint16, instN uint32, propertyId u
 	return objs
 }
 
+// ConfirmedWritePropertyPriorityObjects creates the objects of a WriteProperty
+// request commanding the property at priority rather than at the lowest one.
+func ConfirmedWritePropertyPriorityObjects(objectType uint16, instN uint32, propertyId uint8, value float32, priority uint8) []objects.APDUPayload {
+	objs := ConfirmedWritePropertyObjects(objectType, instN, propertyId, value)
+	objs[len(objs)-1] = objects.EncPriority(true, 4, priority)
+
+	return objs
+}
+
+// WritePropertyValueObjects creates the objects of the WriteProperty request w.
+func WritePropertyValueObjects(w WritePropertyValue) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		objects.EncObjectIdentifier(true, 0, w.ObjectType, w.InstanceId),
+		objects.EncPropertyIdentifier(true, 1, w.PropertyId),
+	}
+	if w.ArrayIndex != objects.ArrayAll {
+		objs = append(objs, ctxUnsigned(2, w.ArrayIndex))
+	}
+	objs = append(objs, encConstructed(3, w.Value...)...)
+	if w.Priority != 0 {
+		objs = append(objs, objects.EncPriority(true, 4, w.Priority))
+	}
+
+	return objs
+}
+
 func NewConfirmedWriteProperty(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedWriteProperty {
 	c := &ConfirmedWriteProperty{
 		BVLC: bvlc,
@@ -132,14 +170,15 @@ func (c *ConfirmedWriteProperty) SetLength() {
 func (c *ConfirmedWriteProperty) Decode() (ConfirmedWritePropertyDec, error) {
 	decCWP := ConfirmedWritePropertyDec{}
 
-	if len(c.APDU.Objects) != 5 {
+	objs := withoutNamedTags(c.APDU.Objects)
+	if len(objs) != 5 {
 		return decCWP, errors.Wrap(
 			common.ErrWrongObjectCount,
-			fmt.Sprintf("failed to decode ConfirmedWP - object count %d", len(c.APDU.Objects)),
+			fmt.Sprintf("failed to decode ConfirmedWP - object count %d", len(objs)),
 		)
 	}
 
-	for i, obj := range c.APDU.Objects {
+	for i, obj := range objs {
 		switch i {
 		case 0:
 			objId, err := objects.DecObjectIdentifier(obj)
@@ -171,3 +210,32 @@ func (c *ConfirmedWriteProperty) Decode() (ConfirmedWritePropertyDec, error) {
 
 	return decCWP, nil
 }
+
+// DecodeValue decodes a WriteProperty request whatever the datatype of its
+// value, which Decode expects to be a REAL.
+func (c *ConfirmedWriteProperty) DecodeValue() (WritePropertyValue, error) {
+	w := WritePropertyValue{ArrayIndex: objects.ArrayAll}
+
+	r := tagReader{objs: c.APDU.Objects}
+	id := r.objectId(0)
+	w.ObjectType, w.InstanceId = id.ObjectType, id.InstanceNumber
+	if obj := r.primitive(1); obj != nil {
+		propId, err := objects.DecPropertyIdentifier(obj)
+		r.check(err, "property identifier", 1)
+		w.PropertyId = propId
+	}
+	if r.has(2) {
+		w.ArrayIndex = r.unsigned(2)
+	}
+	w.Value = r.constructed(3)
+	if r.has(4) {
+		priority, err := objects.DecPriority(r.primitive(4))
+		r.check(err, "priority", 4)
+		w.Priority = priority
+	}
+	if err := r.end(); err != nil {
+		return w, errors.Wrap(err, "decoding ConfirmedWP")
+	}
+
+	return w, nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pierreyves258/bacnet/common"
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pkg/errors"
+)
+
+// UnconfirmedWriteGroup is a BACnet message.
+type UnconfirmedWriteGroup struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// GroupChannelValue is an entry of the change list of a WriteGroup request.
+// Value holds the objects encoding the value written to the channel, and
+// OverridingPriority, when not zero, replaces the priority of the request.
+type GroupChannelValue struct {
+	Channel            uint16
+	OverridingPriority uint8
+	Value              []objects.APDUPayload
+}
+
+// UnconfirmedWriteGroupDec holds a decoded WriteGroup request. InhibitDelay is
+// nil when the request leaves it out.
+type UnconfirmedWriteGroupDec struct {
+	GroupNumber   uint32
+	WritePriority uint8
+	ChangeList    []GroupChannelValue
+	InhibitDelay  *bool
+}
+
+// UnconfirmedWriteGroupObjects creates the objects of the WriteGroup request
+// described by w.
+func UnconfirmedWriteGroupObjects(w UnconfirmedWriteGroupDec) []objects.APDUPayload {
+	objs := []objects.APDUPayload{
+		ctxUnsigned(0, w.GroupNumber),
+		ctxUnsigned(1, uint32(w.WritePriority)),
+		objects.EncOpeningTag(2),
+	}
+	for _, c := range w.ChangeList {
+		objs = append(objs, ctxUnsigned(0, uint32(c.Channel)))
+		if c.OverridingPriority != 0 {
+			objs = append(objs, ctxUnsigned(1, uint32(c.OverridingPriority)))
+		}
+		objs = append(objs, c.Value...)
+	}
+	objs = append(objs, objects.EncClosingTag(2))
+	if w.InhibitDelay != nil {
+		objs = append(objs, ctxBoolean(3, *w.InhibitDelay))
+	}
+	return objs
+}
+
+func NewUnconfirmedWriteGroup(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedWriteGroup {
+	u := &UnconfirmedWriteGroup{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedWriteGroup, nil),
+	}
+	u.SetLength()
+
+	return u
+}
+
+func (u *UnconfirmedWriteGroup) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("failed to unmarshal UnconfirmedWG - marshal length %d binary length %d", u.MarshalLen(), l),
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedWG %v", u),
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedWG %v", u),
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return errors.Wrap(
+			common.ErrTooShortToParse,
+			fmt.Sprintf("unmarshalling UnconfirmedWG %v", u),
+		)
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedWriteGroup) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal binary")
+	}
+	return b, nil
+}
+
+func (u *UnconfirmedWriteGroup) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return errors.Wrap(
+			common.ErrTooShortToMarshalBinary,
+			fmt.Sprintf("failed to marshal UnconfirmedWG - marshal length %d binary length %d", u.MarshalLen(), len(b)),
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedWG")
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedWG")
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return errors.Wrap(err, "failed to marshal UnconfirmedWG")
+	}
+
+	return nil
+}
+
+func (u *UnconfirmedWriteGroup) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedWriteGroup) SetLength() {
+	u.BVLC.Length = uint16(u.MarshalLen())
+}
+
+func (u *UnconfirmedWriteGroup) Decode() (UnconfirmedWriteGroupDec, error) {
+	r := tagReader{objs: u.APDU.Objects}
+	decWG := UnconfirmedWriteGroupDec{}
+
+	decWG.GroupNumber = r.unsigned(0)
+	decWG.WritePriority = uint8(r.unsigned(1))
+
+	changes := r.constructed(2)
+	decWG.ChangeList = []GroupChannelValue{}
+	for i := 0; r.err == nil && i < len(changes); {
+		c := GroupChannelValue{}
+
+		channel, err := objects.DecUnisgnedInteger(changes[i])
+		if err != nil || !isContextTag(changes[i], 0) {
+			return decWG, errors.Wrap(
+				common.ErrWrongStructure,
+				fmt.Sprintf("decoding UnconfirmedWG - expected channel at index %d", i),
+			)
+		}
+		c.Channel = uint16(channel)
+		i++
+
+		if i < len(changes) && isContextTag(changes[i], 1) {
+			priority, err := objects.DecUnisgnedInteger(changes[i])
+			if err != nil {
+				return decWG, errors.Wrap(err, "decoding UnconfirmedWG overriding priority")
+			}
+			c.OverridingPriority = uint8(priority)
+			i++
+		}
+
+		if i >= len(changes) {
+			return decWG, errors.Wrap(common.ErrWrongObjectCount, "decoding UnconfirmedWG - missing channel value")
+		}
+		end := i
+		if _, ok := changes[i].(*objects.NamedTag); ok {
+			if end, err = closingTagIndex(changes, i); err != nil {
+				return decWG, errors.Wrap(err, "decoding UnconfirmedWG channel value")
+			}
+		}
+		c.Value = changes[i : end+1]
+		i = end + 1
+
+		decWG.ChangeList = append(decWG.ChangeList, c)
+	}
+
+	if r.has(3) {
+		inhibit := r.boolean(3)
+		decWG.InhibitDelay = &inhibit
+	}
+
+	if err := r.end(); err != nil {
+		return decWG, errors.Wrap(err, "decoding UnconfirmedWG")
+	}
+	return decWG, nil
+}
@@ -0,0 +1,70 @@
+package bacnet
+
+import (
+	"net"
+
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// TextMessageHandler is called with every text message a device receives and
+// the address of its sender. The error it returns, preferably a
+// *objects.BACnetError, rejects a confirmed text message.
+type TextMessageHandler func(src net.Addr, msg services.TextMessageDec) error
+
+// HandleTextMessage passes the text message msg, received from src on conn,
+// to h and answers it when it is confirmed. It reports whether msg is a text
+// message at all, so that servers can chain it with other handlers.
+func HandleTextMessage(conn net.PacketConn, src net.Addr, msg plumbing.BACnet, h TextMessageHandler) (bool, error) {
+	switch m := msg.(type) {
+	case *services.UnconfirmedTextMessage:
+		dec, err := m.Decode()
+		if err != nil {
+			return true, err
+		}
+		return true, h(src, dec)
+	case *services.ConfirmedTextMessage:
+		dec, err := m.Decode()
+		if err != nil {
+			return true, err
+		}
+
+		var reply []byte
+		if hErr := h(src, dec); hErr != nil {
+			reply, err = NewErrorReply(m.APDU.InvokeID, services.ServiceConfirmedTextMessage, hErr)
+		} else {
+			reply, err = NewSimpleACKReply(m.APDU.InvokeID, services.ServiceConfirmedTextMessage)
+		}
+		if err != nil {
+			return true, errors.Wrap(err, "failed to build TextMessage reply")
+		}
+
+		if _, err := conn.WriteTo(reply, src); err != nil {
+			return true, errors.Wrap(err, "failed to send TextMessage reply")
+		}
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// NewTextMessage builds the TextMessage described by m, as an unconfirmed
+// request unless confirmed is set.
+func NewTextMessage(m services.TextMessageDec, confirmed bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+
+	if confirmed {
+		c := services.NewConfirmedTextMessage(bvlc, plumbing.NewNPDU(false, false, false, true))
+		c.APDU.MaxSize = 5
+		c.APDU.InvokeID = 1
+		c.APDU.Objects = services.TextMessageObjects(m)
+		c.SetLength()
+		return c.MarshalBinary()
+	}
+
+	u := services.NewUnconfirmedTextMessage(bvlc, plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.TextMessageObjects(m)
+	u.SetLength()
+	return u.MarshalBinary()
+}
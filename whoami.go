@@ -0,0 +1,121 @@
+package bacnet
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+	"github.com/pkg/errors"
+)
+
+// NewWhoAmI creates a Who-Am-I request, broadcast by a device waiting for a
+// supervisor to give it an instance number.
+func NewWhoAmI(vendorId uint16, modelName, serialNumber string) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
+	u := services.NewUnconfirmedWhoAmI(bvlc, plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.WhoAmIObjects(services.UnconfirmedWhoAmIDec{
+		VendorId:     vendorId,
+		ModelName:    modelName,
+		SerialNumber: serialNumber,
+	})
+	u.SetLength()
+	return u.MarshalBinary()
+}
+
+// NewYouAre creates the You-Are request described by y.
+func NewYouAre(y services.UnconfirmedYouAreDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	u := services.NewUnconfirmedYouAre(bvlc, plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.YouAreObjects(y)
+	u.SetLength()
+	return u.MarshalBinary()
+}
+
+// Assignment is what a supervisor gives to a device announcing itself with
+// Who-Am-I. MACAddress is left out of the You-Are reply when empty.
+type Assignment struct {
+	DeviceId   uint32
+	MACAddress []byte
+}
+
+// AutoAddresser answers Who-Am-I requests with You-Are, using the assignments
+// registered for the serial numbers of the devices. Unknown is called, when
+// set, with the requests of the devices no assignment was registered for.
+type AutoAddresser struct {
+	Unknown func(src net.Addr, req services.UnconfirmedWhoAmIDec)
+
+	assignments map[string]Assignment
+	mu          sync.Mutex
+}
+
+// NewAutoAddresser creates an AutoAddresser without assignments.
+func NewAutoAddresser() *AutoAddresser {
+	return &AutoAddresser{
+		assignments: map[string]Assignment{},
+	}
+}
+
+// Assign registers the assignment of the device with the serial number.
+func (a *AutoAddresser) Assign(serialNumber string, asg Assignment) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.assignments[serialNumber] = asg
+}
+
+// Unassign drops the assignment of the device with the serial number.
+func (a *AutoAddresser) Unassign(serialNumber string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.assignments, serialNumber)
+}
+
+// Lookup returns the assignment of the device with the serial number.
+func (a *AutoAddresser) Lookup(serialNumber string) (Assignment, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	asg, ok := a.assignments[serialNumber]
+	return asg, ok
+}
+
+// Handle answers msg, received from src on conn, with a You-Are when it's a
+// Who-Am-I from a device having an assignment. It reports whether msg is a
+// Who-Am-I at all, so that supervisors can chain it with other handlers.
+func (a *AutoAddresser) Handle(conn net.PacketConn, src net.Addr, msg plumbing.BACnet) (bool, error) {
+	m, ok := msg.(*services.UnconfirmedWhoAmI)
+	if !ok {
+		return false, nil
+	}
+
+	req, err := m.Decode()
+	if err != nil {
+		return true, err
+	}
+
+	asg, ok := a.Lookup(req.SerialNumber)
+	if !ok {
+		if a.Unknown != nil {
+			a.Unknown(src, req)
+		}
+		return true, nil
+	}
+
+	reply, err := NewYouAre(services.UnconfirmedYouAreDec{
+		VendorId:     req.VendorId,
+		ModelName:    req.ModelName,
+		SerialNumber: req.SerialNumber,
+		DeviceId:     &asg.DeviceId,
+		MACAddress:   asg.MACAddress,
+	})
+	if err != nil {
+		return true, errors.Wrap(err, "failed to build You-Are reply")
+	}
+
+	if _, err := conn.WriteTo(reply, src); err != nil {
+		return true, errors.Wrap(err, "failed to send You-Are reply")
+	}
+	return true, nil
+}
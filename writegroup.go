@@ -0,0 +1,41 @@
+package bacnet
+
+import (
+	"github.com/pierreyves258/bacnet/objects"
+	"github.com/pierreyves258/bacnet/plumbing"
+	"github.com/pierreyves258/bacnet/services"
+)
+
+// NewWriteGroup creates a WriteGroup request commanding the channels of a
+// control group.
+func NewWriteGroup(w services.UnconfirmedWriteGroupDec) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
+	u := services.NewUnconfirmedWriteGroup(bvlc, plumbing.NewNPDU(false, false, false, false))
+	u.APDU.Objects = services.UnconfirmedWriteGroupObjects(w)
+	u.SetLength()
+	return u.MarshalBinary()
+}
+
+// ApplyWriteGroup writes every value of the change list of req to the channels
+// having its channel number and belonging to the control group of req. The
+// overriding priority of an entry, when set, replaces the write priority of
+// the request. Every write is attempted and the first error is returned.
+func ApplyWriteGroup(req services.UnconfirmedWriteGroupDec, channels []*objects.Channel) error {
+	var firstErr error
+	for _, change := range req.ChangeList {
+		priority := req.WritePriority
+		if change.OverridingPriority != 0 {
+			priority = change.OverridingPriority
+		}
+
+		for _, c := range channels {
+			if c.ChannelNumber != change.Channel || !c.InGroup(req.GroupNumber) {
+				continue
+			}
+			if err := c.Write(change.Value, priority); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}